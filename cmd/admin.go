@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"em-test/cmd/config"
+	"em-test/cmd/internal/db"
+	"em-test/cmd/internal/migrations"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// errIssuesFound - check found integrity issues, the command exits with non-zero code
+var errIssuesFound = errors.New("integrity issues found")
+
+// signalContext - context cancelled on interrupt, so a long command stops and rolls its transaction back
+func signalContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+}
+
+// maintenanceService - service over DB from DATABASE_URL with tables of all models created or updated
+func maintenanceService() *service.MaintenanceService {
+	return service.CreateMaintenanceService(db.ConnectPostgres(config.LoadDSN()))
+}
+
+func migrateCommand() *cobra.Command {
+	newMigrator := func() (*db.Migrator, error) {
+		return db.CreateMigrator(db.OpenPostgres(config.LoadDSN()), migrations.FS)
+	}
+	printMigrations := func(cmd *cobra.Command, action string, done []db.Migration, err error) error {
+		for _, migration := range done {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s_%s\n", action, migration.Version, migration.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "nothing to do")
+		}
+		return err
+	}
+	up := func(cmd *cobra.Command, args []string) error {
+		migrator, err := newMigrator()
+		if err != nil {
+			return err
+		}
+		ctx, stop := signalContext(cmd)
+		defer stop()
+		done, err := migrator.Up(ctx)
+		return printMigrations(cmd, "applied", done, err)
+	}
+
+	migrate := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply SQL migrations from cmd/internal/migrations (same as migrate up)",
+		Long: "Applies SQL migrations that are not applied yet, recording them in schema_migrations.\n" +
+			"A database whose tables were created by the server on start should be marked with `migrate baseline` first.",
+		Args: cobra.NoArgs,
+		RunE: up,
+	}
+	var steps int
+	down := &cobra.Command{
+		Use:   "down",
+		Short: "Revert last applied migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			migrator, err := newMigrator()
+			if err != nil {
+				return err
+			}
+			ctx, stop := signalContext(cmd)
+			defer stop()
+			done, err := migrator.Down(ctx, steps)
+			return printMigrations(cmd, "reverted", done, err)
+		},
+	}
+	down.Flags().IntVarP(&steps, "steps", "n", 1, "number of migrations to revert")
+
+	migrate.AddCommand(
+		&cobra.Command{Use: "up", Short: "Apply migrations that are not applied yet", Args: cobra.NoArgs, RunE: up},
+		down,
+		&cobra.Command{
+			Use:   "status",
+			Short: "List migrations and when they were applied",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				migrator, err := newMigrator()
+				if err != nil {
+					return err
+				}
+				statuses, err := migrator.Status(cmd.Context())
+				if err != nil {
+					return err
+				}
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+				fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED_AT\tDOWN")
+				for _, status := range statuses {
+					appliedAt := "-"
+					if status.AppliedAt != nil {
+						appliedAt = status.AppliedAt.Format(time.RFC3339)
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", status.Version, status.Name, appliedAt, status.HasDown)
+				}
+				return w.Flush()
+			},
+		},
+		&cobra.Command{
+			Use:   "baseline",
+			Short: "Mark all migrations as applied without running them",
+			Long:  "Marks all migrations as applied without running them, for a database whose tables were created by the server on start.",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				migrator, err := newMigrator()
+				if err != nil {
+					return err
+				}
+				done, err := migrator.Baseline(cmd.Context())
+				return printMigrations(cmd, "marked", done, err)
+			},
+		},
+	)
+	return migrate
+}
+
+func seedCommand() *cobra.Command {
+	opts := model.SeedOptions{}
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Insert realistic fake subscriptions for load testing",
+		Long: "Inserts subscriptions of random users to popular services with typical prices, trials and ends,\n" +
+			"and links them to the providers catalog. The same --seed gives the same data.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Users <= 0 || opts.SubsPerUser <= 0 || opts.Months <= 0 {
+				return errors.New("--users, --subs-per-user and --months must be positive")
+			}
+			if opts.Seed == 0 {
+				opts.Seed = time.Now().UnixNano()
+			}
+			ctx, stop := signalContext(cmd)
+			defer stop()
+			count, err := maintenanceService().Seed(ctx, opts)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "inserted %d subscriptions of %d users (seed %d)\n", count, opts.Users, opts.Seed)
+			return nil
+		},
+	}
+	flags := cmd.Flags()
+	flags.IntVar(&opts.Users, "users", 1000, "number of users")
+	flags.IntVar(&opts.SubsPerUser, "subs-per-user", 3, "average number of subscriptions of a user")
+	flags.IntVar(&opts.Months, "months", 36, "subscriptions start within that many months before the current one")
+	flags.Int64Var(&opts.Seed, "seed", 0, "seed of random generator, random if 0")
+	return cmd
+}
+
+func recomputeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "recompute",
+		Short: "Rebuild data derived from other tables",
+		Long: "Relinks subscriptions to the providers catalog by names and aliases, brings their service names to canonical ones\n" +
+			"and rebuilds the monthly_spend aggregate read by reports.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signalContext(cmd)
+			defer stop()
+			report, err := maintenanceService().Recompute(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "linked subscriptions: %d\n", report.LinkedSubscriptions)
+			fmt.Fprintf(cmd.OutOrStdout(), "monthly spend rows: %d (through %s)\n", report.SpendRows, report.SpendCoveredUntil.Format("01-2006"))
+			return nil
+		},
+	}
+}
+
+func exportCommand() *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write full dataset backup as NDJSON",
+		Long:  `Writes rows of all tables as NDJSON lines {"table": ..., "row": {column: value}}, referenced tables first.`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			if file != "-" {
+				f, err := os.Create(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				out = f
+			}
+			ctx, stop := signalContext(cmd)
+			defer stop()
+			stats, err := maintenanceService().Export(ctx, out)
+			if err != nil {
+				return err
+			}
+			printTableStats(cmd.ErrOrStderr(), "exported", stats)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "-", "backup file, - for stdout")
+	return cmd
+}
+
+func importCommand() *cobra.Command {
+	var file string
+	var replace bool
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Restore dataset from NDJSON backup written by export",
+		Long:  "Restores backup in a single transaction keeping IDs. Fails if the database has data, unless --replace deletes it first.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in := cmd.InOrStdin()
+			if file != "-" {
+				f, err := os.Open(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+			ctx, stop := signalContext(cmd)
+			defer stop()
+			stats, err := maintenanceService().Import(ctx, in, replace)
+			if err != nil {
+				return err
+			}
+			printTableStats(cmd.OutOrStdout(), "imported", stats)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "-", "backup file, - for stdin")
+	cmd.Flags().BoolVar(&replace, "replace", false, "delete existing data before restoring")
+	return cmd
+}
+
+func printTableStats(w io.Writer, action string, stats []model.BackupTableStat) {
+	for _, stat := range stats {
+		fmt.Fprintf(w, "%s %s: %d rows\n", action, stat.Table, stat.Rows)
+	}
+}
+
+func checkCommand() *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Scan subscriptions for rows breaking validation rules",
+		Long: "Finds overlapping subscriptions of a user to the same service, end or trial before start and references\n" +
+			"to missing subscriptions or providers. Exits with non-zero code if issues are found.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			issues, err := maintenanceService().Check(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(issues); err != nil {
+					return err
+				}
+			} else {
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+				fmt.Fprintln(w, "KIND\tSUBSCRIPTION_ID\tDETAIL")
+				for _, issue := range issues {
+					sid := "-"
+					if issue.SID != 0 {
+						sid = strconv.FormatUint(issue.SID, 10)
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\n", issue.Kind, sid, issue.Detail)
+				}
+				if err := w.Flush(); err != nil {
+					return err
+				}
+			}
+			if len(issues) > 0 {
+				return fmt.Errorf("%w: %d", errIssuesFound, len(issues))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print issues as JSON")
+	return cmd
+}
@@ -1,14 +1,37 @@
 package config
 
 import (
+	"em-test/cmd/internal/service"
+	"em-test/cmd/internal/utils"
 	"log"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
-// Config provides DSN and Port
+// Config provides DSN, Port and settings of background jobs
 type Config struct {
-	DSN  string
-	Port string
+	DSN      string
+	Port     string
+	GRPCPort string // address of gRPC server, e.g. ":9090"
+
+	WebhookInterval    time.Duration // how often pending webhook deliveries are dispatched
+	WebhookMaxAttempts int           // failed attempts before delivery becomes dead
+	SchedulerInterval  time.Duration // how often scheduled subscription jobs run
+	ExpiringWindow     time.Duration // how long before the end subscription.expiring is emitted
+
+	RequireIfMatch bool          // whether subscription update and delete must carry If-Match
+	IdempotencyTTL time.Duration // how long responses of requests with Idempotency-Key are replayed
+
+	RateLimitRPS   float64      // requests per second allowed to a client; 0 disables rate limiting
+	RateLimitBurst int          // requests a client may send at once, at least 1
+	TrustedProxies []*net.IPNet // reverse proxies whose X-Forwarded-For identifies client for rate limiting
+	MaxBodySize    int64        // max request body size in bytes
+
+	ReportCacheSize int           // report totals kept in memory; 0 disables report cache
+	ReportCacheTTL  time.Duration // how long a cached report total is served
 }
 
 // Load provides port for server and link to DB from .env
@@ -19,10 +42,132 @@ func Load() *Config {
 	if config.Port == "" {
 		log.Fatal("SUBSCRIPTION_PORT is not set in env")
 	}
-	config.DSN = os.Getenv("DATABASE_URL")
-	if config.DSN == "" {
-		log.Fatal("DATABASE_URL is not set in env")
+	config.DSN = LoadDSN()
+	config.GRPCPort = os.Getenv("GRPC_PORT")
+	if config.GRPCPort == "" {
+		config.GRPCPort = ":9090"
 	}
+
+	config.WebhookInterval = durationEnv("WEBHOOK_DISPATCH_INTERVAL", 5*time.Second)
+	config.WebhookMaxAttempts = intEnv("WEBHOOK_MAX_ATTEMPTS", 8)
+	config.SchedulerInterval = durationEnv("SCHEDULER_INTERVAL", 10*time.Minute)
+	config.ExpiringWindow = durationEnv("EXPIRING_NOTICE_WINDOW", 7*24*time.Hour)
+	// the notice is built from upcoming expiries, which are not looked up further than MaxUpcomingWindow
+	if config.ExpiringWindow > service.MaxUpcomingWindow {
+		log.Fatalf("EXPIRING_NOTICE_WINDOW is invalid: %v exceeds %v", config.ExpiringWindow, service.MaxUpcomingWindow)
+	}
+	config.RequireIfMatch = boolEnv("REQUIRE_IF_MATCH", false)
+	config.IdempotencyTTL = durationEnv("IDEMPOTENCY_TTL", 24*time.Hour)
+	// clients are told apart by IP only, so limiting is opt-in: behind a proxy without TRUSTED_PROXIES all of them would share one bucket
+	config.RateLimitRPS = floatEnv("RATE_LIMIT_RPS", 0)
+	// RATE_LIMIT_BURST=0 is rejected: a bucket without a whole token would answer 429 to every request
+	config.RateLimitBurst = intEnv("RATE_LIMIT_BURST", 20)
+	config.TrustedProxies = networksEnv("TRUSTED_PROXIES")
+	config.MaxBodySize = int64(intEnv("MAX_BODY_SIZE", 8<<20))
+	config.ReportCacheSize = countEnv("REPORT_CACHE_SIZE", 10000)
+	config.ReportCacheTTL = durationEnv("REPORT_CACHE_TTL", time.Minute)
 	return &config
 
 }
+
+// LoadDSN provides link to DB from .env; maintenance commands need nothing else
+func LoadDSN() string {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		log.Fatal("DATABASE_URL is not set in env")
+	}
+	return dsn
+}
+
+// durationEnv - reads optional duration ("30s", "1h", "7d", "2w") from env
+func durationEnv(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	res, err := utils.ParseWindow(value)
+	if err != nil || res <= 0 {
+		log.Fatalf("%s is invalid: %q", name, value)
+	}
+	return res
+}
+
+// intEnv - reads optional positive integer from env
+func intEnv(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	res, err := strconv.Atoi(value)
+	if err != nil || res <= 0 {
+		log.Fatalf("%s is invalid: %q", name, value)
+	}
+	return res
+}
+
+// countEnv - reads optional non-negative integer from env
+func countEnv(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	res, err := strconv.Atoi(value)
+	if err != nil || res < 0 {
+		log.Fatalf("%s is invalid: %q", name, value)
+	}
+	return res
+}
+
+// networksEnv - reads optional comma-separated list of IP addresses and CIDR networks from env
+func networksEnv(name string) []*net.IPNet {
+	var res []*net.IPNet
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				log.Fatalf("%s is invalid: %q", name, value)
+			}
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			res = append(res, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			log.Fatalf("%s is invalid: %q", name, value)
+		}
+		res = append(res, network)
+	}
+	return res
+}
+
+// boolEnv - reads optional boolean ("true", "false", "1", "0") from env
+func boolEnv(name string, def bool) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	res, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("%s is invalid: %q", name, value)
+	}
+	return res
+}
+
+// floatEnv - reads optional non-negative number from env
+func floatEnv(name string, def float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	res, err := strconv.ParseFloat(value, 64)
+	if err != nil || res < 0 {
+		log.Fatalf("%s is invalid: %q", name, value)
+	}
+	return res
+}
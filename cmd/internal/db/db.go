@@ -9,8 +9,17 @@ import (
 	"gorm.io/gorm"
 )
 
-// ConnectPostgres provides a db-connection to Postgres using destination from caller
+// ConnectPostgres provides a db-connection to Postgres using destination from caller, with tables of all models created or updated
 func ConnectPostgres(dsn string) *gorm.DB {
+	db := OpenPostgres(dsn)
+	if err := Migrate(db); err != nil {
+		log.Fatalf("Failed to migrate: %v", err)
+	}
+	return db
+}
+
+// OpenPostgres provides a db-connection to Postgres without touching the schema
+func OpenPostgres(dsn string) *gorm.DB {
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
 		log.Fatalf("Cannot open db: %v", err)
@@ -22,9 +31,22 @@ func ConnectPostgres(dsn string) *gorm.DB {
 	sqlDB.SetMaxOpenConns(10)
 	sqlDB.SetMaxIdleConns(5)
 	sqlDB.SetConnMaxLifetime(time.Hour)
-
-	if err := db.AutoMigrate(&model.Subscription{}); err != nil {
-		log.Fatalf("Failed to migrate: %v", err)
-	}
 	return db
 }
+
+// Migrate - creates or updates tables of all models used by service
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&model.Subscription{},
+		&model.Provider{},
+		&model.ProviderAlias{},
+		&model.Category{},
+		&model.Webhook{},
+		&model.WebhookDelivery{},
+		&model.Event{},
+		&model.Budget{},
+		&model.IdempotencyKey{},
+		&model.MonthlySpend{},
+		&model.MonthlySpendState{},
+	)
+}
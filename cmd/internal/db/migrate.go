@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrMigrationNoDown = errors.New("migration has no down file")
+var ErrMigrationInvalid = errors.New("invalid migration file name")
+
+// Migration - SQL migration read from NNN_name.up.sql and NNN_name.down.sql (or NNN_name.sql without down)
+type Migration struct {
+	Version string
+	Name    string
+	Up      string
+	Down    string // empty if migration can't be reverted
+}
+
+// Migrator - applies SQL migrations from a file system and records applied versions in schema_migrations
+type Migrator struct {
+	DB         *gorm.DB
+	Migrations []Migration // ordered by version
+}
+
+// CreateMigrator - reads migrations from files of fsys root
+func CreateMigrator(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := readMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Migrations: migrations}, nil
+}
+
+// readMigrations - groups up and down files by version
+func readMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[string]*Migration{}
+	for _, file := range files {
+		base := strings.TrimSuffix(file, ".sql")
+		direction := "up"
+		if trimmed, ok := strings.CutSuffix(base, ".down"); ok {
+			base, direction = trimmed, "down"
+		} else {
+			base = strings.TrimSuffix(base, ".up")
+		}
+		version, name, ok := strings.Cut(base, "_")
+		if !ok || version == "" || strings.Trim(version, "0123456789") != "" {
+			return nil, fmt.Errorf("%s: %w", file, ErrMigrationInvalid)
+		}
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if direction == "down" {
+			migration.Down = string(content)
+		} else {
+			migration.Up = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("%s_%s has no up file: %w", migration.Version, migration.Name, ErrMigrationInvalid)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// applied - versions of applied migrations
+func (m *Migrator) applied(ctx context.Context) (map[string]model.SchemaMigration, error) {
+	if err := m.DB.WithContext(ctx).AutoMigrate(&model.SchemaMigration{}); err != nil {
+		return nil, err
+	}
+	var rows []model.SchemaMigration
+	if err := m.DB.WithContext(ctx).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[string]model.SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Status - all migrations with time they were applied at
+func (m *Migrator) Status(ctx context.Context) ([]model.MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]model.MigrationStatus, len(m.Migrations))
+	for i, migration := range m.Migrations {
+		res[i] = model.MigrationStatus{Version: migration.Version, Name: migration.Name, HasDown: migration.Down != ""}
+		if row, ok := applied[migration.Version]; ok {
+			res[i].AppliedAt = &row.AppliedAt
+		}
+	}
+	return res, nil
+}
+
+// Up - applies not yet applied migrations in version order, each in its own transaction; returns applied ones
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.up(ctx, true)
+}
+
+// Baseline - marks all migrations as applied without running them, for databases whose schema was created by the server on start
+func (m *Migrator) Baseline(ctx context.Context) ([]Migration, error) {
+	return m.up(ctx, false)
+}
+
+func (m *Migrator) up(ctx context.Context, run bool) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, migration := range m.Migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := m.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if run {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Create(&model.SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %s_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down - reverts steps last applied migrations in reverse version order; stops at a migration without down file
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(m.Migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.Migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return done, fmt.Errorf("%s_%s: %w", migration.Version, migration.Name, ErrMigrationNoDown)
+		}
+		err := m.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(migration.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&model.SchemaMigration{Version: migration.Version}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %s_%s revert failed: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
@@ -0,0 +1,138 @@
+package gqlapi
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"sync"
+)
+
+// batchLoader - request-scoped loader of values by keys. Keys are registered with Prime while parent objects are resolved,
+// and the first Load fetches all registered keys that are not loaded yet in one call, so nested lists don't cause N+1 queries
+type batchLoader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending map[K]struct{}
+	loaded  map[K]V
+}
+
+func newBatchLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *batchLoader[K, V] {
+	return &batchLoader[K, V]{fetch: fetch, pending: map[K]struct{}{}, loaded: map[K]V{}}
+}
+
+// Prime - registers keys to be fetched together with the next Load
+func (bl *batchLoader[K, V]) Prime(keys ...K) {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	for _, key := range keys {
+		if _, ok := bl.loaded[key]; !ok {
+			bl.pending[key] = struct{}{}
+		}
+	}
+}
+
+// Load - returns value by key, fetching it with all pending keys if it is not loaded yet; missing keys get zero value
+func (bl *batchLoader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	if value, ok := bl.loaded[key]; ok {
+		return value, nil
+	}
+	bl.pending[key] = struct{}{}
+	keys := make([]K, 0, len(bl.pending))
+	for k := range bl.pending {
+		keys = append(keys, k)
+	}
+	values, err := bl.fetch(ctx, keys)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	for _, k := range keys {
+		bl.loaded[k] = values[k]
+		delete(bl.pending, k)
+	}
+	return bl.loaded[key], nil
+}
+
+// loaders - batch loaders of one GraphQL request
+type loaders struct {
+	subscriptions *batchLoader[uint64, *model.RawSubscription]
+	userSubs      *batchLoader[string, []*model.RawSubscription]
+	providers     *batchLoader[uint64, *model.RawProvider]
+
+	// totals - user totals by report period; users to report on are registered in users once, known holds them as a set
+	mu     sync.Mutex
+	users  []string
+	known  map[string]struct{}
+	totals map[string]*periodTotals
+}
+
+// periodTotals - loader of user totals for one report period and number of registered users already primed in it
+type periodTotals struct {
+	loader *batchLoader[string, uint]
+	primed int
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, root *Resolver) context.Context {
+	l := &loaders{
+		subscriptions: newBatchLoader(root.Service.GetBySIDs),
+		userSubs:      newBatchLoader(root.Service.GetByUIDs),
+		providers:     newBatchLoader(root.ProviderService.GetProvidersByIDs),
+		known:         map[string]struct{}{},
+		totals:        map[string]*periodTotals{},
+	}
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// primeUsers - registers users whose subscriptions and totals are likely to be requested
+func (l *loaders) primeUsers(uids ...string) {
+	l.userSubs.Prime(uids...)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, uid := range uids {
+		if _, ok := l.known[uid]; !ok {
+			l.known[uid] = struct{}{}
+			l.users = append(l.users, uid)
+		}
+	}
+}
+
+// primeSubscriptions - registers users, providers and previous subscriptions of loaded subscriptions
+func (l *loaders) primeSubscriptions(rawSubs []*model.RawSubscription) {
+	uids := make([]string, len(rawSubs))
+	for i, rawSub := range rawSubs {
+		uids[i] = rawSub.UID
+	}
+	l.primeUsers(uids...)
+	for _, rawSub := range rawSubs {
+		if rawSub.ProviderID != nil {
+			l.providers.Prime(*rawSub.ProviderID)
+		}
+		if rawSub.PreviousSID != nil {
+			l.subscriptions.Prime(*rawSub.PreviousSID)
+		}
+	}
+}
+
+// totalsFor - returns loader of user totals for report period with all users registered so far primed
+func (l *loaders) totalsFor(root *Resolver, period string) *batchLoader[string, uint] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	totals, ok := l.totals[period]
+	if !ok {
+		totals = &periodTotals{loader: newBatchLoader(func(ctx context.Context, uids []string) (map[string]uint, error) {
+			return root.Service.ReportByUsers(ctx, &model.RawReportFilter{Period: period}, uids)
+		})}
+		l.totals[period] = totals
+	}
+	totals.loader.Prime(l.users[totals.primed:]...)
+	totals.primed = len(l.users)
+	return totals.loader
+}
@@ -0,0 +1,415 @@
+package gqlapi
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/service"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// Page size limits of subscriptions and users connections
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// Resolver - root resolver of Query type, backed by the same services as REST handlers
+type Resolver struct {
+	Service         *service.SubscriptionService
+	ProviderService *service.ProviderService
+}
+
+type pageArgs struct {
+	First *int32
+	After *string
+}
+
+// pageSize - returns requested page size limited by MaxPageSize, DefaultPageSize if it is not set
+func (args pageArgs) pageSize() (int, error) {
+	switch {
+	case args.First == nil:
+		return DefaultPageSize, nil
+	case *args.First <= 0:
+		return 0, badInput(fmt.Errorf("first must be positive"))
+	case *args.First > MaxPageSize:
+		return MaxPageSize, nil
+	}
+	return int(*args.First), nil
+}
+
+type pageInfo struct {
+	EndCursor   *string
+	HasNextPage bool
+}
+
+func parseID(id graphql.ID) (uint64, error) {
+	res, err := strconv.ParseUint(string(id), 10, 64)
+	if err != nil {
+		return 0, badInput(fmt.Errorf("invalid ID %q", id))
+	}
+	return res, nil
+}
+
+// Subscription - resolves subscription(id)
+func (r *Resolver) Subscription(ctx context.Context, args struct{ ID graphql.ID }) (*subscriptionResolver, error) {
+	sid, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	rawSub, err := r.Service.GetBySID(ctx, sid)
+	if err != nil {
+		if errors.Is(err, repository.ErrSubNotFound) {
+			return nil, nil
+		}
+		return nil, wrapError(err)
+	}
+	return r.newSubscriptions(ctx, []*model.RawSubscription{rawSub})[0], nil
+}
+
+type subscriptionFilter struct {
+	UserID      *string
+	ServiceName *string
+	Status      *string
+}
+
+type subscriptionConnection struct {
+	Nodes    []*subscriptionResolver
+	PageInfo pageInfo
+}
+
+// Subscriptions - resolves subscriptions(filter, first, after)
+func (r *Resolver) Subscriptions(ctx context.Context, args struct {
+	Filter *subscriptionFilter
+	pageArgs
+}) (*subscriptionConnection, error) {
+	limit, err := args.pageSize()
+	if err != nil {
+		return nil, err
+	}
+	filter := model.SubscriptionPageFilter{Limit: limit}
+	if args.After != nil {
+		if filter.AfterSID, err = parseID(graphql.ID(*args.After)); err != nil {
+			return nil, err
+		}
+	}
+	if args.Filter != nil {
+		filter.UID = deref(args.Filter.UserID)
+		filter.Provider = deref(args.Filter.ServiceName)
+		filter.Status = deref(args.Filter.Status)
+	}
+
+	rawSubs, next, err := r.Service.GetPage(ctx, &filter)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	res := &subscriptionConnection{Nodes: r.newSubscriptions(ctx, rawSubs)}
+	if len(rawSubs) > 0 {
+		cursor := strconv.FormatUint(*rawSubs[len(rawSubs)-1].SID, 10)
+		res.PageInfo = pageInfo{EndCursor: &cursor, HasNextPage: next != 0}
+	}
+	return res, nil
+}
+
+// User - resolves user(id)
+func (r *Resolver) User(ctx context.Context, args struct{ ID string }) (*userResolver, error) {
+	rawSubs, err := loadersFrom(ctx).userSubs.Load(ctx, args.ID)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	if len(rawSubs) == 0 {
+		return nil, nil
+	}
+	return r.newUsers(ctx, []string{args.ID})[0], nil
+}
+
+type userConnection struct {
+	Nodes    []*userResolver
+	PageInfo pageInfo
+}
+
+// Users - resolves users(first, after)
+func (r *Resolver) Users(ctx context.Context, args pageArgs) (*userConnection, error) {
+	limit, err := args.pageSize()
+	if err != nil {
+		return nil, err
+	}
+	uids, next, err := r.Service.GetUserIDs(ctx, deref(args.After), limit)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	res := &userConnection{Nodes: r.newUsers(ctx, uids)}
+	if len(uids) > 0 {
+		res.PageInfo = pageInfo{EndCursor: &uids[len(uids)-1], HasNextPage: next != ""}
+	}
+	return res, nil
+}
+
+// Provider - resolves provider(id)
+func (r *Resolver) Provider(ctx context.Context, args struct{ ID graphql.ID }) (*providerResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	rawProvider, err := loadersFrom(ctx).providers.Load(ctx, id)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	if rawProvider == nil {
+		return nil, nil
+	}
+	return &providerResolver{rawProvider}, nil
+}
+
+// Providers - resolves providers
+func (r *Resolver) Providers(ctx context.Context) ([]*providerResolver, error) {
+	rawProviders, err := r.ProviderService.GetProviderList(ctx)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	res := make([]*providerResolver, len(rawProviders))
+	for i, rawProvider := range rawProviders {
+		res[i] = &providerResolver{rawProvider}
+	}
+	return res, nil
+}
+
+type reportGroup struct {
+	CategoryID *graphql.ID
+	Category   string
+	Total      int32
+}
+
+type report struct {
+	Total  int32
+	Groups []reportGroup
+}
+
+// Report - resolves report(period, userId, serviceName, category, groupBy) the same way as GET /subscriptions/report
+func (r *Resolver) Report(ctx context.Context, args struct {
+	Period      string
+	UserID      *string
+	ServiceName *string
+	Category    *string
+	GroupBy     *string
+}) (*report, error) {
+	filter := model.RawReportFilter{
+		Period:   args.Period,
+		UID:      deref(args.UserID),
+		Provider: deref(args.ServiceName),
+		Category: deref(args.Category),
+		GroupBy:  deref(args.GroupBy),
+	}
+	if filter.GroupBy != "" && filter.GroupBy != model.ReportGroupByCategory {
+		return nil, badInput(fmt.Errorf("unsupported groupBy value: expected category"))
+	}
+
+	res := &report{}
+	if filter.GroupBy == model.ReportGroupByCategory {
+		grouped, err := r.Service.ReportByCategory(ctx, &filter)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+		res.Total = int32(grouped.Total)
+		for _, group := range grouped.Groups {
+			res.Groups = append(res.Groups, reportGroup{CategoryID: toID(group.CategoryID), Category: group.Category, Total: int32(group.Total)})
+		}
+		return res, nil
+	}
+	total, err := r.Service.Report(ctx, &filter)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	res.Total = int32(total)
+	return res, nil
+}
+
+// newSubscriptions - wraps subscriptions into resolvers and registers their users, providers and previous subscriptions for batch loading
+func (r *Resolver) newSubscriptions(ctx context.Context, rawSubs []*model.RawSubscription) []*subscriptionResolver {
+	loadersFrom(ctx).primeSubscriptions(rawSubs)
+	res := make([]*subscriptionResolver, len(rawSubs))
+	for i, rawSub := range rawSubs {
+		res[i] = &subscriptionResolver{root: r, sub: rawSub}
+	}
+	return res
+}
+
+// newUsers - wraps user IDs into resolvers and registers them for batch loading of subscriptions and totals
+func (r *Resolver) newUsers(ctx context.Context, uids []string) []*userResolver {
+	loadersFrom(ctx).primeUsers(uids...)
+	res := make([]*userResolver, len(uids))
+	for i, uid := range uids {
+		res[i] = &userResolver{root: r, uid: uid}
+	}
+	return res
+}
+
+type subscriptionResolver struct {
+	root *Resolver
+	sub  *model.RawSubscription
+}
+
+func (sr *subscriptionResolver) ID() graphql.ID {
+	return *toID(sr.sub.SID)
+}
+
+func (sr *subscriptionResolver) UserID() string {
+	return sr.sub.UID
+}
+
+func (sr *subscriptionResolver) ServiceName() string {
+	return sr.sub.Provider
+}
+
+func (sr *subscriptionResolver) Price() int32 {
+	if sr.sub.Price == nil {
+		return 0
+	}
+	return int32(*sr.sub.Price)
+}
+
+func (sr *subscriptionResolver) StartDate() string {
+	return sr.sub.Start
+}
+
+func (sr *subscriptionResolver) EndDate() *string {
+	return optional(sr.sub.End)
+}
+
+func (sr *subscriptionResolver) TrialUntil() *string {
+	return optional(sr.sub.TrialUntil)
+}
+
+func (sr *subscriptionResolver) Status() string {
+	return sr.sub.Status
+}
+
+func (sr *subscriptionResolver) CancelledAt() *string {
+	return optional(sr.sub.CancelledAt)
+}
+
+func (sr *subscriptionResolver) Version() int32 {
+	return int32(deref(sr.sub.Version))
+}
+
+func (sr *subscriptionResolver) User(ctx context.Context) *userResolver {
+	return sr.root.newUsers(ctx, []string{sr.sub.UID})[0]
+}
+
+func (sr *subscriptionResolver) Provider(ctx context.Context) (*providerResolver, error) {
+	if sr.sub.ProviderID == nil {
+		return nil, nil
+	}
+	rawProvider, err := loadersFrom(ctx).providers.Load(ctx, *sr.sub.ProviderID)
+	if err != nil || rawProvider == nil {
+		return nil, wrapError(err)
+	}
+	return &providerResolver{rawProvider}, nil
+}
+
+func (sr *subscriptionResolver) Previous(ctx context.Context) (*subscriptionResolver, error) {
+	if sr.sub.PreviousSID == nil {
+		return nil, nil
+	}
+	rawSub, err := loadersFrom(ctx).subscriptions.Load(ctx, *sr.sub.PreviousSID)
+	if err != nil || rawSub == nil {
+		return nil, wrapError(err)
+	}
+	return sr.root.newSubscriptions(ctx, []*model.RawSubscription{rawSub})[0], nil
+}
+
+type userResolver struct {
+	root *Resolver
+	uid  string
+}
+
+func (ur *userResolver) ID() string {
+	return ur.uid
+}
+
+func (ur *userResolver) Subscriptions(ctx context.Context, args struct{ Status *string }) ([]*subscriptionResolver, error) {
+	rawSubs, err := loadersFrom(ctx).userSubs.Load(ctx, ur.uid)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	if status := deref(args.Status); status != "" {
+		var filtered []*model.RawSubscription
+		for _, rawSub := range rawSubs {
+			if rawSub.Status == status {
+				filtered = append(filtered, rawSub)
+			}
+		}
+		rawSubs = filtered
+	}
+	return ur.root.newSubscriptions(ctx, rawSubs), nil
+}
+
+func (ur *userResolver) Total(ctx context.Context, args struct{ Period string }) (int32, error) {
+	total, err := loadersFrom(ctx).totalsFor(ur.root, args.Period).Load(ctx, ur.uid)
+	if err != nil {
+		return 0, wrapError(err)
+	}
+	return int32(total), nil
+}
+
+type providerResolver struct {
+	provider *model.RawProvider
+}
+
+func (pr *providerResolver) ID() graphql.ID {
+	return *toID(pr.provider.ID)
+}
+
+func (pr *providerResolver) Name() string {
+	return pr.provider.Name
+}
+
+func (pr *providerResolver) Aliases() []string {
+	if pr.provider.Aliases == nil {
+		return []string{}
+	}
+	return pr.provider.Aliases
+}
+
+func (pr *providerResolver) CategoryID() *graphql.ID {
+	return toID(pr.provider.CategoryID)
+}
+
+func (pr *providerResolver) Category() *string {
+	return optional(pr.provider.Category)
+}
+
+func (pr *providerResolver) DefaultPrice() *int32 {
+	if pr.provider.DefaultPrice == nil {
+		return nil
+	}
+	price := int32(*pr.provider.DefaultPrice)
+	return &price
+}
+
+func toID(id *uint64) *graphql.ID {
+	if id == nil {
+		return nil
+	}
+	res := graphql.ID(strconv.FormatUint(*id, 10))
+	return &res
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func deref[T any](value *T) T {
+	var zero T
+	if value == nil {
+		return zero
+	}
+	return *value
+}
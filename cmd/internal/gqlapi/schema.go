@@ -0,0 +1,104 @@
+// Package gqlapi exposes subscriptions, users, providers and reports as GraphQL schema (see schema.graphql).
+// Nested lists are resolved with request-scoped batch loaders, so queries against repositories don't grow with number of parent objects
+package gqlapi
+
+import (
+	"context"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/service"
+	"em-test/cmd/internal/utils"
+	_ "embed"
+	"errors"
+
+	"github.com/graph-gophers/graphql-go"
+	"gorm.io/gorm"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// Limits of queries accepted by schema
+const (
+	MaxDepth       = 8
+	MaxParallelism = 10
+)
+
+// Schema - executable GraphQL schema over subscriptions, providers and reports
+type Schema struct {
+	schema *graphql.Schema
+	root   *Resolver
+}
+
+func CreateSchema(db *gorm.DB) *Schema {
+	root := &Resolver{
+		Service:         service.CreateService(db),
+		ProviderService: service.CreateProviderService(db),
+	}
+	return &Schema{
+		schema: graphql.MustParseSchema(schemaSDL, &operations{query: root}, graphql.UseFieldResolvers(),
+			graphql.MaxDepth(MaxDepth), graphql.MaxParallelism(MaxParallelism)),
+		root: root,
+	}
+}
+
+// Root - resolver of Query type; its services are shared with REST handlers when set up by the server
+func (s *Schema) Root() *Resolver {
+	return s.root
+}
+
+// operations - resolvers of root operation types; Query field "subscription" clashes with Subscription operation when resolved by the same type
+type operations struct {
+	query *Resolver
+}
+
+func (o *operations) Query() *Resolver {
+	return o.query
+}
+
+// Exec - executes query with its own set of batch loaders
+func (s *Schema) Exec(ctx context.Context, query, operationName string, variables map[string]any) *graphql.Response {
+	return s.schema.Exec(withLoaders(ctx, s.root), query, operationName, variables)
+}
+
+// Error codes put to extensions of GraphQL errors
+const (
+	CodeBadUserInput = "BAD_USER_INPUT"
+	CodeNotFound     = "NOT_FOUND"
+	CodeInternal     = "INTERNAL"
+)
+
+// resolverError - error of resolver with code in extensions
+type resolverError struct {
+	err  error
+	code string
+}
+
+func (re *resolverError) Error() string {
+	return re.err.Error()
+}
+
+func (re *resolverError) Unwrap() error {
+	return re.err
+}
+
+func (re *resolverError) Extensions() map[string]any {
+	return map[string]any{"code": re.code}
+}
+
+func badInput(err error) error {
+	return &resolverError{err: err, code: CodeBadUserInput}
+}
+
+// wrapError - attaches code to service and repository error the same way REST handlers map them to HTTP codes; nil stays nil
+func wrapError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, utils.ErrConvertToNorm), errors.Is(err, repository.ErrEmptySomeFields), errors.Is(err, repository.ErrInvalidPeriod):
+		return badInput(err)
+	case errors.Is(err, repository.ErrSubNotFound), errors.Is(err, repository.ErrProviderNotFound), errors.Is(err, repository.ErrCategoryNotFound):
+		return &resolverError{err: err, code: CodeNotFound}
+	default:
+		return &resolverError{err: err, code: CodeInternal}
+	}
+}
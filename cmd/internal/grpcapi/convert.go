@@ -0,0 +1,62 @@
+package grpcapi
+
+import (
+	"em-test/cmd/internal/grpcapi/pb"
+	"em-test/cmd/internal/model"
+)
+
+// subscriptionFromProto - converts gRPC message to raw subscription; computed fields are ignored
+func subscriptionFromProto(sub *pb.Subscription) *model.RawSubscription {
+	rawSub := &model.RawSubscription{
+		SID:         sub.SubscriptionId,
+		UID:         sub.GetUserId(),
+		Provider:    sub.GetServiceName(),
+		Start:       sub.GetStartDate(),
+		End:         sub.GetEndDate(),
+		PreviousSID: sub.PreviousSubscriptionId,
+		ProviderID:  sub.ProviderId,
+		TrialUntil:  sub.GetTrialUntil(),
+		Version:     sub.Version,
+	}
+	if sub.Price != nil {
+		price := uint(sub.GetPrice())
+		rawSub.Price = &price
+	}
+	return rawSub
+}
+
+// subscriptionToProto - converts raw subscription to gRPC message
+func subscriptionToProto(rawSub *model.RawSubscription) *pb.Subscription {
+	sub := &pb.Subscription{
+		SubscriptionId:         rawSub.SID,
+		UserId:                 rawSub.UID,
+		ServiceName:            rawSub.Provider,
+		StartDate:              rawSub.Start,
+		EndDate:                rawSub.End,
+		PreviousSubscriptionId: rawSub.PreviousSID,
+		ProviderId:             rawSub.ProviderID,
+		TrialUntil:             rawSub.TrialUntil,
+		Status:                 rawSub.Status,
+		CancelledAt:            rawSub.CancelledAt,
+		Warnings:               rawSub.Warnings,
+		Version:                rawSub.Version,
+	}
+	if rawSub.Price != nil {
+		price := uint64(*rawSub.Price)
+		sub.Price = &price
+	}
+	return sub
+}
+
+// reportToProto - converts report to gRPC message
+func reportToProto(report *model.Report) *pb.ReportResponse {
+	res := &pb.ReportResponse{Total: uint64(report.Total)}
+	for _, group := range report.Groups {
+		res.Groups = append(res.Groups, &pb.ReportGroup{
+			CategoryId: group.CategoryID,
+			Category:   group.Category,
+			Total:      uint64(group.Total),
+		})
+	}
+	return res
+}
@@ -0,0 +1,177 @@
+// Package grpcapi exposes subscription operations of SubscriptionHandler over gRPC.
+// Generated code lives in pb; to regenerate it run from pb directory:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative subscription.proto
+package grpcapi
+
+import (
+	"context"
+	"em-test/cmd/internal/grpcapi/pb"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/service"
+	"em-test/cmd/internal/utils"
+	"errors"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"gorm.io/gorm"
+)
+
+// Page size limits of ListSubscriptions
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// SubscriptionServer provides process to gRPC-requests; backed by the same service as REST handler
+type SubscriptionServer struct {
+	pb.UnimplementedSubscriptionServiceServer
+	Service *service.SubscriptionService
+}
+
+func CreateServer(db *gorm.DB) *SubscriptionServer {
+	return &SubscriptionServer{Service: service.CreateService(db)}
+}
+
+// Register - registers subscription service on gRPC server
+func (s *SubscriptionServer) Register(gs *grpc.Server) {
+	pb.RegisterSubscriptionServiceServer(gs, s)
+}
+
+// CreateSubscription - creates new subscription, same as POST /subscriptions
+func (s *SubscriptionServer) CreateSubscription(ctx context.Context, req *pb.CreateSubscriptionRequest) (*pb.Subscription, error) {
+	if req.GetSubscription() == nil {
+		return nil, status.Error(codes.InvalidArgument, "subscription is required")
+	}
+	rawSub := subscriptionFromProto(req.GetSubscription())
+	rawSub.SID = nil
+	if err := s.Service.CreateSubscription(ctx, rawSub); err != nil {
+		return nil, errorToStatus(err)
+	}
+	return subscriptionToProto(rawSub), nil
+}
+
+// GetSubscription - returns subscription by SID, same as GET /subscriptions/{sid}
+func (s *SubscriptionServer) GetSubscription(ctx context.Context, req *pb.GetSubscriptionRequest) (*pb.Subscription, error) {
+	rawSub, err := s.Service.GetBySID(ctx, req.GetSubscriptionId())
+	if err != nil {
+		return nil, errorToStatus(err)
+	}
+	return subscriptionToProto(rawSub), nil
+}
+
+// ListSubscriptions - returns page of subscriptions ordered by SID, same as GET /subscriptions
+func (s *SubscriptionServer) ListSubscriptions(ctx context.Context, req *pb.ListSubscriptionsRequest) (*pb.ListSubscriptionsResponse, error) {
+	pageSize := int(req.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case pageSize == 0:
+		pageSize = DefaultPageSize
+	case pageSize > MaxPageSize:
+		pageSize = MaxPageSize
+	}
+	var after uint64
+	if req.GetPageToken() != "" {
+		var err error
+		if after, err = strconv.ParseUint(req.GetPageToken(), 10, 64); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+	}
+
+	rawSubs, next, err := s.Service.GetPage(ctx, &model.SubscriptionPageFilter{Status: req.GetStatus(), AfterSID: after, Limit: pageSize})
+	if err != nil {
+		return nil, errorToStatus(err)
+	}
+	res := &pb.ListSubscriptionsResponse{Subscriptions: make([]*pb.Subscription, len(rawSubs))}
+	for i, rawSub := range rawSubs {
+		res.Subscriptions[i] = subscriptionToProto(rawSub)
+	}
+	if next != 0 {
+		res.NextPageToken = strconv.FormatUint(next, 10)
+	}
+	return res, nil
+}
+
+// UpdateSubscription - applies non-empty fields to subscription, same as PUT /subscriptions/{sid};
+// version of subscription, if set, must be the current one
+func (s *SubscriptionServer) UpdateSubscription(ctx context.Context, req *pb.UpdateSubscriptionRequest) (*pb.Subscription, error) {
+	if req.GetSubscription() == nil {
+		return nil, status.Error(codes.InvalidArgument, "subscription is required")
+	}
+	rawSub := subscriptionFromProto(req.GetSubscription())
+	if err := s.Service.UpdateBySID(ctx, rawSub, strconv.FormatUint(req.GetSubscriptionId(), 10)); err != nil {
+		return nil, errorToStatus(err)
+	}
+	updated, err := s.Service.GetBySID(ctx, req.GetSubscriptionId())
+	if err != nil {
+		return nil, errorToStatus(err)
+	}
+	updated.Warnings = rawSub.Warnings
+	return subscriptionToProto(updated), nil
+}
+
+// DeleteSubscription - removes subscription, same as DELETE /subscriptions/{sid}
+func (s *SubscriptionServer) DeleteSubscription(ctx context.Context, req *pb.DeleteSubscriptionRequest) (*emptypb.Empty, error) {
+	if err := s.Service.DeleteSubscription(ctx, uint(req.GetSubscriptionId()), req.Version); err != nil {
+		return nil, errorToStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// Report - returns total price of subscriptions for month, same as GET /subscriptions/report
+func (s *SubscriptionServer) Report(ctx context.Context, req *pb.ReportRequest) (*pb.ReportResponse, error) {
+	filter := model.RawReportFilter{
+		Period:   req.GetPeriod(),
+		UID:      req.GetUserId(),
+		Provider: req.GetServiceName(),
+		Category: req.GetCategory(),
+		GroupBy:  req.GetGroupBy(),
+	}
+	if filter.Period == "" {
+		return nil, status.Error(codes.InvalidArgument, "empty mandatory period field")
+	}
+	if filter.GroupBy != "" && filter.GroupBy != model.ReportGroupByCategory {
+		return nil, status.Error(codes.InvalidArgument, "unsupported group_by value: expected category")
+	}
+
+	var report model.Report
+	var err error
+	if filter.GroupBy == model.ReportGroupByCategory {
+		var grouped *model.Report
+		if grouped, err = s.Service.ReportByCategory(ctx, &filter); err == nil {
+			report = *grouped
+		}
+	} else {
+		report.Total, err = s.Service.Report(ctx, &filter)
+	}
+	if err != nil {
+		return nil, errorToStatus(err)
+	}
+	return reportToProto(&report), nil
+}
+
+// errorToStatus - maps service and repository errors to gRPC status codes the same way REST handler maps them to HTTP codes
+func errorToStatus(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrSubNotFound), errors.Is(err, repository.ErrProviderNotFound), errors.Is(err, repository.ErrCategoryNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, repository.ErrSubExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, repository.ErrEmptySomeFields), errors.Is(err, repository.ErrEmptyAllFields),
+		errors.Is(err, repository.ErrInvalidPeriod), errors.Is(err, utils.ErrConvertToNorm):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, repository.ErrVersionMismatch), errors.Is(err, repository.ErrBudgetExceeded):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Anomalies - хендлер для отчета о вероятных дубликатах и аномалиях
+// @Summary      Отчет о дубликатах и аномалиях подписок
+// @Description  Находит вероятные дубликаты (пересекающиеся по датам подписки одного пользователя на одного провайдера или на провайдеров с похожими нормализованными названиями), подписки с ценой, в price_ratio раз отличающейся от медианы по провайдеру (при не менее чем 3 подписках на него), и пользователей с max_concurrent и более подписками одной категории, действующими в месяце period.
+// @Tags         subscriptions
+// @Produce      json
+// @Param        uid             query   string  false "UID пользователя" example(adjhdjfnv-njdfv889)
+// @Param        period          query   string  false "Месяц проверки одновременных подписок в формате 07-2024, по умолчанию текущий" example(07-2025)
+// @Param        similarity      query   number  false "Минимальная похожесть названий провайдеров от 0 до 1, по умолчанию 0.8" example(0.8)
+// @Param        price_ratio     query   number  false "Во сколько раз цена должна отличаться от медианы, по умолчанию 2" example(2)
+// @Param        max_concurrent  query   int     false "Число одновременных подписок одной категории, начиная с которого пользователь попадает в отчет, по умолчанию 3" example(3)
+// @Success      200  {object}  model.AnomalyReport  "Status OK"
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /subscriptions/report/anomalies	[get]
+func (SH *SubscriptionHandler) Anomalies(w http.ResponseWriter, r *http.Request) {
+	var filter model.RawAnomalyFilter
+	filter.UID = r.URL.Query().Get("uid")
+	filter.Period = r.URL.Query().Get("period")
+
+	var err error
+	if v := r.URL.Query().Get("similarity"); v != "" {
+		if filter.Similarity, err = strconv.ParseFloat(v, 64); err != nil {
+			http.Error(w, "Incorrect similarity parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := r.URL.Query().Get("price_ratio"); v != "" {
+		if filter.PriceRatio, err = strconv.ParseFloat(v, 64); err != nil {
+			http.Error(w, "Incorrect price_ratio parameter", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := r.URL.Query().Get("max_concurrent"); v != "" {
+		if filter.MaxConcurrent, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Incorrect max_concurrent parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := SH.Service.Anomalies(r.Context(), &filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrConvertToNorm):
+			http.Error(w, fmt.Sprintf("Validation error: %v", err), http.StatusBadRequest)
+		default:
+			http.Error(w, fmt.Sprintf("Failed to compose report: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "Failed to encode report", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/service"
+	"em-test/cmd/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Batch - хендлер для выполнения набора операций над подписками в одной транзакции
+// @Summary      Пакетное создание/обновление/удаление подписок
+// @Description  Выполняет операции create/update/delete по порядку в одной транзакции. При ошибке любой операции вся транзакция откатывается, а в ответе указывается результат каждой операции.
+// @Description  Поле version в subscription операций update и delete задает ожидаемую версию подписки; если подписка уже изменена, пакет откатывается с 412.
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Description  С заголовком Idempotency-Key повторный запрос с тем же ключом и телом получает исходный ответ, а с другим телом - 422.
+// @Param        Idempotency-Key  header  string  false  "Ключ идемпотентности запроса" example(5f0c8d2e-batch-1)
+// @Param        batch  body      model.BatchRequest  true  "Operations" example(`{"operations":[{"op":"update","subscription_id":20,"subscription":{"end_date":"07-2025"}},{"op":"create","subscription":{"service_name":"Yandex Plus Family","price":600,"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date":"08-2025"}}]}`)
+// @Success      200   {object}  model.BatchResult  "All operations committed"
+// @Failure      400   {object}  model.BatchResult  "Incomplete/incorrect data input"
+// @Failure      413   {string}  string  "Request body too large"
+// @Failure      404   {object}  model.BatchResult  "Subscription not found, batch rolled back"
+// @Failure      409   {object}  model.BatchResult  "Subscription already exists, batch rolled back; or request with the same Idempotency-Key is in progress"
+// @Failure      412   {object}  model.BatchResult  "Subscription was modified, batch rolled back"
+// @Failure      422   {object}  model.BatchResult  "Budget exceeded, batch rolled back; or Idempotency-Key reused with another request"
+// @Failure      500   {string}  string  "Internal server error"
+// @Router       /subscriptions/batch [post]
+func (SH *SubscriptionHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	var request model.BatchRequest
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	result, err := SH.Service.ExecuteBatch(r.Context(), request.Operations)
+	status := http.StatusOK
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrBatchInvalid):
+			http.Error(w, fmt.Sprintf("Validation error: %v", err), http.StatusBadRequest)
+			return
+		case !errors.Is(err, service.ErrBatchRolledBack):
+			http.Error(w, fmt.Sprintf("Internal error: %v", err), http.StatusInternalServerError)
+			return
+		case errors.Is(err, repository.ErrSubExists):
+			status = http.StatusConflict
+		case errors.Is(err, repository.ErrSubNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, repository.ErrBudgetExceeded):
+			status = http.StatusUnprocessableEntity
+		case errors.Is(err, repository.ErrVersionMismatch):
+			status = http.StatusPreconditionFailed
+		case errors.Is(err, repository.ErrEmptySomeFields), errors.Is(err, repository.ErrEmptyAllFields),
+			errors.Is(err, repository.ErrInvalidPeriod), errors.Is(err, utils.ErrConvertToNorm):
+			status = http.StatusBadRequest
+		default:
+			status = http.StatusInternalServerError
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, "Failed to encode batch result", http.StatusInternalServerError)
+		return
+	}
+}
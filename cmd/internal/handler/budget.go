@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/service"
+	"em-test/cmd/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// BudgetHandler provides process to HTTP-requests on user budgets
+type BudgetHandler struct {
+	Service *service.BudgetService
+}
+
+func CreateBudgetHandler(db *gorm.DB) *BudgetHandler {
+	return &BudgetHandler{Service: service.CreateBudgetService(db)}
+}
+
+// Create - хендлер для создания бюджета
+// @Summary      Создание месячного бюджета пользователя
+// @Description  Создает бюджет пользователя на месяц; необязательно ограничивается категорией (category_id или путь category, включая подкатегории) и/или сервисом (service_name). Режим warn (по умолчанию) - превышение при создании/изменении подписки возвращается предупреждением, reject - изменение отклоняется.
+// @Tags         budgets
+// @Accept       json
+// @Produce      json
+// @Param        budget  body      model.RawBudget  true  "Budget info" example(`{"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","amount":1500,"category":"Entertainment","mode":"reject"}`)
+// @Success      201  {object}  model.RawBudget  "Budget successfully created"
+// @Failure      400  {string}  string  "Incomplete/incorrect data input"
+// @Failure      413  {string}  string  "Request body too large"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /budgets [post]
+func (BH *BudgetHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var budget model.RawBudget
+
+	if err := decodeJSON(r, &budget); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if err := BH.Service.CreateBudget(r.Context(), &budget); err != nil {
+		writeBudgetError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(budget); err != nil {
+		http.Error(w, "Failed to encode budget", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetByID - хендлер для получения бюджета по ID
+// @Summary      Получение бюджета по ID
+// @Description  Возвращает бюджет пользователя
+// @Tags         budgets
+// @Produce      json
+// @Param        id   path      int  true  "ID бюджета" example(1)
+// @Success      200  {object}  model.RawBudget
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      404  {string}  string  "Budget not found"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /budgets/{id} [get]
+func (BH *BudgetHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Failed to parse budget ID", http.StatusBadRequest)
+		return
+	}
+
+	budget, err := BH.Service.GetBudgetByID(r.Context(), id)
+	if err != nil {
+		writeBudgetError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(budget); err != nil {
+		http.Error(w, "Failed to encode budget", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetList - хендлер для получения списка бюджетов
+// @Summary      Получение списка бюджетов
+// @Description  Отдает массив бюджетов, при указании user_id - только бюджеты этого пользователя
+// @Tags         budgets
+// @Produce      json
+// @Param        user_id  query     string  false  "UID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Success      200  {array}   model.RawBudget
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /budgets [get]
+func (BH *BudgetHandler) GetList(w http.ResponseWriter, r *http.Request) {
+	budgets, err := BH.Service.GetBudgetList(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		http.Error(w, "Failed to fetch budgets", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(budgets); err != nil {
+		http.Error(w, "Failed to encode budgets", http.StatusInternalServerError)
+	}
+}
+
+// UpdateByID - хендлер для обновления бюджета
+// @Summary      Обновление бюджета по ID
+// @Description  Обновляет переданные поля бюджета; category_id равный 0 снимает ограничение по категории
+// @Tags         budgets
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int              true  "ID бюджета" example(1)
+// @Param        budget  body      model.RawBudget  true  "Budget info" example(`{"amount":2000,"mode":"warn"}`)
+// @Success      200  {object}  model.RawBudget  "Budget updated successfully"
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      413  {string}  string  "Request body too large"
+// @Failure      404  {string}  string  "Budget not found"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /budgets/{id} [put]
+func (BH *BudgetHandler) UpdateByID(w http.ResponseWriter, r *http.Request) {
+	var budget model.RawBudget
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Failed to parse budget ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := decodeJSON(r, &budget); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if err := BH.Service.UpdateBudgetByID(r.Context(), &budget, id); err != nil {
+		writeBudgetError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(budget); err != nil {
+		http.Error(w, "Failed to encode budget", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Delete - хендлер для удаления бюджета
+// @Summary      Удаление бюджета по ID
+// @Description  Удаляет бюджет пользователя
+// @Tags         budgets
+// @Param        id   path      int  true  "ID бюджета" example(1)
+// @Success      204  {string}  string  "No Content"
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      404  {string}  string  "Budget not found"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /budgets/{id} [delete]
+func (BH *BudgetHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Failed to parse budget ID", http.StatusBadRequest)
+		return
+	}
+	if err := BH.Service.DeleteBudget(r.Context(), id); err != nil {
+		writeBudgetError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// OverBudget - хендлер для отчета о превышении бюджетов
+// @Summary      Пользователи с превышением бюджета
+// @Description  Отдает бюджеты, траты по которым в указанном месяце (по умолчанию - текущем) превышают сумму бюджета, с фактической суммой и размером превышения
+// @Tags         budgets
+// @Produce      json
+// @Param        period   query     string  false  "Месяц в формате 07-2025" example(07-2025)
+// @Param        user_id  query     string  false  "UID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Success      200  {array}   model.BudgetStatus
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /budgets/over [get]
+func (BH *BudgetHandler) OverBudget(w http.ResponseWriter, r *http.Request) {
+	statuses, err := BH.Service.OverBudget(r.Context(), r.URL.Query().Get("period"), r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeBudgetError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(statuses); err != nil {
+		http.Error(w, "Failed to encode budget report", http.StatusInternalServerError)
+	}
+}
+
+func writeBudgetError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrEmptySomeFields), errors.Is(err, repository.ErrEmptyAllFields), errors.Is(err, utils.ErrConvertToNorm):
+		http.Error(w, fmt.Sprintf("Validation error: %v", err), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrBudgetNotFound):
+		http.Error(w, "Budget not found", http.StatusNotFound)
+	default:
+		http.Error(w, fmt.Sprintf("Internal error: %v", err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"em-test/cmd/internal/repository"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Cancel - хендлер для отмены подписки
+// @Summary      Отмена подписки
+// @Description  Отменяет подписку по SID: она остается оплаченной до конца текущего месяца (или месяца начала, если еще не началась) и заканчивается в нем, если не заканчивается раньше. Статус подписки становится cancelled.
+// @Tags         subscriptions
+// @Produce      json
+// @Param        sid  path      int  true  "SID подписки" example(20)
+// @Success      200  {object}  model.RawSubscription  "Subscription cancelled"
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      404  {string}  string  "Subscription not found"
+// @Failure      409  {string}  string  "Subscription already cancelled"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /subscriptions/{sid}/cancel [post]
+func (SH *SubscriptionHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	sid, err := strconv.ParseUint(chi.URLParam(r, "sid"), 10, 64)
+	if err != nil {
+		http.Error(w, "Failed to parse subscription SID", http.StatusBadRequest)
+		return
+	}
+
+	subscription, err := SH.Service.CancelSubscription(r.Context(), sid)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrSubNotFound):
+			http.Error(w, "Subscription not found", http.StatusNotFound)
+		case errors.Is(err, repository.ErrSubCancelled):
+			http.Error(w, fmt.Sprintf("Conflict: %v", err), http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Internal error: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(subscription); err != nil {
+		http.Error(w, "Failed to encode subscription", http.StatusInternalServerError)
+		return
+	}
+}
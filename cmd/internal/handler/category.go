@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/service"
+	"em-test/cmd/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// CategoryHandler provides process to HTTP-requests on provider categories
+type CategoryHandler struct {
+	Service *service.CategoryService
+}
+
+func CreateCategoryHandler(db *gorm.DB) *CategoryHandler {
+	return &CategoryHandler{Service: service.CreateCategoryService(db)}
+}
+
+// Create - хендлер для создания категории
+// @Summary      Создание категории провайдеров
+// @Description  Создает категорию; parent_id задает родительскую категорию (например, Video внутри Entertainment)
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        category  body      model.RawCategory  true  "Category info" example(`{"name":"Video","parent_id":1}`)
+// @Success      201  {object}  model.RawCategory  "Category successfully created"
+// @Failure      400  {string}  string  "Incomplete/incorrect data input"
+// @Failure      413  {string}  string  "Request body too large"
+// @Failure      404  {string}  string  "Parent category not found"
+// @Failure      409  {string}  string  "Category already exists"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /categories [post]
+func (CH *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var newCategory model.RawCategory
+
+	if err := decodeJSON(r, &newCategory); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if err := CH.Service.CreateCategory(r.Context(), &newCategory); err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(newCategory); err != nil {
+		http.Error(w, "Failed to encode category", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetByID - хендлер для получения категории по ID
+// @Summary      Получение категории по ID
+// @Description  Возвращает категорию с полным путем
+// @Tags         categories
+// @Produce      json
+// @Param        id   path      int  true  "ID категории" example(2)
+// @Success      200  {object}  model.RawCategory
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      404  {string}  string  "Category not found"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /categories/{id} [get]
+func (CH *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Failed to parse category ID", http.StatusBadRequest)
+		return
+	}
+
+	category, err := CH.Service.GetCategoryByID(r.Context(), id)
+	if err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(category); err != nil {
+		http.Error(w, "Failed to encode category", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetList - хендлер для получения всех категорий
+// @Summary      Получение дерева категорий
+// @Description  Отдает все категории с полными путями, отсортированные по пути
+// @Tags         categories
+// @Produce      json
+// @Success      200  {array}   model.RawCategory
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /categories [get]
+func (CH *CategoryHandler) GetList(w http.ResponseWriter, r *http.Request) {
+	categories, err := CH.Service.GetCategoryList(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch categories", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(categories); err != nil {
+		http.Error(w, "Failed to encode categories", http.StatusInternalServerError)
+	}
+}
+
+// UpdateByID - хендлер для переименования или перемещения категории
+// @Summary      Обновление категории по ID
+// @Description  Переименовывает категорию и/или переносит ее под другого родителя; parent_id=0 переносит категорию в корень
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int                true  "ID категории" example(2)
+// @Param        category  body      model.RawCategory  true  "Category info" example(`{"name":"Streaming video"}`)
+// @Success      200  {object}  model.RawCategory  "Category updated successfully"
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      413  {string}  string  "Request body too large"
+// @Failure      404  {string}  string  "Category not found"
+// @Failure      409  {string}  string  "Category already exists or move creates a cycle"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /categories/{id} [put]
+func (CH *CategoryHandler) UpdateByID(w http.ResponseWriter, r *http.Request) {
+	var category model.RawCategory
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Failed to parse category ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := decodeJSON(r, &category); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if err := CH.Service.UpdateCategoryByID(r.Context(), &category, id); err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(category); err != nil {
+		http.Error(w, "Failed to encode category", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Delete - хендлер для удаления категории
+// @Summary      Удаление категории по ID
+// @Description  Удаляет категорию, если у нее нет подкатегорий и привязанных провайдеров
+// @Tags         categories
+// @Param        id   path      int  true  "ID категории" example(2)
+// @Success      204  {string}  string  "No Content"
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      404  {string}  string  "Category not found"
+// @Failure      409  {string}  string  "Category has subcategories or providers"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /categories/{id} [delete]
+func (CH *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Failed to parse category ID", http.StatusBadRequest)
+		return
+	}
+	if err := CH.Service.DeleteCategory(r.Context(), id); err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeCategoryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrEmptySomeFields), errors.Is(err, repository.ErrEmptyAllFields), errors.Is(err, utils.ErrConvertToNorm):
+		http.Error(w, fmt.Sprintf("Validation error: %v", err), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrCategoryNotFound):
+		http.Error(w, fmt.Sprintf("Not found: %v", err), http.StatusNotFound)
+	case errors.Is(err, repository.ErrCategoryExists), errors.Is(err, repository.ErrCategoryInUse), errors.Is(err, repository.ErrCategoryCycle):
+		http.Error(w, fmt.Sprintf("Conflict: %v", err), http.StatusConflict)
+	default:
+		http.Error(w, fmt.Sprintf("Internal error: %v", err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var errTrailingJSON = errors.New("request body must contain a single JSON value")
+
+// decodeJSON - decodes request body into v strictly: unknown fields and anything after the first JSON value are rejected
+func decodeJSON(r *http.Request, v any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	var extra json.RawMessage
+	if err := decoder.Decode(&extra); err != io.EOF {
+		return errTrailingJSON
+	}
+	return nil
+}
+
+// writeDecodeError - responds to a body which could not be read: 413 if it exceeds size limit, 400 otherwise
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("Request body too large: limit is %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"em-test/cmd/internal/model"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// subscriptionETag - strong entity tag of subscription, "<version>-<status>". Computed status is a part of the representation
+// and changes with time or expiry marking without a new version, so it is included to invalidate cached copies
+func subscriptionETag(sub *model.RawSubscription) string {
+	tag := strconv.FormatUint(*sub.Version, 10)
+	if sub.Status != "" {
+		tag += "-" + sub.Status
+	}
+	return `"` + tag + `"`
+}
+
+// parseIfMatch - returns version expected by If-Match header, nil if header is absent or "*". Status part of the tag is ignored:
+// If-Match guards against concurrent writes, and every write changes version. A bare "<version>" tag is accepted too.
+// Weak or foreign tags never match a subscription, so they are reported as version 0 which no subscription has.
+func parseIfMatch(r *http.Request) *uint64 {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil
+	}
+	var version uint64
+	if tag, ok := strings.CutPrefix(header, `"`); ok {
+		if tag, ok = strings.CutSuffix(tag, `"`); ok {
+			tag, _, _ = strings.Cut(tag, "-")
+			version, _ = strconv.ParseUint(tag, 10, 64)
+		}
+	}
+	return &version
+}
+
+// etagMatches - weak comparison of If-None-Match header value with entity tag
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch - writes 428 and returns false if handler requires If-Match and request has none
+func (SH *SubscriptionHandler) checkIfMatch(w http.ResponseWriter, r *http.Request) bool {
+	if SH.RequireIfMatch && r.Header.Get("If-Match") == "" {
+		http.Error(w, "Precondition required: send If-Match with ETag of the subscription", http.StatusPreconditionRequired)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"em-test/cmd/internal/service"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EventHandler provides process to HTTP-requests on event log of subscriptions
+type EventHandler struct {
+	Service      *service.EventService
+	PollInterval time.Duration // how often event stream checks log for new events
+	Heartbeat    time.Duration // how often idle event stream sends a comment to keep connection alive
+}
+
+func CreateEventHandler(db *gorm.DB) *EventHandler {
+	return &EventHandler{Service: service.CreateEventService(db), PollInterval: time.Second, Heartbeat: 15 * time.Second}
+}
+
+// GetList - хендлер для чтения журнала событий
+// @Summary      Чтение журнала событий подписок
+// @Description  Отдает события изменений подписок (subscription.created/updated/deleted/expiring) в порядке записи, следующие за курсором after. Для продолжения чтения передайте next_cursor из ответа как after.
+// @Tags         events
+// @Produce      json
+// @Param        after  query     int  false  "Курсор: ID последнего полученного события (0 - с начала журнала)" example(42)
+// @Param        limit  query     int  false  "Максимум событий (по умолчанию и не более 1000)" example(100)
+// @Success      200  {object}  model.EventPage
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /events [get]
+func (EH *EventHandler) GetList(w http.ResponseWriter, r *http.Request) {
+	after, err := parseCursor(r.URL.Query().Get("after"))
+	if err != nil {
+		http.Error(w, "Failed to parse cursor", http.StatusBadRequest)
+		return
+	}
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Failed to parse limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := EH.Service.ListAfter(r.Context(), after, limit)
+	if err != nil {
+		http.Error(w, "Failed to fetch events", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		http.Error(w, "Failed to encode events", http.StatusInternalServerError)
+	}
+}
+
+// Stream - хендлер для потока событий (Server-Sent Events)
+// @Summary      Поток событий подписок (SSE)
+// @Description  Держит соединение открытым и отправляет события журнала по мере появления в формате text/event-stream (id - курсор события, event - тип, data - событие в JSON). Чтение начинается после курсора after или заголовка Last-Event-ID при переподключении.
+// @Tags         events
+// @Produce      text/event-stream
+// @Param        after          query     int     false  "Курсор: ID последнего полученного события" example(42)
+// @Param        Last-Event-ID  header    string  false  "Курсор при переподключении"
+// @Success      200  {string}  string  "Event stream"
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      500  {string}  string  "Streaming unsupported"
+// @Router       /events/stream [get]
+func (EH *EventHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	cursorStr := r.URL.Query().Get("after")
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		cursorStr = lastID
+	}
+	cursor, err := parseCursor(cursorStr)
+	if err != nil {
+		http.Error(w, "Failed to parse cursor", http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx := r.Context()
+	poll := time.NewTicker(EH.PollInterval)
+	defer poll.Stop()
+	lastWrite := time.Now()
+	for {
+		page, err := EH.Service.ListAfter(ctx, cursor, 0)
+		if err != nil {
+			if ctx.Err() == nil {
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", "failed to fetch events")
+				flusher.Flush()
+			}
+			return
+		}
+		for _, event := range page.Events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		}
+		if len(page.Events) > 0 {
+			cursor = page.NextCursor
+			lastWrite = time.Now()
+			flusher.Flush()
+			if len(page.Events) == service.MaxEventsPage {
+				continue //догоняем журнал без ожидания
+			}
+		} else if time.Since(lastWrite) >= EH.Heartbeat {
+			fmt.Fprint(w, ": keep-alive\n\n")
+			lastWrite = time.Now()
+			flusher.Flush()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
+
+func parseCursor(source string) (uint64, error) {
+	if source == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(source, 10, 64)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/utils"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats supported by content negotiation
+const (
+	formatJSON   = "json"
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+// flushEvery - number of streamed rows after which response is flushed to client
+const flushEvery = 500
+
+// negotiateFormat - picks response format from Accept header by preference of media ranges (q, 1 by default; ranges with q=0 are excluded),
+// earlier range wins among equal q; empty Accept means JSON, empty result means nothing acceptable
+func negotiateFormat(r *http.Request) string {
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return formatJSON
+	}
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, accepted := range ranges {
+		switch accepted.mediaType {
+		case "text/csv":
+			return formatCSV
+		case "application/x-ndjson", "application/jsonl":
+			return formatNDJSON
+		case "application/json", "application/*", "*/*":
+			return formatJSON
+		}
+	}
+	return ""
+}
+
+// streamSubscriptions - writes subscriptions produced by stream as CSV or NDJSON, flushing periodically.
+// Status and headers are sent before the first row, so errors occurring mid-stream are only logged and the response is cut short.
+func streamSubscriptions(w http.ResponseWriter, format, filename string, stream func(fn func(*model.RawSubscription) error) error) {
+	flusher, _ := w.(http.Flusher)
+	var write func(*model.RawSubscription) error
+	var flush func() error
+
+	switch format {
+	case formatCSV:
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".csv"))
+		csvWriter := csv.NewWriter(w)
+		write = func(rawSub *model.RawSubscription) error {
+			return csvWriter.Write(utils.SubscriptionToCSVRecord(rawSub))
+		}
+		flush = func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+		w.WriteHeader(http.StatusOK)
+		if err := csvWriter.Write(utils.SubscriptionCSVHeader); err != nil {
+			return
+		}
+	default:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(w)
+		write = func(rawSub *model.RawSubscription) error {
+			return encoder.Encode(rawSub)
+		}
+		flush = func() error { return nil }
+		w.WriteHeader(http.StatusOK)
+	}
+
+	rowsWritten := 0
+	err := stream(func(rawSub *model.RawSubscription) error {
+		if err := write(rawSub); err != nil {
+			return err
+		}
+		rowsWritten++
+		if rowsWritten%flushEvery == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	if flushErr := flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		log.Printf("[%v] Export of %s interrupted after %d rows: %v\n", time.Now().Format("2006-01-02 15:04:05"), filename, rowsWritten, err)
+	}
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// defaultForecastMonths - forecast horizon used when months parameter is omitted
+const defaultForecastMonths = 12
+
+// Forecast - хендлер для прогноза расходов на подписки
+// @Summary      Прогноз ежемесячных расходов
+// @Description  Начиная с текущего месяца, для каждого из months месяцев выдает отчет в формате /subscriptions/report по текущим подпискам: бессрочные продолжаются, подписки с датой окончания прекращаются после нее, месяцы пробного периода бесплатны, а смена тарифа с будущего месяца учитывается с этого месяца.
+// @Tags         subscriptions
+// @Produce      json
+// @Param        months     query      int     false "Число месяцев прогноза (1-36), по умолчанию 12" example(12)
+// @Param        uid        query      string  false "UID пользователя" example(adjhdjfnv-njdfv889)
+// @Param        provider   query      string  false "Имя провайдера услуги" example(Yandex)
+// @Param        category   query      string  false "ID или путь категории, включая подкатегории" example(Entertainment > Video)
+// @Param        group_by   query      string  false "Группировка по категориям" Enums(category)
+// @Success      200  {object}  model.Forecast  "Status OK"
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /subscriptions/forecast	[get]
+func (SH *SubscriptionHandler) Forecast(w http.ResponseWriter, r *http.Request) {
+	months := defaultForecastMonths
+	if monthsStr := r.URL.Query().Get("months"); monthsStr != "" {
+		var err error
+		if months, err = strconv.Atoi(monthsStr); err != nil {
+			http.Error(w, "Incorrect months parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var filter model.RawReportFilter
+	filter.UID = r.URL.Query().Get("uid")
+	filter.Provider = r.URL.Query().Get("provider")
+	filter.Category = r.URL.Query().Get("category")
+	filter.GroupBy = r.URL.Query().Get("group_by")
+	if filter.GroupBy != "" && filter.GroupBy != model.ReportGroupByCategory {
+		http.Error(w, "Unsupported group_by value: expected category", http.StatusBadRequest)
+		return
+	}
+
+	forecast, err := SH.Service.Forecast(r.Context(), months, &filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrConvertToNorm):
+			http.Error(w, fmt.Sprintf("Validation error: %v", err), http.StatusBadRequest)
+		default:
+			http.Error(w, fmt.Sprintf("Failed to compose forecast: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(forecast); err != nil {
+		http.Error(w, "Failed to encode forecast", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"em-test/cmd/internal/gqlapi"
+	"em-test/cmd/internal/model"
+	"encoding/json"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// GraphQLHandler provides process to GraphQL queries over subscriptions, users, providers and reports
+type GraphQLHandler struct {
+	Schema *gqlapi.Schema
+}
+
+func CreateGraphQLHandler(db *gorm.DB) *GraphQLHandler {
+	return &GraphQLHandler{Schema: gqlapi.CreateSchema(db)}
+}
+
+// Query - хендлер GraphQL-запросов
+// @Summary      GraphQL-запрос
+// @Description  Выполняет GraphQL-запрос к схеме подписок, пользователей, провайдеров и отчётов (cmd/internal/gqlapi/schema.graphql). Списки поддерживают фильтры и постраничную выдачу (first, after); вложенные подписки, провайдеры и суммы пользователей загружаются пакетно, без запроса к базе на каждый элемент. Ошибки выполнения возвращаются в errors с кодом в extensions.code (BAD_USER_INPUT, NOT_FOUND, INTERNAL).
+// @Tags         graphql
+// @Accept       json
+// @Produce      json
+// @Param        request  body      model.GraphQLRequest  true  "GraphQL query"
+// @Success      200  {object}  map[string]interface{}  "data и errors"
+// @Failure      400  {string}  string  "Invalid JSON or empty query"
+// @Failure      413  {string}  string  "Request body too large"
+// @Router       /graphql [post]
+func (GH *GraphQLHandler) Query(w http.ResponseWriter, r *http.Request) {
+	var request model.GraphQLRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if request.Query == "" {
+		http.Error(w, "Empty mandatory query field", http.StatusBadRequest)
+		return
+	}
+
+	response := GH.Schema.Exec(r.Context(), request.Query, request.OperationName, request.Variables)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode result", http.StatusInternalServerError)
+		return
+	}
+}
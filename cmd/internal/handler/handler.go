@@ -18,6 +18,8 @@ import (
 // SubscriptionHandler provides process to HTTP-requests
 type SubscriptionHandler struct {
 	Service *service.SubscriptionService
+	// RequireIfMatch makes If-Match header mandatory for update and delete
+	RequireIfMatch bool
 }
 
 func CreateHandler(db *gorm.DB) *SubscriptionHandler {
@@ -26,38 +28,47 @@ func CreateHandler(db *gorm.DB) *SubscriptionHandler {
 
 // Create - хендлер для создания новой подписки в базе
 // @Summary      Cоздание новой подписки
-// @Description  Создаёт новую подписку из данных в теле запроса
+// @Description  Создаёт новую подписку из данных в теле запроса. Если месячные траты пользователя превышают его бюджет, подписка отклоняется (бюджет в режиме reject) или создается с предупреждением в warnings (режим warn).
 // @Tags         subscriptions
 // @Accept       json
 // @Produce      json
+// @Description  С заголовком Idempotency-Key повторный запрос с тем же ключом и телом получает исходный ответ (с заголовком Idempotent-Replayed), а запрос с тем же ключом и другим телом - 422.
+// @Param        Idempotency-Key  header  string  false  "Ключ идемпотентности запроса" example(5f0c8d2e-create-netflix)
 // @Param        subscription  body      model.RawSubscription  true  "Subscription info" example(`{"service_name": "Yandex Plus","price": 400,"user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date": "07-2025"}`)
 // @Success      201   {object}  model.RawSubscription "Subscription successfully created"
+// @Header       201   {string}  ETag  "Версия и статус подписки"
 // @Failure      400   {string}  string  "Incomplete/incorrect data input"
-// @Failure      409   {string}  string  "Subscription already exists"
+// @Failure      413   {string}  string  "Request body too large"
+// @Failure      409   {string}  string  "Subscription already exists or request with the same Idempotency-Key is in progress"
+// @Failure      422   {string}  string  "Budget exceeded or Idempotency-Key reused with another request"
 // @Failure      500   {string}  string  "Internal server error"
 // @Router       /subscriptions [post]
 func (SH *SubscriptionHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var newSub model.RawSubscription
 
-	if err := json.NewDecoder(r.Body).Decode(&newSub); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+	if err := decodeJSON(r, &newSub); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
 	if err := SH.Service.CreateSubscription(r.Context(), &newSub); err != nil {
 		switch {
-		case errors.Is(err, repository.ErrEmptySomeFields):
+		case errors.Is(err, repository.ErrEmptySomeFields), errors.Is(err, repository.ErrInvalidPeriod), errors.Is(err, utils.ErrConvertToNorm):
 			http.Error(w, fmt.Sprintf("Validation error: %v", err), http.StatusBadRequest)
 			return
 		case errors.Is(err, repository.ErrSubExists):
 			http.Error(w, fmt.Sprintf("Conflict: %v", err), http.StatusConflict)
 			return
+		case errors.Is(err, repository.ErrBudgetExceeded):
+			http.Error(w, fmt.Sprintf("Budget exceeded: %v", err), http.StatusUnprocessableEntity)
+			return
 		default:
 			http.Error(w, fmt.Sprintf("Internal error: %v", err), http.StatusInternalServerError)
 			return
 		}
 	}
 
+	w.Header().Set("ETag", subscriptionETag(&newSub))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(newSub); err != nil {
@@ -69,25 +80,39 @@ func (SH *SubscriptionHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 // UpdateBySID - Обновление данных существующей подписки
 // @Summary      Обновление подписки по ее SID
-// @Description  Обновляет подписку по ее SID из URL, новые данные берутся из тела запроса
+// @Description  Обновляет подписку по ее SID из URL, новые данные берутся из тела запроса; пустые поля не меняются (PUT и PATCH работают одинаково). Бюджеты пользователя проверяются так же, как при создании.
+// @Description  Для защиты от одновременного изменения передайте в If-Match значение ETag, полученное при чтении подписки (или version в теле): если подписка уже изменена, возвращается 412.
 // @Tags         subscriptions
 // @Accept       json
 // @Produce      json
 // @Param        sid   path      int  true  "SID подписки" example(20)
+// @Param        If-Match  header  string  false  "ETag подписки" example("3-active")
 // @Param        subscription  body      model.RawSubscription  true  "Subscription info" example(`{"price": 400,"user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba","end_date": "07-2025"}`)
 // @Success      200  {object}  model.RawSubscription	"Subscription updated successfully"
+// @Header       200  {string}  ETag  "Новая версия и статус подписки"
 // @Failure      404  {string}  string  "Subscription not found"
 // @Failure      400  {string}  string  "Bad request"
+// @Failure      413  {string}  string  "Request body too large"
+// @Failure      412  {string}  string  "Subscription was modified"
+// @Failure      422  {string}  string  "Budget exceeded"
+// @Failure      428  {string}  string  "If-Match required"
 // @Failure      500  {string}  string  "Internal server error"
 // @Router       /subscriptions/{sid}	[put]
+// @Router       /subscriptions/{sid}	[patch]
 func (SH *SubscriptionHandler) UpdateBySID(w http.ResponseWriter, r *http.Request) {
 	var newSub model.RawSubscription
 	sidStr := chi.URLParam(r, "sid")
 
-	if err := json.NewDecoder(r.Body).Decode(&newSub); err != nil {
-		http.Error(w, "Failed to decode subscription from json", http.StatusBadRequest)
+	if !SH.checkIfMatch(w, r) {
+		return
+	}
+	if err := decodeJSON(r, &newSub); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
+	if version := parseIfMatch(r); version != nil {
+		newSub.Version = version
+	}
 
 	if err := SH.Service.UpdateBySID(r.Context(), &newSub, sidStr); err != nil {
 		switch {
@@ -95,10 +120,19 @@ func (SH *SubscriptionHandler) UpdateBySID(w http.ResponseWriter, r *http.Reques
 			http.Error(w, "At least one field must not be empty", http.StatusBadRequest)
 		case errors.Is(err, repository.ErrSubNotFound):
 			http.Error(w, "Subscription not found", http.StatusNotFound)
+		case errors.Is(err, utils.ErrConvertToNorm), errors.Is(err, repository.ErrInvalidPeriod):
+			http.Error(w, fmt.Sprintf("Validation error: %v", err), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrBudgetExceeded):
+			http.Error(w, fmt.Sprintf("Budget exceeded: %v", err), http.StatusUnprocessableEntity)
+		case errors.Is(err, repository.ErrVersionMismatch):
+			http.Error(w, fmt.Sprintf("Precondition failed: %v", err), http.StatusPreconditionFailed)
+		default:
+			http.Error(w, fmt.Sprintf("Internal error: %v", err), http.StatusInternalServerError)
 		}
 		return
 	}
 
+	w.Header().Set("ETag", subscriptionETag(&newSub))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(newSub); err != nil {
@@ -110,11 +144,14 @@ func (SH *SubscriptionHandler) UpdateBySID(w http.ResponseWriter, r *http.Reques
 
 // GetBySID - хендлер для получения подписки по ее SID
 // @Summary      Получение подписки по SID
-// @Description  Возвращает подписку в формате JSON по ее SID из URL
+// @Description  Возвращает подписку в формате JSON по ее SID из URL с ETag из версии и вычисляемого статуса ("3-active"): статус меняется со временем без новой версии. Если ETag совпадает с If-None-Match, возвращается 304 без тела.
 // @Tags         subscriptions
 // @Produce      json
 // @Param        sid   path      int  true  "SID подписки" example(20)
+// @Param        If-None-Match  header  string  false  "ETag ранее полученного ответа" example("3-active")
 // @Success      200  {object}  model.RawSubscription
+// @Header       200  {string}  ETag  "Версия и статус подписки"
+// @Success      304  {string}  string  "Not Modified"
 // @Failure      404  {string}  string  "Subscription not found"
 // @Failure      500  {string}  string  "Internal server error"
 // @Router       /subscriptions/{sid} [get]
@@ -131,6 +168,12 @@ func (SH *SubscriptionHandler) GetBySID(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Failed to find subscription SID", http.StatusNotFound)
 		return
 	}
+	etag := subscriptionETag(subscription)
+	w.Header().Set("ETag", etag)
+	if header := r.Header.Get("If-None-Match"); header != "" && etagMatches(header, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(subscription); err != nil {
@@ -142,14 +185,38 @@ func (SH *SubscriptionHandler) GetBySID(w http.ResponseWriter, r *http.Request)
 
 // GetList - хендлер для получения списка всех подписок из базы
 // @Summary      Получение списка всех подписок из базы
-// @Description  Отдает массив из всех подписок в базе; пустой json если подписок нет
+// @Description  Отдает массив из всех подписок в базе; пустой json если подписок нет.
+// @Description  При заголовке Accept: text/csv или application/x-ndjson подписки выгружаются потоково из базы в соответствующем формате.
+// @Description  Параметр status оставляет только подписки с вычисленным статусом: active, scheduled (начинается в будущем месяце), expired (месяц окончания прошел), cancelled (отменена).
 // @Tags         subscriptions
 // @Produce      json
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        status  query     string  false  "Статус подписки" example(active)
 // @Success      200   {array}  model.RawSubscription
+// @Failure      400   {string}  string  "Unknown status"
+// @Failure      406   {string}  string  "Unsupported Accept header"
 // @Failure      500   {string}  string  "Internal server error"
 // @Router       /subscriptions [get]
 func (SH *SubscriptionHandler) GetList(w http.ResponseWriter, r *http.Request) {
-	subscriptions, err := SH.Service.GetList(r.Context())
+	status := r.URL.Query().Get("status")
+	if !utils.IsSubscriptionStatus(status) {
+		http.Error(w, fmt.Sprintf("Unknown status %q", status), http.StatusBadRequest)
+		return
+	}
+	format := negotiateFormat(r)
+	switch format {
+	case "":
+		http.Error(w, "Not acceptable: supported formats are application/json, text/csv, application/x-ndjson", http.StatusNotAcceptable)
+		return
+	case formatCSV, formatNDJSON:
+		streamSubscriptions(w, format, "subscriptions", func(fn func(*model.RawSubscription) error) error {
+			return SH.Service.StreamList(r.Context(), status, fn)
+		})
+		return
+	}
+
+	subscriptions, err := SH.Service.GetList(r.Context(), status)
 	if err != nil {
 		http.Error(w, "Failed to fetch subscriptions", http.StatusInternalServerError)
 		return
@@ -164,12 +231,15 @@ func (SH *SubscriptionHandler) GetList(w http.ResponseWriter, r *http.Request) {
 
 // Delete - хендлер для удаления подписки по SID
 // @Summary      Удаление подписки по SID
-// @Description  Удаляет подписки по ее SID из URL
+// @Description  Удаляет подписки по ее SID из URL; при заголовке If-Match подписка удаляется, только если ее ETag совпадает, иначе возвращается 412.
 // @Tags         subscriptions
 // @Param        sid   path      int  true  "SID подписки" example(20)
+// @Param        If-Match  header  string  false  "ETag подписки" example("3-active")
 // @Success      204  {string}  string  "No Content"
 // @Failure      404  {string}  string  "Subscription not found"
 // @Failure      400  {string}  string  "Bad request"
+// @Failure      412  {string}  string  "Subscription was modified"
+// @Failure      428  {string}  string  "If-Match required"
 // @Failure      500  {string}  string  "Internal server error"
 // @Router       /subscriptions/{sid}	[delete]
 func (SH *SubscriptionHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -179,11 +249,16 @@ func (SH *SubscriptionHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to parse subscription SID", http.StatusInternalServerError)
 		return
 	}
-	err = SH.Service.DeleteSubscription(r.Context(), uint(sid))
+	if !SH.checkIfMatch(w, r) {
+		return
+	}
+	err = SH.Service.DeleteSubscription(r.Context(), uint(sid), parseIfMatch(r))
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrSubNotFound):
 			http.Error(w, "Subscription not found", http.StatusNotFound)
+		case errors.Is(err, repository.ErrVersionMismatch):
+			http.Error(w, fmt.Sprintf("Precondition failed: %v", err), http.StatusPreconditionFailed)
 		default:
 			http.Error(w, fmt.Sprintf("Failed to delete subscription: %v", err), http.StatusBadRequest)
 		}
@@ -195,12 +270,21 @@ func (SH *SubscriptionHandler) Delete(w http.ResponseWriter, r *http.Request) {
 // Report - хендлер для формирования отчета по подпискам; результат - сумма стоимости подписок за период(конкретный месяц в формате "07-2024") с фильтрацией
 // @Summary      Подсчет суммы подписок удовлетворяющим условиям
 // @Description  Выдает сумму стоимости подписок по указанному периоду(конкретному месяцу в формате "07-2024"), пользователю и провайдеру; пользователь и провайдер не являются обязательными полями.
+// @Description  Месяцы пробного периода (до trial_until включительно) учитываются с нулевой стоимостью.
+// @Description  При заголовке Accept: text/csv или application/x-ndjson вместо суммы потоково выгружаются подписки, учтенные в отчете; сумма передается в заголовке X-Report-Total.
 // @Tags         subscriptions
+// @Produce      json
+// @Produce      text/csv
+// @Produce      application/x-ndjson
 // @Param        period     query      string  true  "Период(конкретный месяц в формате 07-2024) для поиска подписок в активном статусе" example(07-2025)
 // @Param        uid        query      string  false "UID пользователя" example(adjhdjfnv-njdfv889)
 // @Param        provider   query      string  false "Имя провайдера услуги" example(Yandex)
+// @Param        category   query      string  false "ID или путь категории, включая подкатегории" example(Entertainment > Video)
+// @Param        group_by   query      string  false "Группировка суммы: category" example(category)
 // @Success      200  {object}  model.Report  "Status OK"
+// @Header       200  {integer} X-Report-Total "Сумма стоимости подписок (для CSV и NDJSON)"
 // @Failure      400  {string}  string  "Bad request"
+// @Failure      406  {string}  string  "Unsupported Accept header"
 // @Failure      500  {string}  string  "Internal server error"
 // @Router       /subscriptions/report	[get]
 func (SH *SubscriptionHandler) Report(w http.ResponseWriter, r *http.Request) {
@@ -209,14 +293,34 @@ func (SH *SubscriptionHandler) Report(w http.ResponseWriter, r *http.Request) {
 	filter.Period = r.URL.Query().Get("period")
 	filter.UID = r.URL.Query().Get("uid")
 	filter.Provider = r.URL.Query().Get("provider")
+	filter.Category = r.URL.Query().Get("category")
+	filter.GroupBy = r.URL.Query().Get("group_by")
 
 	if filter.Period == "" {
 		http.Error(w, "Empty mandatory period field", http.StatusBadRequest)
 		return
 	}
+	if filter.GroupBy != "" && filter.GroupBy != model.ReportGroupByCategory {
+		http.Error(w, "Unsupported group_by value: expected category", http.StatusBadRequest)
+		return
+	}
+
+	format := negotiateFormat(r)
+	if format == "" {
+		http.Error(w, "Not acceptable: supported formats are application/json, text/csv, application/x-ndjson", http.StatusNotAcceptable)
+		return
+	}
 
 	var err error
-	if result.Total, err = SH.Service.Report(r.Context(), &filter); err != nil {
+	if filter.GroupBy == model.ReportGroupByCategory && format == formatJSON {
+		var grouped *model.Report
+		if grouped, err = SH.Service.ReportByCategory(r.Context(), &filter); err == nil {
+			result = *grouped
+		}
+	} else {
+		result.Total, err = SH.Service.Report(r.Context(), &filter)
+	}
+	if err != nil {
 		switch {
 		case errors.Is(err, utils.ErrConvertToNorm):
 			http.Error(w, "Incorrect input data", http.StatusBadRequest)
@@ -226,6 +330,14 @@ func (SH *SubscriptionHandler) Report(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if format != formatJSON {
+		w.Header().Set("X-Report-Total", strconv.FormatUint(uint64(result.Total), 10))
+		streamSubscriptions(w, format, "report-"+filter.Period, func(fn func(*model.RawSubscription) error) error {
+			return SH.Service.StreamReport(r.Context(), &filter, fn)
+		})
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(result); err != nil {
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/service"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+// IdempotencyKeyHeader - header carrying client-generated key of a POST request; retries with the same key get the original response
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader - header marking response replayed from storage
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyHandler makes POST handlers safe to retry with Idempotency-Key header
+type IdempotencyHandler struct {
+	Service *service.IdempotencyService
+}
+
+func CreateIdempotencyHandler(db *gorm.DB) *IdempotencyHandler {
+	return &IdempotencyHandler{Service: service.CreateIdempotencyService(db)}
+}
+
+// Idempotent - wraps handler: request with Idempotency-Key is executed once and its response is stored with hash of the request;
+// retries with the same key and request get the stored response, with another request - 422, while the first one runs - 409.
+// Server errors are not stored, so such requests may be retried with the same key. Requests without the header pass through.
+func (IH *IdempotencyHandler) Idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			http.Error(w, fmt.Sprintf("%s must not exceed %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength), http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeDecodeError(w, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash := sha256.Sum256(append([]byte(r.Method+" "+r.URL.RequestURI()+"\n"), body...))
+
+		stored, err := IH.Service.Begin(r.Context(), key, hex.EncodeToString(hash[:]))
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrIdempotencyKeyReused):
+				http.Error(w, fmt.Sprintf("Unprocessable: %v", err), http.StatusUnprocessableEntity)
+			case errors.Is(err, service.ErrIdempotencyInProgress):
+				http.Error(w, fmt.Sprintf("Conflict: %v", err), http.StatusConflict)
+			default:
+				http.Error(w, fmt.Sprintf("Internal error: %v", err), http.StatusInternalServerError)
+			}
+			return
+		}
+		if stored != nil {
+			var header http.Header
+			json.Unmarshal([]byte(stored.Headers), &header)
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			writeCaptured(w, header, stored.StatusCode, stored.Body)
+			return
+		}
+
+		capture := &responseCapture{header: http.Header{}}
+		next(capture, r)
+		if capture.status == 0 {
+			capture.status = http.StatusOK
+		}
+		header, _ := json.Marshal(capture.header)
+		//ответ сохраняется, даже если клиент уже отключился
+		IH.Service.Complete(context.WithoutCancel(r.Context()), &model.IdempotencyKey{
+			Key:        key,
+			StatusCode: capture.status,
+			Headers:    string(header),
+			Body:       capture.body.Bytes(),
+		})
+		writeCaptured(w, capture.header, capture.status, capture.body.Bytes())
+	}
+}
+
+// responseCapture - http.ResponseWriter keeping response in memory
+type responseCapture struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rc *responseCapture) Header() http.Header {
+	return rc.header
+}
+
+func (rc *responseCapture) WriteHeader(status int) {
+	if rc.status == 0 {
+		rc.status = status
+	}
+}
+
+func (rc *responseCapture) Write(b []byte) (int, error) {
+	rc.WriteHeader(http.StatusOK)
+	return rc.body.Write(b)
+}
+
+func writeCaptured(w http.ResponseWriter, header http.Header, status int, body []byte) {
+	for k, v := range header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
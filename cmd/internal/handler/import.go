@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/service"
+	"em-test/cmd/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// Import - хендлер для массового импорта подписок из CSV или NDJSON
+// @Summary      Массовый импорт подписок
+// @Description  Принимает CSV (с заголовком service_name,price,user_id,start_date,end_date) или NDJSON (по одному объекту подписки на строку). Каждая строка проверяется по тем же правилам, что и при создании подписки, включая пересечения с базой и внутри пакета.
+// @Description  mode=all_or_nothing (по умолчанию) сохраняет строки только если все они валидны, mode=valid_only сохраняет только валидные строки. dry_run=true только проверяет данные.
+// @Tags         subscriptions
+// @Accept       text/csv
+// @Accept       application/x-ndjson
+// @Produce      json
+// @Param        mode     query     string  false  "Режим импорта: all_or_nothing или valid_only" example(valid_only)
+// @Param        dry_run  query     bool    false  "Только проверка без сохранения" example(true)
+// @Success      200   {object}  model.ImportReport  "Validation report (dry run or nothing to create)"
+// @Success      201   {object}  model.ImportReport  "Rows imported"
+// @Failure      400   {string}  string  "Malformed payload or parameters"
+// @Failure      413   {string}  string  "Request body too large"
+// @Failure      415   {string}  string  "Unsupported content type"
+// @Failure      422   {object}  model.ImportReport  "Some rows are invalid, nothing imported"
+// @Failure      500   {string}  string  "Internal server error"
+// @Router       /subscriptions/import [post]
+func (SH *SubscriptionHandler) Import(w http.ResponseWriter, r *http.Request) {
+	var opts model.ImportOptions
+	opts.Mode = r.URL.Query().Get("mode")
+	if dryRun := r.URL.Query().Get("dry_run"); dryRun != "" {
+		var err error
+		if opts.DryRun, err = strconv.ParseBool(dryRun); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid dry_run value: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var rows []model.ImportRow
+	var err error
+	switch mediaType {
+	case "text/csv":
+		rows, err = utils.ParseCSVSubscriptions(r.Body)
+	case "application/x-ndjson", "application/jsonl":
+		rows, err = utils.ParseNDJSONSubscriptions(r.Body)
+	default:
+		http.Error(w, "Unsupported content type: expected text/csv or application/x-ndjson", http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("Request body too large: limit is %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to parse import payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	report, err := SH.Service.ImportSubscriptions(r.Context(), rows, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnknownImportMode):
+			http.Error(w, fmt.Sprintf("Validation error: %v", err), http.StatusBadRequest)
+		default:
+			http.Error(w, fmt.Sprintf("Failed to import subscriptions: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	status := http.StatusOK
+	switch {
+	case report.Created > 0:
+		status = http.StatusCreated
+	case !report.DryRun && report.Mode == model.ImportModeAllOrNothing && report.Failed > 0:
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "Failed to encode import report", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/service"
+	"em-test/cmd/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// ProviderHandler provides process to HTTP-requests on providers catalog
+type ProviderHandler struct {
+	Service *service.ProviderService
+}
+
+func CreateProviderHandler(db *gorm.DB) *ProviderHandler {
+	return &ProviderHandler{Service: service.CreateProviderService(db)}
+}
+
+// Create - хендлер для добавления провайдера в каталог
+// @Summary      Добавление провайдера в каталог
+// @Description  Создает провайдера с каноническим названием, синонимами, категорией (category_id или путь category) и ценой по умолчанию. Существующие подписки с совпадающим названием сервиса привязываются к провайдеру.
+// @Tags         providers
+// @Accept       json
+// @Produce      json
+// @Param        provider  body      model.RawProvider  true  "Provider info" example(`{"name":"Yandex Plus","aliases":["YandexPlus","Яндекс Плюс"],"category":"Entertainment > Video","default_price":400}`)
+// @Success      201  {object}  model.RawProvider  "Provider successfully created"
+// @Failure      400  {string}  string  "Incomplete/incorrect data input"
+// @Failure      413  {string}  string  "Request body too large"
+// @Failure      409  {string}  string  "Provider name or alias already exists"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /providers [post]
+func (PH *ProviderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var newProvider model.RawProvider
+
+	if err := decodeJSON(r, &newProvider); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if err := PH.Service.CreateProvider(r.Context(), &newProvider); err != nil {
+		writeProviderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(newProvider); err != nil {
+		http.Error(w, "Failed to encode provider", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetByID - хендлер для получения провайдера по ID
+// @Summary      Получение провайдера по ID
+// @Description  Возвращает провайдера из каталога вместе с синонимами
+// @Tags         providers
+// @Produce      json
+// @Param        id   path      int  true  "ID провайдера" example(3)
+// @Success      200  {object}  model.RawProvider
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      404  {string}  string  "Provider not found"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /providers/{id} [get]
+func (PH *ProviderHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Failed to parse provider ID", http.StatusBadRequest)
+		return
+	}
+
+	provider, err := PH.Service.GetProviderByID(r.Context(), id)
+	if err != nil {
+		writeProviderError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(provider); err != nil {
+		http.Error(w, "Failed to encode provider", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetList - хендлер для получения каталога провайдеров
+// @Summary      Получение каталога провайдеров
+// @Description  Отдает массив всех провайдеров каталога, отсортированных по названию
+// @Tags         providers
+// @Produce      json
+// @Success      200  {array}   model.RawProvider
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /providers [get]
+func (PH *ProviderHandler) GetList(w http.ResponseWriter, r *http.Request) {
+	providers, err := PH.Service.GetProviderList(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch providers", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(providers); err != nil {
+		http.Error(w, "Failed to encode providers", http.StatusInternalServerError)
+	}
+}
+
+// UpdateByID - хендлер для обновления провайдера
+// @Summary      Обновление провайдера по ID
+// @Description  Обновляет непустые поля провайдера; список синонимов заменяется целиком, если передан. При переименовании обновляются названия сервиса у привязанных подписок.
+// @Tags         providers
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int                true  "ID провайдера" example(3)
+// @Param        provider  body      model.RawProvider  true  "Provider info" example(`{"aliases":["YandexPlus"],"default_price":450}`)
+// @Success      200  {object}  model.RawProvider  "Provider updated successfully"
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      413  {string}  string  "Request body too large"
+// @Failure      404  {string}  string  "Provider not found"
+// @Failure      409  {string}  string  "Provider name or alias already exists"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /providers/{id} [put]
+func (PH *ProviderHandler) UpdateByID(w http.ResponseWriter, r *http.Request) {
+	var provider model.RawProvider
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Failed to parse provider ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := decodeJSON(r, &provider); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if err := PH.Service.UpdateProviderByID(r.Context(), &provider, id); err != nil {
+		writeProviderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(provider); err != nil {
+		http.Error(w, "Failed to encode provider", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Delete - хендлер для удаления провайдера
+// @Summary      Удаление провайдера по ID
+// @Description  Удаляет провайдера из каталога, если на него не ссылаются подписки
+// @Tags         providers
+// @Param        id   path      int  true  "ID провайдера" example(3)
+// @Success      204  {string}  string  "No Content"
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      404  {string}  string  "Provider not found"
+// @Failure      409  {string}  string  "Provider is referenced by subscriptions"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /providers/{id} [delete]
+func (PH *ProviderHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Failed to parse provider ID", http.StatusBadRequest)
+		return
+	}
+	if err := PH.Service.DeleteProvider(r.Context(), id); err != nil {
+		writeProviderError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeProviderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrEmptySomeFields), errors.Is(err, repository.ErrEmptyAllFields),
+		errors.Is(err, repository.ErrCategoryNotFound), errors.Is(err, utils.ErrConvertToNorm):
+		http.Error(w, fmt.Sprintf("Validation error: %v", err), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrProviderNotFound):
+		http.Error(w, "Provider not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrProviderExists), errors.Is(err, repository.ErrProviderInUse):
+		http.Error(w, fmt.Sprintf("Conflict: %v", err), http.StatusConflict)
+	default:
+		http.Error(w, fmt.Sprintf("Internal error: %v", err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ReportCacheStats - хендлер для метрик кэша отчетов
+// @Summary      Метрики кэша отчетов
+// @Description  Число попаданий и промахов кэша сумм /subscriptions/report с запуска процесса, доля попаданий, число записей, а также записей, сброшенных из-за изменения подписок их пользователя, сервиса или месяца (invalidations) и вытесненных по размеру или сроку жизни (evictions). При REPORT_CACHE_SIZE=0 кэш выключен (enabled=false).
+// @Tags         subscriptions
+// @Produce      json
+// @Success      200  {object}  model.ReportCacheStats  "Status OK"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /subscriptions/report/cache	[get]
+func (SH *SubscriptionHandler) ReportCacheStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := SH.Service.ReportCacheStats(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read report cache stats: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		http.Error(w, "Failed to encode report cache stats", http.StatusInternalServerError)
+	}
+}
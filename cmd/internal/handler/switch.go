@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Switch - хендлер для перехода на другой тариф/сервис
+// @Summary      Переход подписки на другой тариф
+// @Description  Закрывает подписку по SID в указанном месяце (switch_month) и создает новую подписку с началом в следующем месяце, связанную с предыдущей через previous_subscription_id. Название сервиса и цена по умолчанию берутся из текущей подписки.
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        sid     path      int                  true  "SID подписки" example(20)
+// @Param        switch  body      model.SwitchRequest  true  "Switch info" example(`{"service_name":"Yandex Plus Family","price":600,"switch_month":"07-2025"}`)
+// @Success      201  {object}  model.SwitchResult  "Subscription switched"
+// @Failure      400  {string}  string  "Incomplete/incorrect data input"
+// @Failure      413  {string}  string  "Request body too large"
+// @Failure      404  {string}  string  "Subscription not found"
+// @Failure      409  {string}  string  "Successor overlaps existing subscription"
+// @Failure      422  {string}  string  "Budget exceeded"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /subscriptions/{sid}/switch [post]
+func (SH *SubscriptionHandler) Switch(w http.ResponseWriter, r *http.Request) {
+	var request model.SwitchRequest
+	sid, err := strconv.ParseUint(chi.URLParam(r, "sid"), 10, 64)
+	if err != nil {
+		http.Error(w, "Failed to parse subscription SID", http.StatusBadRequest)
+		return
+	}
+
+	if err := decodeJSON(r, &request); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	result, err := SH.Service.SwitchPlan(r.Context(), sid, &request)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEmptySomeFields), errors.Is(err, repository.ErrInvalidPeriod), errors.Is(err, utils.ErrConvertToNorm):
+			http.Error(w, fmt.Sprintf("Validation error: %v", err), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrSubNotFound):
+			http.Error(w, "Subscription not found", http.StatusNotFound)
+		case errors.Is(err, repository.ErrSubExists):
+			http.Error(w, fmt.Sprintf("Conflict: %v", err), http.StatusConflict)
+		case errors.Is(err, repository.ErrBudgetExceeded):
+			http.Error(w, fmt.Sprintf("Budget exceeded: %v", err), http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, fmt.Sprintf("Internal error: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, "Failed to encode switch result", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// TrialReport - хендлер для отчета по пробным периодам
+// @Summary      Отчет по пробным периодам за месяц
+// @Description  Для указанного месяца выдает число подписок в пробном периоде, число закончившихся в этом месяце пробных периодов и сколько из них переходят в платные (подписка продолжается после trial_until) с их ежемесячной стоимостью.
+// @Tags         subscriptions
+// @Produce      json
+// @Param        period     query      string  true  "Месяц в формате 07-2024" example(07-2025)
+// @Param        uid        query      string  false "UID пользователя" example(adjhdjfnv-njdfv889)
+// @Param        provider   query      string  false "Имя провайдера услуги" example(Yandex)
+// @Param        category   query      string  false "ID или путь категории, включая подкатегории" example(Entertainment > Video)
+// @Success      200  {object}  model.TrialReport  "Status OK"
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /subscriptions/report/trials	[get]
+func (SH *SubscriptionHandler) TrialReport(w http.ResponseWriter, r *http.Request) {
+	var filter model.RawReportFilter
+	filter.Period = r.URL.Query().Get("period")
+	filter.UID = r.URL.Query().Get("uid")
+	filter.Provider = r.URL.Query().Get("provider")
+	filter.Category = r.URL.Query().Get("category")
+
+	if filter.Period == "" {
+		http.Error(w, "Empty mandatory period field", http.StatusBadRequest)
+		return
+	}
+
+	report, err := SH.Service.TrialReport(r.Context(), &filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrConvertToNorm):
+			http.Error(w, "Incorrect input data", http.StatusBadRequest)
+		default:
+			http.Error(w, fmt.Sprintf("Failed to compose report: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "Failed to encode report", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// defaultUpcomingWindow - window used when within parameter is omitted
+const defaultUpcomingWindow = "30d"
+
+// Upcoming - хендлер для получения подписок, которые скоро закончатся или продлятся
+// @Summary      Ближайшие продления и окончания подписок
+// @Description  Возвращает подписки, у которых дата окончания (конец месяца end_date) попадает в окно от текущего момента, и бессрочные подписки, очередное ежемесячное списание которых (первое число ближайшего неоплаченного месяца) попадает в окно. Результат отсортирован по дате события.
+// @Tags         subscriptions
+// @Produce      json
+// @Param        within   query     string  false  "Окно: дни (30d), недели (2w) или длительность Go (36h); по умолчанию 30d" example(30d)
+// @Param        user_id  query     string  false  "UID пользователя" example(60601fee-2bf1-4721-ae6f-7636e79a0cba)
+// @Success      200  {array}   model.UpcomingSubscription
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /subscriptions/upcoming [get]
+func (SH *SubscriptionHandler) Upcoming(w http.ResponseWriter, r *http.Request) {
+	withinStr := r.URL.Query().Get("within")
+	if withinStr == "" {
+		withinStr = defaultUpcomingWindow
+	}
+	within, err := utils.ParseWindow(withinStr)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Validation error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	upcoming, err := SH.Service.Upcoming(r.Context(), within, r.URL.Query().Get("user_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrConvertToNorm):
+			http.Error(w, fmt.Sprintf("Validation error: %v", err), http.StatusBadRequest)
+		default:
+			http.Error(w, fmt.Sprintf("Failed to find upcoming subscriptions: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if upcoming == nil {
+		upcoming = []*model.UpcomingSubscription{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(upcoming); err != nil {
+		http.Error(w, "Failed to encode upcoming subscriptions", http.StatusInternalServerError)
+		return
+	}
+}
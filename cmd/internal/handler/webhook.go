@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/service"
+	"em-test/cmd/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// WebhookHandler provides process to HTTP-requests on webhook registrations and deliveries
+type WebhookHandler struct {
+	Service *service.WebhookService
+}
+
+func CreateWebhookHandler(db *gorm.DB) *WebhookHandler {
+	return &WebhookHandler{Service: service.CreateWebhookService(db)}
+}
+
+// Create - хендлер для регистрации вебхука
+// @Summary      Регистрация вебхука
+// @Description  Регистрирует URL-получатель событий подписок (subscription.created/updated/deleted/expiring). Пустой event_types означает все события. Если secret не передан, он генерируется; secret возвращается только в ответе на создание. Каждый запрос подписывается заголовком X-Webhook-Signature: sha256=HMAC-SHA256(secret, "<X-Webhook-Timestamp>.<body>").
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        webhook  body      model.RawWebhook  true  "Webhook info" example(`{"url":"https://notify.example.com/hooks","event_types":["subscription.created","subscription.expiring"]}`)
+// @Success      201  {object}  model.RawWebhook  "Webhook registered"
+// @Failure      400  {string}  string  "Incomplete/incorrect data input"
+// @Failure      413  {string}  string  "Request body too large"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /webhooks [post]
+func (WH *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var webhook model.RawWebhook
+
+	if err := decodeJSON(r, &webhook); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if err := WH.Service.CreateWebhook(r.Context(), &webhook); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(webhook); err != nil {
+		http.Error(w, "Failed to encode webhook", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetByID - хендлер для получения вебхука по ID
+// @Summary      Получение вебхука по ID
+// @Description  Возвращает зарегистрированный вебхук (без secret)
+// @Tags         webhooks
+// @Produce      json
+// @Param        id   path      int  true  "ID вебхука" example(1)
+// @Success      200  {object}  model.RawWebhook
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      404  {string}  string  "Webhook not found"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /webhooks/{id} [get]
+func (WH *WebhookHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Failed to parse webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := WH.Service.GetWebhookByID(r.Context(), id)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(webhook); err != nil {
+		http.Error(w, "Failed to encode webhook", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetList - хендлер для получения списка вебхуков
+// @Summary      Получение списка вебхуков
+// @Description  Отдает массив всех зарегистрированных вебхуков (без secret)
+// @Tags         webhooks
+// @Produce      json
+// @Success      200  {array}   model.RawWebhook
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /webhooks [get]
+func (WH *WebhookHandler) GetList(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := WH.Service.GetWebhookList(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch webhooks", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(webhooks); err != nil {
+		http.Error(w, "Failed to encode webhooks", http.StatusInternalServerError)
+	}
+}
+
+// UpdateByID - хендлер для обновления вебхука
+// @Summary      Обновление вебхука по ID
+// @Description  Обновляет переданные поля вебхука: url, secret, event_types, active. Неактивный вебхук не получает новых событий.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int               true  "ID вебхука" example(1)
+// @Param        webhook  body      model.RawWebhook  true  "Webhook info" example(`{"active":false}`)
+// @Success      200  {object}  model.RawWebhook  "Webhook updated successfully"
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      413  {string}  string  "Request body too large"
+// @Failure      404  {string}  string  "Webhook not found"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /webhooks/{id} [put]
+func (WH *WebhookHandler) UpdateByID(w http.ResponseWriter, r *http.Request) {
+	var webhook model.RawWebhook
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Failed to parse webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := decodeJSON(r, &webhook); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if err := WH.Service.UpdateWebhookByID(r.Context(), &webhook, id); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(webhook); err != nil {
+		http.Error(w, "Failed to encode webhook", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Delete - хендлер для удаления вебхука
+// @Summary      Удаление вебхука по ID
+// @Description  Удаляет вебхук вместе с историей его доставок
+// @Tags         webhooks
+// @Param        id   path      int  true  "ID вебхука" example(1)
+// @Success      204  {string}  string  "No Content"
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      404  {string}  string  "Webhook not found"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /webhooks/{id} [delete]
+func (WH *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Failed to parse webhook ID", http.StatusBadRequest)
+		return
+	}
+	if err := WH.Service.DeleteWebhook(r.Context(), id); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDeliveries - хендлер для просмотра доставок вебхуков
+// @Summary      Просмотр доставок вебхуков
+// @Description  Отдает доставки событий (новые первыми) с фильтром по статусу (pending, delivered, dead) и вебхуку. status=dead - просмотр недоставленных событий, попытки по которым исчерпаны.
+// @Tags         webhooks
+// @Produce      json
+// @Param        status      query     string  false  "Статус доставки" example(dead)
+// @Param        webhook_id  query     int     false  "ID вебхука" example(1)
+// @Param        limit       query     int     false  "Максимум записей (по умолчанию и не более 500)" example(100)
+// @Success      200  {array}   model.WebhookDelivery
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /webhooks/deliveries [get]
+func (WH *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var webhookID *uint64
+	if v := query.Get("webhook_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, "Failed to parse webhook ID", http.StatusBadRequest)
+			return
+		}
+		webhookID = &id
+	}
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Failed to parse limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	deliveries, err := WH.Service.GetDeliveries(r.Context(), query.Get("status"), webhookID, limit)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(deliveries); err != nil {
+		http.Error(w, "Failed to encode deliveries", http.StatusInternalServerError)
+	}
+}
+
+// RetryDelivery - хендлер для повторной отправки недоставленного события
+// @Summary      Повторная отправка недоставленного события
+// @Description  Возвращает доставку со статусом dead в очередь: счетчик попыток сбрасывается, отправка происходит при ближайшем проходе диспетчера
+// @Tags         webhooks
+// @Produce      json
+// @Param        id   path      int  true  "ID доставки" example(15)
+// @Success      200  {object}  model.WebhookDelivery  "Delivery requeued"
+// @Failure      400  {string}  string  "Bad request"
+// @Failure      404  {string}  string  "Delivery not found"
+// @Failure      409  {string}  string  "Delivery is not dead"
+// @Failure      500  {string}  string  "Internal server error"
+// @Router       /webhooks/deliveries/{id}/retry [post]
+func (WH *WebhookHandler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Failed to parse delivery ID", http.StatusBadRequest)
+		return
+	}
+	delivery, err := WH.Service.RetryDelivery(r.Context(), id)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(delivery); err != nil {
+		http.Error(w, "Failed to encode delivery", http.StatusInternalServerError)
+	}
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrEmptySomeFields), errors.Is(err, repository.ErrEmptyAllFields), errors.Is(err, utils.ErrConvertToNorm):
+		http.Error(w, fmt.Sprintf("Validation error: %v", err), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrWebhookNotFound):
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+	case errors.Is(err, repository.ErrDeliveryNotFound):
+		http.Error(w, "Delivery not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrDeliveryNotDead):
+		http.Error(w, fmt.Sprintf("Conflict: %v", err), http.StatusConflict)
+	default:
+		http.Error(w, fmt.Sprintf("Internal error: %v", err), http.StatusInternalServerError)
+	}
+}
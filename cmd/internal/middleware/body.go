@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// MaxBodySize - limits request body to limit bytes: requests declaring larger Content-Length get 413 at once,
+// reading beyond the limit fails with *http.MaxBytesError
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				http.Error(w, fmt.Sprintf("Request body too large: limit is %d bytes", limit), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"container/list"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sweepInterval - how often buckets of idle clients are dropped
+const sweepInterval = time.Minute
+
+// defaultMaxClients - number of client buckets kept by CreateRateLimiter
+const defaultMaxClients = 100000
+
+// RateLimiter - token bucket per client: a client may send Burst requests at once and then Rate requests per second.
+// Clients are told about their budget in RateLimit-* headers; requests over the limit get 429 with Retry-After.
+type RateLimiter struct {
+	Rate  float64 // tokens added per second
+	Burst int     // bucket capacity
+	// MaxClients - max number of buckets kept; buckets of least recently seen clients are dropped first
+	MaxClients int
+	// Key identifies client of request, ClientKey if not set. Keys must not be chosen freely by clients,
+	// otherwise a client gets a fresh bucket with every new key
+	Key func(r *http.Request) string
+	// Now is used instead of time.Now when set
+	Now func() time.Time
+
+	mu        sync.Mutex
+	order     *list.List // buckets, front is the most recently used
+	buckets   map[string]*list.Element
+	lastSweep time.Time
+}
+
+type bucket struct {
+	client  string
+	tokens  float64
+	updated time.Time
+}
+
+// CreateRateLimiter - limiter of rate requests per second with bursts of burst requests; burst below 1 is raised to 1,
+// as a bucket that can't hold a whole token rejects every request
+func CreateRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{Rate: rate, Burst: max(burst, 1), MaxClients: defaultMaxClients, order: list.New(), buckets: map[string]*list.Element{}}
+}
+
+// Allow - takes a token from bucket of client; returns whether request is allowed, tokens left
+// and time until the bucket is full again (or, if request is not allowed, until the next token)
+func (rl *RateLimiter) Allow(client string) (bool, int, time.Duration) {
+	now := time.Now()
+	if rl.Now != nil {
+		now = rl.Now()
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.sweep(now)
+
+	var b *bucket
+	if element, ok := rl.buckets[client]; ok {
+		rl.order.MoveToFront(element)
+		b = element.Value.(*bucket)
+	} else {
+		b = &bucket{client: client, tokens: float64(rl.Burst), updated: now}
+		rl.buckets[client] = rl.order.PushFront(b)
+		for rl.order.Len() > rl.MaxClients {
+			rl.remove(rl.order.Back())
+		}
+	}
+	b.tokens = math.Min(float64(rl.Burst), b.tokens+now.Sub(b.updated).Seconds()*rl.Rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false, 0, rl.timeFor(1 - b.tokens)
+	}
+	b.tokens--
+	return true, int(b.tokens), rl.timeFor(float64(rl.Burst) - b.tokens)
+}
+
+// timeFor - time needed to add provided number of tokens
+func (rl *RateLimiter) timeFor(tokens float64) time.Duration {
+	return time.Duration(tokens / rl.Rate * float64(time.Second))
+}
+
+// sweep - drops buckets which have been refilled completely, they are equal to new ones. Buckets are ordered by last use,
+// so refilled ones are at the back
+func (rl *RateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.lastSweep) < sweepInterval {
+		return
+	}
+	rl.lastSweep = now
+	full := rl.timeFor(float64(rl.Burst))
+	for element := rl.order.Back(); element != nil && now.Sub(element.Value.(*bucket).updated) >= full; element = rl.order.Back() {
+		rl.remove(element)
+	}
+}
+
+func (rl *RateLimiter) remove(element *list.Element) {
+	rl.order.Remove(element)
+	delete(rl.buckets, element.Value.(*bucket).client)
+}
+
+// Middleware - limits requests per client (see Key) and sets RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	policy := strconv.Itoa(rl.Burst) + ";w=" + strconv.Itoa(int(math.Ceil(float64(rl.Burst)/rl.Rate)))
+	key := rl.Key
+	if key == nil {
+		key = ClientKey
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, remaining, reset := rl.Allow(key(r))
+		seconds := strconv.Itoa(int(math.Ceil(reset.Seconds())))
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(rl.Burst))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("RateLimit-Reset", seconds)
+		w.Header().Set("RateLimit-Policy", policy)
+		if !allowed {
+			w.Header().Set("Retry-After", seconds)
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientKey - identifies client of request for rate limiting by IP address. API keys and bearer tokens are not verified
+// by the service, so they can't separate clients: any request could carry a new one
+func ClientKey(r *http.Request) string {
+	return "ip:" + remoteIP(r)
+}
+
+// ForwardedClientKey - same as ClientKey for a service behind reverse proxies: when request comes from one of trusted networks,
+// client is the rightmost address of X-Forwarded-For not belonging to them. Addresses to the left of it are set by the client itself
+// and are ignored
+func ForwardedClientKey(trusted []*net.IPNet) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		client := remoteIP(r)
+		if !isTrusted(trusted, client) {
+			return "ip:" + client
+		}
+		forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		for i := len(forwarded) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(forwarded[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			client = hop
+			if !isTrusted(trusted, hop) {
+				break
+			}
+		}
+		return "ip:" + client
+	}
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return host
+}
+
+func isTrusted(trusted []*net.IPNet, addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
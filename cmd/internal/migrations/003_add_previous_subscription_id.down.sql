@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_subscriptions_previous_id;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS previous_subscription_id;
@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS previous_subscription_id INTEGER REFERENCES subscriptions(subscription_id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_subscriptions_previous_id ON subscriptions(previous_subscription_id);
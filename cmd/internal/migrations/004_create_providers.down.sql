@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_subscriptions_provider_id;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS provider_id;
+DROP TABLE IF EXISTS provider_aliases;
+DROP TABLE IF EXISTS providers;
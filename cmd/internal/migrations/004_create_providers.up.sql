@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS providers (
+    provider_id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    normalized_name TEXT NOT NULL UNIQUE,
+    category TEXT,
+    default_price INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS provider_aliases (
+    alias_id SERIAL PRIMARY KEY,
+    provider_id INTEGER NOT NULL REFERENCES providers(provider_id) ON DELETE CASCADE,
+    alias TEXT NOT NULL,
+    normalized_alias TEXT NOT NULL UNIQUE
+);
+CREATE INDEX IF NOT EXISTS idx_provider_aliases_provider_id ON provider_aliases(provider_id);
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS provider_id INTEGER REFERENCES providers(provider_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_provider_id ON subscriptions(provider_id);
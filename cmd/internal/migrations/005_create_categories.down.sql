@@ -0,0 +1,6 @@
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS category TEXT;
+UPDATE providers SET category = categories.name
+FROM categories WHERE categories.category_id = providers.category_id;
+DROP INDEX IF EXISTS idx_providers_category_id;
+ALTER TABLE providers DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS categories;
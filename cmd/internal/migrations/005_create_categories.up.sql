@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS categories (
+    category_id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    parent_id INTEGER REFERENCES categories(category_id)
+);
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
+
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS category_id INTEGER REFERENCES categories(category_id);
+CREATE INDEX IF NOT EXISTS idx_providers_category_id ON providers(category_id);
+
+-- Текстовые категории провайдеров становятся корневыми категориями
+INSERT INTO categories (name)
+SELECT DISTINCT category FROM providers WHERE category IS NOT NULL AND category <> '';
+UPDATE providers SET category_id = categories.category_id
+FROM categories WHERE categories.parent_id IS NULL AND categories.name = providers.category;
+ALTER TABLE providers DROP COLUMN IF EXISTS category;
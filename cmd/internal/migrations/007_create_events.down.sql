@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_event_id;
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS dedupe_key TEXT;
+-- ключи дедупликации уведомлений возвращаются из журнала в доставки
+UPDATE webhook_deliveries d SET dedupe_key = e.dedupe_key FROM events e WHERE e.event_id = d.event_id;
+ALTER TABLE webhook_deliveries ALTER COLUMN event_id TYPE TEXT USING event_id::TEXT;
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_dedupe ON webhook_deliveries(webhook_id, dedupe_key);
+DROP TABLE IF EXISTS events;
@@ -0,0 +1,39 @@
+-- Упорядоченный журнал событий подписок; event_id служит курсором для потребителей
+CREATE TABLE IF NOT EXISTS events (
+    event_id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    subscription_id INTEGER,
+    dedupe_key TEXT,
+    data TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_events_subscription_id ON events(subscription_id);
+CREATE INDEX IF NOT EXISTS idx_events_dedupe_key ON events(dedupe_key);
+
+-- Доставки ссылаются на событие журнала. События доставок, созданных до появления журнала (текстовый event_id), переносятся в журнал
+-- в порядке создания вместе с ключом дедупликации, чтобы планировщик не повторял уведомления; доставки и их тела получают новый event_id
+CREATE TEMPORARY TABLE legacy_events ON COMMIT DROP AS
+SELECT event_id AS legacy_id,
+       ROW_NUMBER() OVER (ORDER BY MIN(created_at), event_id) AS new_id,
+       MIN(event_type) AS event_type,
+       MIN(subscription_id) AS subscription_id,
+       MIN(dedupe_key) AS dedupe_key,
+       COALESCE((MIN(payload)::jsonb -> 'subscription')::text, '{}') AS data,
+       MIN(created_at) AS created_at
+FROM webhook_deliveries
+GROUP BY event_id;
+
+INSERT INTO events (event_id, event_type, subscription_id, dedupe_key, data, created_at)
+SELECT new_id, event_type, subscription_id, dedupe_key, data, created_at FROM legacy_events;
+SELECT setval(pg_get_serial_sequence('events', 'event_id'), COALESCE(MAX(event_id), 0) + 1, false) FROM events;
+
+UPDATE webhook_deliveries d
+SET event_id = l.new_id::TEXT,
+    payload = jsonb_set(d.payload::jsonb, '{id}', to_jsonb(l.new_id))::TEXT
+FROM legacy_events l
+WHERE d.event_id = l.legacy_id;
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_dedupe;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS dedupe_key;
+ALTER TABLE webhook_deliveries ALTER COLUMN event_id TYPE BIGINT USING event_id::BIGINT;
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries(event_id);
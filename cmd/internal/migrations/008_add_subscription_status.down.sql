@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_subscriptions_unmarked_expired;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS expired_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS cancelled_at;
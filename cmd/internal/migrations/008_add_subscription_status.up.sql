@@ -0,0 +1,8 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMPTZ;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS expired_at TIMESTAMPTZ;
+
+-- Подписки, закончившиеся до появления планировщика, считаются уже обработанными, чтобы не рассылать по ним subscription.expired
+UPDATE subscriptions SET expired_at = NOW()
+WHERE end_date < date_trunc('month', NOW()) AND expired_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_unmarked_expired ON subscriptions(end_date) WHERE expired_at IS NULL AND cancelled_at IS NULL;
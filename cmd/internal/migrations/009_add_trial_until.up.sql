@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS trial_until DATE;
+CREATE INDEX IF NOT EXISTS idx_subscriptions_trial_until ON subscriptions(trial_until) WHERE trial_until IS NOT NULL;
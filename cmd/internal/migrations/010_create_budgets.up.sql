@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS budgets (
+    budget_id SERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    amount INTEGER NOT NULL,
+    category_id INTEGER REFERENCES categories(category_id),
+    service_name TEXT,
+    mode TEXT NOT NULL DEFAULT 'warn',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_budgets_user_id ON budgets(user_id);
+CREATE INDEX IF NOT EXISTS idx_budgets_category_id ON budgets(category_id);
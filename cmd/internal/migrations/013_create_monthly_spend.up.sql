@@ -0,0 +1,18 @@
+-- Агрегат отчёта: сумма за месяц по пользователю и сервису; provider_id = 0 у подписок вне каталога
+CREATE TABLE IF NOT EXISTS monthly_spend (
+    month TIMESTAMPTZ NOT NULL,
+    user_id TEXT NOT NULL,
+    provider_id BIGINT NOT NULL,
+    service_name TEXT NOT NULL,
+    amount BIGINT NOT NULL,
+    subscriptions BIGINT NOT NULL,
+    PRIMARY KEY (month, user_id, provider_id, service_name)
+);
+
+-- Единственная строка: до какого месяца агрегат заполнен для бессрочных подписок. Пока строки нет, отчёты считаются по подпискам;
+-- агрегат строит `em-test recompute` или первый запуск планировщика
+CREATE TABLE IF NOT EXISTS monthly_spend_state (
+    id BIGINT PRIMARY KEY,
+    covered_until TIMESTAMPTZ NOT NULL,
+    rebuilt_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,7 @@
+CREATE INDEX IF NOT EXISTS idx_subscriptions_start_end ON subscriptions(start_date, end_date);
+DROP INDEX IF EXISTS idx_subscriptions_period;
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_name ON subscriptions(service_name);
+DROP INDEX IF EXISTS idx_subscriptions_service_sid;
+DROP INDEX IF EXISTS idx_subscriptions_service_period;
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_id ON subscriptions(user_id);
+DROP INDEX IF EXISTS idx_subscriptions_user_service_period;
@@ -0,0 +1,21 @@
+-- gorm AutoMigrate сервера хранит даты подписок как timestamptz; приводим явно, чтобы индекс по диапазону не зависел от того, кто создал таблицу
+ALTER TABLE subscriptions ALTER COLUMN start_date TYPE TIMESTAMPTZ, ALTER COLUMN end_date TYPE TIMESTAMPTZ;
+
+-- Проверка пересечения при создании, отчёты и списки по пользователю; заменяет индекс по user_id
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_service_period ON subscriptions(user_id, service_name, start_date, end_date);
+DROP INDEX IF EXISTS idx_subscriptions_user_id;
+
+-- Отчёты по сервису
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_period ON subscriptions(service_name, start_date, end_date);
+
+-- Страницы списка по сервису в порядке subscription_id; заменяет индекс по service_name
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_sid ON subscriptions(service_name, subscription_id);
+DROP INDEX IF EXISTS idx_subscriptions_service_name;
+
+-- Пересечение периода подписки с месяцем отчёта; заменяет индекс по (start_date, end_date), по которому условие start_date <= конца месяца
+-- отбирает почти все подписки. Конец раньше начала заменяется началом, чтобы диапазон строился для любых данных;
+-- выражение совпадает с subscriptionPeriodSQL репозитория
+CREATE INDEX IF NOT EXISTS idx_subscriptions_period ON subscriptions USING gist (
+    tstzrange(start_date, CASE WHEN end_date < start_date THEN start_date ELSE end_date END, '[]')
+);
+DROP INDEX IF EXISTS idx_subscriptions_start_end;
@@ -0,0 +1,9 @@
+// Package migrations holds SQL migrations of Postgres schema: NNN_name.up.sql applies a change, NNN_name.down.sql reverts it
+package migrations
+
+import "embed"
+
+// FS - SQL files of migrations, applied by `em-test migrate`
+//
+//go:embed *.sql
+var FS embed.FS
@@ -1,17 +1,45 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Subscription is a model for storing subscription
 type Subscription struct {
-	SID      *uint64    `gorm:"column:subscription_id;primaryKey" json:"subscription_id"`
-	UID      string     `gorm:"column:user_id;not null" json:"user_id"`
-	Provider string     `gorm:"column:service_name;not null" json:"service_name"`
+	SID *uint64 `gorm:"column:subscription_id;primaryKey;index:idx_subscriptions_service_sid,priority:2" json:"subscription_id"`
+	// indexes serve overlap check, reports and lists filtered by user and service; migration 014 adds GiST index on period for Postgres
+	UID      string     `gorm:"column:user_id;not null;index:idx_subscriptions_user_service_period,priority:1" json:"user_id"`
+	Provider string     `gorm:"column:service_name;not null;index:idx_subscriptions_user_service_period,priority:2;index:idx_subscriptions_service_period,priority:1;index:idx_subscriptions_service_sid,priority:1" json:"service_name"`
 	Price    uint       `gorm:"column:price;not null" json:"price"`
-	Start    time.Time  `gorm:"column:start_date;not null" json:"start_date"`
-	End      *time.Time `gorm:"column:end_date" json:"end_date"`
+	Start    time.Time  `gorm:"column:start_date;not null;index:idx_subscriptions_user_service_period,priority:3;index:idx_subscriptions_service_period,priority:2" json:"start_date"`
+	End      *time.Time `gorm:"column:end_date;index:idx_subscriptions_user_service_period,priority:4;index:idx_subscriptions_service_period,priority:3" json:"end_date"`
+	// PreviousSID links subscription to the one it replaced on plan switching
+	PreviousSID *uint64 `gorm:"column:previous_subscription_id" json:"previous_subscription_id"`
+	// ProviderID references catalogued provider; nil for service names absent in catalog
+	ProviderID *uint64 `gorm:"column:provider_id;index" json:"provider_id"`
+	// CancelledAt is set when subscription is cancelled explicitly
+	CancelledAt *time.Time `gorm:"column:cancelled_at" json:"cancelled_at"`
+	// TrialUntil is the last month of free trial; subscription costs nothing up to this month inclusive
+	TrialUntil *time.Time `gorm:"column:trial_until" json:"trial_until"`
+	// ExpiredAt is set by scheduler once end month of subscription has passed and subscription.expired was emitted
+	ExpiredAt *time.Time `gorm:"column:expired_at" json:"expired_at"`
+	// Version is incremented on every update; used with computed status as ETag and alone for optimistic concurrency
+	Version uint64 `gorm:"column:version;not null;default:1" json:"version"`
 }
 
+// Subscription statuses, computed from dates: scheduled - starts in a future month, expired - end month has passed,
+// cancelled - cancelled explicitly, active - otherwise
+const (
+	StatusActive    = "active"
+	StatusScheduled = "scheduled"
+	StatusExpired   = "expired"
+	StatusCancelled = "cancelled"
+)
+
+// SubscriptionStatuses - all subscription statuses
+var SubscriptionStatuses = []string{StatusActive, StatusScheduled, StatusExpired, StatusCancelled}
+
 // RawSubscription - a model used in handler for basic json-decoding. Converted to model.Subscription in Service-layer.
 type RawSubscription struct {
 	SID      *uint64 `json:"subscription_id" example:"20"`
@@ -20,24 +48,530 @@ type RawSubscription struct {
 	Price    *uint   `json:"price" example:"400"`
 	Start    string  `json:"start_date" example:"07-2025"`
 	End      string  `json:"end_date,omitempty" example:"12-2025"`
+
+	PreviousSID *uint64 `json:"previous_subscription_id,omitempty" example:"19"`
+	ProviderID  *uint64 `json:"provider_id,omitempty" example:"3"`
+	TrialUntil  string  `json:"trial_until,omitempty" example:"08-2025"`
+
+	// Status and CancelledAt are computed on output and ignored on input
+	Status      string `json:"status,omitempty" example:"active"`
+	CancelledAt string `json:"cancelled_at,omitempty" example:"2025-07-10T12:00:00Z"`
+	// Warnings are returned on creation/update, e.g. when a budget in warn mode is exceeded
+	Warnings []string `json:"warnings,omitempty"`
+	// Version is returned on output; on update and in batch operations it is the expected current version, the change fails if it differs
+	Version *uint64 `json:"version,omitempty" example:"3"`
+}
+
+// Provider is a model for storing catalogued service provider with canonical name
+type Provider struct {
+	ID             uint64          `gorm:"column:provider_id;primaryKey" json:"provider_id"`
+	Name           string          `gorm:"column:name;not null;uniqueIndex" json:"name"`
+	NormalizedName string          `gorm:"column:normalized_name;not null;uniqueIndex" json:"-"`
+	CategoryID     *uint64         `gorm:"column:category_id;index" json:"category_id"`
+	DefaultPrice   *uint           `gorm:"column:default_price" json:"default_price"`
+	Aliases        []ProviderAlias `gorm:"foreignKey:ProviderID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// ProviderAlias is a model for storing alternative spelling of provider name
+type ProviderAlias struct {
+	ID              uint64 `gorm:"column:alias_id;primaryKey"`
+	ProviderID      uint64 `gorm:"column:provider_id;not null;index"`
+	Alias           string `gorm:"column:alias;not null"`
+	NormalizedAlias string `gorm:"column:normalized_alias;not null;uniqueIndex"`
+}
+
+// RawProvider - a model used in handler for json-decoding of provider. Converted to model.Provider in Service-layer.
+type RawProvider struct {
+	ID           *uint64  `json:"provider_id" example:"3"`
+	Name         string   `json:"name" example:"Yandex Plus"`
+	Aliases      []string `json:"aliases" example:"yandex plus,YandexPlus"`
+	CategoryID   *uint64  `json:"category_id,omitempty" example:"2"`
+	Category     string   `json:"category,omitempty" example:"Entertainment > Video"`
+	DefaultPrice *uint    `json:"default_price,omitempty" example:"400"`
+}
+
+// Category is a model for storing node of hierarchical provider categories, e.g. Entertainment > Video
+type Category struct {
+	ID       uint64  `gorm:"column:category_id;primaryKey" json:"category_id"`
+	Name     string  `gorm:"column:name;not null" json:"name"`
+	ParentID *uint64 `gorm:"column:parent_id;index" json:"parent_id"`
+}
+
+// RawCategory - a model used in handler for json-decoding of category; Path is filled on output only
+type RawCategory struct {
+	ID       *uint64 `json:"category_id" example:"2"`
+	Name     string  `json:"name" example:"Video"`
+	ParentID *uint64 `json:"parent_id,omitempty" example:"1"`
+	Path     string  `json:"path,omitempty" example:"Entertainment > Video"`
+}
+
+// SwitchRequest - a model used for switching subscription to another plan: current subscription ends at SwitchMonth, the successor starts next month.
+// Provider and Price default to the ones of current subscription.
+type SwitchRequest struct {
+	Provider    string `json:"service_name,omitempty" example:"Yandex Plus Family"`
+	Price       *uint  `json:"price,omitempty" example:"600"`
+	SwitchMonth string `json:"switch_month" example:"07-2025"`
+	End         string `json:"end_date,omitempty" example:"12-2026"`
+}
+
+// SwitchResult - closed subscription and its successor
+type SwitchResult struct {
+	Previous *RawSubscription `json:"previous"`
+	Next     *RawSubscription `json:"next"`
 }
 
+// Report grouping options
+const (
+	ReportGroupByCategory = "category"
+)
+
 // RawReportFilter - a model used for composing report - used only for storing raw data
 type RawReportFilter struct {
 	Period   string //mandatory, конкретный месяц в формате "07-2024"
 	UID      string //optional
 	Provider string //optional
+	Category string //optional, ID или путь категории "Entertainment > Video"; включает подкатегории
+	GroupBy  string //optional, "category"
+}
+
+// SubscriptionPageFilter - a model used for requesting page of subscriptions ordered by SID; empty fields are not filtered on
+type SubscriptionPageFilter struct {
+	Status   string //optional, computed status
+	UID      string //optional
+	Provider string //optional, name or alias of provider
+	AfterSID uint64 //SID of the last subscription on previous page, 0 for the first page
+	Limit    int    //mandatory, max subscriptions on page
 }
 
 // ReportFilter - a model used for composing report - used in Repository for query
 type ReportFilter struct {
-	Start    time.Time //mandatory
-	End      time.Time //mandatory
-	UID      *string   //optional
-	Provider *string   //optional
+	Start      time.Time //mandatory
+	End        time.Time //mandatory
+	UID        *string   //optional
+	Provider   *string   //optional
+	CategoryID *uint64   //optional, including descendants
+}
+
+// ReportCacheKey - normalized ReportFilter identifying a cached report total; empty UID and Provider and zero CategoryID stand for absent filters
+type ReportCacheKey struct {
+	Month      string // "2006-01"
+	UID        string
+	Provider   string
+	CategoryID uint64
+}
+
+// ReportCacheStats - counters of report cache since the start of the process
+type ReportCacheStats struct {
+	Enabled       bool    `json:"enabled" example:"true"`
+	Entries       int     `json:"entries" example:"812"`
+	Capacity      int     `json:"capacity" example:"10000"`
+	Hits          uint64  `json:"hits" example:"15230"`
+	Misses        uint64  `json:"misses" example:"1904"`
+	HitRatio      float64 `json:"hit_ratio" example:"0.89"`
+	Invalidations uint64  `json:"invalidations" example:"640"` // entries dropped because a write touched their user, service, month or category
+	Evictions     uint64  `json:"evictions" example:"120"`     // entries dropped as least recently used or expired
+}
+
+// TrialReport - trial statistics for a month: trials ending in the month either convert to paid (subscription continues
+// after the trial) or not (subscription ends with the trial)
+type TrialReport struct {
+	Period         string  `json:"period" example:"07-2025"`
+	InTrial        int     `json:"in_trial" example:"12"`
+	TrialsEnded    int     `json:"trials_ended" example:"5"`
+	Converted      int     `json:"converted" example:"3"`
+	NotConverted   int     `json:"not_converted" example:"2"`
+	ConversionRate float64 `json:"conversion_rate" example:"0.6"`
+	// ConvertedRevenue is monthly price of converted subscriptions, charged from the next month
+	ConvertedRevenue uint `json:"converted_revenue" example:"1200"`
 }
 
 // Report used for responding with subscription total price
 type Report struct {
-	Total uint `json:"total"`
+	Total  uint          `json:"total"`
+	Groups []ReportGroup `json:"groups,omitempty"`
+}
+
+// ReportGroup - total price of subscriptions in a category including its subcategories; nil CategoryID stands for uncategorized subscriptions
+type ReportGroup struct {
+	CategoryID *uint64 `json:"category_id" example:"2"`
+	Category   string  `json:"category" example:"Entertainment > Video"`
+	Total      uint    `json:"total" example:"800"`
+}
+
+// ForecastMonth - projected report for one month of forecast; fields of Report are inlined, so every month reads as report output
+type ForecastMonth struct {
+	Period string `json:"period" example:"07-2025"`
+	Report
+}
+
+// Forecast - projected monthly spend starting from the current month and its sum over the whole horizon
+type Forecast struct {
+	Total  uint            `json:"total" example:"4800"`
+	Months []ForecastMonth `json:"months"`
+}
+
+// Anomaly report defaults
+const (
+	DefaultAnomalySimilarity    = 0.8
+	DefaultAnomalyPriceRatio    = 2.0
+	DefaultAnomalyMaxConcurrent = 3
+)
+
+// RawAnomalyFilter - parameters of anomaly report - used only for storing raw data
+type RawAnomalyFilter struct {
+	UID           string  //optional
+	Period        string  //optional, month of concurrent subscriptions check in "07-2024" format, current month by default
+	Similarity    float64 //optional, minimal similarity of normalized provider names treated as duplicate, 0..1
+	PriceRatio    float64 //optional, price differing from provider median this many times is abnormal, > 1
+	MaxConcurrent int     //optional, number of subscriptions of a user in one category in the period reported as too many
+}
+
+// AnomalyReport - likely duplicates, abnormally priced subscriptions and users with too many concurrent subscriptions in one category
+type AnomalyReport struct {
+	Duplicates        []DuplicateSubscriptions `json:"duplicates"`
+	PriceOutliers     []PriceOutlier           `json:"price_outliers"`
+	CategoryOverloads []CategoryOverload       `json:"category_overloads"`
+}
+
+// DuplicateSubscriptions - two overlapping subscriptions of the same user to the same or similarly named provider
+type DuplicateSubscriptions struct {
+	Similarity    float64            `json:"similarity" example:"0.91"`
+	Subscriptions []*RawSubscription `json:"subscriptions"`
+}
+
+// PriceOutlier - subscription with price far from median price of its provider
+type PriceOutlier struct {
+	Median       float64          `json:"median" example:"400"`
+	Ratio        float64          `json:"ratio" example:"3.5"`
+	Subscription *RawSubscription `json:"subscription"`
+}
+
+// CategoryOverload - subscriptions of a user in one category active in the same month
+type CategoryOverload struct {
+	UID           string             `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	CategoryID    uint64             `json:"category_id" example:"2"`
+	Category      string             `json:"category" example:"Entertainment > Video"`
+	Period        string             `json:"period" example:"07-2025"`
+	Subscriptions []*RawSubscription `json:"subscriptions"`
+}
+
+// Import modes: all_or_nothing commits rows only if every row is valid, valid_only commits valid rows and reports the rest
+const (
+	ImportModeAllOrNothing = "all_or_nothing"
+	ImportModeValidOnly    = "valid_only"
+)
+
+// Import row statuses
+const (
+	ImportRowCreated = "created"
+	ImportRowValid   = "valid"
+	ImportRowFailed  = "failed"
+)
+
+// ImportRow - a single decoded row of import payload; Err is set if the row could not be decoded
+type ImportRow struct {
+	Line int
+	Sub  *RawSubscription
+	Err  error
+}
+
+// ImportOptions - parameters of bulk import
+type ImportOptions struct {
+	DryRun bool
+	Mode   string
+}
+
+// ImportRowResult - per-row result of bulk import
+type ImportRowResult struct {
+	Line   int     `json:"line" example:"2"`
+	Status string  `json:"status" example:"created"`
+	SID    *uint64 `json:"subscription_id,omitempty" example:"20"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// ImportReport - result of bulk import returned to the caller
+type ImportReport struct {
+	DryRun    bool              `json:"dry_run"`
+	Mode      string            `json:"mode" example:"all_or_nothing"`
+	Committed bool              `json:"committed"`
+	Total     int               `json:"total"`
+	Valid     int               `json:"valid"`
+	Failed    int               `json:"failed"`
+	Created   int               `json:"created"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+// Batch operation types
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// Batch operation statuses: done - executed and committed, failed - caused rollback, rolled_back - executed but reverted, skipped - not executed
+const (
+	BatchOpDone       = "done"
+	BatchOpFailed     = "failed"
+	BatchOpRolledBack = "rolled_back"
+	BatchOpSkipped    = "skipped"
+)
+
+// BatchOperation - a single create/update/delete operation of batch request; SID is mandatory for update and delete
+type BatchOperation struct {
+	Op           string           `json:"op" example:"update"`
+	SID          *uint64          `json:"subscription_id,omitempty" example:"20"`
+	Subscription *RawSubscription `json:"subscription,omitempty"`
+}
+
+// BatchRequest - list of operations executed in a single transaction
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOpResult - result of a single batch operation
+type BatchOpResult struct {
+	Index        int              `json:"index" example:"0"`
+	Op           string           `json:"op" example:"update"`
+	Status       string           `json:"status" example:"done"`
+	Subscription *RawSubscription `json:"subscription,omitempty"`
+	Error        string           `json:"error,omitempty"`
+}
+
+// BatchResult - per-operation results of batch request
+type BatchResult struct {
+	Committed bool            `json:"committed"`
+	Results   []BatchOpResult `json:"results"`
+}
+
+// Upcoming events of subscription
+const (
+	UpcomingExpiry  = "expiry"
+	UpcomingRenewal = "renewal"
+)
+
+// UpcomingSubscription - subscription that ends (expiry) or is charged again (renewal) within requested window
+type UpcomingSubscription struct {
+	Event        string           `json:"event" example:"renewal"`
+	Date         string           `json:"date" example:"2025-08-01"`
+	Subscription *RawSubscription `json:"subscription"`
+}
+
+// Subscription lifecycle event types
+const (
+	EventSubscriptionCreated  = "subscription.created"
+	EventSubscriptionUpdated  = "subscription.updated"
+	EventSubscriptionDeleted  = "subscription.deleted"
+	EventSubscriptionExpiring = "subscription.expiring"
+	EventSubscriptionExpired  = "subscription.expired"
+)
+
+// EventTypes - all subscription lifecycle event types
+var EventTypes = []string{EventSubscriptionCreated, EventSubscriptionUpdated, EventSubscriptionDeleted, EventSubscriptionExpiring, EventSubscriptionExpired}
+
+// Webhook is a model for storing registered receiver of subscription lifecycle events
+type Webhook struct {
+	ID         uint64    `gorm:"column:webhook_id;primaryKey"`
+	URL        string    `gorm:"column:url;not null"`
+	Secret     string    `gorm:"column:secret;not null"`
+	EventTypes string    `gorm:"column:event_types;not null"` // comma-separated list of event types
+	Active     bool      `gorm:"column:active;not null"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+}
+
+// RawWebhook - a model used in handler for json-decoding of webhook. Secret is returned only on creation.
+type RawWebhook struct {
+	ID         *uint64  `json:"webhook_id" example:"1"`
+	URL        string   `json:"url" example:"https://notify.example.com/hooks/subscriptions"`
+	Secret     string   `json:"secret,omitempty" example:"s3cr3t"`
+	EventTypes []string `json:"event_types" example:"subscription.created,subscription.deleted"`
+	Active     *bool    `json:"active,omitempty" example:"true"`
+	CreatedAt  string   `json:"created_at,omitempty" example:"2025-07-01T10:00:00Z"`
+}
+
+// Webhook delivery statuses: pending - waiting for (next) attempt, delivered - receiver responded with 2xx, dead - attempts exhausted
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery is a model for storing outbox record of a single event for a single webhook; written in the same transaction as the change
+type WebhookDelivery struct {
+	ID            uint64     `gorm:"column:delivery_id;primaryKey" json:"delivery_id"`
+	WebhookID     uint64     `gorm:"column:webhook_id;not null;index" json:"webhook_id"`
+	EventID       uint64     `gorm:"column:event_id;not null;index" json:"event_id"`
+	EventType     string     `gorm:"column:event_type;not null" json:"event_type"`
+	SID           *uint64    `gorm:"column:subscription_id;index" json:"subscription_id"`
+	Payload       string     `gorm:"column:payload;not null" json:"payload"`
+	Status        string     `gorm:"column:status;not null;index" json:"status"`
+	Attempts      int        `gorm:"column:attempts;not null" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;not null;index" json:"next_attempt_at"`
+	LastError     string     `gorm:"column:last_error" json:"last_error,omitempty"`
+	CreatedAt     time.Time  `gorm:"column:created_at" json:"created_at"`
+	DeliveredAt   *time.Time `gorm:"column:delivered_at" json:"delivered_at,omitempty"`
+}
+
+// Event is a model for storing ordered log of subscription lifecycle events; event_id serves as cursor for consumers
+type Event struct {
+	ID        uint64    `gorm:"column:event_id;primaryKey"`
+	Type      string    `gorm:"column:event_type;not null"`
+	SID       *uint64   `gorm:"column:subscription_id;index"`
+	DedupeKey *string   `gorm:"column:dedupe_key;index"`
+	Data      string    `gorm:"column:data;not null"` // subscription state as json
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+// EventPayload - event as seen by consumers: body of webhook request, item of event stream
+type EventPayload struct {
+	ID           uint64           `json:"id" example:"42"`
+	Type         string           `json:"type" example:"subscription.created"`
+	CreatedAt    time.Time        `json:"created_at"`
+	Subscription *RawSubscription `json:"subscription"`
+}
+
+// EventPage - portion of event log after cursor; NextCursor is to be passed as "after" in the next request
+type EventPage struct {
+	Events     []*EventPayload `json:"events"`
+	NextCursor uint64          `json:"next_cursor" example:"42"`
+}
+
+// Budget modes: warn - change exceeding budget is accepted with a warning, reject - change is refused
+const (
+	BudgetModeWarn   = "warn"
+	BudgetModeReject = "reject"
+)
+
+// Budget is a model for storing monthly spend cap of a user; optionally limited to a category (including subcategories) or to a provider
+type Budget struct {
+	ID         uint64    `gorm:"column:budget_id;primaryKey"`
+	UID        string    `gorm:"column:user_id;not null;index"`
+	Amount     uint      `gorm:"column:amount;not null"`
+	CategoryID *uint64   `gorm:"column:category_id;index"`
+	Provider   *string   `gorm:"column:service_name"`
+	Mode       string    `gorm:"column:mode;not null"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+}
+
+// RawBudget - a model used in handler for json-decoding of budget; category may be given by ID or path, service name is resolved to catalogued provider name
+type RawBudget struct {
+	ID         *uint64 `json:"budget_id" example:"1"`
+	UID        string  `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	Amount     *uint   `json:"amount" example:"1500"`
+	CategoryID *uint64 `json:"category_id,omitempty" example:"2"`
+	Category   string  `json:"category,omitempty" example:"Entertainment > Video"`
+	Provider   string  `json:"service_name,omitempty" example:"Yandex Plus"`
+	Mode       string  `json:"mode,omitempty" example:"warn"`
+}
+
+// BudgetStatus - spend of a month against budget
+type BudgetStatus struct {
+	Budget *RawBudget `json:"budget"`
+	Period string     `json:"period" example:"07-2025"`
+	Spent  uint       `json:"spent" example:"1800"`
+	OverBy uint       `json:"over_by" example:"300"`
+}
+
+// IdempotencyKey is a model for storing key of a retried POST request with hash of the request and the response to replay
+type IdempotencyKey struct {
+	Key         string    `gorm:"column:idempotency_key;primaryKey"`
+	RequestHash string    `gorm:"column:request_hash;not null"`
+	StatusCode  int       `gorm:"column:status_code;not null"` // 0 while the first request with the key is in progress
+	Headers     string    `gorm:"column:headers"`              // JSON object of response headers
+	Body        []byte    `gorm:"column:body"`
+	CreatedAt   time.Time `gorm:"column:created_at;not null;index"`
+}
+
+// GraphQLRequest - a model used in handler for json-decoding of GraphQL query
+type GraphQLRequest struct {
+	Query         string         `json:"query" example:"{ users(first: 10) { nodes { id total(period: \"07-2025\") subscriptions { serviceName price } } } }"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	Extensions    map[string]any `json:"extensions,omitempty"`
+}
+
+// MonthlySpend is a model for storing aggregate of report: amount paid in a month by a user for a service.
+// It is maintained on every subscription write and rebuilt by `recompute`; open-ended subscriptions are counted up to MonthlySpendState.CoveredUntil
+type MonthlySpend struct {
+	Month         time.Time `gorm:"column:month;primaryKey"` // first day of month
+	UID           string    `gorm:"column:user_id;primaryKey"`
+	ProviderID    uint64    `gorm:"column:provider_id;primaryKey"` // 0 for subscriptions not linked to catalog
+	Provider      string    `gorm:"column:service_name;primaryKey"`
+	Amount        int64     `gorm:"column:amount;not null"`
+	Subscriptions int64     `gorm:"column:subscriptions;not null"` // number of counted subscriptions, the row is removed when it drops to 0
+}
+
+func (MonthlySpend) TableName() string {
+	return "monthly_spend"
+}
+
+// MonthlySpendState is a model for storing the only row describing monthly_spend; without it the aggregate is not built and reports are computed on the fly
+type MonthlySpendState struct {
+	ID           uint      `gorm:"column:id;primaryKey;autoIncrement:false"`
+	CoveredUntil time.Time `gorm:"column:covered_until;not null"` // first day of the last month present in aggregate
+	RebuiltAt    time.Time `gorm:"column:rebuilt_at;not null"`
+}
+
+func (MonthlySpendState) TableName() string {
+	return "monthly_spend_state"
+}
+
+// SchemaMigration is a model for storing versions of SQL migrations applied by `migrate`
+type SchemaMigration struct {
+	Version   string    `gorm:"column:version;primaryKey"` // file prefix, e.g. "007"
+	Name      string    `gorm:"column:name;not null"`
+	AppliedAt time.Time `gorm:"column:applied_at;not null"`
+}
+
+// MigrationStatus - SQL migration and whether it is applied
+type MigrationStatus struct {
+	Version   string     `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+	HasDown   bool       `json:"has_down"`
+}
+
+// SeedOptions - parameters of fake data generated by `seed`
+type SeedOptions struct {
+	Users       int       // number of generated users
+	SubsPerUser int       // average number of subscriptions of a user
+	Months      int       // subscriptions start within that many months before Now
+	Seed        int64     // seed of random generator, the same seed gives the same data
+	Now         time.Time // current time, time.Now() if zero
+}
+
+// BackupRecord - a line of NDJSON backup: a row of table keyed by column names
+type BackupRecord struct {
+	Table string                     `json:"table"`
+	Row   map[string]json.RawMessage `json:"row"`
+}
+
+// BackupTableStat - number of rows of table written to or read from backup
+type BackupTableStat struct {
+	Table string `json:"table"`
+	Rows  int    `json:"rows"`
+}
+
+// Kinds of integrity issues found by `check`
+const (
+	IssueOverlap          = "overlap"            // subscriptions of a user to the same service with overlapping periods
+	IssueEndBeforeStart   = "end_before_start"   // end_date before start_date
+	IssueTrialBeforeStart = "trial_before_start" // trial_until before start_date
+	IssueMissingPrevious  = "missing_previous"   // previous_subscription_id references no subscription
+	IssueMissingProvider  = "missing_provider"   // provider_id references no provider
+	IssueSpendMismatch    = "spend_mismatch"     // row of monthly_spend differs from report computed on the fly
+)
+
+// IntegrityIssue - a row that breaks rules validated on write
+type IntegrityIssue struct {
+	Kind     string  `json:"kind"`
+	SID      uint64  `json:"subscription_id,omitempty"`       // 0 for issues of monthly_spend
+	OtherSID *uint64 `json:"other_subscription_id,omitempty"` // second subscription of overlap
+	Detail   string  `json:"detail"`
+}
+
+// RecomputeReport - result of `recompute`
+type RecomputeReport struct {
+	LinkedSubscriptions int64     `json:"linked_subscriptions"` // subscriptions linked to catalog or renamed to canonical name
+	SpendRows           int64     `json:"spend_rows"`           // rows of rebuilt monthly_spend
+	SpendCoveredUntil   time.Time `json:"spend_covered_until"`
 }
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// BudgetRepo - structure provides access to DB-requests on user budgets
+type BudgetRepo struct {
+	DB *gorm.DB
+}
+
+var ErrBudgetNotFound = errors.New("budget not found")
+var ErrBudgetExceeded = errors.New("monthly budget exceeded")
+
+func CreateBudgetRepo(db *gorm.DB) *BudgetRepo {
+	return &BudgetRepo{DB: db}
+}
+
+// WithTx - returns a copy of repo bound to the provided transaction
+func (br BudgetRepo) WithTx(tx *gorm.DB) BudgetRepo {
+	return BudgetRepo{DB: tx}
+}
+
+// CreateBudget -
+func (br BudgetRepo) CreateBudget(ctx context.Context, budget *model.Budget) error {
+	return br.DB.WithContext(ctx).Create(budget).Error
+}
+
+// GetBudgetByID - returns budget, gorm.ErrRecordNotFound if there is none
+func (br BudgetRepo) GetBudgetByID(ctx context.Context, id uint64) (*model.Budget, error) {
+	var budget model.Budget
+	err := br.DB.WithContext(ctx).First(&budget, id).Error
+	return &budget, err
+}
+
+// GetBudgets - returns budgets ordered by ID, optionally only of provided user
+func (br BudgetRepo) GetBudgets(ctx context.Context, uid *string) ([]*model.Budget, error) {
+	var budgets []*model.Budget
+	query := br.DB.WithContext(ctx).Order("budget_id")
+	if uid != nil {
+		query = query.Where("user_id = ?", *uid)
+	}
+	err := query.Find(&budgets).Error
+	return budgets, err
+}
+
+// UpdateBudget -
+func (br BudgetRepo) UpdateBudget(ctx context.Context, budget *model.Budget) error {
+	return br.DB.WithContext(ctx).Save(budget).Error
+}
+
+// DeleteBudget -
+func (br BudgetRepo) DeleteBudget(ctx context.Context, id uint64) (int64, error) {
+	res := br.DB.WithContext(ctx).Delete(&model.Budget{}, id)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// CategoryRepo - structure provides access to DB-requests on provider categories
+type CategoryRepo struct {
+	DB *gorm.DB
+}
+
+var ErrCategoryNotFound = errors.New("category not found")
+var ErrCategoryExists = errors.New("category already exists")
+var ErrCategoryInUse = errors.New("category has subcategories, providers or budgets")
+var ErrCategoryCycle = errors.New("category cannot be moved under itself")
+
+// categoryClosureSQL - derived table of (ancestor_id, descendant_id) pairs for every category, including pair of category with itself
+const categoryClosureSQL = `(WITH RECURSIVE closure(ancestor_id, descendant_id) AS (
+	SELECT category_id, category_id FROM categories
+	UNION ALL
+	SELECT closure.ancestor_id, categories.category_id FROM closure JOIN categories ON categories.parent_id = closure.descendant_id
+) SELECT ancestor_id, descendant_id FROM closure)`
+
+func CreateCategoryRepo(db *gorm.DB) *CategoryRepo {
+	return &CategoryRepo{DB: db}
+}
+
+// WithTx - returns a copy of repo bound to the provided transaction
+func (cr CategoryRepo) WithTx(tx *gorm.DB) CategoryRepo {
+	return CategoryRepo{DB: tx}
+}
+
+// CreateCategory -
+func (cr CategoryRepo) CreateCategory(ctx context.Context, category *model.Category) error {
+	return cr.DB.WithContext(ctx).Create(category).Error
+}
+
+// GetCategoryByID - returns category, gorm.ErrRecordNotFound if there is none
+func (cr CategoryRepo) GetCategoryByID(ctx context.Context, id uint64) (*model.Category, error) {
+	var category model.Category
+	err := cr.DB.WithContext(ctx).First(&category, id).Error
+	return &category, err
+}
+
+// GetAllCategories - returns all categories ordered by ID, so parents created first come first
+func (cr CategoryRepo) GetAllCategories(ctx context.Context) ([]*model.Category, error) {
+	var categories []*model.Category
+	err := cr.DB.WithContext(ctx).Order("category_id").Find(&categories).Error
+	return categories, err
+}
+
+// IsNameTaken - checks case-insensitively if parent already has another child category with the same name
+func (cr CategoryRepo) IsNameTaken(ctx context.Context, parentID *uint64, name string, excludeID uint64) (bool, error) {
+	var res int64
+	query := cr.DB.WithContext(ctx).Model(&model.Category{}).
+		Where("LOWER(name) = ?", strings.ToLower(name)).
+		Where("category_id <> ?", excludeID)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	err := query.Count(&res).Error
+	return res > 0, err
+}
+
+// UpdateCategory -
+func (cr CategoryRepo) UpdateCategory(ctx context.Context, category *model.Category) error {
+	return cr.DB.WithContext(ctx).Save(category).Error
+}
+
+// DeleteCategory -
+func (cr CategoryRepo) DeleteCategory(ctx context.Context, id uint64) (int64, error) {
+	res := cr.DB.WithContext(ctx).Delete(&model.Category{}, id)
+	return res.RowsAffected, res.Error
+}
+
+// CountDependents - returns number of subcategories, providers and budgets attached directly to category
+func (cr CategoryRepo) CountDependents(ctx context.Context, id uint64) (int64, error) {
+	var children, providers, budgets int64
+	if err := cr.DB.WithContext(ctx).Model(&model.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+		return 0, err
+	}
+	if err := cr.DB.WithContext(ctx).Model(&model.Provider{}).Where("category_id = ?", id).Count(&providers).Error; err != nil {
+		return 0, err
+	}
+	err := cr.DB.WithContext(ctx).Model(&model.Budget{}).Where("category_id = ?", id).Count(&budgets).Error
+	return children + providers + budgets, err
+}
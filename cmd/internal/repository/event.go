@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// eventLogLockKey - key of postgres advisory lock serializing writes to event log
+const eventLogLockKey = 7340001
+
+// EventRepo - structure provides access to DB-requests on event log
+type EventRepo struct {
+	DB *gorm.DB
+}
+
+func CreateEventRepo(db *gorm.DB) *EventRepo {
+	return &EventRepo{DB: db}
+}
+
+// WithTx - returns a copy of repo bound to the provided transaction
+func (er EventRepo) WithTx(tx *gorm.DB) EventRepo {
+	return EventRepo{DB: tx}
+}
+
+// LockEventLog - takes transaction-level lock on event log, so event IDs become visible to readers in commit order
+// and a consumer reading "after" its cursor never skips an event committed later with a smaller ID.
+// SQLite serializes writers by itself.
+func (er EventRepo) LockEventLog(ctx context.Context) error {
+	if er.DB.Dialector.Name() != "postgres" {
+		return nil
+	}
+	return er.DB.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?)", eventLogLockKey).Error
+}
+
+// CreateEvent - appends event to log
+func (er EventRepo) CreateEvent(ctx context.Context, event *model.Event) error {
+	return er.DB.WithContext(ctx).Create(event).Error
+}
+
+// EventExists - checks if event with dedupe key was already recorded
+func (er EventRepo) EventExists(ctx context.Context, dedupeKey string) (bool, error) {
+	var res int64
+	err := er.DB.WithContext(ctx).Model(&model.Event{}).Where("dedupe_key = ?", dedupeKey).Count(&res).Error
+	return res > 0, err
+}
+
+// GetEventsAfter - returns up to limit events with ID greater than cursor in log order
+func (er EventRepo) GetEventsAfter(ctx context.Context, cursor uint64, limit int) ([]*model.Event, error) {
+	var events []*model.Event
+	err := er.DB.WithContext(ctx).Where("event_id > ?", cursor).Order("event_id").Limit(limit).Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepo - structure provides access to DB-requests on idempotency keys
+type IdempotencyRepo struct {
+	DB *gorm.DB
+}
+
+func CreateIdempotencyRepo(db *gorm.DB) *IdempotencyRepo {
+	return &IdempotencyRepo{DB: db}
+}
+
+// ReserveKey - stores key of a request in progress; returns false if the key is already stored
+func (ir IdempotencyRepo) ReserveKey(ctx context.Context, key *model.IdempotencyKey) (bool, error) {
+	res := ir.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	return res.RowsAffected > 0, res.Error
+}
+
+// GetKey - returns stored key, gorm.ErrRecordNotFound if there is none
+func (ir IdempotencyRepo) GetKey(ctx context.Context, key string) (*model.IdempotencyKey, error) {
+	var res model.IdempotencyKey
+	err := ir.DB.WithContext(ctx).Where("idempotency_key = ?", key).First(&res).Error
+	return &res, err
+}
+
+// ReclaimKey - takes over key of a request which is in progress since before staleBefore (its process is considered gone);
+// returns false if the request has completed or is still fresh
+func (ir IdempotencyRepo) ReclaimKey(ctx context.Context, key *model.IdempotencyKey, staleBefore time.Time) (bool, error) {
+	res := ir.DB.WithContext(ctx).Model(&model.IdempotencyKey{}).
+		Where("idempotency_key = ? AND status_code = 0 AND created_at < ?", key.Key, staleBefore).
+		Updates(map[string]any{"request_hash": key.RequestHash, "created_at": key.CreatedAt})
+	return res.RowsAffected > 0, res.Error
+}
+
+// CompleteKey - stores response of the request with the key
+func (ir IdempotencyRepo) CompleteKey(ctx context.Context, key *model.IdempotencyKey) error {
+	return ir.DB.WithContext(ctx).Model(&model.IdempotencyKey{}).
+		Where("idempotency_key = ?", key.Key).
+		Updates(map[string]any{"status_code": key.StatusCode, "headers": key.Headers, "body": key.Body}).Error
+}
+
+// DeleteKey -
+func (ir IdempotencyRepo) DeleteKey(ctx context.Context, key string) error {
+	return ir.DB.WithContext(ctx).Where("idempotency_key = ?", key).Delete(&model.IdempotencyKey{}).Error
+}
+
+// DeleteExpiredKeys - removes keys stored before provided moment; returns number of removed keys
+func (ir IdempotencyRepo) DeleteExpiredKeys(ctx context.Context, before time.Time) (int64, error) {
+	res := ir.DB.WithContext(ctx).Where("created_at < ?", before).Delete(&model.IdempotencyKey{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// MaintenanceRepo - structure provides access to DB-requests of maintenance commands: bulk inserts, backups and integrity checks
+type MaintenanceRepo struct {
+	DB *gorm.DB
+}
+
+var ErrUnknownTable = errors.New("unknown table")
+var ErrUnknownColumn = errors.New("unknown column")
+
+// backupModels - models of backed up tables, every table goes after the tables it references.
+// Idempotency keys only live for IDEMPOTENCY_TTL and are not backed up
+var backupModels = []any{
+	&model.Category{},
+	&model.Provider{},
+	&model.ProviderAlias{},
+	&model.Subscription{},
+	&model.Budget{},
+	&model.Webhook{},
+	&model.Event{},
+	&model.WebhookDelivery{},
+}
+
+func CreateMaintenanceRepo(db *gorm.DB) *MaintenanceRepo {
+	return &MaintenanceRepo{DB: db}
+}
+
+// WithTx - returns a copy of repo bound to the provided transaction
+func (mr MaintenanceRepo) WithTx(tx *gorm.DB) MaintenanceRepo {
+	return MaintenanceRepo{DB: tx}
+}
+
+// InsertSubscriptions - inserts subscriptions in batches as they are, without validation and events
+func (mr MaintenanceRepo) InsertSubscriptions(ctx context.Context, subs []*model.Subscription) error {
+	return mr.DB.WithContext(ctx).CreateInBatches(subs, 500).Error
+}
+
+// BackupTables - names of backed up tables in order they are restored
+func (mr MaintenanceRepo) BackupTables() ([]string, error) {
+	tables := make([]string, len(backupModels))
+	for i, m := range backupModels {
+		tableSchema, err := mr.parse(m)
+		if err != nil {
+			return nil, err
+		}
+		tables[i] = tableSchema.Table
+	}
+	return tables, nil
+}
+
+// tableSchema - schema of backed up table by its name
+func (mr MaintenanceRepo) tableSchema(table string) (*schema.Schema, error) {
+	for _, m := range backupModels {
+		tableSchema, err := mr.parse(m)
+		if err != nil {
+			return nil, err
+		}
+		if tableSchema.Table == table {
+			return tableSchema, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownTable, table)
+}
+
+func (mr MaintenanceRepo) parse(m any) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: mr.DB}
+	if err := stmt.Parse(m); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+// CountRows - number of rows in backed up table
+func (mr MaintenanceRepo) CountRows(ctx context.Context, table string) (int64, error) {
+	if _, err := mr.tableSchema(table); err != nil {
+		return 0, err
+	}
+	var count int64
+	err := mr.DB.WithContext(ctx).Table(table).Count(&count).Error
+	return count, err
+}
+
+// ExportTable - iterates over rows of backed up table ordered by primary key without loading them all into memory;
+// a row is passed to fn as JSON values keyed by column names
+func (mr MaintenanceRepo) ExportTable(ctx context.Context, table string, fn func(row map[string]json.RawMessage) error) error {
+	tableSchema, err := mr.tableSchema(table)
+	if err != nil {
+		return err
+	}
+	rows, err := mr.DB.WithContext(ctx).Table(table).Order(clause.OrderByColumn{Column: clause.Column{Name: tableSchema.PrioritizedPrimaryField.DBName}}).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		value := reflect.New(tableSchema.ModelType)
+		if err := mr.DB.ScanRows(rows, value.Interface()); err != nil {
+			return err
+		}
+		row := make(map[string]json.RawMessage, len(tableSchema.DBNames))
+		for _, field := range tableSchema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			fieldValue, _ := field.ValueOf(ctx, value.Elem())
+			if row[field.DBName], err = json.Marshal(fieldValue); err != nil {
+				return err
+			}
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// InsertRows - inserts rows of backed up table given as JSON values keyed by column names, keeping primary keys
+func (mr MaintenanceRepo) InsertRows(ctx context.Context, table string, rows []map[string]json.RawMessage) error {
+	tableSchema, err := mr.tableSchema(table)
+	if err != nil {
+		return err
+	}
+	values := reflect.MakeSlice(reflect.SliceOf(reflect.PointerTo(tableSchema.ModelType)), 0, len(rows))
+	for _, row := range rows {
+		value := reflect.New(tableSchema.ModelType)
+		for column, raw := range row {
+			field := tableSchema.FieldsByDBName[column]
+			if field == nil {
+				return fmt.Errorf("%s.%s: %w", table, column, ErrUnknownColumn)
+			}
+			if err := json.Unmarshal(raw, field.ReflectValueOf(ctx, value.Elem()).Addr().Interface()); err != nil {
+				return fmt.Errorf("%s.%s: %w", table, column, err)
+			}
+		}
+		values = reflect.Append(values, value)
+	}
+	if values.Len() == 0 {
+		return nil
+	}
+	return mr.DB.WithContext(ctx).Omit(clause.Associations).CreateInBatches(values.Interface(), 500).Error
+}
+
+// ClearTables - deletes all rows of backed up tables, referencing tables first, and monthly_spend derived from them
+func (mr MaintenanceRepo) ClearTables(ctx context.Context) error {
+	for i := len(backupModels) - 1; i >= 0; i-- {
+		err := mr.DB.WithContext(ctx).Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(backupModels[i]).Error
+		if err != nil {
+			return err
+		}
+	}
+	return SpendRepo{DB: mr.DB}.Clear(ctx)
+}
+
+// ResetSequences - moves postgres sequences of primary keys of backed up tables past the restored rows. Does nothing on other databases
+func (mr MaintenanceRepo) ResetSequences(ctx context.Context) error {
+	if mr.DB.Dialector.Name() != "postgres" {
+		return nil
+	}
+	for _, m := range backupModels {
+		tableSchema, err := mr.parse(m)
+		if err != nil {
+			return err
+		}
+		field := tableSchema.PrioritizedPrimaryField
+		if field == nil || !field.AutoIncrement {
+			continue
+		}
+		err = mr.DB.WithContext(ctx).Exec(fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%[1]s', '%[2]s'), COALESCE(MAX(%[2]s), 1), MAX(%[2]s) IS NOT NULL) FROM %[1]s",
+			tableSchema.Table, field.DBName)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetOverlappingPairs - SIDs of pairs of subscriptions of the same user to the same service with overlapping periods (see CheckIfExists), lower SID first
+func (mr MaintenanceRepo) GetOverlappingPairs(ctx context.Context) ([][2]uint64, error) {
+	var pairs []struct {
+		SID      uint64 `gorm:"column:sid"`
+		OtherSID uint64 `gorm:"column:other_sid"`
+	}
+	err := mr.DB.WithContext(ctx).Table("subscriptions AS a").
+		Select("a.subscription_id AS sid, b.subscription_id AS other_sid").
+		Joins("JOIN subscriptions AS b ON b.user_id = a.user_id AND b.service_name = a.service_name AND b.subscription_id > a.subscription_id").
+		Where("a.end_date IS NULL OR a.end_date >= b.start_date").
+		Where("b.end_date IS NULL OR b.end_date >= a.start_date").
+		Order("a.subscription_id, b.subscription_id").
+		Scan(&pairs).Error
+	res := make([][2]uint64, len(pairs))
+	for i, pair := range pairs {
+		res[i] = [2]uint64{pair.SID, pair.OtherSID}
+	}
+	return res, err
+}
+
+// GetEndBeforeStart - subscriptions ending before they start
+func (mr MaintenanceRepo) GetEndBeforeStart(ctx context.Context) ([]*model.Subscription, error) {
+	return mr.subscriptionsWhere(ctx, "end_date < start_date")
+}
+
+// GetTrialBeforeStart - subscriptions with trial ending before subscription starts
+func (mr MaintenanceRepo) GetTrialBeforeStart(ctx context.Context) ([]*model.Subscription, error) {
+	return mr.subscriptionsWhere(ctx, "trial_until < start_date")
+}
+
+// GetMissingPrevious - subscriptions referencing previous subscription that doesn't exist
+func (mr MaintenanceRepo) GetMissingPrevious(ctx context.Context) ([]*model.Subscription, error) {
+	return mr.subscriptionsWhere(ctx, "previous_subscription_id IS NOT NULL AND previous_subscription_id NOT IN (SELECT subscription_id FROM subscriptions)")
+}
+
+// GetMissingProvider - subscriptions referencing provider that doesn't exist
+func (mr MaintenanceRepo) GetMissingProvider(ctx context.Context) ([]*model.Subscription, error) {
+	return mr.subscriptionsWhere(ctx, "provider_id IS NOT NULL AND provider_id NOT IN (SELECT provider_id FROM providers)")
+}
+
+func (mr MaintenanceRepo) subscriptionsWhere(ctx context.Context, condition string) ([]*model.Subscription, error) {
+	var dbSubs []*model.Subscription
+	err := mr.DB.WithContext(ctx).Where(condition).Order("subscription_id").Find(&dbSubs).Error
+	return dbSubs, err
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ProviderRepo - structure provides access to DB-requests on providers catalog
+type ProviderRepo struct {
+	DB *gorm.DB
+}
+
+var ErrProviderNotFound = errors.New("provider not found")
+var ErrProviderExists = errors.New("provider name or alias already exists")
+var ErrProviderInUse = errors.New("provider is referenced by subscriptions")
+
+// linkBatchSize - subscriptions relinked to provider in one statement
+const linkBatchSize = 1000
+
+func CreateProviderRepo(db *gorm.DB) *ProviderRepo {
+	return &ProviderRepo{DB: db}
+}
+
+// WithTx - returns a copy of repo bound to the provided transaction
+func (pr ProviderRepo) WithTx(tx *gorm.DB) ProviderRepo {
+	return ProviderRepo{DB: tx}
+}
+
+// CreateProvider - stores provider together with its aliases
+func (pr ProviderRepo) CreateProvider(ctx context.Context, provider *model.Provider) error {
+	return pr.DB.WithContext(ctx).Create(provider).Error
+}
+
+// GetProviderByID - returns provider with aliases, gorm.ErrRecordNotFound if there is none
+func (pr ProviderRepo) GetProviderByID(ctx context.Context, id uint64) (*model.Provider, error) {
+	var provider model.Provider
+	err := pr.DB.WithContext(ctx).Preload("Aliases").First(&provider, id).Error
+	return &provider, err
+}
+
+// GetAllProviders - returns all providers with aliases ordered by name
+func (pr ProviderRepo) GetAllProviders(ctx context.Context) ([]*model.Provider, error) {
+	var providers []*model.Provider
+	err := pr.DB.WithContext(ctx).Preload("Aliases").Order("name").Find(&providers).Error
+	return providers, err
+}
+
+// GetProvidersByIDs - returns providers with aliases with provided IDs in one query; missing IDs are skipped
+func (pr ProviderRepo) GetProvidersByIDs(ctx context.Context, ids []uint64) ([]*model.Provider, error) {
+	var providers []*model.Provider
+	err := pr.DB.WithContext(ctx).Preload("Aliases").Where("provider_id IN ?", ids).Find(&providers).Error
+	return providers, err
+}
+
+// FindProviderByKey - looks provider up by normalized name or alias, gorm.ErrRecordNotFound if there is none
+func (pr ProviderRepo) FindProviderByKey(ctx context.Context, key string) (*model.Provider, error) {
+	var provider model.Provider
+	err := pr.DB.WithContext(ctx).
+		Where("normalized_name = ?", key).
+		Or("provider_id IN (?)", pr.DB.Model(&model.ProviderAlias{}).Select("provider_id").Where("normalized_alias = ?", key)).
+		First(&provider).Error
+	return &provider, err
+}
+
+// IsKeyTaken - checks if any of normalized keys is already used as a name or alias of a provider other than excludeID
+func (pr ProviderRepo) IsKeyTaken(ctx context.Context, keys []string, excludeID uint64) (bool, error) {
+	var names, aliases int64
+	err := pr.DB.WithContext(ctx).Model(&model.Provider{}).
+		Where("normalized_name IN ?", keys).
+		Where("provider_id <> ?", excludeID).
+		Count(&names).Error
+	if err != nil {
+		return false, err
+	}
+	err = pr.DB.WithContext(ctx).Model(&model.ProviderAlias{}).
+		Where("normalized_alias IN ?", keys).
+		Where("provider_id <> ?", excludeID).
+		Count(&aliases).Error
+	return names+aliases > 0, err
+}
+
+// UpdateProvider - saves provider fields; if aliases is not nil, replaces provider aliases with it
+func (pr ProviderRepo) UpdateProvider(ctx context.Context, provider *model.Provider, aliases []model.ProviderAlias) error {
+	db := pr.DB.WithContext(ctx)
+	if err := db.Omit("Aliases").Save(provider).Error; err != nil {
+		return err
+	}
+	if aliases == nil {
+		return nil
+	}
+	if err := db.Where("provider_id = ?", provider.ID).Delete(&model.ProviderAlias{}).Error; err != nil {
+		return err
+	}
+	for i := range aliases {
+		aliases[i].ProviderID = provider.ID
+	}
+	if len(aliases) > 0 {
+		if err := db.Create(&aliases).Error; err != nil {
+			return err
+		}
+	}
+	provider.Aliases = aliases
+	return nil
+}
+
+// DeleteProvider - removes provider and its aliases
+func (pr ProviderRepo) DeleteProvider(ctx context.Context, id uint64) (int64, error) {
+	db := pr.DB.WithContext(ctx)
+	if err := db.Where("provider_id = ?", id).Delete(&model.ProviderAlias{}).Error; err != nil {
+		return 0, err
+	}
+	res := db.Delete(&model.Provider{}, id)
+	return res.RowsAffected, res.Error
+}
+
+// CountProviderSubscriptions - returns number of subscriptions referencing provider
+func (pr ProviderRepo) CountProviderSubscriptions(ctx context.Context, id uint64) (int64, error) {
+	var res int64
+	err := pr.DB.WithContext(ctx).Model(&model.Subscription{}).Where("provider_id = ?", id).Count(&res).Error
+	return res, err
+}
+
+// GetUnlinkedServiceNames - returns distinct service names of subscriptions not referencing any provider
+func (pr ProviderRepo) GetUnlinkedServiceNames(ctx context.Context) ([]string, error) {
+	var names []string
+	err := pr.DB.WithContext(ctx).Model(&model.Subscription{}).
+		Where("provider_id IS NULL").
+		Distinct("service_name").
+		Pluck("service_name", &names).Error
+	return names, err
+}
+
+// LinkSubscriptions - makes subscriptions with provided service names (or already linked to provider) reference provider and carry its canonical name;
+// subscriptions that already do are left untouched. Returns changed subscriptions as they were before the change
+func (pr ProviderRepo) LinkSubscriptions(ctx context.Context, provider *model.Provider, serviceNames []string) ([]*model.Subscription, error) {
+	query := pr.DB.WithContext(ctx).Where("provider_id = ? AND service_name <> ?", provider.ID, provider.Name)
+	if len(serviceNames) > 0 {
+		query = query.Or("provider_id IS NULL AND service_name IN ?", serviceNames)
+	}
+	var dbSubs []*model.Subscription
+	if err := query.Order("subscription_id").Find(&dbSubs).Error; err != nil {
+		return nil, err
+	}
+	for start := 0; start < len(dbSubs); start += linkBatchSize {
+		end := min(start+linkBatchSize, len(dbSubs))
+		sids := make([]uint64, 0, end-start)
+		for _, dbSub := range dbSubs[start:end] {
+			sids = append(sids, *dbSub.SID)
+		}
+		err := pr.DB.WithContext(ctx).Model(&model.Subscription{}).Where("subscription_id IN ?", sids).
+			Updates(map[string]any{"provider_id": provider.ID, "service_name": provider.Name, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dbSubs, nil
+}
@@ -6,6 +6,7 @@ import (
 	"em-test/cmd/internal/model"
 	"errors"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -17,16 +18,25 @@ type SubscriptionRepo struct {
 
 var ErrSubNotFound = errors.New("subscription not found")
 var ErrSubExists = errors.New("subscription already exists")
+var ErrSubCancelled = errors.New("subscription already cancelled")
+var ErrVersionMismatch = errors.New("subscription was modified by another request")
 
 var ErrEmptyAllFields = errors.New("all fields are empty")
 var ErrEmptySomeFields = errors.New("mandatory fields are empty")
+var ErrInvalidPeriod = errors.New("end date is before start date")
 
 func CreateRepo(db *gorm.DB) *SubscriptionRepo {
 	return &SubscriptionRepo{DB: db}
 }
 
+// WithTx - returns a copy of repo bound to the provided transaction
+func (sr SubscriptionRepo) WithTx(tx *gorm.DB) SubscriptionRepo {
+	return SubscriptionRepo{DB: tx}
+}
+
 // CreateSubscription -
 func (sr SubscriptionRepo) CreateSubscription(ctx context.Context, newSub *model.Subscription) error {
+	newSub.Version = 1
 	return sr.DB.WithContext(ctx).Create(newSub).Error
 }
 
@@ -37,41 +47,112 @@ func (sr SubscriptionRepo) GetSubscriptionBySID(ctx context.Context, sid uint64)
 	return &dbSub, err
 }
 
-// GetAllSubscriptions -
-func (sr SubscriptionRepo) GetAllSubscriptions(ctx context.Context) ([]*model.Subscription, error) {
+// GetAllSubscriptions - returns subscriptions, optionally only those with provided computed status
+func (sr SubscriptionRepo) GetAllSubscriptions(ctx context.Context, status string) ([]*model.Subscription, error) {
 	var dbSubs []*model.Subscription
-	err := sr.DB.WithContext(ctx).Find(&dbSubs).Error
+	err := withStatus(sr.DB.WithContext(ctx), status, time.Now()).Find(&dbSubs).Error
 	return dbSubs, err
 }
 
-// UpdateSubscriptionInfo -
+// GetSubscriptionsPage - returns up to filter.Limit subscriptions with SID greater than filter.AfterSID ordered by SID, optionally only those matching status, user and provider
+func (sr SubscriptionRepo) GetSubscriptionsPage(ctx context.Context, filter *model.SubscriptionPageFilter) ([]*model.Subscription, error) {
+	var dbSubs []*model.Subscription
+	query := withStatus(sr.DB.WithContext(ctx), filter.Status, time.Now()).
+		Where("subscriptions.subscription_id > ?", filter.AfterSID)
+	if filter.UID != "" {
+		query = query.Where("subscriptions.user_id = ?", filter.UID)
+	}
+	if filter.Provider != "" {
+		query = query.Where("subscriptions.service_name = ?", filter.Provider)
+	}
+	err := query.Order("subscriptions.subscription_id").Limit(filter.Limit).Find(&dbSubs).Error
+	return dbSubs, err
+}
+
+// GetSubscriptionsBySIDs - returns subscriptions with provided SIDs in one query; missing SIDs are skipped
+func (sr SubscriptionRepo) GetSubscriptionsBySIDs(ctx context.Context, sids []uint64) ([]*model.Subscription, error) {
+	var dbSubs []*model.Subscription
+	err := sr.DB.WithContext(ctx).Where("subscription_id IN ?", sids).Find(&dbSubs).Error
+	return dbSubs, err
+}
+
+// GetSubscriptionsByUIDs - returns subscriptions of all provided users in one query ordered by SID
+func (sr SubscriptionRepo) GetSubscriptionsByUIDs(ctx context.Context, uids []string) ([]*model.Subscription, error) {
+	var dbSubs []*model.Subscription
+	err := sr.DB.WithContext(ctx).Where("user_id IN ?", uids).Order("subscription_id").Find(&dbSubs).Error
+	return dbSubs, err
+}
+
+// GetUserIDsPage - returns up to limit distinct IDs of users having subscriptions, greater than afterUID in lexical order
+func (sr SubscriptionRepo) GetUserIDsPage(ctx context.Context, afterUID string, limit int) ([]string, error) {
+	var uids []string
+	err := sr.DB.WithContext(ctx).Model(&model.Subscription{}).
+		Distinct("user_id").
+		Where("user_id > ?", afterUID).
+		Order("user_id").
+		Limit(limit).
+		Pluck("user_id", &uids).Error
+	return uids, err
+}
+
+// withStatus - limits query to subscriptions with computed status at now (see utils.SubscriptionStatus); empty status keeps query as is
+func withStatus(query *gorm.DB, status string, now time.Time) *gorm.DB {
+	now = now.UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	nextMonth := thisMonth.AddDate(0, 1, 0)
+	switch status {
+	case model.StatusCancelled:
+		return query.Where("subscriptions.cancelled_at IS NOT NULL")
+	case model.StatusExpired:
+		return query.Where("subscriptions.cancelled_at IS NULL AND subscriptions.end_date < ?", thisMonth)
+	case model.StatusScheduled:
+		return query.Where("subscriptions.cancelled_at IS NULL AND (subscriptions.end_date IS NULL OR subscriptions.end_date >= ?) AND subscriptions.start_date >= ?", thisMonth, nextMonth)
+	case model.StatusActive:
+		return query.Where("subscriptions.cancelled_at IS NULL AND (subscriptions.end_date IS NULL OR subscriptions.end_date >= ?) AND subscriptions.start_date < ?", thisMonth, nextMonth)
+	}
+	return query
+}
+
+// UpdateSubscriptionInfo - saves all fields of subscription only if it still has the version it was read with and increments the version;
+// returns ErrVersionMismatch if subscription was changed or removed meanwhile
 func (sr SubscriptionRepo) UpdateSubscriptionInfo(ctx context.Context, newSub *model.Subscription) error {
-	return sr.DB.WithContext(ctx).Save(newSub).Error
+	version := newSub.Version
+	newSub.Version++
+	res := sr.DB.WithContext(ctx).Model(newSub).Where("version = ?", version).Select("*").Updates(newSub)
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = ErrVersionMismatch
+	}
+	if res.Error != nil {
+		newSub.Version = version
+	}
+	return res.Error
 }
 
-// DeleteSubcription -
-func (sr SubscriptionRepo) DeleteSubcription(ctx context.Context, sid uint) (int64, error) {
-	res := sr.DB.WithContext(ctx).Delete(&model.Subscription{}, sid)
+// DeleteSubcription - removes subscription only if it still has provided version
+func (sr SubscriptionRepo) DeleteSubcription(ctx context.Context, sid uint, version uint64) (int64, error) {
+	res := sr.DB.WithContext(ctx).Where("version = ?", version).Delete(&model.Subscription{}, sid)
 	return res.RowsAffected, res.Error
 }
 
-// ComposeReport provides a total summ of subscription prices that meet requirements of filterSub
-func (sr SubscriptionRepo) ComposeReport(ctx context.Context, filterSub *model.ReportFilter) (uint, error) {
-	var total sql.NullInt64
+// paidPriceSQL - price of subscription in report month given as parameter (first moment of month): zero while the month is covered by trial
+const paidPriceSQL = "CASE WHEN subscriptions.trial_until IS NOT NULL AND subscriptions.trial_until >= ? THEN 0 ELSE subscriptions.price END"
 
-	query := sr.DB.WithContext(ctx).Model(&model.Subscription{}).
-		Select("SUM(price) as total").
-		Where("start_date <= ?", filterSub.End).
-		Where("end_date IS NULL OR end_date >= ?", filterSub.Start)
-
-	if filterSub.UID != nil {
-		query = query.Where("user_id = ?", filterSub.UID)
+// ComposeReport provides a total summ of subscription prices that meet requirements of filterSub; read from monthly_spend when it covers report month
+func (sr SubscriptionRepo) ComposeReport(ctx context.Context, filterSub *model.ReportFilter) (uint, error) {
+	spend := SpendRepo{DB: sr.DB}
+	covered, err := spend.covers(ctx, filterSub)
+	if err != nil {
+		return 0, err
 	}
-	if filterSub.Provider != nil {
-		query = query.Where("service_name = ?", filterSub.Provider)
+	if covered {
+		return spend.composeReport(ctx, filterSub)
 	}
 
-	err := query.Scan(&total).Error
+	var total sql.NullInt64
+
+	err = sr.reportQuery(ctx, filterSub).
+		Select("SUM("+paidPriceSQL+") as total", filterSub.Start).
+		Scan(&total).Error
 
 	if err != nil {
 		return 0, err
@@ -83,6 +164,211 @@ func (sr SubscriptionRepo) ComposeReport(ctx context.Context, filterSub *model.R
 	return 0, nil
 }
 
+// ComposeReportByUsers provides total summs of subscription prices that meet requirements of filterSub for each of provided users in one query;
+// users without subscriptions in period are absent in result. Read from monthly_spend when it covers report month
+func (sr SubscriptionRepo) ComposeReportByUsers(ctx context.Context, filterSub *model.ReportFilter, uids []string) (map[string]uint, error) {
+	spend := SpendRepo{DB: sr.DB}
+	covered, err := spend.covers(ctx, filterSub)
+	if err != nil {
+		return nil, err
+	}
+	if covered {
+		return spend.composeReportByUsers(ctx, filterSub, uids)
+	}
+
+	var rows []struct {
+		UserID string
+		Total  int64
+	}
+	err = sr.reportQuery(ctx, filterSub).
+		Where("subscriptions.user_id IN ?", uids).
+		Select("subscriptions.user_id AS user_id, SUM("+paidPriceSQL+") AS total", filterSub.Start).
+		Group("subscriptions.user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]uint, len(rows))
+	for _, row := range rows {
+		res[row.UserID] = uint(row.Total)
+	}
+	return res, nil
+}
+
+// ComposeReportByCategory provides total summs of subscription prices that meet requirements of filterSub, grouped by category.
+// Total of every category includes its subcategories; subscriptions without category are summed up in a group with nil CategoryID.
+// Read from monthly_spend when it covers report month
+func (sr SubscriptionRepo) ComposeReportByCategory(ctx context.Context, filterSub *model.ReportFilter) ([]model.ReportGroup, error) {
+	spend := SpendRepo{DB: sr.DB}
+	covered, err := spend.covers(ctx, filterSub)
+	if err != nil {
+		return nil, err
+	}
+	if covered {
+		return spend.composeReportByCategory(ctx, filterSub)
+	}
+
+	var rows []struct {
+		CategoryID *uint64
+		Total      int64
+	}
+	err = sr.reportQuery(ctx, filterSub).
+		Joins("JOIN providers ON providers.provider_id = subscriptions.provider_id").
+		Joins("JOIN "+categoryClosureSQL+" AS category_tree ON category_tree.descendant_id = providers.category_id").
+		Select("category_tree.ancestor_id AS category_id, SUM("+paidPriceSQL+") AS total", filterSub.Start).
+		Group("category_tree.ancestor_id").
+		Order("category_tree.ancestor_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var uncategorized sql.NullInt64
+	err = sr.reportQuery(ctx, filterSub).
+		Where("subscriptions.provider_id IS NULL OR subscriptions.provider_id IN (SELECT provider_id FROM providers WHERE category_id IS NULL)").
+		Select("SUM("+paidPriceSQL+") as total", filterSub.Start).
+		Scan(&uncategorized).Error
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]model.ReportGroup, 0, len(rows)+1)
+	for _, row := range rows {
+		groups = append(groups, model.ReportGroup{CategoryID: row.CategoryID, Total: uint(row.Total)})
+	}
+	if uncategorized.Valid && uncategorized.Int64 > 0 {
+		groups = append(groups, model.ReportGroup{Total: uint(uncategorized.Int64)})
+	}
+	return groups, nil
+}
+
+// ComposeTrialReport provides trial statistics for report month; trial ends in the month if trial_until falls into it
+func (sr SubscriptionRepo) ComposeTrialReport(ctx context.Context, filterSub *model.ReportFilter) (*model.TrialReport, error) {
+	var row struct {
+		InTrial          int64
+		TrialsEnded      int64
+		Converted        int64
+		ConvertedRevenue int64
+	}
+	trialEnds := "subscriptions.trial_until >= @start AND subscriptions.trial_until <= @end"
+	converts := trialEnds + " AND (subscriptions.end_date IS NULL OR subscriptions.end_date > @end)"
+	err := sr.reportQuery(ctx, filterSub).
+		Select("COALESCE(SUM(CASE WHEN subscriptions.trial_until >= @start THEN 1 ELSE 0 END), 0) AS in_trial, "+
+			"COALESCE(SUM(CASE WHEN "+trialEnds+" THEN 1 ELSE 0 END), 0) AS trials_ended, "+
+			"COALESCE(SUM(CASE WHEN "+converts+" THEN 1 ELSE 0 END), 0) AS converted, "+
+			"COALESCE(SUM(CASE WHEN "+converts+" THEN subscriptions.price ELSE 0 END), 0) AS converted_revenue",
+			sql.Named("start", filterSub.Start), sql.Named("end", filterSub.End)).
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	return &model.TrialReport{
+		InTrial:          int(row.InTrial),
+		TrialsEnded:      int(row.TrialsEnded),
+		Converted:        int(row.Converted),
+		NotConverted:     int(row.TrialsEnded - row.Converted),
+		ConvertedRevenue: uint(row.ConvertedRevenue),
+	}, nil
+}
+
+// GetUpcomingCandidates - returns subscriptions ending between from and to, and open-ended ones started before to; uid is optional
+func (sr SubscriptionRepo) GetUpcomingCandidates(ctx context.Context, uid *string, from, to time.Time) ([]*model.Subscription, error) {
+	var dbSubs []*model.Subscription
+	query := sr.DB.WithContext(ctx).
+		Where(sr.DB.Where("end_date IS NOT NULL AND end_date >= ? AND end_date <= ?", from, to).
+			Or("end_date IS NULL AND start_date <= ?", to))
+	if uid != nil {
+		query = query.Where("user_id = ?", *uid)
+	}
+	err := query.Order("subscription_id").Find(&dbSubs).Error
+	return dbSubs, err
+}
+
+// GetExpiredUnmarked - returns up to limit not cancelled subscriptions whose end date is before the provided moment and which are not marked as expired yet
+func (sr SubscriptionRepo) GetExpiredUnmarked(ctx context.Context, before time.Time, limit int) ([]*model.Subscription, error) {
+	var dbSubs []*model.Subscription
+	err := sr.DB.WithContext(ctx).
+		Where("end_date < ? AND expired_at IS NULL AND cancelled_at IS NULL", before).
+		Order("subscription_id").
+		Limit(limit).
+		Find(&dbSubs).Error
+	return dbSubs, err
+}
+
+// MarkExpired - sets expiration mark of subscription unless it is already set, returns number of affected rows.
+// Bumps version, so an update of the subscription read before the mark fails instead of clearing it
+func (sr SubscriptionRepo) MarkExpired(ctx context.Context, sid uint64, at time.Time) (int64, error) {
+	res := sr.DB.WithContext(ctx).Model(&model.Subscription{}).
+		Where("subscription_id = ? AND expired_at IS NULL", sid).
+		Updates(map[string]any{"expired_at": at, "version": gorm.Expr("version + 1")})
+	return res.RowsAffected, res.Error
+}
+
+// TryJobLock - tries to take transaction-level postgres advisory lock with provided key, so a background job runs on one replica at a time;
+// returns false if the lock is held by another transaction. Always succeeds on other databases.
+func (sr SubscriptionRepo) TryJobLock(ctx context.Context, key int64) (bool, error) {
+	if sr.DB.Dialector.Name() != "postgres" {
+		return true, nil
+	}
+	var locked bool
+	err := sr.DB.WithContext(ctx).Raw("SELECT pg_try_advisory_xact_lock(?)", key).Scan(&locked).Error
+	return locked, err
+}
+
+// StreamSubscriptions - iterates over subscriptions ordered by SID without loading them all into memory; filterSub is optional and limits rows to those counted in report,
+// status is optional and limits rows to subscriptions with that computed status
+func (sr SubscriptionRepo) StreamSubscriptions(ctx context.Context, filterSub *model.ReportFilter, status string, fn func(*model.Subscription) error) error {
+	query := sr.DB.WithContext(ctx).Model(&model.Subscription{})
+	if filterSub != nil {
+		query = sr.reportQuery(ctx, filterSub)
+	}
+	query = withStatus(query, status, time.Now())
+
+	rows, err := query.Order("subscription_id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dbSub model.Subscription
+		if err := sr.DB.ScanRows(rows, &dbSub); err != nil {
+			return err
+		}
+		if err := fn(&dbSub); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// subscriptionPeriodSQL - period of subscription as postgres range, indexed by idx_subscriptions_period of migration 014.
+// End before start is replaced with start, so the range covers at least the rows matched by comparison of dates
+const subscriptionPeriodSQL = "tstzrange(subscriptions.start_date, CASE WHEN subscriptions.end_date < subscriptions.start_date THEN subscriptions.start_date ELSE subscriptions.end_date END, '[]')"
+
+// reportQuery - base query selecting subscriptions active in report period and matching optional filters
+func (sr SubscriptionRepo) reportQuery(ctx context.Context, filterSub *model.ReportFilter) *gorm.DB {
+	query := sr.DB.WithContext(ctx).Model(&model.Subscription{}).
+		Where("subscriptions.start_date <= ?", filterSub.End).
+		Where("subscriptions.end_date IS NULL OR subscriptions.end_date >= ?", filterSub.Start)
+	if sr.DB.Dialector.Name() == "postgres" {
+		// overlap of ranges lets postgres use GiST index instead of scanning all subscriptions started before the period end
+		query = query.Where(subscriptionPeriodSQL+" && tstzrange(?, ?, '[]')", filterSub.Start, filterSub.End)
+	}
+
+	if filterSub.UID != nil {
+		query = query.Where("subscriptions.user_id = ?", filterSub.UID)
+	}
+	if filterSub.Provider != nil {
+		query = query.Where("subscriptions.service_name = ?", filterSub.Provider)
+	}
+	if filterSub.CategoryID != nil {
+		query = query.Where("subscriptions.provider_id IN (SELECT providers.provider_id FROM providers JOIN "+categoryClosureSQL+
+			" AS category_tree ON category_tree.descendant_id = providers.category_id WHERE category_tree.ancestor_id = ?)", *filterSub.CategoryID)
+	}
+	return query
+}
+
 // CheckIfExists - checks if subscription data already exists in DB, returns informative error in both cases
 func (sr SubscriptionRepo) CheckIfExists(ctx context.Context, candidate *model.Subscription) error {
 	var res int64
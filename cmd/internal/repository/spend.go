@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/utils"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SpendRepo - structure provides access to DB-requests of monthly_spend aggregate
+type SpendRepo struct {
+	DB *gorm.DB
+}
+
+// spendStateID - primary key of the only row of monthly_spend_state
+const spendStateID = 1
+
+// spendBatchSize - rows of monthly_spend written in one statement
+const spendBatchSize = 500
+
+func CreateSpendRepo(db *gorm.DB) *SpendRepo {
+	return &SpendRepo{DB: db}
+}
+
+// WithTx - returns a copy of repo bound to the provided transaction
+func (sr SpendRepo) WithTx(tx *gorm.DB) SpendRepo {
+	return SpendRepo{DB: tx}
+}
+
+// GetState - state of aggregate, nil if it is not built
+func (sr SpendRepo) GetState(ctx context.Context) (*model.MonthlySpendState, error) {
+	// Find instead of Take: the state is read on every report and write, missing row is not an error to log
+	var states []model.MonthlySpendState
+	err := sr.DB.WithContext(ctx).Where("id = ?", spendStateID).Limit(1).Find(&states).Error
+	if err != nil || len(states) == 0 {
+		return nil, err
+	}
+	return &states[0], nil
+}
+
+// SaveState - creates or replaces state of aggregate
+func (sr SpendRepo) SaveState(ctx context.Context, state *model.MonthlySpendState) error {
+	state.ID = spendStateID
+	return sr.DB.WithContext(ctx).Save(state).Error
+}
+
+// Clear - deletes all rows of aggregate and its state, so reports are computed on the fly
+func (sr SpendRepo) Clear(ctx context.Context) error {
+	db := sr.DB.WithContext(ctx).Session(&gorm.Session{AllowGlobalUpdate: true})
+	if err := db.Delete(&model.MonthlySpendState{}).Error; err != nil {
+		return err
+	}
+	return db.Delete(&model.MonthlySpend{}).Error
+}
+
+// AddSpend - adds amounts and counts of rows to aggregate (negative ones subtract), creating missing rows and removing rows left without subscriptions
+func (sr SpendRepo) AddSpend(ctx context.Context, rows []model.MonthlySpend) error {
+	rows = utils.MergeSpendRows(rows)
+	if len(rows) == 0 {
+		return nil
+	}
+	err := sr.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "month"}, {Name: "user_id"}, {Name: "provider_id"}, {Name: "service_name"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "amount"}, Value: gorm.Expr("monthly_spend.amount + excluded.amount")},
+			{Column: clause.Column{Name: "subscriptions"}, Value: gorm.Expr("monthly_spend.subscriptions + excluded.subscriptions")},
+		},
+	}).CreateInBatches(rows, spendBatchSize).Error
+	if err != nil {
+		return err
+	}
+
+	uids := map[string]bool{}
+	for _, row := range rows {
+		if row.Subscriptions < 0 {
+			uids[row.UID] = true
+		}
+	}
+	if len(uids) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(uids))
+	for uid := range uids {
+		keys = append(keys, uid)
+	}
+	return sr.DB.WithContext(ctx).Where("subscriptions <= 0 AND user_id IN ?", keys).Delete(&model.MonthlySpend{}).Error
+}
+
+// CountRows - number of rows in aggregate
+func (sr SpendRepo) CountRows(ctx context.Context) (int64, error) {
+	var count int64
+	err := sr.DB.WithContext(ctx).Model(&model.MonthlySpend{}).Count(&count).Error
+	return count, err
+}
+
+// StreamSubscriptionsByUser - iterates over subscriptions ordered by user without loading them all into memory;
+// activeAfter is optional and limits rows to subscriptions not ended before it
+func (sr SpendRepo) StreamSubscriptionsByUser(ctx context.Context, activeAfter *time.Time, fn func(*model.Subscription) error) error {
+	query := sr.DB.WithContext(ctx).Model(&model.Subscription{})
+	if activeAfter != nil {
+		query = query.Where("end_date IS NULL OR end_date >= ?", *activeAfter)
+	}
+	rows, err := query.Order("user_id, subscription_id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dbSub model.Subscription
+		if err := sr.DB.ScanRows(rows, &dbSub); err != nil {
+			return err
+		}
+		if err := fn(&dbSub); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetFirstMonth - first day of the earliest month in aggregate, nil if it is empty
+func (sr SpendRepo) GetFirstMonth(ctx context.Context) (*time.Time, error) {
+	var first model.MonthlySpend
+	err := sr.DB.WithContext(ctx).Order("month").Take(&first).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	month := first.Month.UTC()
+	return &month, nil
+}
+
+// GetFirstSubscriptionStart - start of the earliest subscription, nil if there are none
+func (sr SpendRepo) GetFirstSubscriptionStart(ctx context.Context) (*time.Time, error) {
+	var first model.Subscription
+	err := sr.DB.WithContext(ctx).Order("start_date").Take(&first).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &first.Start, nil
+}
+
+// GetMonth - rows of aggregate for month
+func (sr SpendRepo) GetMonth(ctx context.Context, month time.Time) ([]model.MonthlySpend, error) {
+	var rows []model.MonthlySpend
+	err := sr.DB.WithContext(ctx).
+		Where("month >= ? AND month <= ?", month, utils.EndOfMonth(month)).
+		Order("user_id, provider_id, service_name").
+		Find(&rows).Error
+	return rows, err
+}
+
+// ComposeLiveMonth - rows of aggregate for month computed on the fly from subscriptions, the way reports without aggregate are
+func (sr SpendRepo) ComposeLiveMonth(ctx context.Context, month time.Time) ([]model.MonthlySpend, error) {
+	var rows []model.MonthlySpend
+	filter := &model.ReportFilter{Start: month, End: utils.EndOfMonth(month)}
+	err := SubscriptionRepo{DB: sr.DB}.reportQuery(ctx, filter).
+		Select("subscriptions.user_id AS user_id, COALESCE(subscriptions.provider_id, 0) AS provider_id, subscriptions.service_name AS service_name, "+
+			"SUM("+paidPriceSQL+") AS amount, COUNT(*) AS subscriptions", filter.Start).
+		Group("subscriptions.user_id, COALESCE(subscriptions.provider_id, 0), subscriptions.service_name").
+		Order("user_id, provider_id, service_name").
+		Scan(&rows).Error
+	for i := range rows {
+		rows[i].Month = month
+	}
+	return rows, err
+}
+
+// covers - whether aggregate is built and has rows of report month of filterSub
+func (sr SpendRepo) covers(ctx context.Context, filterSub *model.ReportFilter) (bool, error) {
+	state, err := sr.GetState(ctx)
+	if err != nil || state == nil {
+		return false, err
+	}
+	return !filterSub.Start.After(state.CoveredUntil), nil
+}
+
+// reportQuery - base query selecting rows of aggregate for report month matching optional filters; the same rows as SubscriptionRepo.reportQuery sums up
+func (sr SpendRepo) reportQuery(ctx context.Context, filterSub *model.ReportFilter) *gorm.DB {
+	query := sr.DB.WithContext(ctx).Model(&model.MonthlySpend{}).
+		Where("monthly_spend.month >= ? AND monthly_spend.month <= ?", filterSub.Start, filterSub.End)
+	if filterSub.UID != nil {
+		query = query.Where("monthly_spend.user_id = ?", filterSub.UID)
+	}
+	if filterSub.Provider != nil {
+		query = query.Where("monthly_spend.service_name = ?", filterSub.Provider)
+	}
+	if filterSub.CategoryID != nil {
+		query = query.Where("monthly_spend.provider_id IN (SELECT providers.provider_id FROM providers JOIN "+categoryClosureSQL+
+			" AS category_tree ON category_tree.descendant_id = providers.category_id WHERE category_tree.ancestor_id = ?)", *filterSub.CategoryID)
+	}
+	return query
+}
+
+// composeReport - see SubscriptionRepo.ComposeReport
+func (sr SpendRepo) composeReport(ctx context.Context, filterSub *model.ReportFilter) (uint, error) {
+	var total sql.NullInt64
+	err := sr.reportQuery(ctx, filterSub).Select("SUM(monthly_spend.amount)").Scan(&total).Error
+	if err != nil || !total.Valid {
+		return 0, err
+	}
+	return uint(total.Int64), nil
+}
+
+// composeReportByUsers - see SubscriptionRepo.ComposeReportByUsers
+func (sr SpendRepo) composeReportByUsers(ctx context.Context, filterSub *model.ReportFilter, uids []string) (map[string]uint, error) {
+	var rows []struct {
+		UserID string
+		Total  int64
+	}
+	err := sr.reportQuery(ctx, filterSub).
+		Where("monthly_spend.user_id IN ?", uids).
+		Select("monthly_spend.user_id AS user_id, SUM(monthly_spend.amount) AS total").
+		Group("monthly_spend.user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]uint, len(rows))
+	for _, row := range rows {
+		res[row.UserID] = uint(row.Total)
+	}
+	return res, nil
+}
+
+// composeReportByCategory - see SubscriptionRepo.ComposeReportByCategory
+func (sr SpendRepo) composeReportByCategory(ctx context.Context, filterSub *model.ReportFilter) ([]model.ReportGroup, error) {
+	var rows []struct {
+		CategoryID *uint64
+		Total      int64
+	}
+	err := sr.reportQuery(ctx, filterSub).
+		Joins("JOIN providers ON providers.provider_id = monthly_spend.provider_id").
+		Joins("JOIN " + categoryClosureSQL + " AS category_tree ON category_tree.descendant_id = providers.category_id").
+		Select("category_tree.ancestor_id AS category_id, SUM(monthly_spend.amount) AS total").
+		Group("category_tree.ancestor_id").
+		Order("category_tree.ancestor_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var uncategorized sql.NullInt64
+	err = sr.reportQuery(ctx, filterSub).
+		Where("monthly_spend.provider_id = 0 OR monthly_spend.provider_id IN (SELECT provider_id FROM providers WHERE category_id IS NULL)").
+		Select("SUM(monthly_spend.amount)").
+		Scan(&uncategorized).Error
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]model.ReportGroup, 0, len(rows)+1)
+	for _, row := range rows {
+		groups = append(groups, model.ReportGroup{CategoryID: row.CategoryID, Total: uint(row.Total)})
+	}
+	if uncategorized.Valid && uncategorized.Int64 > 0 {
+		groups = append(groups, model.ReportGroup{Total: uint(uncategorized.Int64)})
+	}
+	return groups, nil
+}
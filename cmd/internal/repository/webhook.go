@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookRepo - structure provides access to DB-requests on webhooks and their delivery outbox
+type WebhookRepo struct {
+	DB *gorm.DB
+}
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+func CreateWebhookRepo(db *gorm.DB) *WebhookRepo {
+	return &WebhookRepo{DB: db}
+}
+
+// WithTx - returns a copy of repo bound to the provided transaction
+func (wr WebhookRepo) WithTx(tx *gorm.DB) WebhookRepo {
+	return WebhookRepo{DB: tx}
+}
+
+// CreateWebhook -
+func (wr WebhookRepo) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	return wr.DB.WithContext(ctx).Create(webhook).Error
+}
+
+// GetWebhookByID - returns webhook, gorm.ErrRecordNotFound if there is none
+func (wr WebhookRepo) GetWebhookByID(ctx context.Context, id uint64) (*model.Webhook, error) {
+	var webhook model.Webhook
+	err := wr.DB.WithContext(ctx).First(&webhook, id).Error
+	return &webhook, err
+}
+
+// GetAllWebhooks -
+func (wr WebhookRepo) GetAllWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	var webhooks []*model.Webhook
+	err := wr.DB.WithContext(ctx).Order("webhook_id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// GetActiveWebhooks - returns active webhooks; filtering by event type is done by caller as event types are stored as a list
+func (wr WebhookRepo) GetActiveWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	var webhooks []*model.Webhook
+	err := wr.DB.WithContext(ctx).Where("active = ?", true).Order("webhook_id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// UpdateWebhook -
+func (wr WebhookRepo) UpdateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	return wr.DB.WithContext(ctx).Save(webhook).Error
+}
+
+// DeleteWebhook - removes webhook together with its deliveries
+func (wr WebhookRepo) DeleteWebhook(ctx context.Context, id uint64) (int64, error) {
+	db := wr.DB.WithContext(ctx)
+	if err := db.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+		return 0, err
+	}
+	res := db.Delete(&model.Webhook{}, id)
+	return res.RowsAffected, res.Error
+}
+
+// CreateDeliveries - stores outbox records
+func (wr WebhookRepo) CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	// relinking to a provider may produce deliveries for thousands of subscriptions at once
+	return wr.DB.WithContext(ctx).CreateInBatches(&deliveries, 500).Error
+}
+
+// GetDueDeliveries - returns pending deliveries whose next attempt is due, oldest first
+func (wr WebhookRepo) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	err := wr.DB.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", model.DeliveryPending, now).
+		Order("next_attempt_at, delivery_id").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimDelivery - postpones next attempt of delivery by lease if it wasn't changed since it was read, so concurrent dispatchers don't send it twice
+func (wr WebhookRepo) ClaimDelivery(ctx context.Context, delivery *model.WebhookDelivery, leaseUntil time.Time) (bool, error) {
+	res := wr.DB.WithContext(ctx).Model(&model.WebhookDelivery{}).
+		Where("delivery_id = ? AND status = ? AND attempts = ? AND next_attempt_at = ?", delivery.ID, model.DeliveryPending, delivery.Attempts, delivery.NextAttemptAt).
+		Update("next_attempt_at", leaseUntil)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+	delivery.NextAttemptAt = leaseUntil
+	return true, nil
+}
+
+// UpdateDelivery - saves result of delivery attempt
+func (wr WebhookRepo) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return wr.DB.WithContext(ctx).Save(delivery).Error
+}
+
+// GetDeliveryByID - returns delivery, gorm.ErrRecordNotFound if there is none
+func (wr WebhookRepo) GetDeliveryByID(ctx context.Context, id uint64) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := wr.DB.WithContext(ctx).First(&delivery, id).Error
+	return &delivery, err
+}
+
+// GetDeliveries - returns deliveries filtered by optional status and webhook, newest first
+func (wr WebhookRepo) GetDeliveries(ctx context.Context, status string, webhookID *uint64, limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	query := wr.DB.WithContext(ctx).Order("delivery_id DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if webhookID != nil {
+		query = query.Where("webhook_id = ?", *webhookID)
+	}
+	err := query.Find(&deliveries).Error
+	return deliveries, err
+}
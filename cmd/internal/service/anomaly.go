@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/utils"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+)
+
+// minMedianSamples - minimal number of subscriptions to a provider to judge their prices by median
+const minMedianSamples = 3
+
+// Anomalies - analyses all stored subscriptions and reports likely duplicates (overlapping subscriptions of the same user whose
+// provider is the same or has similar normalized name), subscriptions priced filter.PriceRatio times above or below median price
+// of their provider among all users, and users having at least filter.MaxConcurrent subscriptions in one category in filter.Period
+func (ss *SubscriptionService) Anomalies(ctx context.Context, filter *model.RawAnomalyFilter) (*model.AnomalyReport, error) {
+	if filter.Similarity == 0 {
+		filter.Similarity = model.DefaultAnomalySimilarity
+	}
+	if filter.PriceRatio == 0 {
+		filter.PriceRatio = model.DefaultAnomalyPriceRatio
+	}
+	if filter.MaxConcurrent == 0 {
+		filter.MaxConcurrent = model.DefaultAnomalyMaxConcurrent
+	}
+	if filter.Period == "" {
+		filter.Period = time.Now().Format("01-2006")
+	}
+	if filter.Similarity < 0 || filter.Similarity > 1 || filter.PriceRatio <= 1 || filter.MaxConcurrent < 2 {
+		return nil, fmt.Errorf("%w: similarity must be within 0..1, price_ratio above 1, max_concurrent at least 2", utils.ErrConvertToNorm)
+	}
+	period, err := utils.ConvertFilterToNorm(&model.RawReportFilter{Period: filter.Period})
+	if err != nil {
+		return nil, err
+	}
+
+	var subs []*model.Subscription
+	err = ss.Repo.StreamSubscriptions(ctx, nil, "", func(dbSub *model.Subscription) error {
+		subs = append(subs, dbSub)
+		return nil
+	})
+	if err != nil {
+		//проблема с подключением к базе
+		log.Printf("[%v] DB problem while StreamSubscriptions attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, filter)
+		return nil, fmt.Errorf("Failed to make anomaly report: %w", err)
+	}
+
+	report := &model.AnomalyReport{
+		Duplicates:    findDuplicates(subs, filter),
+		PriceOutliers: findPriceOutliers(subs, filter),
+	}
+	report.CategoryOverloads, err = ss.findCategoryOverloads(ctx, subs, filter, period)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// findDuplicates - pairs of overlapping subscriptions of one user to the same provider or providers with similar names
+func findDuplicates(subs []*model.Subscription, filter *model.RawAnomalyFilter) []model.DuplicateSubscriptions {
+	byUser := map[string][]*model.Subscription{}
+	for _, sub := range subs {
+		if filter.UID == "" || sub.UID == filter.UID {
+			byUser[sub.UID] = append(byUser[sub.UID], sub)
+		}
+	}
+	duplicates := []model.DuplicateSubscriptions{}
+	for _, sub := range subs {
+		for _, other := range byUser[sub.UID] {
+			if *other.SID <= *sub.SID || !utils.PeriodsOverlap(sub, other) {
+				continue
+			}
+			similarity := 1.0
+			if sub.ProviderID == nil || other.ProviderID == nil || *sub.ProviderID != *other.ProviderID {
+				similarity = utils.NameSimilarity(sub.Provider, other.Provider)
+			}
+			if similarity < filter.Similarity {
+				continue
+			}
+			duplicates = append(duplicates, model.DuplicateSubscriptions{
+				Similarity:    math.Round(similarity*100) / 100,
+				Subscriptions: []*model.RawSubscription{utils.ConvertNormalSubToRaw(sub), utils.ConvertNormalSubToRaw(other)},
+			})
+		}
+	}
+	return duplicates
+}
+
+// findPriceOutliers - subscriptions whose price differs from median price of their provider at least filter.PriceRatio times;
+// providers are matched by catalog ID or normalized name, prices of all users count towards median
+func findPriceOutliers(subs []*model.Subscription, filter *model.RawAnomalyFilter) []model.PriceOutlier {
+	providerKey := func(sub *model.Subscription) string {
+		if sub.ProviderID != nil {
+			return strconv.FormatUint(*sub.ProviderID, 10)
+		}
+		return utils.NormalizeProviderName(sub.Provider)
+	}
+	prices := map[string][]uint{}
+	for _, sub := range subs {
+		prices[providerKey(sub)] = append(prices[providerKey(sub)], sub.Price)
+	}
+	medians := make(map[string]float64, len(prices))
+	for key, v := range prices {
+		if len(v) >= minMedianSamples {
+			medians[key] = utils.MedianPrice(v)
+		}
+	}
+
+	outliers := []model.PriceOutlier{}
+	for _, sub := range subs {
+		median := medians[providerKey(sub)]
+		if median == 0 || (filter.UID != "" && sub.UID != filter.UID) {
+			continue
+		}
+		ratio := float64(sub.Price) / median
+		if ratio < filter.PriceRatio && ratio*filter.PriceRatio > 1 {
+			continue
+		}
+		outliers = append(outliers, model.PriceOutlier{
+			Median:       median,
+			Ratio:        math.Round(ratio*100) / 100,
+			Subscription: utils.ConvertNormalSubToRaw(sub),
+		})
+	}
+	return outliers
+}
+
+// findCategoryOverloads - users with at least filter.MaxConcurrent subscriptions active in period whose providers belong to the same category
+func (ss *SubscriptionService) findCategoryOverloads(ctx context.Context, subs []*model.Subscription, filter *model.RawAnomalyFilter, period *model.ReportFilter) ([]model.CategoryOverload, error) {
+	providers, err := ss.Providers.GetAllProviders(ctx)
+	if err != nil {
+		log.Printf("[%v] DB problem while GetAllProviders attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return nil, fmt.Errorf("Failed to make anomaly report: %w", err)
+	}
+	categories, err := ss.Categories.GetAllCategories(ctx)
+	if err != nil {
+		log.Printf("[%v] DB problem while GetAllCategories attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return nil, fmt.Errorf("Failed to make anomaly report: %w", err)
+	}
+	paths := utils.BuildCategoryPaths(categories)
+	providerCategory := make(map[uint64]uint64, len(providers))
+	for _, provider := range providers {
+		if provider.CategoryID != nil {
+			providerCategory[provider.ID] = *provider.CategoryID
+		}
+	}
+
+	type userCategory struct {
+		uid        string
+		categoryID uint64
+	}
+	var order []userCategory
+	groups := map[userCategory][]*model.RawSubscription{}
+	for _, sub := range subs {
+		if (filter.UID != "" && sub.UID != filter.UID) || sub.ProviderID == nil || sub.Start.After(period.End) || (sub.End != nil && sub.End.Before(period.Start)) {
+			continue
+		}
+		categoryID, ok := providerCategory[*sub.ProviderID]
+		if !ok {
+			continue
+		}
+		key := userCategory{uid: sub.UID, categoryID: categoryID}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], utils.ConvertNormalSubToRaw(sub))
+	}
+
+	overloads := []model.CategoryOverload{}
+	for _, key := range order {
+		if len(groups[key]) < filter.MaxConcurrent {
+			continue
+		}
+		overloads = append(overloads, model.CategoryOverload{
+			UID:           key.uid,
+			CategoryID:    key.categoryID,
+			Category:      paths[key.categoryID],
+			Period:        filter.Period,
+			Subscriptions: groups[key],
+		})
+	}
+	return overloads, nil
+}
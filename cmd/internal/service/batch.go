@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// MaxBatchOperations - upper limit of operations in a single batch request
+const MaxBatchOperations = 1000
+
+// ErrBatchRolledBack - error reflecting that one of batch operations failed and the whole batch was rolled back
+var ErrBatchRolledBack = errors.New("batch rolled back")
+
+// ErrBatchInvalid - error reflecting malformed batch request: empty, too large or with unknown/incomplete operations
+var ErrBatchInvalid = errors.New("invalid batch request")
+
+// ExecuteBatch - executes create/update/delete operations in order within a single transaction; the first failed operation rolls back the whole batch.
+// Result is returned in both cases, the error wraps ErrBatchRolledBack and the cause of failure.
+func (ss *SubscriptionService) ExecuteBatch(ctx context.Context, ops []model.BatchOperation) (*model.BatchResult, error) {
+	if len(ops) == 0 || len(ops) > MaxBatchOperations {
+		return nil, fmt.Errorf("%w: expected from 1 to %d operations, got %d", ErrBatchInvalid, MaxBatchOperations, len(ops))
+	}
+	for i, op := range ops {
+		if err := validateBatchOp(op); err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %w", ErrBatchInvalid, i, err)
+		}
+	}
+
+	result := &model.BatchResult{Results: make([]model.BatchOpResult, len(ops))}
+	for i, op := range ops {
+		result.Results[i] = model.BatchOpResult{Index: i, Op: op.Op, Status: model.BatchOpSkipped}
+	}
+
+	err := ss.RunInTx(ctx, func(txService *SubscriptionService) error {
+		for i, op := range ops {
+			rawSub, err := txService.executeBatchOp(ctx, op)
+			if err != nil {
+				result.Results[i].Status = model.BatchOpFailed
+				result.Results[i].Error = err.Error()
+				for j := 0; j < i; j++ {
+					result.Results[j].Status = model.BatchOpRolledBack
+				}
+				return fmt.Errorf("%w: operation %d: %w", ErrBatchRolledBack, i, err)
+			}
+			result.Results[i].Status = model.BatchOpDone
+			result.Results[i].Subscription = rawSub
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Committed = true
+	return result, nil
+}
+
+func validateBatchOp(op model.BatchOperation) error {
+	switch op.Op {
+	case model.BatchOpCreate:
+		if op.Subscription == nil {
+			return fmt.Errorf("create requires subscription: %w", repository.ErrEmptySomeFields)
+		}
+	case model.BatchOpUpdate:
+		if op.SID == nil || op.Subscription == nil {
+			return fmt.Errorf("update requires subscription_id and subscription: %w", repository.ErrEmptySomeFields)
+		}
+	case model.BatchOpDelete:
+		if op.SID == nil {
+			return fmt.Errorf("delete requires subscription_id: %w", repository.ErrEmptySomeFields)
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", op.Op)
+	}
+	return nil
+}
+
+// executeBatchOp - runs a single batch operation through the regular service methods
+func (ss *SubscriptionService) executeBatchOp(ctx context.Context, op model.BatchOperation) (*model.RawSubscription, error) {
+	switch op.Op {
+	case model.BatchOpCreate:
+		rawSub := *op.Subscription
+		rawSub.SID = nil
+		if err := ss.CreateSubscription(ctx, &rawSub); err != nil {
+			return nil, err
+		}
+		return &rawSub, nil
+	case model.BatchOpUpdate:
+		rawSub := *op.Subscription
+		if err := ss.UpdateBySID(ctx, &rawSub, strconv.FormatUint(*op.SID, 10)); err != nil {
+			return nil, err
+		}
+		updated, err := ss.GetBySID(ctx, *op.SID)
+		if err != nil {
+			return nil, err
+		}
+		updated.Warnings = rawSub.Warnings
+		return updated, nil
+	default:
+		var version *uint64
+		if op.Subscription != nil {
+			version = op.Subscription.Version
+		}
+		if err := ss.DeleteSubscription(ctx, uint(*op.SID), version); err != nil {
+			return nil, err
+		}
+		return &model.RawSubscription{SID: op.SID}, nil
+	}
+}
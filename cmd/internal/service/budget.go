@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/utils"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BudgetService provides methods to business logics of user budgets and further repo(bd-requeste) calls.
+type BudgetService struct {
+	Repo          repository.BudgetRepo
+	Categories    repository.CategoryRepo
+	Providers     repository.ProviderRepo
+	Subscriptions repository.SubscriptionRepo
+}
+
+func CreateBudgetService(db *gorm.DB) *BudgetService {
+	return &BudgetService{
+		Repo:          *repository.CreateBudgetRepo(db),
+		Categories:    *repository.CreateCategoryRepo(db),
+		Providers:     *repository.CreateProviderRepo(db),
+		Subscriptions: *repository.CreateRepo(db),
+	}
+}
+
+// CreateBudget - validates and stores budget; mode defaults to warn
+func (bs *BudgetService) CreateBudget(ctx context.Context, rawBudget *model.RawBudget) error {
+	if strings.TrimSpace(rawBudget.UID) == "" || rawBudget.Amount == nil {
+		return fmt.Errorf("Warning on budget creation: %w", repository.ErrEmptySomeFields)
+	}
+	budget := &model.Budget{UID: strings.TrimSpace(rawBudget.UID), Amount: *rawBudget.Amount, Mode: model.BudgetModeWarn}
+	categoryPath, err := bs.applyScope(ctx, budget, rawBudget)
+	if err != nil {
+		return err
+	}
+	if err := bs.Repo.CreateBudget(ctx, budget); err != nil {
+		//проблема с подключением к базе
+		log.Printf("[%v] DB problem while CreateBudget attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, rawBudget)
+		return err
+	}
+	*rawBudget = *utils.ConvertNormalBudgetToRaw(budget, categoryPath)
+	return nil
+}
+
+// GetBudgetByID - returns budget with path of its category
+func (bs *BudgetService) GetBudgetByID(ctx context.Context, id uint64) (*model.RawBudget, error) {
+	budget, err := bs.Repo.GetBudgetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("Failed to get budget info: %w", repository.ErrBudgetNotFound)
+		}
+		log.Printf("[%v] DB problem while GetBudgetByID attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, id)
+		return nil, err
+	}
+	paths, err := bs.categoryPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ConvertNormalBudgetToRaw(budget, paths[derefID(budget.CategoryID)]), nil
+}
+
+// GetBudgetList - provides budgets, optionally only of provided user
+func (bs *BudgetService) GetBudgetList(ctx context.Context, uid string) ([]*model.RawBudget, error) {
+	budgets, err := bs.getBudgets(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := bs.categoryPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rawBudgets := make([]*model.RawBudget, len(budgets))
+	for i, v := range budgets {
+		rawBudgets[i] = utils.ConvertNormalBudgetToRaw(v, paths[derefID(v.CategoryID)])
+	}
+	return rawBudgets, nil
+}
+
+// UpdateBudgetByID - updates provided fields of budget; category_id equal to 0 removes category scope
+func (bs *BudgetService) UpdateBudgetByID(ctx context.Context, rawBudget *model.RawBudget, id uint64) error {
+	if rawBudget.UID == "" && rawBudget.Amount == nil && rawBudget.CategoryID == nil && rawBudget.Category == "" && rawBudget.Provider == "" && rawBudget.Mode == "" {
+		return fmt.Errorf("Failed to update budget %v: %w", id, repository.ErrEmptyAllFields)
+	}
+	budget, err := bs.Repo.GetBudgetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("Failed to update budget: %w", repository.ErrBudgetNotFound)
+		}
+		log.Printf("[%v] DB problem while GetBudgetByID attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, id)
+		return err
+	}
+	if uid := strings.TrimSpace(rawBudget.UID); uid != "" {
+		budget.UID = uid
+	}
+	if rawBudget.Amount != nil {
+		budget.Amount = *rawBudget.Amount
+	}
+	categoryPath, err := bs.applyScope(ctx, budget, rawBudget)
+	if err != nil {
+		return err
+	}
+	if err := bs.Repo.UpdateBudget(ctx, budget); err != nil {
+		log.Printf("[%v] DB problem while UpdateBudget attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, id)
+		return fmt.Errorf("Failed to update budget: %w", err)
+	}
+	*rawBudget = *utils.ConvertNormalBudgetToRaw(budget, categoryPath)
+	return nil
+}
+
+// DeleteBudget -
+func (bs *BudgetService) DeleteBudget(ctx context.Context, id uint64) error {
+	deleted, err := bs.Repo.DeleteBudget(ctx, id)
+	if err != nil {
+		log.Printf("[%v] DB problem while DeleteBudget attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, id)
+		return err
+	}
+	if deleted == 0 {
+		return fmt.Errorf("Failed to remove budget: %w", repository.ErrBudgetNotFound)
+	}
+	return nil
+}
+
+// OverBudget - provides budgets whose spend in the month (current one if period is empty) exceeds the amount; uid is optional
+func (bs *BudgetService) OverBudget(ctx context.Context, period, uid string) ([]*model.BudgetStatus, error) {
+	month := time.Now().UTC()
+	if period != "" {
+		parsed, err := utils.ParseMonth(period)
+		if err != nil {
+			return nil, fmt.Errorf("Convert failure: %w", err)
+		}
+		month = *parsed
+	}
+	budgets, err := bs.getBudgets(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := bs.categoryPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*model.BudgetStatus, 0)
+	for _, budget := range budgets {
+		spent, err := bs.Subscriptions.ComposeReport(ctx, utils.BudgetFilter(budget, month))
+		if err != nil {
+			log.Printf("[%v] DB problem while ComposeReport attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, budget.ID)
+			return nil, fmt.Errorf("Failed to make report: %w", err)
+		}
+		if spent <= budget.Amount {
+			continue
+		}
+		res = append(res, &model.BudgetStatus{
+			Budget: utils.ConvertNormalBudgetToRaw(budget, paths[derefID(budget.CategoryID)]),
+			Period: month.Format("01-2006"),
+			Spent:  spent,
+			OverBy: spent - budget.Amount,
+		})
+	}
+	return res, nil
+}
+
+// applyScope - applies mode, category and provider of raw budget to budget; returns path of budget category
+func (bs *BudgetService) applyScope(ctx context.Context, budget *model.Budget, rawBudget *model.RawBudget) (string, error) {
+	switch rawBudget.Mode {
+	case "":
+	case model.BudgetModeWarn, model.BudgetModeReject:
+		budget.Mode = rawBudget.Mode
+	default:
+		return "", fmt.Errorf("%w: unknown budget mode %q, expected warn or reject", utils.ErrConvertToNorm, rawBudget.Mode)
+	}
+
+	if rawBudget.Provider != "" {
+		name := rawBudget.Provider
+		provider, err := bs.Providers.FindProviderByKey(ctx, utils.NormalizeProviderName(name))
+		if err == nil {
+			name = provider.Name
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("[%v] DB problem while provider lookup: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, rawBudget)
+			return "", fmt.Errorf("Provider lookup failed: %w", err)
+		}
+		budget.Provider = &name
+	}
+
+	ref := rawBudget.Category
+	if rawBudget.CategoryID != nil {
+		if *rawBudget.CategoryID == 0 {
+			budget.CategoryID = nil
+			return "", nil
+		}
+		ref = strconv.FormatUint(*rawBudget.CategoryID, 10)
+	}
+	if ref != "" {
+		category, _, err := findCategory(ctx, bs.Categories, ref)
+		if err != nil {
+			if errors.Is(err, repository.ErrCategoryNotFound) {
+				return "", fmt.Errorf("%w: %w", utils.ErrConvertToNorm, err)
+			}
+			return "", err
+		}
+		budget.CategoryID = &category.ID
+	}
+	if budget.CategoryID == nil {
+		return "", nil
+	}
+	return categoryPath(ctx, bs.Categories, *budget.CategoryID)
+}
+
+func (bs *BudgetService) getBudgets(ctx context.Context, uid string) ([]*model.Budget, error) {
+	var uidFilter *string
+	if uid != "" {
+		uidFilter = &uid
+	}
+	budgets, err := bs.Repo.GetBudgets(ctx, uidFilter)
+	if err != nil {
+		log.Printf("[%v] DB problem while GetBudgets attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return nil, err
+	}
+	return budgets, nil
+}
+
+func (bs *BudgetService) categoryPaths(ctx context.Context) (map[uint64]string, error) {
+	categories, err := bs.Categories.GetAllCategories(ctx)
+	if err != nil {
+		log.Printf("[%v] DB problem while GetAllCategories attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return nil, err
+	}
+	return utils.BuildCategoryPaths(categories), nil
+}
+
+// budgetCheck - budget applicable to a changed subscription together with its spend before the change
+type budgetCheck struct {
+	budget *model.Budget
+	filter *model.ReportFilter
+	before uint
+}
+
+// prepareBudgetChecks - finds budgets of subscription owner covering sub in the month it is charged next (current one or start month)
+// and remembers their spend before the change; must be called before the change is written
+func (ss *SubscriptionService) prepareBudgetChecks(ctx context.Context, sub *model.Subscription) ([]budgetCheck, error) {
+	month := utils.StartOfMonth(time.Now().UTC())
+	if sub.Start.After(month) {
+		month = utils.StartOfMonth(sub.Start)
+	}
+	if sub.End != nil && sub.End.Before(month) {
+		return nil, nil
+	}
+	uid := sub.UID
+	budgets, err := ss.Budgets.GetBudgets(ctx, &uid)
+	if err != nil || len(budgets) == 0 {
+		return nil, err
+	}
+
+	var ancestors map[uint64]bool
+	var checks []budgetCheck
+	for _, budget := range budgets {
+		if budget.Provider != nil && *budget.Provider != sub.Provider {
+			continue
+		}
+		if budget.CategoryID != nil {
+			if ancestors == nil {
+				if ancestors, err = ss.providerCategories(ctx, sub.ProviderID); err != nil {
+					return nil, err
+				}
+			}
+			if !ancestors[*budget.CategoryID] {
+				continue
+			}
+		}
+		filter := utils.BudgetFilter(budget, month)
+		before, err := ss.Repo.ComposeReport(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, budgetCheck{budget: budget, filter: filter, before: before})
+	}
+	return checks, nil
+}
+
+// applyBudgetChecks - compares spend after the change with budgets: in reject mode a change raising spend above the amount fails
+// with ErrBudgetExceeded, in warn mode a warning is returned
+func (ss *SubscriptionService) applyBudgetChecks(ctx context.Context, checks []budgetCheck) ([]string, error) {
+	var warnings []string
+	for _, check := range checks {
+		after, err := ss.Repo.ComposeReport(ctx, check.filter)
+		if err != nil {
+			return nil, err
+		}
+		if after <= check.budget.Amount {
+			continue
+		}
+		message := fmt.Sprintf("budget %d of user %s: projected spend %d exceeds %d in %s",
+			check.budget.ID, check.budget.UID, after, check.budget.Amount, check.filter.Start.Format("01-2006"))
+		if check.budget.Mode == model.BudgetModeReject && after > check.before {
+			return nil, fmt.Errorf("%s: %w", message, repository.ErrBudgetExceeded)
+		}
+		warnings = append(warnings, message)
+	}
+	return warnings, nil
+}
+
+// providerCategories - returns category of provider with all its ancestors
+func (ss *SubscriptionService) providerCategories(ctx context.Context, providerID *uint64) (map[uint64]bool, error) {
+	res := map[uint64]bool{}
+	if providerID == nil {
+		return res, nil
+	}
+	provider, err := ss.Providers.GetProviderByID(ctx, *providerID)
+	if err != nil || provider.CategoryID == nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = nil
+		}
+		return res, err
+	}
+	categories, err := ss.Categories.GetAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parents := make(map[uint64]*uint64, len(categories))
+	for _, c := range categories {
+		parents[c.ID] = c.ParentID
+	}
+	for id := provider.CategoryID; id != nil && !res[*id]; id = parents[*id] {
+		res[*id] = true
+	}
+	return res, nil
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/utils"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CategoryService provides methods to business logics of provider categories and further repo(bd-requeste) calls.
+type CategoryService struct {
+	Repo  repository.CategoryRepo
+	Cache *ReportCache // optional cache of reports filtered by category to invalidate when the tree changes
+}
+
+func CreateCategoryService(db *gorm.DB) *CategoryService {
+	return &CategoryService{Repo: *repository.CreateCategoryRepo(db)}
+}
+
+// runInTx - executes fn within a single DB-transaction with repo bound to it
+func (cs *CategoryService) runInTx(ctx context.Context, fn func(txRepo repository.CategoryRepo) error) error {
+	return cs.Repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(cs.Repo.WithTx(tx))
+	})
+}
+
+// CreateCategory - creates category under optional parent, names are unique among siblings
+func (cs *CategoryService) CreateCategory(ctx context.Context, rawCategory *model.RawCategory) error {
+	category := &model.Category{Name: strings.TrimSpace(rawCategory.Name), ParentID: rawCategory.ParentID}
+	if category.Name == "" || strings.Contains(category.Name, ">") {
+		return fmt.Errorf("Warning on category creation: name must be non-empty and must not contain '>': %w", repository.ErrEmptySomeFields)
+	}
+
+	var path string
+	err := cs.runInTx(ctx, func(txRepo repository.CategoryRepo) error {
+		if err := checkCategoryPlacement(ctx, txRepo, category); err != nil {
+			return err
+		}
+		if err := txRepo.CreateCategory(ctx, category); err != nil {
+			return err
+		}
+		var err error
+		path, err = categoryPath(ctx, txRepo, category.ID)
+		return err
+	})
+	if err != nil {
+		logCategoryError("CreateCategory", err, rawCategory)
+		return fmt.Errorf("Failed to create category: %w", err)
+	}
+	*rawCategory = *utils.ConvertNormalCategoryToRaw(category, path)
+	return nil
+}
+
+// GetCategoryByID - returns category with its full path
+func (cs *CategoryService) GetCategoryByID(ctx context.Context, id uint64) (*model.RawCategory, error) {
+	category, err := cs.Repo.GetCategoryByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("Failed to get category info: %w", repository.ErrCategoryNotFound)
+		}
+		logCategoryError("GetCategoryByID", err, id)
+		return nil, err
+	}
+	path, err := categoryPath(ctx, cs.Repo, id)
+	if err != nil {
+		logCategoryError("GetAllCategories", err, id)
+		return nil, err
+	}
+	return utils.ConvertNormalCategoryToRaw(category, path), nil
+}
+
+// GetCategoryList - provides all categories with their full paths, sorted by path
+func (cs *CategoryService) GetCategoryList(ctx context.Context) ([]*model.RawCategory, error) {
+	categories, err := cs.Repo.GetAllCategories(ctx)
+	if err != nil {
+		logCategoryError("GetAllCategories", err, nil)
+		return nil, err
+	}
+	paths := utils.BuildCategoryPaths(categories)
+	rawCategories := make([]*model.RawCategory, len(categories))
+	for i, v := range categories {
+		rawCategories[i] = utils.ConvertNormalCategoryToRaw(v, paths[v.ID])
+	}
+	sort.Slice(rawCategories, func(i, j int) bool {
+		return strings.ToLower(rawCategories[i].Path) < strings.ToLower(rawCategories[j].Path)
+	})
+	return rawCategories, nil
+}
+
+// UpdateCategoryByID - renames category and/or moves it under another parent; ParentID equal to 0 moves category to the root
+func (cs *CategoryService) UpdateCategoryByID(ctx context.Context, rawCategory *model.RawCategory, id uint64) error {
+	name := strings.TrimSpace(rawCategory.Name)
+	if name == "" && rawCategory.ParentID == nil {
+		return fmt.Errorf("Failed to update category %v: %w", id, repository.ErrEmptyAllFields)
+	}
+	if strings.Contains(name, ">") {
+		return fmt.Errorf("Failed to update category %v: name must not contain '>': %w", id, utils.ErrConvertToNorm)
+	}
+
+	var category *model.Category
+	var path string
+	var moved bool
+	err := cs.runInTx(ctx, func(txRepo repository.CategoryRepo) error {
+		var err error
+		category, err = txRepo.GetCategoryByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return repository.ErrCategoryNotFound
+			}
+			return err
+		}
+		oldParentID := derefID(category.ParentID)
+		if name != "" {
+			category.Name = name
+		}
+		if rawCategory.ParentID != nil {
+			category.ParentID = rawCategory.ParentID
+			if *rawCategory.ParentID == 0 {
+				category.ParentID = nil
+			}
+		}
+		if err := checkCategoryPlacement(ctx, txRepo, category); err != nil {
+			return err
+		}
+		if err := txRepo.UpdateCategory(ctx, category); err != nil {
+			return err
+		}
+		moved = derefID(category.ParentID) != oldParentID
+		path, err = categoryPath(ctx, txRepo, category.ID)
+		return err
+	})
+	if err != nil {
+		logCategoryError("UpdateCategory", err, rawCategory)
+		return fmt.Errorf("Failed to update category: %w", err)
+	}
+	if moved {
+		cs.Cache.InvalidateCategories(ctx)
+	}
+	*rawCategory = *utils.ConvertNormalCategoryToRaw(category, path)
+	return nil
+}
+
+// DeleteCategory - removes category unless it has subcategories or providers attached
+func (cs *CategoryService) DeleteCategory(ctx context.Context, id uint64) error {
+	err := cs.runInTx(ctx, func(txRepo repository.CategoryRepo) error {
+		dependents, err := txRepo.CountDependents(ctx, id)
+		if err != nil {
+			return err
+		}
+		if dependents > 0 {
+			return repository.ErrCategoryInUse
+		}
+		deleted, err := txRepo.DeleteCategory(ctx, id)
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return repository.ErrCategoryNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		logCategoryError("DeleteCategory", err, id)
+		return fmt.Errorf("Failed to remove category: %w", err)
+	}
+	return nil
+}
+
+// checkCategoryPlacement - makes sure that parent exists, isn't category itself or its descendant and has no sibling with the same name
+func checkCategoryPlacement(ctx context.Context, repo repository.CategoryRepo, category *model.Category) error {
+	if category.ParentID != nil {
+		categories, err := repo.GetAllCategories(ctx)
+		if err != nil {
+			return err
+		}
+		parents := make(map[uint64]*uint64, len(categories))
+		for _, c := range categories {
+			parents[c.ID] = c.ParentID
+		}
+		if _, ok := parents[*category.ParentID]; !ok {
+			return fmt.Errorf("parent %d: %w", *category.ParentID, repository.ErrCategoryNotFound)
+		}
+		for ancestor := category.ParentID; ancestor != nil; ancestor = parents[*ancestor] {
+			if category.ID != 0 && *ancestor == category.ID {
+				return repository.ErrCategoryCycle
+			}
+		}
+	}
+	taken, err := repo.IsNameTaken(ctx, category.ParentID, category.Name, category.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return repository.ErrCategoryExists
+	}
+	return nil
+}
+
+// categoryPath - returns full path of category by its ID
+func categoryPath(ctx context.Context, repo repository.CategoryRepo, id uint64) (string, error) {
+	categories, err := repo.GetAllCategories(ctx)
+	if err != nil {
+		return "", err
+	}
+	return utils.BuildCategoryPaths(categories)[id], nil
+}
+
+// findCategory - resolves category reference given as ID or as path ("Entertainment > Video"); a single name matches a category of any level if it is unique
+func findCategory(ctx context.Context, repo repository.CategoryRepo, ref string) (*model.Category, string, error) {
+	categories, err := repo.GetAllCategories(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	paths := utils.BuildCategoryPaths(categories)
+
+	if id, err := strconv.ParseUint(strings.TrimSpace(ref), 10, 64); err == nil {
+		for _, category := range categories {
+			if category.ID == id {
+				return category, paths[id], nil
+			}
+		}
+		return nil, "", fmt.Errorf("category %d: %w", id, repository.ErrCategoryNotFound)
+	}
+
+	names := utils.SplitCategoryPath(ref)
+	if len(names) == 0 {
+		return nil, "", fmt.Errorf("empty category: %w", repository.ErrCategoryNotFound)
+	}
+	wanted := strings.ToLower(strings.Join(names, utils.CategoryPathSeparator))
+	var found *model.Category
+	for _, category := range categories {
+		path := strings.ToLower(paths[category.ID])
+		matches := path == wanted || (len(names) == 1 && strings.EqualFold(category.Name, names[0]))
+		if !matches {
+			continue
+		}
+		if path == wanted {
+			return category, paths[category.ID], nil
+		}
+		if found != nil {
+			return nil, "", fmt.Errorf("category name %q is ambiguous, use full path: %w", ref, utils.ErrConvertToNorm)
+		}
+		found = category
+	}
+	if found == nil {
+		return nil, "", fmt.Errorf("category %q: %w", ref, repository.ErrCategoryNotFound)
+	}
+	return found, paths[found.ID], nil
+}
+
+func logCategoryError(operation string, err error, input any) {
+	switch {
+	case errors.Is(err, repository.ErrCategoryNotFound), errors.Is(err, repository.ErrCategoryExists),
+		errors.Is(err, repository.ErrCategoryInUse), errors.Is(err, repository.ErrCategoryCycle):
+		return
+	}
+	//проблема с подключением к базе
+	log.Printf("[%v] DB problem while %s attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), operation, err, input)
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/utils"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxEventsPage - upper limit of events returned by one request to event log
+const MaxEventsPage = 1000
+
+// emit - appends event to event log and writes outbox deliveries for every active webhook subscribed to it;
+// must be called within the transaction of the change
+func (ss *SubscriptionService) emit(ctx context.Context, eventType string, sub *model.Subscription) error {
+	return ss.emitDeduped(ctx, eventType, sub, "")
+}
+
+// emitDeduped - same as emit, but does nothing if event with the same non-empty dedupe key was already recorded
+func (ss *SubscriptionService) emitDeduped(ctx context.Context, eventType string, sub *model.Subscription, dedupeKey string) error {
+	if err := ss.Events.LockEventLog(ctx); err != nil {
+		return fmt.Errorf("Failed to lock event log: %w", err)
+	}
+	var key *string
+	if dedupeKey != "" {
+		exists, err := ss.Events.EventExists(ctx, dedupeKey)
+		if err != nil {
+			return fmt.Errorf("Failed to check event log: %w", err)
+		}
+		if exists {
+			return nil
+		}
+		key = &dedupeKey
+	}
+	return writeEvents(ctx, ss.Events, ss.Webhooks, eventType, []*model.Subscription{sub}, key)
+}
+
+// emitAll - appends event of the same type for every subscription to event log and writes outbox deliveries for them;
+// used by changes of many subscriptions outside of SubscriptionService, must be called within the transaction of the change
+func emitAll(ctx context.Context, events repository.EventRepo, webhooks repository.WebhookRepo, eventType string, subs []*model.Subscription) error {
+	if len(subs) == 0 {
+		return nil
+	}
+	if err := events.LockEventLog(ctx); err != nil {
+		return fmt.Errorf("Failed to lock event log: %w", err)
+	}
+	return writeEvents(ctx, events, webhooks, eventType, subs, nil)
+}
+
+// writeEvents - records events of subscriptions and their webhook deliveries; event log must be already locked
+func writeEvents(ctx context.Context, events repository.EventRepo, webhooks repository.WebhookRepo, eventType string, subs []*model.Subscription, dedupeKey *string) error {
+	active, err := webhooks.GetActiveWebhooks(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to load webhooks: %w", err)
+	}
+	var deliveries []*model.WebhookDelivery
+	for _, sub := range subs {
+		event := &model.Event{Type: eventType, SID: sub.SID, CreatedAt: time.Now().UTC(), DedupeKey: dedupeKey}
+		data, err := json.Marshal(utils.ConvertNormalSubToRaw(sub))
+		if err != nil {
+			return fmt.Errorf("Failed to encode event: %w", err)
+		}
+		event.Data = string(data)
+		if err := events.CreateEvent(ctx, event); err != nil {
+			return fmt.Errorf("Failed to record event: %w", err)
+		}
+
+		for _, webhook := range active {
+			if !utils.WebhookAccepts(webhook, eventType) {
+				continue
+			}
+			payload, err := json.Marshal(utils.ConvertEventToPayload(event))
+			if err != nil {
+				return fmt.Errorf("Failed to encode event: %w", err)
+			}
+			deliveries = append(deliveries, &model.WebhookDelivery{
+				WebhookID:     webhook.ID,
+				EventID:       event.ID,
+				EventType:     eventType,
+				SID:           sub.SID,
+				Payload:       string(payload),
+				Status:        model.DeliveryPending,
+				NextAttemptAt: event.CreatedAt,
+			})
+		}
+	}
+	if err := webhooks.CreateDeliveries(ctx, deliveries); err != nil {
+		return fmt.Errorf("Failed to store webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// EventService provides read access to the ordered log of subscription events.
+type EventService struct {
+	Repo repository.EventRepo
+}
+
+func CreateEventService(db *gorm.DB) *EventService {
+	return &EventService{Repo: *repository.CreateEventRepo(db)}
+}
+
+// ListAfter - provides up to limit events following cursor (0 - from the beginning of log) and cursor for the next request
+func (es *EventService) ListAfter(ctx context.Context, after uint64, limit int) (*model.EventPage, error) {
+	if limit <= 0 || limit > MaxEventsPage {
+		limit = MaxEventsPage
+	}
+	events, err := es.Repo.GetEventsAfter(ctx, after, limit)
+	if err != nil {
+		//проблема с подключением к базе
+		log.Printf("[%v] DB problem while GetEventsAfter attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, after)
+		return nil, err
+	}
+	page := &model.EventPage{Events: make([]*model.EventPayload, len(events)), NextCursor: after}
+	for i, event := range events {
+		page.Events[i] = utils.ConvertEventToPayload(event)
+		page.NextCursor = event.ID
+	}
+	return page, nil
+}
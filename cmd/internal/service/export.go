@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/utils"
+	"log"
+	"time"
+)
+
+// StreamList - passes every subscription record existing in DB (optionally only with provided status) to fn one by one, without loading the whole list into memory
+func (ss *SubscriptionService) StreamList(ctx context.Context, status string, fn func(*model.RawSubscription) error) error {
+	err := ss.Repo.StreamSubscriptions(ctx, nil, status, func(dbSub *model.Subscription) error {
+		return fn(utils.ConvertNormalSubToRaw(dbSub))
+	})
+	if err != nil {
+		log.Printf("[%v] Problem while StreamSubscriptions attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+	}
+	return err
+}
+
+// StreamReport - passes every subscription counted in report for the provided filter to fn one by one
+func (ss *SubscriptionService) StreamReport(ctx context.Context, filter *model.RawReportFilter, fn func(*model.RawSubscription) error) error {
+	normFilter, err := ss.normalizeFilter(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	err = ss.Repo.StreamSubscriptions(ctx, normFilter, "", func(dbSub *model.Subscription) error {
+		return fn(utils.ConvertNormalSubToRaw(dbSub))
+	})
+	if err != nil {
+		log.Printf("[%v] Problem while StreamSubscriptions attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, normFilter)
+	}
+	return err
+}
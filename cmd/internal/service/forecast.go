@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/utils"
+	"fmt"
+	"time"
+)
+
+// MaxForecastMonths - upper limit of forecast horizon
+const MaxForecastMonths = 36
+
+// Forecast - projects monthly spend for months starting from the current one. Every month is composed the same way as Report
+// from subscriptions stored now: open-ended ones keep going, ended ones stop after their end month, trial months are free
+// and future price changes (subscription switched from a later month) apply from their start month.
+// Filters of RawReportFilter besides Period are applied to every month.
+func (ss *SubscriptionService) Forecast(ctx context.Context, months int, filter *model.RawReportFilter) (*model.Forecast, error) {
+	if months < 1 || months > MaxForecastMonths {
+		return nil, fmt.Errorf("%w: months must be between 1 and %d", utils.ErrConvertToNorm, MaxForecastMonths)
+	}
+
+	forecast := &model.Forecast{Months: make([]model.ForecastMonth, 0, months)}
+	month := utils.StartOfMonth(time.Now())
+	for i := 0; i < months; i++ {
+		monthFilter := *filter
+		monthFilter.Period = month.AddDate(0, i, 0).Format("01-2006")
+
+		var report model.Report
+		var err error
+		if filter.GroupBy == model.ReportGroupByCategory {
+			var grouped *model.Report
+			if grouped, err = ss.ReportByCategory(ctx, &monthFilter); err == nil {
+				report = *grouped
+			}
+		} else {
+			report.Total, err = ss.Report(ctx, &monthFilter)
+		}
+		if err != nil {
+			return nil, err
+		}
+		forecast.Total += report.Total
+		forecast.Months = append(forecast.Months, model.ForecastMonth{Period: monthFilter.Period, Report: report})
+	}
+	return forecast, nil
+}
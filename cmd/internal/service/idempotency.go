@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrIdempotencyKeyReused = errors.New("idempotency key was used with a different request")
+var ErrIdempotencyInProgress = errors.New("request with this idempotency key is in progress")
+
+// DefaultIdempotencyTTL - how long stored responses are replayed for retried requests
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// defaultIdempotencyLease - how long a request with a key may run before a retry takes the key over
+const defaultIdempotencyLease = time.Minute
+
+// IdempotencyService provides methods to business logics of idempotency keys and further repo(bd-requeste) calls.
+type IdempotencyService struct {
+	Repo  repository.IdempotencyRepo
+	Lease time.Duration
+}
+
+func CreateIdempotencyService(db *gorm.DB) *IdempotencyService {
+	return &IdempotencyService{Repo: *repository.CreateIdempotencyRepo(db), Lease: defaultIdempotencyLease}
+}
+
+// Begin - reserves key for a request with provided hash. Returns nil if the request should be executed,
+// or stored key with the response to replay if the key was used before with the same request.
+// Fails with ErrIdempotencyKeyReused if the key was used with another request and with ErrIdempotencyInProgress if the first request is still running.
+func (is *IdempotencyService) Begin(ctx context.Context, key, hash string) (*model.IdempotencyKey, error) {
+	reserved := &model.IdempotencyKey{Key: key, RequestHash: hash, CreatedAt: time.Now().UTC()}
+	ok, err := is.Repo.ReserveKey(ctx, reserved)
+	if err != nil || ok {
+		if err != nil {
+			//проблема с подключением к базе
+			log.Printf("[%v] DB problem while ReserveKey attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, key)
+		}
+		return nil, err
+	}
+
+	stored, err := is.Repo.GetKey(ctx, key)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) { //ключ удален между запросами - повторяем резервирование
+			return is.Begin(ctx, key, hash)
+		}
+		log.Printf("[%v] DB problem while GetKey attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, key)
+		return nil, err
+	}
+	if stored.RequestHash != hash {
+		return nil, fmt.Errorf("Key %q: %w", key, ErrIdempotencyKeyReused)
+	}
+	if stored.StatusCode != 0 {
+		return stored, nil
+	}
+	ok, err = is.Repo.ReclaimKey(ctx, reserved, reserved.CreatedAt.Add(-is.Lease))
+	if err != nil {
+		log.Printf("[%v] DB problem while ReclaimKey attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, key)
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("Key %q: %w", key, ErrIdempotencyInProgress)
+	}
+	return nil, nil
+}
+
+// Complete - stores response of the request reserved by Begin; server errors release the key, so the request can be retried
+func (is *IdempotencyService) Complete(ctx context.Context, key *model.IdempotencyKey) error {
+	var err error
+	if key.StatusCode >= 500 {
+		err = is.Repo.DeleteKey(ctx, key.Key)
+	} else {
+		err = is.Repo.CompleteKey(ctx, key)
+	}
+	if err != nil {
+		log.Printf("[%v] DB problem while storing idempotent response: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, key.Key)
+	}
+	return err
+}
+
+// DeleteExpired - removes keys older than ttl
+func (is *IdempotencyService) DeleteExpired(ctx context.Context, now time.Time, ttl time.Duration) (int64, error) {
+	count, err := is.Repo.DeleteExpiredKeys(ctx, now.UTC().Add(-ttl))
+	if err != nil {
+		return 0, fmt.Errorf("Failed to delete expired idempotency keys: %w", err)
+	}
+	return count, nil
+}
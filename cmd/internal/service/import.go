@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/utils"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrUnknownImportMode - error for unsupported import mode
+var ErrUnknownImportMode = errors.New("unknown import mode")
+
+// ImportSubscriptions - validates every row with the same rules as CreateSubscription (including overlaps with DB and within the batch)
+// and, unless it is a dry run, commits rows according to the import mode in a single transaction.
+func (ss *SubscriptionService) ImportSubscriptions(ctx context.Context, rows []model.ImportRow, opts model.ImportOptions) (*model.ImportReport, error) {
+	if opts.Mode == "" {
+		opts.Mode = model.ImportModeAllOrNothing
+	}
+	if opts.Mode != model.ImportModeAllOrNothing && opts.Mode != model.ImportModeValidOnly {
+		return nil, fmt.Errorf("Failed to import subscriptions: %w: %q", ErrUnknownImportMode, opts.Mode)
+	}
+
+	report := &model.ImportReport{
+		DryRun: opts.DryRun,
+		Mode:   opts.Mode,
+		Total:  len(rows),
+		Rows:   make([]model.ImportRowResult, len(rows)),
+	}
+
+	err := ss.RunInTx(ctx, func(txService *SubscriptionService) error {
+		accepted := make([]*model.Subscription, len(rows))
+		for i, row := range rows {
+			report.Rows[i].Line = row.Line
+			newSub, err := txService.validateImportRow(ctx, row, accepted[:i])
+			if err != nil {
+				report.Rows[i].Status = model.ImportRowFailed
+				report.Rows[i].Error = err.Error()
+				report.Failed++
+				continue
+			}
+			accepted[i] = newSub
+			report.Rows[i].Status = model.ImportRowValid
+			report.Valid++
+		}
+
+		if opts.DryRun || report.Valid == 0 || (opts.Mode == model.ImportModeAllOrNothing && report.Failed > 0) {
+			return nil
+		}
+
+		for i, newSub := range accepted {
+			if newSub == nil {
+				continue
+			}
+			err := txService.Repo.CreateSubscription(ctx, newSub)
+			if err == nil {
+				err = txService.recordWrite(ctx, nil, []*model.Subscription{newSub})
+			}
+			if err == nil {
+				err = txService.emit(ctx, model.EventSubscriptionCreated, newSub)
+			}
+			if err != nil { //проблема с подключением к базе
+				log.Printf("[%v] DB problem while CreateSubscription attempt during import: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, rows[i].Sub)
+				return fmt.Errorf("Failed to import line %d: %w", rows[i].Line, err)
+			}
+			report.Rows[i].Status = model.ImportRowCreated
+			report.Rows[i].SID = newSub.SID
+			report.Created++
+		}
+		report.Committed = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// validateImportRow - validates a single import row against DB and rows already accepted within the batch
+func (ss *SubscriptionService) validateImportRow(ctx context.Context, row model.ImportRow, accepted []*model.Subscription) (*model.Subscription, error) {
+	if row.Err != nil {
+		return nil, row.Err
+	}
+	newSub, err := ss.validateNewSub(ctx, row.Sub)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range accepted {
+		if other != nil && utils.SubsOverlap(newSub, other) {
+			return nil, fmt.Errorf("Failed to create subscription: %w within the batch", repository.ErrSubExists)
+		}
+	}
+	return newSub, nil
+}
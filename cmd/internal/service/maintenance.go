@@ -0,0 +1,291 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrBackupInvalid - error for a backup line that is not a table row
+var ErrBackupInvalid = errors.New("invalid backup line")
+
+// ErrDatabaseNotEmpty - error for restoring a backup over existing data without replace
+var ErrDatabaseNotEmpty = errors.New("database is not empty")
+
+// importBatchSize - rows of a table inserted at once on import
+const importBatchSize = 500
+
+// MaintenanceService provides methods of maintenance commands: fake data, rebuild of derived data, backups and integrity checks.
+// They work on the whole dataset and bypass validation, events and webhooks of SubscriptionService,
+// except for relinking of subscriptions to providers, which emits subscription.updated as ProviderService does.
+type MaintenanceService struct {
+	Repo      repository.MaintenanceRepo
+	Providers repository.ProviderRepo
+	Spend     repository.SpendRepo
+	Events    repository.EventRepo
+	Webhooks  repository.WebhookRepo
+}
+
+func CreateMaintenanceService(db *gorm.DB) *MaintenanceService {
+	return &MaintenanceService{
+		Repo:      *repository.CreateMaintenanceRepo(db),
+		Providers: *repository.CreateProviderRepo(db),
+		Spend:     *repository.CreateSpendRepo(db),
+		Events:    *repository.CreateEventRepo(db),
+		Webhooks:  *repository.CreateWebhookRepo(db),
+	}
+}
+
+// runInTx - executes fn within a single DB-transaction with service bound to it
+func (ms *MaintenanceService) runInTx(ctx context.Context, fn func(txService *MaintenanceService) error) error {
+	return ms.Repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&MaintenanceService{
+			Repo:      ms.Repo.WithTx(tx),
+			Providers: ms.Providers.WithTx(tx),
+			Spend:     ms.Spend.WithTx(tx),
+			Events:    ms.Events.WithTx(tx),
+			Webhooks:  ms.Webhooks.WithTx(tx),
+		})
+	})
+}
+
+// Seed - inserts fake subscriptions generated by utils.GenerateSubscriptions, adds them to monthly_spend and links them to the providers catalog;
+// returns number of inserted subscriptions
+func (ms *MaintenanceService) Seed(ctx context.Context, opts model.SeedOptions) (int, error) {
+	subs := utils.GenerateSubscriptions(opts)
+	err := ms.runInTx(ctx, func(txService *MaintenanceService) error {
+		if err := txService.Repo.InsertSubscriptions(ctx, subs); err != nil {
+			return err
+		}
+		// линковка ниже переносит суммы в агрегате на провайдеров каталога, поэтому подписки добавляются в него до неё
+		if err := applySpend(ctx, txService.Spend, nil, subs); err != nil {
+			return err
+		}
+		_, err := txService.linkAll(ctx)
+		return err
+	})
+	if err != nil {
+		log.Printf("[%v] DB problem while Seed attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return 0, err
+	}
+	return len(subs), nil
+}
+
+// Recompute - rebuilds data derived from other tables: links subscriptions to providers of the catalog by name and aliases,
+// brings their service names to canonical ones and fills monthly_spend from scratch
+func (ms *MaintenanceService) Recompute(ctx context.Context) (*model.RecomputeReport, error) {
+	report := &model.RecomputeReport{}
+	err := ms.runInTx(ctx, func(txService *MaintenanceService) error {
+		var err error
+		if report.LinkedSubscriptions, err = txService.linkAll(ctx); err != nil {
+			return err
+		}
+		if report.SpendRows, err = rebuildSpend(ctx, txService.Spend, time.Now()); err != nil {
+			return err
+		}
+		state, err := txService.Spend.GetState(ctx)
+		if err != nil {
+			return err
+		}
+		report.SpendCoveredUntil = state.CoveredUntil
+		return nil
+	})
+	if err != nil {
+		log.Printf("[%v] DB problem while Recompute attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return nil, err
+	}
+	return report, nil
+}
+
+// linkAll - links subscriptions to every provider of the catalog emitting subscription.updated for them, returns number of changed subscriptions
+func (ms *MaintenanceService) linkAll(ctx context.Context) (int64, error) {
+	providers, err := ms.Providers.GetAllProviders(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var linked int64
+	for _, provider := range providers {
+		changed, _, err := linkSubscriptions(ctx, ms.Providers, linkRepos{Spend: ms.Spend, Events: ms.Events, Webhooks: ms.Webhooks}, provider)
+		if err != nil {
+			return linked, err
+		}
+		linked += int64(len(changed))
+	}
+	return linked, nil
+}
+
+// Export - writes rows of all backed up tables to w as NDJSON lines of model.BackupRecord, referenced tables first.
+// Rows are read in one transaction, so the backup is consistent
+func (ms *MaintenanceService) Export(ctx context.Context, w io.Writer) ([]model.BackupTableStat, error) {
+	tables, err := ms.Repo.BackupTables()
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]model.BackupTableStat, len(tables))
+	encoder := json.NewEncoder(w)
+	err = ms.runInTx(ctx, func(txService *MaintenanceService) error {
+		for i, table := range tables {
+			stats[i].Table = table
+			err := txService.Repo.ExportTable(ctx, table, func(row map[string]json.RawMessage) error {
+				stats[i].Rows++
+				return encoder.Encode(model.BackupRecord{Table: table, Row: row})
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[%v] Problem while Export attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Import - restores backup written by Export in a single transaction, keeping primary keys, and rebuilds monthly_spend from restored rows.
+// Fails with ErrDatabaseNotEmpty if backed up tables have rows, unless replace is set: then their rows are deleted first
+func (ms *MaintenanceService) Import(ctx context.Context, r io.Reader, replace bool) ([]model.BackupTableStat, error) {
+	tables, err := ms.Repo.BackupTables()
+	if err != nil {
+		return nil, err
+	}
+	var stats []model.BackupTableStat
+	err = ms.runInTx(ctx, func(txService *MaintenanceService) error {
+		if replace {
+			if err := txService.Repo.ClearTables(ctx); err != nil {
+				return err
+			}
+		} else {
+			for _, table := range tables {
+				count, err := txService.Repo.CountRows(ctx, table)
+				if err != nil {
+					return err
+				}
+				if count > 0 {
+					return fmt.Errorf("%w: table %s has %d rows", ErrDatabaseNotEmpty, table, count)
+				}
+			}
+		}
+
+		// строки одной таблицы идут подряд, их вставляют пачками
+		var batch []map[string]json.RawMessage
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			err := txService.Repo.InsertRows(ctx, stats[len(stats)-1].Table, batch)
+			batch = batch[:0]
+			return err
+		}
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			var record model.BackupRecord
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.Table == "" || record.Row == nil {
+				return fmt.Errorf("line %d: %w", line, ErrBackupInvalid)
+			}
+			if len(stats) == 0 || stats[len(stats)-1].Table != record.Table {
+				if err := flush(); err != nil {
+					return err
+				}
+				stats = append(stats, model.BackupTableStat{Table: record.Table})
+			}
+			stats[len(stats)-1].Rows++
+			batch = append(batch, record.Row)
+			if len(batch) == importBatchSize {
+				if err := flush(); err != nil {
+					return fmt.Errorf("line %d: %w", line, err)
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		if err := txService.Repo.ResetSequences(ctx); err != nil {
+			return err
+		}
+		_, err := rebuildSpend(ctx, txService.Spend, time.Now())
+		return err
+	})
+	if err != nil {
+		log.Printf("[%v] Problem while Import attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Check - finds subscriptions breaking rules that are validated on write: overlapping periods of the same service, end or trial before start
+// and references to missing subscriptions or providers. Such rows get into DB by direct SQL or imported backups.
+// Rows of monthly_spend differing from the report computed on the fly are reported too
+func (ms *MaintenanceService) Check(ctx context.Context) ([]model.IntegrityIssue, error) {
+	issues := []model.IntegrityIssue{}
+	pairs, err := ms.Repo.GetOverlappingPairs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, pair := range pairs {
+		other := pair[1]
+		issues = append(issues, model.IntegrityIssue{
+			Kind:     model.IssueOverlap,
+			SID:      pair[0],
+			OtherSID: &other,
+			Detail:   fmt.Sprintf("overlaps subscription %d of the same user and service", other),
+		})
+	}
+
+	checks := []struct {
+		kind   string
+		find   func(context.Context) ([]*model.Subscription, error)
+		detail func(*model.Subscription) string
+	}{
+		{model.IssueEndBeforeStart, ms.Repo.GetEndBeforeStart, func(sub *model.Subscription) string {
+			return fmt.Sprintf("end %s is before start %s", monthOf(sub.End), monthOf(&sub.Start))
+		}},
+		{model.IssueTrialBeforeStart, ms.Repo.GetTrialBeforeStart, func(sub *model.Subscription) string {
+			return fmt.Sprintf("trial until %s is before start %s", monthOf(sub.TrialUntil), monthOf(&sub.Start))
+		}},
+		{model.IssueMissingPrevious, ms.Repo.GetMissingPrevious, func(sub *model.Subscription) string {
+			return fmt.Sprintf("previous subscription %d does not exist", *sub.PreviousSID)
+		}},
+		{model.IssueMissingProvider, ms.Repo.GetMissingProvider, func(sub *model.Subscription) string {
+			return fmt.Sprintf("provider %d does not exist", *sub.ProviderID)
+		}},
+	}
+	for _, check := range checks {
+		subs, err := check.find(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, sub := range subs {
+			issues = append(issues, model.IntegrityIssue{Kind: check.kind, SID: *sub.SID, Detail: check.detail(sub)})
+		}
+	}
+
+	spendIssues, err := checkSpend(ctx, ms.Spend)
+	if err != nil {
+		return nil, err
+	}
+	return append(issues, spendIssues...), nil
+}
+
+// monthOf - month of subscription date in "01-2006" format
+func monthOf(date *time.Time) string {
+	return date.Format("01-2006")
+}
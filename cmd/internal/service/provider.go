@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/utils"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProviderService provides methods to business logics of providers catalog and further repo(bd-requeste) calls.
+type ProviderService struct {
+	Repo       repository.ProviderRepo
+	Categories repository.CategoryRepo
+	Spend      repository.SpendRepo
+	Events     repository.EventRepo
+	Webhooks   repository.WebhookRepo
+	Cache      *ReportCache // optional cache of reports to invalidate when subscriptions are relinked
+}
+
+func CreateProviderService(db *gorm.DB) *ProviderService {
+	return &ProviderService{
+		Repo:       *repository.CreateProviderRepo(db),
+		Categories: *repository.CreateCategoryRepo(db),
+		Spend:      *repository.CreateSpendRepo(db),
+		Events:     *repository.CreateEventRepo(db),
+		Webhooks:   *repository.CreateWebhookRepo(db),
+	}
+}
+
+// runInTx - executes fn within a single DB-transaction with repos bound to it
+func (ps *ProviderService) runInTx(ctx context.Context, fn func(txRepo repository.ProviderRepo, txCategories repository.CategoryRepo) error) error {
+	return ps.Repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(ps.Repo.WithTx(tx), ps.Categories.WithTx(tx))
+	})
+}
+
+// linkRepos - repositories bound to transaction, written to when subscriptions are relinked
+func (ps *ProviderService) linkRepos(tx *gorm.DB) linkRepos {
+	return linkRepos{Spend: ps.Spend.WithTx(tx), Events: ps.Events.WithTx(tx), Webhooks: ps.Webhooks.WithTx(tx)}
+}
+
+// CreateProvider - validates input data, checks that name and aliases are not taken, creates provider and links existing subscriptions with matching service names to it
+func (ps *ProviderService) CreateProvider(ctx context.Context, rawProvider *model.RawProvider) error {
+	provider := utils.ConvertRawProviderToNormal(rawProvider)
+	if provider.NormalizedName == "" {
+		return fmt.Errorf("Warning on provider creation: %w", repository.ErrEmptySomeFields)
+	}
+	provider.ID = 0
+	provider.Aliases = withoutKey(provider.Aliases, provider.NormalizedName)
+
+	var categoryPath string
+	var removed, added []*model.Subscription
+	err := ps.runInTx(ctx, func(txRepo repository.ProviderRepo, txCategories repository.CategoryRepo) error {
+		var err error
+		if provider.CategoryID, categoryPath, err = resolveProviderCategory(ctx, txCategories, rawProvider); err != nil {
+			return err
+		}
+		if err := checkKeysFree(ctx, txRepo, provider); err != nil {
+			return err
+		}
+		if err := txRepo.CreateProvider(ctx, provider); err != nil {
+			return err
+		}
+		removed, added, err = linkSubscriptions(ctx, txRepo, ps.linkRepos(txRepo.DB), provider)
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrProviderExists) && !errors.Is(err, repository.ErrCategoryNotFound) && !errors.Is(err, utils.ErrConvertToNorm) {
+			log.Printf("[%v] DB problem while CreateProvider attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, rawProvider)
+		}
+		return fmt.Errorf("Failed to create provider: %w", err)
+	}
+	ps.Cache.Invalidate(ctx, append(removed, added...)...)
+	*rawProvider = *utils.ConvertNormalProviderToRaw(provider)
+	rawProvider.Category = categoryPath
+	return nil
+}
+
+// GetProviderByID - returns provider with aliases if there is a record under provided ID in DB
+func (ps *ProviderService) GetProviderByID(ctx context.Context, id uint64) (*model.RawProvider, error) {
+	provider, err := ps.Repo.GetProviderByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("Failed to get provider info: %w", repository.ErrProviderNotFound)
+		}
+		//проблема с подключением к базе
+		log.Printf("[%v] DB problem while GetProviderByID attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, id)
+		return nil, err
+	}
+	paths, err := ps.categoryPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rawProvider := utils.ConvertNormalProviderToRaw(provider)
+	rawProvider.Category = paths[derefID(provider.CategoryID)]
+	return rawProvider, nil
+}
+
+// GetProviderList - provides array of all catalogued providers
+func (ps *ProviderService) GetProviderList(ctx context.Context) ([]*model.RawProvider, error) {
+	providers, err := ps.Repo.GetAllProviders(ctx)
+	if err != nil {
+		//проблема с подключением к базе
+		log.Printf("[%v] DB problem while GetAllProviders attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return nil, err
+	}
+	paths, err := ps.categoryPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rawProviders := make([]*model.RawProvider, len(providers))
+	for i, v := range providers {
+		rawProviders[i] = utils.ConvertNormalProviderToRaw(v)
+		rawProviders[i].Category = paths[derefID(v.CategoryID)]
+	}
+	return rawProviders, nil
+}
+
+// GetProvidersByIDs - provides providers with provided IDs loaded in one query; missing IDs are absent in result
+func (ps *ProviderService) GetProvidersByIDs(ctx context.Context, ids []uint64) (map[uint64]*model.RawProvider, error) {
+	providers, err := ps.Repo.GetProvidersByIDs(ctx, ids)
+	if err != nil {
+		//проблема с подключением к базе
+		log.Printf("[%v] DB problem while GetProvidersByIDs attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, ids)
+		return nil, err
+	}
+	paths, err := ps.categoryPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[uint64]*model.RawProvider, len(providers))
+	for _, v := range providers {
+		res[v.ID] = utils.ConvertNormalProviderToRaw(v)
+		res[v.ID].Category = paths[derefID(v.CategoryID)]
+	}
+	return res, nil
+}
+
+// UpdateProviderByID - updates non-empty fields of provider; aliases are replaced if provided. Renaming updates service names of linked subscriptions.
+func (ps *ProviderService) UpdateProviderByID(ctx context.Context, rawProvider *model.RawProvider, id uint64) error {
+	if rawProvider.Name == "" && rawProvider.Aliases == nil && rawProvider.Category == "" && rawProvider.CategoryID == nil && rawProvider.DefaultPrice == nil {
+		return fmt.Errorf("Failed to update provider %v: %w", id, repository.ErrEmptyAllFields)
+	}
+	update := utils.ConvertRawProviderToNormal(rawProvider)
+
+	var provider *model.Provider
+	var categoryPath string
+	var categoryMoved bool
+	var removed, added []*model.Subscription
+	err := ps.runInTx(ctx, func(txRepo repository.ProviderRepo, txCategories repository.CategoryRepo) error {
+		var err error
+		provider, err = txRepo.GetProviderByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return repository.ErrProviderNotFound
+			}
+			return err
+		}
+		oldCategoryID := derefID(provider.CategoryID)
+
+		if update.NormalizedName != "" {
+			provider.Name = update.Name
+			provider.NormalizedName = update.NormalizedName
+		}
+		if rawProvider.Category != "" || rawProvider.CategoryID != nil {
+			if provider.CategoryID, _, err = resolveProviderCategory(ctx, txCategories, rawProvider); err != nil {
+				return err
+			}
+		}
+		if categoryPath, err = categoryPathOf(ctx, txCategories, provider.CategoryID); err != nil {
+			return err
+		}
+		if rawProvider.DefaultPrice != nil {
+			provider.DefaultPrice = update.DefaultPrice
+		}
+		var aliases []model.ProviderAlias
+		if rawProvider.Aliases != nil {
+			aliases = withoutKey(update.Aliases, provider.NormalizedName)
+			provider.Aliases = aliases
+		}
+
+		if err := checkKeysFree(ctx, txRepo, provider); err != nil {
+			return err
+		}
+		if err := txRepo.UpdateProvider(ctx, provider, aliases); err != nil {
+			return err
+		}
+		categoryMoved = derefID(provider.CategoryID) != oldCategoryID
+		removed, added, err = linkSubscriptions(ctx, txRepo, ps.linkRepos(txRepo.DB), provider)
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrProviderExists) && !errors.Is(err, repository.ErrProviderNotFound) &&
+			!errors.Is(err, repository.ErrCategoryNotFound) && !errors.Is(err, utils.ErrConvertToNorm) {
+			log.Printf("[%v] DB problem while UpdateProvider attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, rawProvider)
+		}
+		return fmt.Errorf("Failed to update provider: %w", err)
+	}
+	ps.Cache.Invalidate(ctx, append(removed, added...)...)
+	if categoryMoved {
+		ps.Cache.InvalidateCategories(ctx)
+	}
+	*rawProvider = *utils.ConvertNormalProviderToRaw(provider)
+	rawProvider.Category = categoryPath
+	return nil
+}
+
+// DeleteProvider - removes provider by ID unless it is referenced by subscriptions
+func (ps *ProviderService) DeleteProvider(ctx context.Context, id uint64) error {
+	err := ps.runInTx(ctx, func(txRepo repository.ProviderRepo, _ repository.CategoryRepo) error {
+		count, err := txRepo.CountProviderSubscriptions(ctx, id)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return repository.ErrProviderInUse
+		}
+		deleted, err := txRepo.DeleteProvider(ctx, id)
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return repository.ErrProviderNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrProviderInUse) && !errors.Is(err, repository.ErrProviderNotFound) {
+			//проблема с подключением к базе
+			log.Printf("[%v] DB problem while DeleteProvider attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, id)
+		}
+		return fmt.Errorf("Failed to remove provider: %w", err)
+	}
+	return nil
+}
+
+// resolveProviderCategory - resolves category of provider given by ID or path; category_id equal to 0 detaches provider from category
+func resolveProviderCategory(ctx context.Context, repo repository.CategoryRepo, rawProvider *model.RawProvider) (*uint64, string, error) {
+	ref := rawProvider.Category
+	if rawProvider.CategoryID != nil {
+		if *rawProvider.CategoryID == 0 {
+			return nil, "", nil
+		}
+		ref = strconv.FormatUint(*rawProvider.CategoryID, 10)
+	}
+	if ref == "" {
+		return nil, "", nil
+	}
+	category, path, err := findCategory(ctx, repo, ref)
+	if err != nil {
+		return nil, "", err
+	}
+	return &category.ID, path, nil
+}
+
+// categoryPathOf - returns full path of category, empty for nil ID
+func categoryPathOf(ctx context.Context, repo repository.CategoryRepo, id *uint64) (string, error) {
+	if id == nil {
+		return "", nil
+	}
+	return categoryPath(ctx, repo, *id)
+}
+
+// categoryPaths - returns full paths of all categories by their IDs
+func (ps *ProviderService) categoryPaths(ctx context.Context) (map[uint64]string, error) {
+	categories, err := ps.Categories.GetAllCategories(ctx)
+	if err != nil {
+		log.Printf("[%v] DB problem while GetAllCategories attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return nil, err
+	}
+	return utils.BuildCategoryPaths(categories), nil
+}
+
+func derefID(id *uint64) uint64 {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
+
+// checkKeysFree - makes sure that name and aliases of provider are not used by other providers
+func checkKeysFree(ctx context.Context, repo repository.ProviderRepo, provider *model.Provider) error {
+	keys := []string{provider.NormalizedName}
+	for _, alias := range provider.Aliases {
+		keys = append(keys, alias.NormalizedAlias)
+	}
+	taken, err := repo.IsKeyTaken(ctx, keys, provider.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return repository.ErrProviderExists
+	}
+	return nil
+}
+
+// linkRepos - repositories written to along with subscriptions relinked to provider
+type linkRepos struct {
+	Spend    repository.SpendRepo
+	Events   repository.EventRepo
+	Webhooks repository.WebhookRepo
+}
+
+// linkSubscriptions - links subscriptions with free-text service names matching provider name or aliases to provider, moves their amounts in
+// monthly_spend to it and emits subscription.updated for each of them; returns changed subscriptions before and after the change
+func linkSubscriptions(ctx context.Context, repo repository.ProviderRepo, repos linkRepos, provider *model.Provider) (removed, added []*model.Subscription, err error) {
+	keys := map[string]bool{provider.NormalizedName: true}
+	for _, alias := range provider.Aliases {
+		keys[alias.NormalizedAlias] = true
+	}
+	names, err := repo.GetUnlinkedServiceNames(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var matching []string
+	for _, name := range names {
+		if keys[utils.NormalizeProviderName(name)] {
+			matching = append(matching, name)
+		}
+	}
+	removed, err = repo.LinkSubscriptions(ctx, provider, matching)
+	if err != nil {
+		return nil, nil, err
+	}
+	added = make([]*model.Subscription, len(removed))
+	for i, dbSub := range removed {
+		linked := *dbSub
+		linked.ProviderID = &provider.ID
+		linked.Provider = provider.Name
+		linked.Version++
+		added[i] = &linked
+	}
+	if err := applySpend(ctx, repos.Spend, removed, added); err != nil {
+		return nil, nil, err
+	}
+	return removed, added, emitAll(ctx, repos.Events, repos.Webhooks, model.EventSubscriptionUpdated, added)
+}
+
+// withoutKey - drops aliases equal to provider name
+func withoutKey(aliases []model.ProviderAlias, key string) []model.ProviderAlias {
+	res := aliases[:0]
+	for _, alias := range aliases {
+		if alias.NormalizedAlias != key {
+			res = append(res, alias)
+		}
+	}
+	return res
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/utils"
+	"log"
+	"sync"
+	"time"
+)
+
+// ReportCacheBackend - storage of report totals cached by ReportCache. LRUReportBackend keeps them in process memory;
+// a shared store lets replicas see invalidations made by each other
+type ReportCacheBackend interface {
+	Get(ctx context.Context, key model.ReportCacheKey) (uint, bool, error)
+	Set(ctx context.Context, key model.ReportCacheKey, total uint) error
+	// DeleteMatching - removes entries whose keys match, returns number of removed entries
+	DeleteMatching(ctx context.Context, match func(model.ReportCacheKey) bool) (int, error)
+	// Stats - fills Entries, Capacity and Evictions of stats
+	Stats(ctx context.Context) (model.ReportCacheStats, error)
+}
+
+// ReportCache caches totals of SubscriptionService.Report keyed by normalized filter. Writes made through services sharing the cache
+// invalidate entries of touched users, services and months after commit; a nil cache caches nothing.
+// Writes bypassing services (maintenance commands, other replicas with in-process backend) become visible after entry expiry
+type ReportCache struct {
+	Backend ReportCacheBackend
+
+	mu            sync.Mutex
+	generation    uint64 // incremented by every invalidation, totals computed before it are not stored
+	hits          uint64
+	misses        uint64
+	invalidations uint64
+}
+
+func CreateReportCache(backend ReportCacheBackend) *ReportCache {
+	return &ReportCache{Backend: backend}
+}
+
+// Get - cached total for key; generation must be passed to Set of the total computed on miss
+func (rc *ReportCache) Get(ctx context.Context, key model.ReportCacheKey) (total uint, generation uint64, ok bool) {
+	if rc == nil {
+		return 0, 0, false
+	}
+	rc.mu.Lock()
+	generation = rc.generation
+	rc.mu.Unlock()
+
+	total, ok, err := rc.Backend.Get(ctx, key)
+	if err != nil {
+		log.Printf("[%v] Problem while report cache lookup: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+	}
+	rc.mu.Lock()
+	if ok {
+		rc.hits++
+	} else {
+		rc.misses++
+	}
+	rc.mu.Unlock()
+	return total, generation, ok
+}
+
+// Set - stores total computed on miss, unless an invalidation happened since Get returned generation: the total may miss that write
+func (rc *ReportCache) Set(ctx context.Context, key model.ReportCacheKey, total uint, generation uint64) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if generation != rc.generation {
+		return
+	}
+	if err := rc.Backend.Set(ctx, key, total); err != nil {
+		log.Printf("[%v] Problem while report cache store: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+	}
+}
+
+// Invalidate - drops totals of reports counting any of subscriptions; pass states of changed subscriptions both before and after the write
+func (rc *ReportCache) Invalidate(ctx context.Context, subs ...*model.Subscription) {
+	if rc == nil || len(subs) == 0 {
+		return
+	}
+	rc.invalidate(ctx, func(key model.ReportCacheKey) bool {
+		for _, sub := range subs {
+			if utils.ReportCacheKeyTouched(key, sub) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// InvalidateCategories - drops totals of reports filtered by category, after providers or categories are moved in the tree
+func (rc *ReportCache) InvalidateCategories(ctx context.Context) {
+	if rc == nil {
+		return
+	}
+	rc.invalidate(ctx, func(key model.ReportCacheKey) bool {
+		return key.CategoryID != 0
+	})
+}
+
+func (rc *ReportCache) invalidate(ctx context.Context, match func(model.ReportCacheKey) bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.generation++
+	count, err := rc.Backend.DeleteMatching(ctx, match)
+	if err != nil {
+		log.Printf("[%v] Problem while report cache invalidation: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+	}
+	rc.invalidations += uint64(count)
+}
+
+// Stats - counters of cache; Enabled is false for a nil cache
+func (rc *ReportCache) Stats(ctx context.Context) (*model.ReportCacheStats, error) {
+	if rc == nil {
+		return &model.ReportCacheStats{}, nil
+	}
+	stats, err := rc.Backend.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	stats.Enabled = true
+	stats.Hits, stats.Misses, stats.Invalidations = rc.hits, rc.misses, rc.invalidations
+	if lookups := rc.hits + rc.misses; lookups > 0 {
+		stats.HitRatio = float64(rc.hits) / float64(lookups)
+	}
+	return &stats, nil
+}
+
+// LRUReportBackend - in-process ReportCacheBackend holding up to Capacity entries for TTL, least recently used entries are evicted first
+type LRUReportBackend struct {
+	Capacity int
+	TTL      time.Duration // 0 keeps entries until they are evicted or invalidated
+
+	mu        sync.Mutex
+	order     *list.List // front is the most recently used
+	entries   map[model.ReportCacheKey]*list.Element
+	evictions uint64
+}
+
+type lruReportEntry struct {
+	key       model.ReportCacheKey
+	total     uint
+	expiresAt time.Time
+}
+
+func CreateLRUReportBackend(capacity int, ttl time.Duration) *LRUReportBackend {
+	return &LRUReportBackend{
+		Capacity: capacity,
+		TTL:      ttl,
+		order:    list.New(),
+		entries:  make(map[model.ReportCacheKey]*list.Element),
+	}
+}
+
+func (lb *LRUReportBackend) Get(_ context.Context, key model.ReportCacheKey) (uint, bool, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	element, ok := lb.entries[key]
+	if !ok {
+		return 0, false, nil
+	}
+	entry := element.Value.(*lruReportEntry)
+	if lb.TTL > 0 && time.Now().After(entry.expiresAt) {
+		lb.remove(element)
+		lb.evictions++
+		return 0, false, nil
+	}
+	lb.order.MoveToFront(element)
+	return entry.total, true, nil
+}
+
+func (lb *LRUReportBackend) Set(_ context.Context, key model.ReportCacheKey, total uint) error {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	entry := &lruReportEntry{key: key, total: total, expiresAt: time.Now().Add(lb.TTL)}
+	if element, ok := lb.entries[key]; ok {
+		element.Value = entry
+		lb.order.MoveToFront(element)
+		return nil
+	}
+	lb.entries[key] = lb.order.PushFront(entry)
+	for lb.order.Len() > lb.Capacity {
+		lb.remove(lb.order.Back())
+		lb.evictions++
+	}
+	return nil
+}
+
+func (lb *LRUReportBackend) DeleteMatching(_ context.Context, match func(model.ReportCacheKey) bool) (int, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	count := 0
+	for key, element := range lb.entries {
+		if match(key) {
+			lb.remove(element)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (lb *LRUReportBackend) Stats(_ context.Context) (model.ReportCacheStats, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return model.ReportCacheStats{Entries: lb.order.Len(), Capacity: lb.Capacity, Evictions: lb.evictions}, nil
+}
+
+func (lb *LRUReportBackend) remove(element *list.Element) {
+	lb.order.Remove(element)
+	delete(lb.entries, element.Value.(*lruReportEntry).key)
+}
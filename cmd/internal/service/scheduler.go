@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/utils"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// schedulerLockKey - key of postgres advisory lock held by replica running scheduled jobs
+const schedulerLockKey = 7340002
+
+// expireBatchSize - number of subscriptions marked as expired in one scheduler run
+const expireBatchSize = 500
+
+// CancelSubscription - cancels subscription: it stays paid through the current month (or its start month if it hasn't started yet)
+// and ends there unless it ends earlier; emits subscription.updated
+func (ss *SubscriptionService) CancelSubscription(ctx context.Context, sid uint64) (*model.RawSubscription, error) {
+	var dbSub *model.Subscription
+	err := ss.RunInTx(ctx, func(txService *SubscriptionService) error {
+		var err error
+		dbSub, err = txService.Repo.GetSubscriptionBySID(ctx, sid)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("Failed to cancel subscription: %w", repository.ErrSubNotFound)
+			}
+			return err
+		}
+		if dbSub.CancelledAt != nil {
+			return fmt.Errorf("Failed to cancel subscription %d: %w", sid, repository.ErrSubCancelled)
+		}
+
+		before := *dbSub
+		now := time.Now().UTC()
+		lastMonth := utils.StartOfMonth(now).AddDate(0, 0, 14)
+		if dbSub.Start.After(lastMonth) {
+			lastMonth = dbSub.Start
+		}
+		if dbSub.End == nil || dbSub.End.After(lastMonth) {
+			dbSub.End = &lastMonth
+		}
+		dbSub.CancelledAt = &now
+		if err := txService.Repo.UpdateSubscriptionInfo(ctx, dbSub); err != nil {
+			return err
+		}
+		if err := txService.recordWrite(ctx, []*model.Subscription{&before}, []*model.Subscription{dbSub}); err != nil {
+			return err
+		}
+		return txService.emit(ctx, model.EventSubscriptionUpdated, dbSub)
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrSubNotFound) && !errors.Is(err, repository.ErrSubCancelled) {
+			//проблема с подключением к базе
+			log.Printf("[%v] DB problem while CancelSubscription attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, sid)
+		}
+		return nil, err
+	}
+	return utils.ConvertNormalSubToRaw(dbSub), nil
+}
+
+// ExpireSubscriptions - marks subscriptions whose end month has passed by now as expired and emits subscription.expired for each; returns number of marked ones
+func (ss *SubscriptionService) ExpireSubscriptions(ctx context.Context, now time.Time) (int, error) {
+	dbSubs, err := ss.Repo.GetExpiredUnmarked(ctx, utils.StartOfMonth(now.UTC()), expireBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to find expired subscriptions: %w", err)
+	}
+	count := 0
+	for _, dbSub := range dbSubs {
+		err := ss.RunInTx(ctx, func(txService *SubscriptionService) error {
+			marked, err := txService.Repo.MarkExpired(ctx, *dbSub.SID, now.UTC())
+			if err != nil || marked == 0 {
+				return err
+			}
+			expiredAt := now.UTC()
+			dbSub.ExpiredAt = &expiredAt
+			dbSub.Version++
+			count++
+			return txService.emit(ctx, model.EventSubscriptionExpired, dbSub)
+		})
+		if err != nil {
+			return count, fmt.Errorf("Failed to expire subscription %d: %w", *dbSub.SID, err)
+		}
+	}
+	return count, nil
+}
+
+// Scheduler runs periodic subscription jobs: marking expired subscriptions, notifying about upcoming expiries, extending monthly_spend
+// and removing expired idempotency keys.
+// Every run takes a postgres advisory lock, so with several replicas only one of them does the work at a time.
+type Scheduler struct {
+	Service        *SubscriptionService
+	Idempotency    *IdempotencyService
+	Interval       time.Duration
+	ExpiringWindow time.Duration // how long before the end subscription.expiring is emitted
+	IdempotencyTTL time.Duration // how long idempotency keys are kept
+}
+
+func CreateScheduler(db *gorm.DB, interval, expiringWindow time.Duration) *Scheduler {
+	return &Scheduler{
+		Service:        CreateService(db),
+		Idempotency:    CreateIdempotencyService(db),
+		Interval:       interval,
+		ExpiringWindow: expiringWindow,
+		IdempotencyTTL: DefaultIdempotencyTTL,
+	}
+}
+
+// Run - runs jobs every Interval until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	RunEvery(ctx, s.Interval, "subscription scheduler", func(ctx context.Context) error {
+		_, err := s.RunOnce(ctx, time.Now())
+		return err
+	})
+}
+
+// RunOnce - runs all jobs in one transaction under advisory lock; returns false if the lock is held by another replica
+func (s *Scheduler) RunOnce(ctx context.Context, now time.Time) (bool, error) {
+	locked := false
+	err := s.Service.RunInTx(ctx, func(txService *SubscriptionService) error {
+		var err error
+		if locked, err = txService.Repo.TryJobLock(ctx, schedulerLockKey); err != nil || !locked {
+			return err
+		}
+		if _, err := txService.ExpireSubscriptions(ctx, now); err != nil {
+			return err
+		}
+		if err := extendSpend(ctx, txService.Spend, now); err != nil {
+			return err
+		}
+		_, err = txService.NotifyExpiring(ctx, s.ExpiringWindow)
+		return err
+	})
+	if err == nil && locked {
+		_, err = s.Idempotency.DeleteExpired(ctx, now, s.IdempotencyTTL)
+	}
+	if err != nil {
+		log.Printf("[%v] Problem while scheduler run: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+	}
+	return locked, err
+}
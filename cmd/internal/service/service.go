@@ -16,42 +16,150 @@ import (
 
 // SubscriptionService provides methods to business logics and further repo(bd-requeste) calls.
 type SubscriptionService struct {
-	Repo repository.SubscriptionRepo
+	Repo       repository.SubscriptionRepo
+	Providers  repository.ProviderRepo
+	Categories repository.CategoryRepo
+	Webhooks   repository.WebhookRepo
+	Events     repository.EventRepo
+	Budgets    repository.BudgetRepo
+	Spend      repository.SpendRepo
+	Cache      *ReportCache // optional cache of Report, shared by services of the process
+
+	inTx    bool
+	written *[]*model.Subscription // states of subscriptions changed in the transaction, invalidated in Cache after commit
 }
 
 func CreateService(db *gorm.DB) *SubscriptionService {
-	return &SubscriptionService{Repo: *repository.CreateRepo(db)}
+	return &SubscriptionService{
+		Repo:       *repository.CreateRepo(db),
+		Providers:  *repository.CreateProviderRepo(db),
+		Categories: *repository.CreateCategoryRepo(db),
+		Webhooks:   *repository.CreateWebhookRepo(db),
+		Events:     *repository.CreateEventRepo(db),
+		Budgets:    *repository.CreateBudgetRepo(db),
+		Spend:      *repository.CreateSpendRepo(db),
+	}
+}
+
+// RunInTx - executes fn within a single DB-transaction; the service passed to fn is bound to that transaction.
+// Nested calls reuse the already opened transaction. Cached reports touched by the transaction are invalidated after commit
+func (ss *SubscriptionService) RunInTx(ctx context.Context, fn func(txService *SubscriptionService) error) error {
+	if ss.inTx {
+		return fn(ss)
+	}
+	var written []*model.Subscription
+	err := ss.Repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txService := ss.withDB(tx)
+		txService.written = &written
+		return fn(txService)
+	})
+	if err == nil {
+		ss.Cache.Invalidate(ctx, written...)
+	}
+	return err
+}
+
+// withDB - returns a copy of service with all repositories bound to the provided transaction
+func (ss *SubscriptionService) withDB(tx *gorm.DB) *SubscriptionService {
+	return &SubscriptionService{
+		Repo:       ss.Repo.WithTx(tx),
+		Providers:  ss.Providers.WithTx(tx),
+		Categories: ss.Categories.WithTx(tx),
+		Webhooks:   ss.Webhooks.WithTx(tx),
+		Events:     ss.Events.WithTx(tx),
+		Budgets:    ss.Budgets.WithTx(tx),
+		Spend:      ss.Spend.WithTx(tx),
+		Cache:      ss.Cache,
+		inTx:       true,
+	}
+}
+
+// recordWrite - applies a write to monthly_spend and remembers changed subscriptions for invalidation of cached reports;
+// removed are states of subscriptions before the write, added - after it
+func (ss *SubscriptionService) recordWrite(ctx context.Context, removed, added []*model.Subscription) error {
+	if err := applySpend(ctx, ss.Spend, removed, added); err != nil {
+		return err
+	}
+	if ss.written == nil {
+		ss.Cache.Invalidate(ctx, append(removed, added...)...)
+		return nil
+	}
+	*ss.written = append(append(*ss.written, removed...), added...)
+	return nil
 }
 
 // CreateSubscription - validates input data, checks if such subscription already exists, and if not - creates it in DB via Repository layer.
+// Webhook deliveries of subscription.created are written in the same transaction. Exceeded budgets of the user reject the creation or add warnings to rawSub.
 func (ss *SubscriptionService) CreateSubscription(ctx context.Context, rawSub *model.RawSubscription) error {
-	if rawSub.UID == "" || rawSub.Start == "" || rawSub.Provider == "" {
-		return fmt.Errorf("Warning on creation: %w", repository.ErrEmptySomeFields)
+	return ss.RunInTx(ctx, func(txService *SubscriptionService) error {
+		newSub, err := txService.validateNewSub(ctx, rawSub)
+		if err != nil {
+			return err
+		}
+		checks, err := txService.prepareBudgetChecks(ctx, newSub)
+		if err == nil {
+			err = txService.Repo.CreateSubscription(ctx, newSub)
+		}
+		if err == nil {
+			err = txService.recordWrite(ctx, nil, []*model.Subscription{newSub})
+		}
+		if err == nil {
+			err = txService.emit(ctx, model.EventSubscriptionCreated, newSub)
+		}
+		if err != nil { //проблема с подключением к базе
+			log.Printf("[%v] DB problem while CreateSubscription attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, rawSub)
+			return err
+		}
+		if rawSub.Warnings, err = txService.applyBudgetChecks(ctx, checks); err != nil {
+			return err
+		}
+		rawSub.SID = newSub.SID
+		rawSub.Status = utils.SubscriptionStatus(newSub, time.Now())
+		rawSub.Version = &newSub.Version
+		return nil
+	})
+}
+
+// validateNewSub - checks mandatory fields and period of a new subscription, converts it to normal and makes sure it doesn't overlap with existing ones in DB
+func (ss *SubscriptionService) validateNewSub(ctx context.Context, rawSub *model.RawSubscription) (*model.Subscription, error) {
+	if err := ss.resolveProvider(ctx, rawSub, true); err != nil {
+		return nil, err
+	}
+	if rawSub.UID == "" || rawSub.Start == "" || rawSub.Provider == "" || rawSub.Price == nil {
+		return nil, fmt.Errorf("Warning on creation: %w", repository.ErrEmptySomeFields)
 	}
 
 	newSub, err := utils.ConvertRawSubToNormal(rawSub)
 	if err != nil {
-		return fmt.Errorf("Convert failure: %w", err)
+		return nil, fmt.Errorf("Convert failure: %w", err)
+	}
+	if newSub.End != nil && newSub.End.Before(newSub.Start) {
+		return nil, fmt.Errorf("Warning on creation: %w", repository.ErrInvalidPeriod)
+	}
+	if newSub.TrialUntil != nil && newSub.TrialUntil.Before(newSub.Start) {
+		return nil, fmt.Errorf("Warning on creation: trial ends before start: %w", repository.ErrInvalidPeriod)
+	}
+
+	if newSub.PreviousSID != nil {
+		if _, err := ss.Repo.GetSubscriptionBySID(ctx, *newSub.PreviousSID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("Previous subscription %d: %w", *newSub.PreviousSID, repository.ErrSubNotFound)
+			}
+			return nil, fmt.Errorf("Creation failed: %w", err)
+		}
 	}
 
 	err = ss.Repo.CheckIfExists(ctx, newSub)
 	if err != nil {
 		if errors.Is(err, repository.ErrSubExists) {
-			return fmt.Errorf("Failed to create subscription: %w", err)
+			return nil, fmt.Errorf("Failed to create subscription: %w", err)
 		}
 		if !errors.Is(err, repository.ErrSubNotFound) {
 			log.Printf("DB problem while CheckIfExists attempt: %v", err)
-			return fmt.Errorf("Creation failed: %w", err)
+			return nil, fmt.Errorf("Creation failed: %w", err)
 		}
 	}
-
-	err = ss.Repo.CreateSubscription(ctx, newSub)
-	if err != nil { //проблема с подключением к базе
-		log.Printf("[%v] DB problem while CreateSubscription attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, rawSub)
-		return err
-	}
-	rawSub.SID = newSub.SID
-	return nil
+	return newSub, nil
 }
 
 // UpdateBySID - validates data, checks if such SID exists, and if so - updates record in DB via Repository layer.
@@ -59,7 +167,7 @@ func (ss *SubscriptionService) UpdateBySID(ctx context.Context, rawSub *model.Ra
 	if sidStr == "" {
 		return fmt.Errorf("Failed to update subscription: %w", repository.ErrEmptySomeFields)
 	}
-	if rawSub.UID == "" && rawSub.Provider == "" && rawSub.Price == nil && rawSub.Start == "" && rawSub.End == "" {
+	if rawSub.UID == "" && rawSub.Provider == "" && rawSub.ProviderID == nil && rawSub.Price == nil && rawSub.Start == "" && rawSub.End == "" && rawSub.TrialUntil == "" {
 		return fmt.Errorf("Failed to update subscription %v: %w", sidStr, repository.ErrEmptyAllFields)
 	}
 	sid, err := strconv.ParseUint(sidStr, 10, 64)
@@ -67,6 +175,19 @@ func (ss *SubscriptionService) UpdateBySID(ctx context.Context, rawSub *model.Ra
 		return fmt.Errorf("Convert failure: %w, %w", utils.ErrConvertToNorm, err)
 	}
 	rawSub.SID = &sid
+	return ss.RunInTx(ctx, func(txService *SubscriptionService) error {
+		return txService.updateBySID(ctx, rawSub)
+	})
+}
+
+// updateBySID - applies non-empty fields of rawSub to existing subscription, checks budgets of the owner and emits subscription.updated
+func (ss *SubscriptionService) updateBySID(ctx context.Context, rawSub *model.RawSubscription) error {
+	sidStr := strconv.FormatUint(*rawSub.SID, 10)
+	if rawSub.Provider != "" || rawSub.ProviderID != nil {
+		if err := ss.resolveProvider(ctx, rawSub, false); err != nil {
+			return err
+		}
+	}
 	newSub, err := utils.ConvertRawSubToNormal(rawSub)
 	if err != nil {
 		return fmt.Errorf("Convert failure: %w", err)
@@ -81,12 +202,17 @@ func (ss *SubscriptionService) UpdateBySID(ctx context.Context, rawSub *model.Ra
 		log.Printf("[%v] DB problem while GetSubscriptionBySID attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, sidStr)
 		return err
 	}
+	if rawSub.Version != nil && *rawSub.Version != dbSub.Version {
+		return fmt.Errorf("Failed to update subscription %s: version %d is not current: %w", sidStr, *rawSub.Version, repository.ErrVersionMismatch)
+	}
+	before := *dbSub
 
 	if rawSub.UID != "" {
 		dbSub.UID = newSub.UID
 	}
 	if rawSub.Provider != "" {
 		dbSub.Provider = newSub.Provider
+		dbSub.ProviderID = newSub.ProviderID
 	}
 	if rawSub.Price != nil {
 		dbSub.Price = newSub.Price
@@ -96,11 +222,35 @@ func (ss *SubscriptionService) UpdateBySID(ctx context.Context, rawSub *model.Ra
 	}
 	if rawSub.End != "" {
 		dbSub.End = newSub.End
+		dbSub.ExpiredAt = nil //планировщик снова отметит, если новый срок уже прошел
+	}
+	if rawSub.TrialUntil != "" {
+		dbSub.TrialUntil = newSub.TrialUntil
+	}
+	if dbSub.TrialUntil != nil && dbSub.TrialUntil.Before(dbSub.Start) {
+		return fmt.Errorf("Failed to update subscription: trial ends before start: %w", repository.ErrInvalidPeriod)
 	}
-	if err := ss.Repo.UpdateSubscriptionInfo(ctx, dbSub); err != nil { //проблема с подключением к базе
-		log.Printf("[%v] DB problem while UpdateSubscriptionInfo attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, rawSub)
+	checks, err := ss.prepareBudgetChecks(ctx, dbSub)
+	if err == nil {
+		err = ss.Repo.UpdateSubscriptionInfo(ctx, dbSub)
+	}
+	if err == nil {
+		err = ss.recordWrite(ctx, []*model.Subscription{&before}, []*model.Subscription{dbSub})
+	}
+	if err == nil {
+		err = ss.emit(ctx, model.EventSubscriptionUpdated, dbSub)
+	}
+	if err != nil {
+		if !errors.Is(err, repository.ErrVersionMismatch) { //проблема с подключением к базе
+			log.Printf("[%v] DB problem while UpdateSubscriptionInfo attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, rawSub)
+		}
 		return fmt.Errorf("Failed to update subscription info: %w", err)
 	}
+	rawSub.Status = utils.SubscriptionStatus(dbSub, time.Now())
+	rawSub.Version = &dbSub.Version
+	if rawSub.Warnings, err = ss.applyBudgetChecks(ctx, checks); err != nil {
+		return fmt.Errorf("Failed to update subscription: %w", err)
+	}
 	return nil
 }
 
@@ -119,9 +269,12 @@ func (ss *SubscriptionService) GetBySID(ctx context.Context, sid uint64) (*model
 	return rawSub, nil
 }
 
-// GetList - provides array of all subscription records existing in DB
-func (ss *SubscriptionService) GetList(ctx context.Context) ([]*model.RawSubscription, error) {
-	dbSubs, err := ss.Repo.GetAllSubscriptions(ctx)
+// GetList - provides array of all subscription records existing in DB, optionally only with provided status
+func (ss *SubscriptionService) GetList(ctx context.Context, status string) ([]*model.RawSubscription, error) {
+	if !utils.IsSubscriptionStatus(status) {
+		return nil, fmt.Errorf("%w: unknown status %q", utils.ErrConvertToNorm, status)
+	}
+	dbSubs, err := ss.Repo.GetAllSubscriptions(ctx, status)
 	if err != nil {
 		//проблема с подключением к базе
 		log.Printf("[%v]DB problem while GetAllSubscriptions attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
@@ -137,14 +290,122 @@ func (ss *SubscriptionService) GetList(ctx context.Context) ([]*model.RawSubscri
 	return rawSubs, nil
 }
 
-// DeleteSubscription - removes record by SID, returns error if no rows affected
-func (ss *SubscriptionService) DeleteSubscription(ctx context.Context, sid uint) error {
-	count, err := ss.Repo.DeleteSubcription(ctx, sid)
-	if count == 0 && err == nil {
-		return fmt.Errorf("Failed to remove susbcription: %w", repository.ErrSubNotFound)
+// GetPage - provides up to filter.Limit subscriptions following filter.AfterSID in SID order, optionally only matching status, user and provider.
+// Returned cursor is SID of the last subscription, or 0 if there are no more pages
+func (ss *SubscriptionService) GetPage(ctx context.Context, filter *model.SubscriptionPageFilter) ([]*model.RawSubscription, uint64, error) {
+	if !utils.IsSubscriptionStatus(filter.Status) {
+		return nil, 0, fmt.Errorf("%w: unknown status %q", utils.ErrConvertToNorm, filter.Status)
 	}
-	if count != 0 && err == nil {
-		return nil
+	if filter.Limit <= 0 {
+		return nil, 0, fmt.Errorf("%w: page size must be positive", utils.ErrConvertToNorm)
+	}
+	normFilter := *filter
+	if normFilter.Provider != "" {
+		name, err := ss.resolveProviderName(ctx, normFilter.Provider)
+		if err != nil {
+			log.Printf("[%v] DB problem while provider lookup: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, filter)
+			return nil, 0, fmt.Errorf("Provider lookup failed: %w", err)
+		}
+		normFilter.Provider = name
+	}
+	//запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница
+	normFilter.Limit++
+	dbSubs, err := ss.Repo.GetSubscriptionsPage(ctx, &normFilter)
+	if err != nil {
+		//проблема с подключением к базе
+		log.Printf("[%v]DB problem while GetSubscriptionsPage attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return nil, 0, err
+	}
+	var next uint64
+	if len(dbSubs) > filter.Limit {
+		dbSubs = dbSubs[:filter.Limit]
+		next = *dbSubs[filter.Limit-1].SID
+	}
+	rawSubs := make([]*model.RawSubscription, len(dbSubs))
+	for i, v := range dbSubs {
+		rawSubs[i] = utils.ConvertNormalSubToRaw(v)
+	}
+	return rawSubs, next, nil
+}
+
+// GetBySIDs - provides subscriptions with provided SIDs loaded in one query; missing SIDs are absent in result
+func (ss *SubscriptionService) GetBySIDs(ctx context.Context, sids []uint64) (map[uint64]*model.RawSubscription, error) {
+	dbSubs, err := ss.Repo.GetSubscriptionsBySIDs(ctx, sids)
+	if err != nil {
+		//проблема с подключением к базе
+		log.Printf("[%v]DB problem while GetSubscriptionsBySIDs attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, sids)
+		return nil, err
+	}
+	res := make(map[uint64]*model.RawSubscription, len(dbSubs))
+	for _, v := range dbSubs {
+		res[*v.SID] = utils.ConvertNormalSubToRaw(v)
+	}
+	return res, nil
+}
+
+// GetByUIDs - provides subscriptions of every provided user ordered by SID, loaded in one query
+func (ss *SubscriptionService) GetByUIDs(ctx context.Context, uids []string) (map[string][]*model.RawSubscription, error) {
+	dbSubs, err := ss.Repo.GetSubscriptionsByUIDs(ctx, uids)
+	if err != nil {
+		//проблема с подключением к базе
+		log.Printf("[%v]DB problem while GetSubscriptionsByUIDs attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, uids)
+		return nil, err
+	}
+	res := make(map[string][]*model.RawSubscription, len(uids))
+	for _, v := range dbSubs {
+		res[v.UID] = append(res[v.UID], utils.ConvertNormalSubToRaw(v))
+	}
+	return res, nil
+}
+
+// GetUserIDs - provides up to limit IDs of users having subscriptions following afterUID in lexical order.
+// Returned cursor is the last user ID, or empty string if there are no more pages
+func (ss *SubscriptionService) GetUserIDs(ctx context.Context, afterUID string, limit int) ([]string, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("%w: page size must be positive", utils.ErrConvertToNorm)
+	}
+	uids, err := ss.Repo.GetUserIDsPage(ctx, afterUID, limit+1)
+	if err != nil {
+		//проблема с подключением к базе
+		log.Printf("[%v]DB problem while GetUserIDsPage attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return nil, "", err
+	}
+	var next string
+	if len(uids) > limit {
+		uids = uids[:limit]
+		next = uids[limit-1]
+	}
+	return uids, next, nil
+}
+
+// DeleteSubscription - removes record by SID, returns error if no rows affected; subscription.deleted carries the removed record.
+// version is optional: if set, the record is removed only while it has this version
+func (ss *SubscriptionService) DeleteSubscription(ctx context.Context, sid uint, version *uint64) error {
+	err := ss.RunInTx(ctx, func(txService *SubscriptionService) error {
+		dbSub, err := txService.Repo.GetSubscriptionBySID(ctx, uint64(sid))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("Failed to remove susbcription: %w", repository.ErrSubNotFound)
+			}
+			return err
+		}
+		if version != nil && *version != dbSub.Version {
+			return fmt.Errorf("Failed to remove susbcription %d: version %d is not current: %w", sid, *version, repository.ErrVersionMismatch)
+		}
+		count, err := txService.Repo.DeleteSubcription(ctx, sid, dbSub.Version)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("Failed to remove susbcription: %w", repository.ErrVersionMismatch)
+		}
+		if err := txService.recordWrite(ctx, []*model.Subscription{dbSub}, nil); err != nil {
+			return err
+		}
+		return txService.emit(ctx, model.EventSubscriptionDeleted, dbSub)
+	})
+	if err == nil || errors.Is(err, repository.ErrSubNotFound) || errors.Is(err, repository.ErrVersionMismatch) {
+		return err
 	}
 
 	//проблема с подключением к базе
@@ -152,19 +413,171 @@ func (ss *SubscriptionService) DeleteSubscription(ctx context.Context, sid uint)
 	return err
 }
 
-// Report - provides a total price of subscriptions which meet the search request: period(mandatory, specific month), UID(optional) and Provider(optional)
+// resolveProvider - replaces service name (or provider ID) of raw subscription with canonical name of catalogued provider and sets provider ID.
+// Service names absent in catalog are kept as is. If withDefaultPrice is set, missing price is taken from provider default price.
+func (ss *SubscriptionService) resolveProvider(ctx context.Context, rawSub *model.RawSubscription, withDefaultPrice bool) error {
+	var provider *model.Provider
+	var err error
+	switch {
+	case rawSub.ProviderID != nil:
+		provider, err = ss.Providers.GetProviderByID(ctx, *rawSub.ProviderID)
+		if err == nil && rawSub.Provider != "" && utils.NormalizeProviderName(rawSub.Provider) != provider.NormalizedName &&
+			!hasAlias(provider, utils.NormalizeProviderName(rawSub.Provider)) {
+			return fmt.Errorf("Service name %q doesn't match provider %d: %w", rawSub.Provider, *rawSub.ProviderID, utils.ErrConvertToNorm)
+		}
+	case rawSub.Provider != "":
+		provider, err = ss.Providers.FindProviderByKey(ctx, utils.NormalizeProviderName(rawSub.Provider))
+	default:
+		return nil
+	}
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("[%v] DB problem while provider lookup: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, rawSub)
+			return fmt.Errorf("Provider lookup failed: %w", err)
+		}
+		if rawSub.ProviderID != nil {
+			return fmt.Errorf("Unknown provider %d: %w", *rawSub.ProviderID, utils.ErrConvertToNorm)
+		}
+		return nil
+	}
+
+	rawSub.Provider = provider.Name
+	rawSub.ProviderID = &provider.ID
+	if withDefaultPrice && rawSub.Price == nil && provider.DefaultPrice != nil {
+		price := *provider.DefaultPrice
+		rawSub.Price = &price
+	}
+	return nil
+}
+
+// resolveProviderName - returns canonical name of catalogued provider matching name or alias, or the name itself if it is not catalogued
+func (ss *SubscriptionService) resolveProviderName(ctx context.Context, name string) (string, error) {
+	provider, err := ss.Providers.FindProviderByKey(ctx, utils.NormalizeProviderName(name))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return name, nil
+		}
+		return "", err
+	}
+	return provider.Name, nil
+}
+
+func hasAlias(provider *model.Provider, key string) bool {
+	for _, alias := range provider.Aliases {
+		if alias.NormalizedAlias == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Report - provides a total price of subscriptions which meet the search request: period(mandatory, specific month), UID(optional) and Provider(optional).
+// Totals are taken from Cache if it is set; within a transaction the cache is bypassed, as it doesn't see uncommitted writes
 func (ss *SubscriptionService) Report(ctx context.Context, filter *model.RawReportFilter) (uint, error) {
-	normFilter, err := utils.ConvertFilterToNorm(filter)
+	normFilter, err := ss.normalizeFilter(ctx, filter)
 	if err != nil {
 		return 0, err
 	}
 
-	res, err := ss.Repo.ComposeReport(ctx, normFilter)
+	cache := ss.Cache
+	if ss.inTx {
+		cache = nil
+	}
+	key := utils.ReportCacheKey(normFilter)
+	res, generation, ok := cache.Get(ctx, key)
+	if ok {
+		return res, nil
+	}
+
+	res, err = ss.Repo.ComposeReport(ctx, normFilter)
 	if err != nil {
 		//проблема с подключением к базе
 		log.Printf("[%v] DB problem while ComposeReport attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, normFilter)
 		return 0, fmt.Errorf("Failed to make report: %w", err)
 	}
+	cache.Set(ctx, key, res, generation)
+
+	return res, nil
+}
+
+// ReportCacheStats - hit and miss counters of Cache
+func (ss *SubscriptionService) ReportCacheStats(ctx context.Context) (*model.ReportCacheStats, error) {
+	return ss.Cache.Stats(ctx)
+}
+
+// ReportByUsers - provides total price of subscriptions which meet the search request for each of provided users, composed in one query.
+// UID of filter is ignored; users without subscriptions in period are absent in result
+func (ss *SubscriptionService) ReportByUsers(ctx context.Context, filter *model.RawReportFilter, uids []string) (map[string]uint, error) {
+	normFilter, err := ss.normalizeFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	normFilter.UID = nil
 
+	res, err := ss.Repo.ComposeReportByUsers(ctx, normFilter, uids)
+	if err != nil {
+		//проблема с подключением к базе
+		log.Printf("[%v] DB problem while ComposeReportByUsers attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, normFilter)
+		return nil, fmt.Errorf("Failed to make report: %w", err)
+	}
 	return res, nil
 }
+
+// normalizeFilter - converts raw report filter to normal one, resolving provider name to its canonical form
+func (ss *SubscriptionService) normalizeFilter(ctx context.Context, filter *model.RawReportFilter) (*model.ReportFilter, error) {
+	normFilter, err := utils.ConvertFilterToNorm(filter)
+	if err != nil {
+		return nil, err
+	}
+	if normFilter.Provider != nil {
+		name, err := ss.resolveProviderName(ctx, *normFilter.Provider)
+		if err != nil {
+			log.Printf("[%v] DB problem while provider lookup: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, filter)
+			return nil, fmt.Errorf("Provider lookup failed: %w", err)
+		}
+		normFilter.Provider = &name
+	}
+	if filter.Category != "" {
+		category, _, err := findCategory(ctx, ss.Categories, filter.Category)
+		if err != nil {
+			if errors.Is(err, repository.ErrCategoryNotFound) {
+				return nil, fmt.Errorf("%w: %w", utils.ErrConvertToNorm, err)
+			}
+			log.Printf("[%v] DB problem while category lookup: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, filter)
+			return nil, fmt.Errorf("Category lookup failed: %w", err)
+		}
+		normFilter.CategoryID = &category.ID
+	}
+	return normFilter, nil
+}
+
+// ReportByCategory - provides total price of subscriptions which meet the search request, split by categories (each including its subcategories)
+func (ss *SubscriptionService) ReportByCategory(ctx context.Context, filter *model.RawReportFilter) (*model.Report, error) {
+	normFilter, err := ss.normalizeFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := ss.Repo.ComposeReport(ctx, normFilter)
+	if err != nil {
+		log.Printf("[%v] DB problem while ComposeReport attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, normFilter)
+		return nil, fmt.Errorf("Failed to make report: %w", err)
+	}
+	groups, err := ss.Repo.ComposeReportByCategory(ctx, normFilter)
+	if err != nil {
+		log.Printf("[%v] DB problem while ComposeReportByCategory attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, normFilter)
+		return nil, fmt.Errorf("Failed to make report: %w", err)
+	}
+	categories, err := ss.Categories.GetAllCategories(ctx)
+	if err != nil {
+		log.Printf("[%v] DB problem while GetAllCategories attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return nil, fmt.Errorf("Failed to make report: %w", err)
+	}
+	paths := utils.BuildCategoryPaths(categories)
+	for i := range groups {
+		if groups[i].CategoryID != nil {
+			groups[i].Category = paths[*groups[i].CategoryID]
+		}
+	}
+	return &model.Report{Total: total, Groups: groups}, nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/utils"
+	"fmt"
+	"time"
+)
+
+// spendAheadMonths - how many months after the current one monthly_spend covers open-ended subscriptions;
+// reports for later months are computed on the fly
+const spendAheadMonths = 24
+
+// applySpend - replaces contribution of removed subscriptions to monthly_spend with contribution of added ones;
+// removed are states of subscriptions before the write, added - after it. Does nothing while aggregate is not built
+func applySpend(ctx context.Context, repo repository.SpendRepo, removed, added []*model.Subscription) error {
+	state, err := repo.GetState(ctx)
+	if err != nil || state == nil {
+		return err
+	}
+	var rows []model.MonthlySpend
+	for _, sub := range removed {
+		rows = append(rows, utils.SpendRows(sub, -1, time.Time{}, state.CoveredUntil)...)
+	}
+	for _, sub := range added {
+		rows = append(rows, utils.SpendRows(sub, 1, time.Time{}, state.CoveredUntil)...)
+	}
+	if err := repo.AddSpend(ctx, rows); err != nil {
+		return fmt.Errorf("Failed to update monthly spend: %w", err)
+	}
+	return nil
+}
+
+// rebuildSpend - fills monthly_spend from scratch up to spendAheadMonths after the month of now, returns number of its rows
+func rebuildSpend(ctx context.Context, repo repository.SpendRepo, now time.Time) (int64, error) {
+	if err := repo.Clear(ctx); err != nil {
+		return 0, err
+	}
+	state := &model.MonthlySpendState{
+		CoveredUntil: utils.StartOfMonth(now.UTC()).AddDate(0, spendAheadMonths, 0),
+		RebuiltAt:    now.UTC(),
+	}
+	if err := addSpendOf(ctx, repo, nil, time.Time{}, state.CoveredUntil); err != nil {
+		return 0, err
+	}
+	if err := repo.SaveState(ctx, state); err != nil {
+		return 0, err
+	}
+	return repo.CountRows(ctx)
+}
+
+// extendSpend - moves the end of monthly_spend to spendAheadMonths after the month of now as time goes, building aggregate if it doesn't exist yet
+func extendSpend(ctx context.Context, repo repository.SpendRepo, now time.Time) error {
+	state, err := repo.GetState(ctx)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		_, err := rebuildSpend(ctx, repo, now)
+		return err
+	}
+	until := utils.StartOfMonth(now.UTC()).AddDate(0, spendAheadMonths, 0)
+	if !until.After(state.CoveredUntil) {
+		return nil
+	}
+	from := state.CoveredUntil.AddDate(0, 1, 0)
+	if err := addSpendOf(ctx, repo, &from, from, until); err != nil {
+		return err
+	}
+	state.CoveredUntil = until
+	return repo.SaveState(ctx, state)
+}
+
+// addSpendOf - adds contribution of subscriptions active after activeAfter (all if nil) in months from `from` to `until`,
+// writing rows of one user at a time
+func addSpendOf(ctx context.Context, repo repository.SpendRepo, activeAfter *time.Time, from, until time.Time) error {
+	var rows []model.MonthlySpend
+	uid := ""
+	err := repo.StreamSubscriptionsByUser(ctx, activeAfter, func(sub *model.Subscription) error {
+		if sub.UID != uid && len(rows) > 0 {
+			if err := repo.AddSpend(ctx, rows); err != nil {
+				return err
+			}
+			rows = rows[:0]
+		}
+		uid = sub.UID
+		rows = append(rows, utils.SpendRows(sub, 1, from, until)...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return repo.AddSpend(ctx, rows)
+}
+
+// checkSpend - compares every month of monthly_spend with the same rows computed on the fly from subscriptions
+func checkSpend(ctx context.Context, repo repository.SpendRepo) ([]model.IntegrityIssue, error) {
+	state, err := repo.GetState(ctx)
+	if err != nil || state == nil {
+		return nil, err
+	}
+	first, err := repo.GetFirstSubscriptionStart(ctx)
+	if err != nil {
+		return nil, err
+	}
+	firstAggregated, err := repo.GetFirstMonth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if first == nil || (firstAggregated != nil && firstAggregated.Before(*first)) {
+		first = firstAggregated
+	}
+	if first == nil {
+		return nil, nil
+	}
+
+	var issues []model.IntegrityIssue
+	for month := utils.StartOfMonth(first.UTC()); !month.After(state.CoveredUntil); month = month.AddDate(0, 1, 0) {
+		stored, err := repo.GetMonth(ctx, month)
+		if err != nil {
+			return nil, err
+		}
+		live, err := repo.ComposeLiveMonth(ctx, month)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, compareSpend(month, stored, live)...)
+	}
+	return issues, nil
+}
+
+// compareSpend - issues for rows of month that differ between aggregate and computation on the fly
+func compareSpend(month time.Time, stored, live []model.MonthlySpend) []model.IntegrityIssue {
+	type key struct {
+		uid        string
+		providerID uint64
+		provider   string
+	}
+	expected := make(map[key]model.MonthlySpend, len(live))
+	for _, row := range live {
+		expected[key{row.UID, row.ProviderID, row.Provider}] = row
+	}
+	var issues []model.IntegrityIssue
+	mismatch := func(row model.MonthlySpend, detail string) {
+		issues = append(issues, model.IntegrityIssue{
+			Kind:   model.IssueSpendMismatch,
+			Detail: fmt.Sprintf("%s, user %s, service %q: %s", monthOf(&month), row.UID, row.Provider, detail),
+		})
+	}
+	for _, row := range stored {
+		k := key{row.UID, row.ProviderID, row.Provider}
+		want, ok := expected[k]
+		delete(expected, k)
+		if !ok {
+			mismatch(row, fmt.Sprintf("stored %d for %d subscriptions, none expected", row.Amount, row.Subscriptions))
+			continue
+		}
+		if want.Amount != row.Amount || want.Subscriptions != row.Subscriptions {
+			mismatch(row, fmt.Sprintf("stored %d for %d subscriptions, expected %d for %d", row.Amount, row.Subscriptions, want.Amount, want.Subscriptions))
+		}
+	}
+	for _, row := range live {
+		if _, ok := expected[key{row.UID, row.ProviderID, row.Provider}]; ok {
+			mismatch(row, fmt.Sprintf("missing, expected %d for %d subscriptions", row.Amount, row.Subscriptions))
+		}
+	}
+	return issues
+}
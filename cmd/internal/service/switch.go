@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/utils"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SwitchPlan - closes subscription sid at the switch month and creates its successor starting next month, linked via PreviousSID; both changes are made in one transaction
+func (ss *SubscriptionService) SwitchPlan(ctx context.Context, sid uint64, request *model.SwitchRequest) (*model.SwitchResult, error) {
+	if request.SwitchMonth == "" {
+		return nil, fmt.Errorf("Failed to switch subscription: %w", repository.ErrEmptySomeFields)
+	}
+	nextMonth, err := utils.NextMonth(request.SwitchMonth)
+	if err != nil {
+		return nil, fmt.Errorf("Convert failure: %w", err)
+	}
+	switchMonth, err := utils.ParseMonth(request.SwitchMonth)
+	if err != nil {
+		return nil, fmt.Errorf("Convert failure: %w", err)
+	}
+
+	result := &model.SwitchResult{}
+	err = ss.RunInTx(ctx, func(txService *SubscriptionService) error {
+		current, err := txService.Repo.GetSubscriptionBySID(ctx, sid)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("Failed to switch subscription: %w", repository.ErrSubNotFound)
+			}
+			log.Printf("[%v] DB problem while GetSubscriptionBySID attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, sid)
+			return err
+		}
+		if switchMonth.Before(current.Start) {
+			return fmt.Errorf("Failed to switch subscription: switch month precedes start: %w", repository.ErrInvalidPeriod)
+		}
+		if current.End != nil && current.End.Before(*switchMonth) {
+			return fmt.Errorf("Failed to switch subscription: subscription ended before switch month: %w", repository.ErrInvalidPeriod)
+		}
+
+		before := *current
+		current.End = switchMonth
+		err = txService.Repo.UpdateSubscriptionInfo(ctx, current)
+		if err == nil {
+			err = txService.recordWrite(ctx, []*model.Subscription{&before}, []*model.Subscription{current})
+		}
+		if err == nil {
+			err = txService.emit(ctx, model.EventSubscriptionUpdated, current)
+		}
+		if err != nil {
+			log.Printf("[%v] DB problem while UpdateSubscriptionInfo attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, sid)
+			return fmt.Errorf("Failed to update subscription info: %w", err)
+		}
+
+		next := &model.RawSubscription{
+			UID:         current.UID,
+			Provider:    request.Provider,
+			Price:       request.Price,
+			Start:       nextMonth,
+			End:         request.End,
+			PreviousSID: current.SID,
+		}
+		if next.Provider == "" {
+			next.Provider = current.Provider
+		}
+		if next.Price == nil && next.Provider == current.Provider {
+			price := current.Price
+			next.Price = &price
+		}
+		if err := txService.CreateSubscription(ctx, next); err != nil {
+			return err
+		}
+
+		result.Previous = utils.ConvertNormalSubToRaw(current)
+		result.Next = next
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
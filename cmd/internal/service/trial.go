@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"fmt"
+	"log"
+	"time"
+)
+
+// TrialReport - provides trial statistics for report month: subscriptions in trial, trials ending in the month and how many of them convert to paid
+func (ss *SubscriptionService) TrialReport(ctx context.Context, filter *model.RawReportFilter) (*model.TrialReport, error) {
+	normFilter, err := ss.normalizeFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := ss.Repo.ComposeTrialReport(ctx, normFilter)
+	if err != nil {
+		//проблема с подключением к базе
+		log.Printf("[%v] DB problem while ComposeTrialReport attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, normFilter)
+		return nil, fmt.Errorf("Failed to make trial report: %w", err)
+	}
+	report.Period = filter.Period
+	if report.TrialsEnded > 0 {
+		report.ConversionRate = float64(report.Converted) / float64(report.TrialsEnded)
+	}
+	return report, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/utils"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// MaxUpcomingWindow - upper limit of window for upcoming renewals and expiries
+const MaxUpcomingWindow = 366 * 24 * time.Hour
+
+// Upcoming - provides subscriptions whose end date falls within window from now (expiry, at the end of the end month)
+// and open-ended subscriptions whose next monthly charge (after trial, if any) falls within it (renewal), sorted by date; uid is optional
+func (ss *SubscriptionService) Upcoming(ctx context.Context, within time.Duration, uid string) ([]*model.UpcomingSubscription, error) {
+	if within > MaxUpcomingWindow {
+		return nil, fmt.Errorf("%w: window exceeds %v", utils.ErrConvertToNorm, MaxUpcomingWindow)
+	}
+	now := time.Now()
+	windowEnd := now.Add(within)
+
+	var uidFilter *string
+	if uid != "" {
+		uidFilter = &uid
+	}
+	dbSubs, err := ss.Repo.GetUpcomingCandidates(ctx, uidFilter, utils.StartOfMonth(now), windowEnd)
+	if err != nil {
+		//проблема с подключением к базе
+		log.Printf("[%v] DB problem while GetUpcomingCandidates attempt: %v\nInput data: %v, %v\n", time.Now().Format("2006-01-02 15:04:05"), err, within, uid)
+		return nil, fmt.Errorf("Failed to find upcoming subscriptions: %w", err)
+	}
+
+	upcoming := make([]*model.UpcomingSubscription, 0, len(dbSubs))
+	for _, dbSub := range dbSubs {
+		event, date := model.UpcomingRenewal, time.Time{}
+		if dbSub.End != nil {
+			event, date = model.UpcomingExpiry, utils.EndOfMonth(*dbSub.End)
+			if date.Before(now) {
+				continue
+			}
+		} else {
+			from := now
+			if dbSub.TrialUntil != nil && utils.EndOfMonth(*dbSub.TrialUntil).After(now) {
+				from = utils.StartOfMonth(*dbSub.TrialUntil).AddDate(0, 1, 0) //первое списание после пробного периода
+			}
+			date = utils.NextCharge(dbSub.Start, from)
+		}
+		if date.After(windowEnd) {
+			continue
+		}
+		upcoming = append(upcoming, &model.UpcomingSubscription{
+			Event:        event,
+			Date:         date.Format("2006-01-02"),
+			Subscription: utils.ConvertNormalSubToRaw(dbSub),
+		})
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		return upcoming[i].Date < upcoming[j].Date
+	})
+	return upcoming, nil
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/utils"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrDeliveryNotDead - only dead deliveries can be requeued manually
+var ErrDeliveryNotDead = errors.New("webhook delivery is not dead")
+
+// MaxDeliveriesList - upper limit of deliveries returned by dead-letter view
+const MaxDeliveriesList = 500
+
+// NotifyExpiring - emits subscription.expiring for subscriptions ending within window from now; the event is recorded
+// once per subscription and end month, so the check may run as often as needed. Returns number of subscriptions checked.
+func (ss *SubscriptionService) NotifyExpiring(ctx context.Context, within time.Duration) (int, error) {
+	upcoming, err := ss.Upcoming(ctx, within, "")
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, item := range upcoming {
+		if item.Event != model.UpcomingExpiry {
+			continue
+		}
+		sub, err := utils.ConvertRawSubToNormal(item.Subscription)
+		if err != nil {
+			return count, err
+		}
+		dedupeKey := fmt.Sprintf("%s:%d:%s", model.EventSubscriptionExpiring, *sub.SID, item.Subscription.End)
+		err = ss.RunInTx(ctx, func(txService *SubscriptionService) error {
+			return txService.emitDeduped(ctx, model.EventSubscriptionExpiring, sub, dedupeKey)
+		})
+		if err != nil {
+			log.Printf("[%v] DB problem while NotifyExpiring attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, *sub.SID)
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// WebhookService provides methods to business logics of webhook registrations and their deliveries.
+type WebhookService struct {
+	Repo repository.WebhookRepo
+}
+
+func CreateWebhookService(db *gorm.DB) *WebhookService {
+	return &WebhookService{Repo: *repository.CreateWebhookRepo(db)}
+}
+
+// CreateWebhook - validates and registers webhook; secret is generated if not provided and is returned only here
+func (ws *WebhookService) CreateWebhook(ctx context.Context, rawWebhook *model.RawWebhook) error {
+	webhook, err := utils.ConvertRawWebhookToNormal(rawWebhook)
+	if err != nil {
+		return fmt.Errorf("Convert failure: %w", err)
+	}
+	if webhook.URL == "" {
+		return fmt.Errorf("Warning on webhook creation: %w", repository.ErrEmptySomeFields)
+	}
+	if webhook.Secret == "" {
+		webhook.Secret = utils.RandomHex(32)
+	}
+	if err := ws.Repo.CreateWebhook(ctx, webhook); err != nil {
+		//проблема с подключением к базе
+		log.Printf("[%v] DB problem while CreateWebhook attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, webhook.URL)
+		return err
+	}
+	*rawWebhook = *utils.ConvertNormalWebhookToRaw(webhook)
+	rawWebhook.Secret = webhook.Secret
+	return nil
+}
+
+// GetWebhookByID - returns webhook without secret
+func (ws *WebhookService) GetWebhookByID(ctx context.Context, id uint64) (*model.RawWebhook, error) {
+	webhook, err := ws.Repo.GetWebhookByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("Failed to get webhook info: %w", repository.ErrWebhookNotFound)
+		}
+		log.Printf("[%v] DB problem while GetWebhookByID attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, id)
+		return nil, err
+	}
+	return utils.ConvertNormalWebhookToRaw(webhook), nil
+}
+
+// GetWebhookList - provides all registered webhooks without secrets
+func (ws *WebhookService) GetWebhookList(ctx context.Context) ([]*model.RawWebhook, error) {
+	webhooks, err := ws.Repo.GetAllWebhooks(ctx)
+	if err != nil {
+		log.Printf("[%v] DB problem while GetAllWebhooks attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return nil, err
+	}
+	rawWebhooks := make([]*model.RawWebhook, len(webhooks))
+	for i, v := range webhooks {
+		rawWebhooks[i] = utils.ConvertNormalWebhookToRaw(v)
+	}
+	return rawWebhooks, nil
+}
+
+// UpdateWebhookByID - updates provided fields of webhook: URL, secret, event types and active flag
+func (ws *WebhookService) UpdateWebhookByID(ctx context.Context, rawWebhook *model.RawWebhook, id uint64) error {
+	if rawWebhook.URL == "" && rawWebhook.Secret == "" && rawWebhook.EventTypes == nil && rawWebhook.Active == nil {
+		return fmt.Errorf("Failed to update webhook %v: %w", id, repository.ErrEmptyAllFields)
+	}
+	update, err := utils.ConvertRawWebhookToNormal(rawWebhook)
+	if err != nil {
+		return fmt.Errorf("Convert failure: %w", err)
+	}
+
+	webhook, err := ws.Repo.GetWebhookByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("Failed to update webhook: %w", repository.ErrWebhookNotFound)
+		}
+		log.Printf("[%v] DB problem while GetWebhookByID attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, id)
+		return err
+	}
+	if update.URL != "" {
+		webhook.URL = update.URL
+	}
+	if update.Secret != "" {
+		webhook.Secret = update.Secret
+	}
+	if rawWebhook.EventTypes != nil {
+		webhook.EventTypes = update.EventTypes
+	}
+	if rawWebhook.Active != nil {
+		webhook.Active = update.Active
+	}
+	if err := ws.Repo.UpdateWebhook(ctx, webhook); err != nil {
+		log.Printf("[%v] DB problem while UpdateWebhook attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, id)
+		return fmt.Errorf("Failed to update webhook: %w", err)
+	}
+	*rawWebhook = *utils.ConvertNormalWebhookToRaw(webhook)
+	return nil
+}
+
+// DeleteWebhook - removes webhook together with its deliveries
+func (ws *WebhookService) DeleteWebhook(ctx context.Context, id uint64) error {
+	var deleted int64
+	err := ws.Repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		deleted, err = ws.Repo.WithTx(tx).DeleteWebhook(ctx, id)
+		return err
+	})
+	if err != nil {
+		log.Printf("[%v] DB problem while DeleteWebhook attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, id)
+		return err
+	}
+	if deleted == 0 {
+		return fmt.Errorf("Failed to remove webhook: %w", repository.ErrWebhookNotFound)
+	}
+	return nil
+}
+
+// GetDeliveries - provides deliveries filtered by status (e.g. dead-letter view) and webhook, newest first
+func (ws *WebhookService) GetDeliveries(ctx context.Context, status string, webhookID *uint64, limit int) ([]*model.WebhookDelivery, error) {
+	switch status {
+	case "", model.DeliveryPending, model.DeliveryDelivered, model.DeliveryDead:
+	default:
+		return nil, fmt.Errorf("%w: unknown delivery status %q", utils.ErrConvertToNorm, status)
+	}
+	if limit <= 0 || limit > MaxDeliveriesList {
+		limit = MaxDeliveriesList
+	}
+	deliveries, err := ws.Repo.GetDeliveries(ctx, status, webhookID, limit)
+	if err != nil {
+		log.Printf("[%v] DB problem while GetDeliveries attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RetryDelivery - requeues dead delivery for immediate attempt with reset attempts counter
+func (ws *WebhookService) RetryDelivery(ctx context.Context, id uint64) (*model.WebhookDelivery, error) {
+	delivery, err := ws.Repo.GetDeliveryByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("Failed to retry delivery: %w", repository.ErrDeliveryNotFound)
+		}
+		log.Printf("[%v] DB problem while GetDeliveryByID attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, id)
+		return nil, err
+	}
+	if delivery.Status != model.DeliveryDead {
+		return nil, fmt.Errorf("Failed to retry delivery %d: %w", id, ErrDeliveryNotDead)
+	}
+	delivery.Status = model.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now().UTC()
+	if err := ws.Repo.UpdateDelivery(ctx, delivery); err != nil {
+		log.Printf("[%v] DB problem while UpdateDelivery attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, id)
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// WebhookDispatcher sends pending outbox deliveries to webhook receivers, retrying failed ones with exponential backoff
+type WebhookDispatcher struct {
+	Repo        repository.WebhookRepo
+	Client      *http.Client
+	BatchSize   int
+	MaxAttempts int           // after that many failed attempts delivery becomes dead
+	BaseBackoff time.Duration // delay after the first failure, doubled on each next one
+	MaxBackoff  time.Duration
+	Lease       time.Duration // time reserved for a claimed delivery before another dispatcher may take it
+}
+
+func CreateWebhookDispatcher(db *gorm.DB) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		Repo:        *repository.CreateWebhookRepo(db),
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		BatchSize:   100,
+		MaxAttempts: 8,
+		BaseBackoff: 30 * time.Second,
+		MaxBackoff:  6 * time.Hour,
+		Lease:       time.Minute,
+	}
+}
+
+// DispatchPending - makes one attempt for every due delivery (up to BatchSize), returns number of successfully delivered ones
+func (wd *WebhookDispatcher) DispatchPending(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	deliveries, err := wd.Repo.GetDueDeliveries(ctx, now, wd.BatchSize)
+	if err != nil {
+		log.Printf("[%v] DB problem while GetDueDeliveries attempt: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+		return 0, err
+	}
+
+	webhooks := make(map[uint64]*model.Webhook)
+	delivered := 0
+	for _, delivery := range deliveries {
+		if err := ctx.Err(); err != nil {
+			return delivered, err
+		}
+		claimed, err := wd.Repo.ClaimDelivery(ctx, delivery, now.Add(wd.Lease))
+		if err != nil {
+			return delivered, err
+		}
+		if !claimed { //забрал другой экземпляр сервиса
+			continue
+		}
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			if webhook, err = wd.Repo.GetWebhookByID(ctx, delivery.WebhookID); err != nil {
+				return delivered, err
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+
+		sendErr := wd.send(ctx, webhook, delivery)
+		delivery.Attempts++
+		switch {
+		case sendErr == nil:
+			deliveredAt := time.Now().UTC()
+			delivery.Status = model.DeliveryDelivered
+			delivery.DeliveredAt = &deliveredAt
+			delivery.LastError = ""
+			delivered++
+		case delivery.Attempts >= wd.MaxAttempts:
+			delivery.Status = model.DeliveryDead
+			delivery.LastError = sendErr.Error()
+		default:
+			delivery.NextAttemptAt = time.Now().UTC().Add(utils.WebhookBackoff(delivery.Attempts, wd.BaseBackoff, wd.MaxBackoff))
+			delivery.LastError = sendErr.Error()
+		}
+		if err := wd.Repo.UpdateDelivery(ctx, delivery); err != nil {
+			log.Printf("[%v] DB problem while UpdateDelivery attempt: %v\nInput data: %v\n", time.Now().Format("2006-01-02 15:04:05"), err, delivery.ID)
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// send - posts signed payload to webhook URL, any non-2xx response is a failure
+func (wd *WebhookDispatcher) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) error {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(utils.WebhookEventHeader, delivery.EventType)
+	req.Header.Set(utils.WebhookEventIDHeader, strconv.FormatUint(delivery.EventID, 10))
+	req.Header.Set(utils.WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(utils.WebhookSignatureHeader, utils.SignWebhookPayload(webhook.Secret, timestamp, body))
+
+	resp, err := wd.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("receiver responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// RunEvery - calls fn every interval until ctx is cancelled, errors are logged
+func RunEvery(ctx context.Context, interval time.Duration, name string, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := fn(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("[%v] Background job %q failed: %v\n", time.Now().Format("2006-01-02 15:04:05"), name, err)
+			}
+		}
+	}
+}
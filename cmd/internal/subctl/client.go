@@ -0,0 +1,95 @@
+package subctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client - HTTP client of subscription API
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+// Request - single API call; Body is sent as is with ContentType, JSON is marshalled otherwise
+type Request struct {
+	Method      string
+	Path        string
+	Query       url.Values
+	Header      http.Header
+	JSON        any
+	Body        io.Reader
+	ContentType string
+}
+
+// Response - status, headers and body of API response with 2xx status
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// APIError - API response with non-2xx status
+type APIError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), strings.TrimSpace(string(e.Body)))
+}
+
+// Do - executes request, returns *APIError for non-2xx responses
+func (c *Client) Do(ctx context.Context, request *Request) (*Response, error) {
+	target := strings.TrimSuffix(c.BaseURL, "/") + request.Path
+	if len(request.Query) > 0 {
+		target += "?" + request.Query.Encode()
+	}
+	body, contentType := request.Body, request.ContentType
+	if request.JSON != nil {
+		data, err := json.Marshal(request.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		body, contentType = bytes.NewReader(data), "application/json"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, request.Method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range request.Header {
+		req.Header[name] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: data}
+	}
+	return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: data}, nil
+}
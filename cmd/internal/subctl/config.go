@@ -0,0 +1,221 @@
+package subctl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// DefaultBaseURL - base URL used when neither flag, env nor profile sets it
+const DefaultBaseURL = "http://localhost:8080"
+
+// Profile - connection settings of one API deployment
+type Profile struct {
+	BaseURL string `json:"base_url"`
+	Token   string `json:"token,omitempty"`
+}
+
+// Config - subctl config file: named profiles and the one used by default
+type Config struct {
+	Current  string              `json:"current,omitempty"`
+	Profiles map[string]*Profile `json:"profiles"`
+}
+
+var ErrProfileNotFound = errors.New("profile not found")
+
+// DefaultConfigPath - $SUBCTL_CONFIG or subctl/config.json in user config directory
+func DefaultConfigPath() string {
+	if path := os.Getenv("SUBCTL_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "subctl.json"
+	}
+	return filepath.Join(dir, "subctl", "config.json")
+}
+
+// LoadConfig - reads config file; missing file gives empty config
+func LoadConfig(path string) (*Config, error) {
+	config := &Config{Profiles: map[string]*Profile{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if config.Profiles == nil {
+		config.Profiles = map[string]*Profile{}
+	}
+	return config, nil
+}
+
+// Save - writes config file readable only by owner, as it may contain tokens
+func (c *Config) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// Profile - returns profile by name, current profile for empty name; nil if there is no current profile
+func (c *Config) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = c.Current
+	}
+	if name == "" {
+		return nil, nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrProfileNotFound, name)
+	}
+	return profile, nil
+}
+
+// ProfileNames - names of all profiles in lexical order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func configCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage connection profiles",
+	}
+	profileCompletion := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		config, err := LoadConfig(opts.configPath)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return config.ProfileNames(), cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var baseURL, token string
+	var use bool
+	set := &cobra.Command{
+		Use:     "set NAME",
+		Short:   "Create or update profile",
+		Example: `  subctl config set prod --base-url https://subs.example.com --token $TOKEN --use`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadConfig(opts.configPath)
+			if err != nil {
+				return err
+			}
+			profile, ok := config.Profiles[args[0]]
+			if !ok {
+				profile = &Profile{BaseURL: DefaultBaseURL}
+				config.Profiles[args[0]] = profile
+			}
+			if cmd.Flags().Changed("base-url") {
+				profile.BaseURL = baseURL
+			}
+			if cmd.Flags().Changed("token") {
+				profile.Token = token
+			}
+			if use || config.Current == "" {
+				config.Current = args[0]
+			}
+			return config.Save(opts.configPath)
+		},
+	}
+	// локальные флаги перекрывают одноимённые глобальные, которые переопределяют профиль при запросах
+	set.Flags().StringVar(&baseURL, "base-url", "", "base URL of API")
+	set.Flags().StringVar(&token, "token", "", "bearer token")
+	set.Flags().BoolVar(&use, "use", false, "make profile current")
+
+	useCmd := &cobra.Command{
+		Use:               "use NAME",
+		Short:             "Make profile current",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: profileCompletion,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadConfig(opts.configPath)
+			if err != nil {
+				return err
+			}
+			if _, err := config.Profile(args[0]); err != nil {
+				return err
+			}
+			config.Current = args[0]
+			return config.Save(opts.configPath)
+		},
+	}
+
+	list := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List profiles",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadConfig(opts.configPath)
+			if err != nil {
+				return err
+			}
+			// токены не выводятся, только признак их наличия
+			type profileRow struct {
+				Name     string `json:"name"`
+				BaseURL  string `json:"base_url"`
+				HasToken bool   `json:"token"`
+				Current  bool   `json:"current"`
+			}
+			rows := []profileRow{}
+			for _, name := range config.ProfileNames() {
+				profile := config.Profiles[name]
+				rows = append(rows, profileRow{Name: name, BaseURL: profile.BaseURL, HasToken: profile.Token != "", Current: name == config.Current})
+			}
+			data, err := json.Marshal(rows)
+			if err != nil {
+				return err
+			}
+			return printResult(cmd.OutOrStdout(), opts.output, data, view{Columns: []string{"name", "base_url", "token", "current"}})
+		},
+	}
+
+	remove := &cobra.Command{
+		Use:               "delete NAME",
+		Aliases:           []string{"rm"},
+		Short:             "Delete profile",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: profileCompletion,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := LoadConfig(opts.configPath)
+			if err != nil {
+				return err
+			}
+			if _, err := config.Profile(args[0]); err != nil {
+				return err
+			}
+			delete(config.Profiles, args[0])
+			if config.Current == args[0] {
+				config.Current = ""
+			}
+			return config.Save(opts.configPath)
+		},
+	}
+
+	cmd.AddCommand(set, useCmd, list, remove)
+	return cmd
+}
@@ -0,0 +1,167 @@
+package subctl
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+)
+
+var outputFormats = []string{OutputTable, OutputJSON, OutputCSV}
+
+// view - how response is shown as table or CSV: Columns of rows (all keys in lexical order if empty),
+// List - field of response object holding rows (response itself if empty)
+type view struct {
+	Columns []string
+	List    string
+}
+
+var (
+	subscriptionView = view{Columns: []string{"subscription_id", "user_id", "service_name", "price", "start_date", "end_date", "trial_until", "status", "version"}}
+	providerView     = view{Columns: []string{"provider_id", "name", "aliases", "category", "default_price"}}
+	categoryView     = view{Columns: []string{"category_id", "name", "parent_id", "path"}}
+	budgetView       = view{Columns: []string{"budget_id", "user_id", "amount", "mode", "category", "service_name"}}
+	webhookView      = view{Columns: []string{"webhook_id", "url", "event_types", "active", "created_at"}}
+	deliveryView     = view{Columns: []string{"delivery_id", "webhook_id", "event_type", "status", "attempts", "next_attempt_at", "last_error"}}
+	eventView        = view{Columns: []string{"id", "type", "created_at", "subscription"}, List: "events"}
+)
+
+// printResult - writes JSON response body in format: indented JSON as is, table or CSV by view
+func printResult(out io.Writer, format string, body []byte, v view) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if format == OutputJSON {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, body, "", "  "); err != nil {
+			_, err = out.Write(body)
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err := buf.WriteTo(out)
+		return err
+	}
+
+	var data any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if object, ok := data.(map[string]any); ok && v.List != "" {
+		data = object[v.List]
+	}
+
+	var header []string
+	var rows [][]string
+	switch data := data.(type) {
+	case nil:
+		// пустой список сервер может вернуть как null
+		header = v.Columns
+	case []any:
+		header = v.Columns
+		if len(header) == 0 {
+			header = allKeys(data)
+		}
+		for _, item := range data {
+			object, _ := item.(map[string]any)
+			row := make([]string, len(header))
+			for i, column := range header {
+				row[i] = formatValue(object[column])
+			}
+			rows = append(rows, row)
+		}
+	case map[string]any:
+		// одиночный объект выводится как пары поле-значение: сначала колонки вида, затем остальные поля
+		header = []string{"field", "value"}
+		keys := append([]string{}, v.Columns...)
+		for _, key := range allKeys([]any{data}) {
+			if !slices.Contains(v.Columns, key) {
+				keys = append(keys, key)
+			}
+		}
+		for _, key := range keys {
+			if value, ok := data[key]; ok {
+				rows = append(rows, []string{key, formatValue(value)})
+			}
+		}
+	default:
+		header = []string{"value"}
+		rows = [][]string{{formatValue(data)}}
+	}
+
+	if format == OutputCSV {
+		writer := csv.NewWriter(out)
+		writer.Write(header)
+		writer.WriteAll(rows)
+		return writer.Error()
+	}
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	upper := make([]string, len(header))
+	for i, column := range header {
+		upper[i] = strings.ToUpper(column)
+	}
+	fmt.Fprintln(writer, strings.Join(upper, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
+
+// allKeys - union of keys of all objects in lexical order
+func allKeys(items []any) []string {
+	set := map[string]struct{}{}
+	for _, item := range items {
+		if object, ok := item.(map[string]any); ok {
+			for key := range object {
+				set[key] = struct{}{}
+			}
+		}
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatValue - scalar as text, list of scalars joined by comma, anything else as compact JSON
+func formatValue(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		return strconv.FormatBool(value)
+	case []any:
+		parts := make([]string, len(value))
+		for i, item := range value {
+			if _, nested := item.(map[string]any); nested {
+				data, _ := json.Marshal(value)
+				return string(data)
+			}
+			parts[i] = formatValue(item)
+		}
+		return strings.Join(parts, ",")
+	default:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+}
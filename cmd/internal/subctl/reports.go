@@ -0,0 +1,139 @@
+package subctl
+
+import (
+	"em-test/cmd/internal/model"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// reportFilter - filter flags shared by report commands
+type reportFilter struct {
+	period   string
+	uid      string
+	provider string
+	category string
+	groupBy  string
+}
+
+func (rf *reportFilter) register(cmd *cobra.Command, withPeriod, withGroupBy bool) {
+	flags := cmd.PersistentFlags()
+	if withPeriod {
+		flags.StringVar(&rf.period, "period", "", "month in 07-2025 format")
+	}
+	flags.StringVar(&rf.uid, "user", "", "user ID")
+	flags.StringVar(&rf.provider, "service", "", "service (provider) name")
+	flags.StringVar(&rf.category, "category", "", "category ID or path, e.g. \"Entertainment > Video\"; includes subcategories")
+	if withGroupBy {
+		flags.StringVar(&rf.groupBy, "group-by", "", "group totals: category")
+		cmd.RegisterFlagCompletionFunc("group-by", fixedCompletion(model.ReportGroupByCategory))
+	}
+}
+
+func (rf *reportFilter) query() url.Values {
+	query := url.Values{}
+	for name, value := range map[string]string{"period": rf.period, "uid": rf.uid, "provider": rf.provider, "category": rf.category, "group_by": rf.groupBy} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	return query
+}
+
+func reportCommand(opts *options) *cobra.Command {
+	var filter reportFilter
+	cmd := &cobra.Command{
+		Use:     "report",
+		Short:   "Total price of subscriptions for month",
+		Example: `  subctl report --period 07-2025 --user 60601fee-2bf1-4721-ae6f-7636e79a0cba --group-by category`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// period is checked here: a required persistent flag would be required by subcommands too
+			if filter.period == "" {
+				return errors.New(`required flag(s) "period" not set`)
+			}
+			v := view{Columns: []string{"total"}}
+			if filter.groupBy != "" {
+				v = view{Columns: []string{"category_id", "category", "total"}, List: "groups"}
+			}
+			return opts.call(cmd, &Request{Method: http.MethodGet, Path: "/subscriptions/report", Query: filter.query()}, v)
+		},
+	}
+	filter.register(cmd, true, true)
+
+	var trialFilter reportFilter
+	trials := &cobra.Command{
+		Use:   "trials",
+		Short: "Conversion of trial periods to paid subscriptions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.call(cmd, &Request{Method: http.MethodGet, Path: "/subscriptions/report/trials", Query: trialFilter.query()}, view{})
+		},
+	}
+	trialFilter.register(trials, true, false)
+
+	var anomalyUID, anomalyPeriod string
+	var similarity, priceRatio float64
+	var maxConcurrent int
+	anomalies := &cobra.Command{
+		Use:   "anomalies",
+		Short: "Probable duplicates, price outliers and category overloads",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			if anomalyUID != "" {
+				query.Set("uid", anomalyUID)
+			}
+			if anomalyPeriod != "" {
+				query.Set("period", anomalyPeriod)
+			}
+			if cmd.Flags().Changed("similarity") {
+				query.Set("similarity", strconv.FormatFloat(similarity, 'f', -1, 64))
+			}
+			if cmd.Flags().Changed("price-ratio") {
+				query.Set("price_ratio", strconv.FormatFloat(priceRatio, 'f', -1, 64))
+			}
+			if cmd.Flags().Changed("max-concurrent") {
+				query.Set("max_concurrent", strconv.Itoa(maxConcurrent))
+			}
+			return opts.call(cmd, &Request{Method: http.MethodGet, Path: "/subscriptions/report/anomalies", Query: query}, view{})
+		},
+	}
+	anomalies.Flags().StringVar(&anomalyUID, "user", "", "user ID")
+	anomalies.Flags().StringVar(&anomalyPeriod, "period", "", "month of concurrent subscriptions check, current by default")
+	anomalies.Flags().Float64Var(&similarity, "similarity", model.DefaultAnomalySimilarity, "min similarity of provider names from 0 to 1")
+	anomalies.Flags().Float64Var(&priceRatio, "price-ratio", model.DefaultAnomalyPriceRatio, "how many times price must differ from median")
+	anomalies.Flags().IntVar(&maxConcurrent, "max-concurrent", model.DefaultAnomalyMaxConcurrent, "concurrent subscriptions of one category to report user")
+
+	var forecastFilter reportFilter
+	var months int
+	forecast := &cobra.Command{
+		Use:   "forecast",
+		Short: "Projected monthly spend for months ahead",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := forecastFilter.query()
+			if cmd.Flags().Changed("months") {
+				query.Set("months", strconv.Itoa(months))
+			}
+			return opts.call(cmd, &Request{Method: http.MethodGet, Path: "/subscriptions/forecast", Query: query}, view{Columns: []string{"period", "total", "groups"}, List: "months"})
+		},
+	}
+	forecastFilter.register(forecast, false, true)
+	forecast.Flags().IntVar(&months, "months", 12, "number of months (1-36)")
+
+	cache := &cobra.Command{
+		Use:   "cache",
+		Short: "Hit and miss counters of server report cache",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.call(cmd, &Request{Method: http.MethodGet, Path: "/subscriptions/report/cache"}, view{})
+		},
+	}
+
+	cmd.AddCommand(trials, anomalies, forecast, cache)
+	return cmd
+}
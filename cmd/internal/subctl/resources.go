@@ -0,0 +1,238 @@
+package subctl
+
+import (
+	"em-test/cmd/internal/model"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// resource - API collection with plain CRUD routes: POST and GET on Path, GET, PUT and DELETE on Path/{id}
+type resource struct {
+	Name    string
+	Aliases []string
+	Path    string
+	View    view
+	Example string
+}
+
+var (
+	providersResource = resource{
+		Name:    "providers",
+		Aliases: []string{"provider"},
+		Path:    "/providers",
+		View:    providerView,
+		Example: `{"name": "Yandex Plus", "aliases": ["yandex+"], "category": "Entertainment > Video", "default_price": 400}`,
+	}
+	categoriesResource = resource{
+		Name:    "categories",
+		Aliases: []string{"category"},
+		Path:    "/categories",
+		View:    categoryView,
+		Example: `{"name": "Video", "parent_id": 1}`,
+	}
+	budgetsResource = resource{
+		Name:    "budgets",
+		Aliases: []string{"budget"},
+		Path:    "/budgets",
+		View:    budgetView,
+		Example: `{"user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba", "amount": 1500, "mode": "warn"}`,
+	}
+	webhooksResource = resource{
+		Name:    "webhooks",
+		Aliases: []string{"webhook"},
+		Path:    "/webhooks",
+		View:    webhookView,
+		Example: `{"url": "https://example.com/hook", "event_types": ["subscription.created"]}`,
+	}
+)
+
+func (res resource) itemPath(id uint64) string {
+	return res.Path + "/" + strconv.FormatUint(id, 10)
+}
+
+// resourceCommand - create, get, list, update and delete commands of resource; create and update read JSON body from file
+func resourceCommand(opts *options, res resource) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     res.Name,
+		Aliases: res.Aliases,
+		Short:   "Manage " + res.Name,
+	}
+
+	var createFile string
+	create := &cobra.Command{
+		Use:     "create",
+		Short:   "Create one of " + res.Name + " from JSON",
+		Example: "  echo '" + res.Example + "' | subctl " + res.Name + " create",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var body json.RawMessage
+			if err := readJSONFile(cmd, createFile, &body); err != nil {
+				return err
+			}
+			return opts.call(cmd, &Request{Method: http.MethodPost, Path: res.Path, JSON: body}, res.View)
+		},
+	}
+	create.Flags().StringVarP(&createFile, "file", "f", "-", "JSON file with fields, - for stdin")
+
+	get := &cobra.Command{
+		Use:   "get ID",
+		Short: "Get one of " + res.Name + " by ID",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			return opts.call(cmd, &Request{Method: http.MethodGet, Path: res.itemPath(id)}, res.View)
+		},
+	}
+
+	list := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List " + res.Name,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.call(cmd, &Request{Method: http.MethodGet, Path: res.Path, Query: queryFlags(cmd)}, res.View)
+		},
+	}
+
+	var updateFile string
+	update := &cobra.Command{
+		Use:   "update ID",
+		Short: "Replace fields of one of " + res.Name + " from JSON",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			var body json.RawMessage
+			if err := readJSONFile(cmd, updateFile, &body); err != nil {
+				return err
+			}
+			return opts.call(cmd, &Request{Method: http.MethodPut, Path: res.itemPath(id), JSON: body}, res.View)
+		},
+	}
+	update.Flags().StringVarP(&updateFile, "file", "f", "-", "JSON file with fields, - for stdin")
+
+	remove := &cobra.Command{
+		Use:     "delete ID",
+		Aliases: []string{"rm"},
+		Short:   "Delete one of " + res.Name,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			return opts.call(cmd, &Request{Method: http.MethodDelete, Path: res.itemPath(id)}, view{})
+		},
+	}
+
+	cmd.AddCommand(create, get, list, update, remove)
+	return cmd
+}
+
+// queryFlags - query parameters from changed flags annotated with queryParam
+func queryFlags(cmd *cobra.Command) url.Values {
+	query := url.Values{}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if param, ok := flag.Annotations[queryParam]; ok && len(param) > 0 {
+			query.Set(param[0], flag.Value.String())
+		}
+	})
+	return query
+}
+
+// queryParam - flag annotation with name of query parameter the flag is sent as
+const queryParam = "subctl_query_param"
+
+// queryFlag - string flag sent as query parameter param
+func queryFlag(cmd *cobra.Command, name, param, usage string) {
+	cmd.Flags().String(name, "", usage)
+	cmd.Flags().SetAnnotation(name, queryParam, []string{param})
+}
+
+// subcommand - finds direct subcommand by name
+func subcommand(cmd *cobra.Command, name string) *cobra.Command {
+	for _, sub := range cmd.Commands() {
+		if sub.Name() == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+func budgetsCommand(opts *options) *cobra.Command {
+	cmd := resourceCommand(opts, budgetsResource)
+	queryFlag(subcommand(cmd, "list"), "user", "user_id", "only budgets of user")
+
+	over := &cobra.Command{
+		Use:   "over",
+		Short: "Budgets exceeded in month",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.call(cmd, &Request{Method: http.MethodGet, Path: "/budgets/over", Query: queryFlags(cmd)},
+				view{Columns: []string{"budget", "period", "spent", "over_by"}})
+		},
+	}
+	queryFlag(over, "period", "period", "month in 07-2025 format, current by default")
+	queryFlag(over, "user", "user_id", "only budgets of user")
+
+	cmd.AddCommand(over)
+	return cmd
+}
+
+func webhooksCommand(opts *options) *cobra.Command {
+	cmd := resourceCommand(opts, webhooksResource)
+
+	deliveries := &cobra.Command{
+		Use:   "deliveries",
+		Short: "List webhook deliveries",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.call(cmd, &Request{Method: http.MethodGet, Path: "/webhooks/deliveries", Query: queryFlags(cmd)}, deliveryView)
+		},
+	}
+	queryFlag(deliveries, "status", "status", "only deliveries with status: pending, delivered or dead")
+	queryFlag(deliveries, "webhook", "webhook_id", "only deliveries of webhook")
+	queryFlag(deliveries, "limit", "limit", "max number of deliveries")
+	deliveries.RegisterFlagCompletionFunc("status", fixedCompletion(model.DeliveryPending, model.DeliveryDelivered, model.DeliveryDead))
+
+	retry := &cobra.Command{
+		Use:   "retry DELIVERY_ID",
+		Short: "Send failed delivery once more",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			path := "/webhooks/deliveries/" + strconv.FormatUint(id, 10) + "/retry"
+			return opts.call(cmd, &Request{Method: http.MethodPost, Path: path}, deliveryView)
+		},
+	}
+
+	cmd.AddCommand(deliveries, retry)
+	return cmd
+}
+
+func eventsCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "List subscription change events",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.call(cmd, &Request{Method: http.MethodGet, Path: "/events", Query: queryFlags(cmd)}, eventView)
+		},
+	}
+	queryFlag(cmd, "after", "after", "cursor: only events after this one")
+	queryFlag(cmd, "limit", "limit", "max number of events")
+	return cmd
+}
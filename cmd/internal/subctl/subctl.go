@@ -0,0 +1,136 @@
+// Package subctl implements command-line client of subscription API: commands wrap HTTP endpoints
+// and print responses as table, JSON or CSV. Connection settings come from flags, SUBCTL_* env or config profiles.
+package subctl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// options - global flags shared by all commands
+type options struct {
+	configPath string
+	profile    string
+	baseURL    string
+	token      string
+	output     string
+	timeout    time.Duration
+
+	// HTTP - transport of API client, http.DefaultClient if nil; replaced in tests
+	HTTP *http.Client
+}
+
+// CreateRootCommand - builds subctl command tree; httpClient may be nil
+func CreateRootCommand(httpClient *http.Client) *cobra.Command {
+	opts := &options{HTTP: httpClient}
+	root := &cobra.Command{
+		Use:           "subctl",
+		Short:         "Command-line client of subscription API",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(outputFormats, opts.output) {
+				return fmt.Errorf("unknown output format %q: expected one of %s", opts.output, strings.Join(outputFormats, ", "))
+			}
+			return nil
+		},
+	}
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.configPath, "config", DefaultConfigPath(), "config file with profiles ($SUBCTL_CONFIG)")
+	flags.StringVarP(&opts.profile, "profile", "p", os.Getenv("SUBCTL_PROFILE"), "profile from config file, current one by default ($SUBCTL_PROFILE)")
+	flags.StringVar(&opts.baseURL, "base-url", os.Getenv("SUBCTL_BASE_URL"), "base URL of API, overrides profile ($SUBCTL_BASE_URL)")
+	flags.StringVar(&opts.token, "token", os.Getenv("SUBCTL_TOKEN"), "bearer token, overrides profile ($SUBCTL_TOKEN)")
+	flags.StringVarP(&opts.output, "output", "o", OutputTable, "output format: table, json or csv")
+	flags.DurationVar(&opts.timeout, "timeout", 30*time.Second, "request timeout")
+	root.RegisterFlagCompletionFunc("output", fixedCompletion(outputFormats...))
+	root.RegisterFlagCompletionFunc("profile", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		config, err := LoadConfig(opts.configPath)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return config.ProfileNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	root.AddCommand(
+		subscriptionsCommand(opts),
+		reportCommand(opts),
+		resourceCommand(opts, providersResource),
+		resourceCommand(opts, categoriesResource),
+		budgetsCommand(opts),
+		webhooksCommand(opts),
+		eventsCommand(opts),
+		configCommand(opts),
+	)
+	return root
+}
+
+// Execute - runs subctl with command-line arguments and exits with non-zero code on error
+func Execute() {
+	root := CreateRootCommand(nil)
+	if err := root.Execute(); err != nil {
+		fmt.Fprintln(root.ErrOrStderr(), "Error:", err)
+		os.Exit(1)
+	}
+}
+
+// client - API client for selected profile; flags and env override profile settings
+func (opts *options) client() (*Client, error) {
+	config, err := LoadConfig(opts.configPath)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := config.Profile(opts.profile)
+	if err != nil {
+		return nil, err
+	}
+	client := &Client{BaseURL: DefaultBaseURL, HTTP: opts.HTTP}
+	if profile != nil {
+		client.BaseURL, client.Token = profile.BaseURL, profile.Token
+	}
+	if opts.baseURL != "" {
+		client.BaseURL = opts.baseURL
+	}
+	if opts.token != "" {
+		client.Token = opts.token
+	}
+	return client, nil
+}
+
+// call - executes request and prints response in selected output format
+func (opts *options) call(cmd *cobra.Command, request *Request, v view) error {
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), opts.timeout)
+	defer cancel()
+	resp, err := client.Do(ctx, request)
+	if err != nil {
+		return err
+	}
+	return printResult(cmd.OutOrStdout(), opts.output, resp.Body, v)
+}
+
+// fixedCompletion - completion of flag or argument with fixed set of values
+func fixedCompletion(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// parseID - parses positional ID argument
+func parseID(arg string) (uint64, error) {
+	id, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID %q", arg)
+	}
+	return id, nil
+}
@@ -0,0 +1,332 @@
+package subctl
+
+import (
+	"em-test/cmd/internal/model"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var subscriptionStatuses = []string{model.StatusActive, model.StatusScheduled, model.StatusExpired, model.StatusCancelled}
+
+// subscriptionFlags - fields of subscription set from command-line flags
+type subscriptionFlags struct {
+	file       string
+	uid        string
+	provider   string
+	providerID uint64
+	price      uint
+	start      string
+	end        string
+	trialUntil string
+}
+
+func (sf *subscriptionFlags) register(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVarP(&sf.file, "file", "f", "", "JSON file with subscription fields, - for stdin; flags override its fields")
+	flags.StringVar(&sf.uid, "user", "", "user ID")
+	flags.StringVar(&sf.provider, "service", "", "service (provider) name")
+	flags.Uint64Var(&sf.providerID, "provider-id", 0, "ID of catalogued provider")
+	flags.UintVar(&sf.price, "price", 0, "monthly price")
+	flags.StringVar(&sf.start, "start", "", "start month, e.g. 07-2025")
+	flags.StringVar(&sf.end, "end", "", "end month, e.g. 12-2025")
+	flags.StringVar(&sf.trialUntil, "trial-until", "", "last month of trial period")
+}
+
+// subscription - builds subscription from file and changed flags
+func (sf *subscriptionFlags) subscription(cmd *cobra.Command) (*model.RawSubscription, error) {
+	var rawSub model.RawSubscription
+	if sf.file != "" {
+		if err := readJSONFile(cmd, sf.file, &rawSub); err != nil {
+			return nil, err
+		}
+	}
+	flags := cmd.Flags()
+	if flags.Changed("user") {
+		rawSub.UID = sf.uid
+	}
+	if flags.Changed("service") {
+		rawSub.Provider = sf.provider
+	}
+	if flags.Changed("provider-id") {
+		rawSub.ProviderID = &sf.providerID
+	}
+	if flags.Changed("price") {
+		rawSub.Price = &sf.price
+	}
+	if flags.Changed("start") {
+		rawSub.Start = sf.start
+	}
+	if flags.Changed("end") {
+		rawSub.End = sf.end
+	}
+	if flags.Changed("trial-until") {
+		rawSub.TrialUntil = sf.trialUntil
+	}
+	return &rawSub, nil
+}
+
+// readJSONFile - decodes JSON file or stdin ("-") into v
+func readJSONFile(cmd *cobra.Command, path string, v any) error {
+	reader, closeFn, err := openInput(cmd, path)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	if err := json.NewDecoder(reader).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// openInput - opens file or stdin ("-")
+func openInput(cmd *cobra.Command, path string) (io.Reader, func() error, error) {
+	if path == "-" {
+		return cmd.InOrStdin(), func() error { return nil }, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, file.Close, nil
+}
+
+// ifMatch - If-Match header with ETag of expected subscription version, none if version is 0
+func ifMatch(version uint64) http.Header {
+	if version == 0 {
+		return nil
+	}
+	return http.Header{"If-Match": {`"` + strconv.FormatUint(version, 10) + `"`}}
+}
+
+func subscriptionPath(sid uint64, suffix string) string {
+	return "/subscriptions/" + strconv.FormatUint(sid, 10) + suffix
+}
+
+func subscriptionsCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "subscriptions",
+		Aliases: []string{"subscription", "subs", "sub"},
+		Short:   "Manage subscriptions",
+	}
+
+	var createFlags subscriptionFlags
+	var createKey string
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create subscription",
+		Example: `  subctl subs create --user 60601fee-2bf1-4721-ae6f-7636e79a0cba --service "Yandex Plus" --price 400 --start 07-2025
+  subctl subs create -f subscription.json --idempotency-key 5f0c8d2e`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rawSub, err := createFlags.subscription(cmd)
+			if err != nil {
+				return err
+			}
+			request := &Request{Method: http.MethodPost, Path: "/subscriptions", JSON: rawSub}
+			if createKey != "" {
+				request.Header = http.Header{"Idempotency-Key": {createKey}}
+			}
+			return opts.call(cmd, request, subscriptionView)
+		},
+	}
+	createFlags.register(create)
+	create.Flags().StringVar(&createKey, "idempotency-key", "", "Idempotency-Key of request, repeated request with the same key gets the same response")
+
+	get := &cobra.Command{
+		Use:   "get SID",
+		Short: "Show subscription",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sid, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			return opts.call(cmd, &Request{Method: http.MethodGet, Path: subscriptionPath(sid, "")}, subscriptionView)
+		},
+	}
+
+	var status string
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List subscriptions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			if status != "" {
+				query.Set("status", status)
+			}
+			return opts.call(cmd, &Request{Method: http.MethodGet, Path: "/subscriptions", Query: query}, subscriptionView)
+		},
+	}
+	list.Flags().StringVar(&status, "status", "", "only subscriptions with status: "+strings.Join(subscriptionStatuses, ", "))
+	list.RegisterFlagCompletionFunc("status", fixedCompletion(subscriptionStatuses...))
+
+	var updateFlags subscriptionFlags
+	var updateVersion uint64
+	update := &cobra.Command{
+		Use:     "update SID",
+		Short:   "Update non-empty fields of subscription",
+		Example: `  subctl subs update 20 --price 500 --if-match 3`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sid, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			rawSub, err := updateFlags.subscription(cmd)
+			if err != nil {
+				return err
+			}
+			request := &Request{Method: http.MethodPut, Path: subscriptionPath(sid, ""), JSON: rawSub, Header: ifMatch(updateVersion)}
+			return opts.call(cmd, request, subscriptionView)
+		},
+	}
+	updateFlags.register(update)
+	update.Flags().Uint64Var(&updateVersion, "if-match", 0, "expected current version of subscription")
+
+	var deleteVersion uint64
+	remove := &cobra.Command{
+		Use:     "delete SID",
+		Aliases: []string{"rm"},
+		Short:   "Delete subscription",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sid, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			if err := opts.call(cmd, &Request{Method: http.MethodDelete, Path: subscriptionPath(sid, ""), Header: ifMatch(deleteVersion)}, view{}); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Subscription %d deleted\n", sid)
+			return nil
+		},
+	}
+	remove.Flags().Uint64Var(&deleteVersion, "if-match", 0, "expected current version of subscription")
+
+	cancel := &cobra.Command{
+		Use:   "cancel SID",
+		Short: "Cancel subscription at the end of current month",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sid, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			return opts.call(cmd, &Request{Method: http.MethodPost, Path: subscriptionPath(sid, "/cancel")}, subscriptionView)
+		},
+	}
+
+	var switchRequest model.SwitchRequest
+	var switchPrice uint
+	switchCmd := &cobra.Command{
+		Use:     "switch SID",
+		Short:   "Switch subscription to another plan",
+		Example: `  subctl subs switch 20 --service "Yandex Plus Family" --price 600 --month 07-2025`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sid, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("price") {
+				switchRequest.Price = &switchPrice
+			}
+			return opts.call(cmd, &Request{Method: http.MethodPost, Path: subscriptionPath(sid, "/switch"), JSON: &switchRequest}, subscriptionView)
+		},
+	}
+	switchCmd.Flags().StringVar(&switchRequest.Provider, "service", "", "service name of new plan, current one by default")
+	switchCmd.Flags().UintVar(&switchPrice, "price", 0, "price of new plan, current one by default")
+	switchCmd.Flags().StringVar(&switchRequest.SwitchMonth, "month", "", "last month of current plan, new plan starts next month")
+	switchCmd.MarkFlagRequired("month")
+
+	var importMode, importFormat string
+	var dryRun bool
+	importCmd := &cobra.Command{
+		Use:     "import FILE",
+		Short:   "Import subscriptions from CSV or NDJSON file, - for stdin",
+		Example: `  subctl subs import subscriptions.csv --dry-run`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format := importFormat
+			if format == "" {
+				format = strings.TrimPrefix(filepath.Ext(args[0]), ".")
+			}
+			contentType, ok := map[string]string{"csv": "text/csv", "ndjson": "application/x-ndjson", "jsonl": "application/x-ndjson"}[format]
+			if !ok {
+				return fmt.Errorf("unknown import format %q: expected csv or ndjson", format)
+			}
+			reader, closeFn, err := openInput(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+			query := url.Values{}
+			if importMode != "" {
+				query.Set("mode", importMode)
+			}
+			if dryRun {
+				query.Set("dry_run", "true")
+			}
+			request := &Request{Method: http.MethodPost, Path: "/subscriptions/import", Query: query, Body: reader, ContentType: contentType}
+			return opts.call(cmd, request, view{Columns: []string{"dry_run", "mode", "committed", "total", "valid", "failed", "created", "rows"}})
+		},
+	}
+	importCmd.Flags().StringVar(&importMode, "mode", "", "import mode: all_or_nothing or valid_only")
+	importCmd.Flags().StringVar(&importFormat, "format", "", "file format: csv or ndjson, by file extension if empty")
+	importCmd.Flags().BoolVar(&dryRun, "dry-run", false, "only validate rows")
+	importCmd.RegisterFlagCompletionFunc("mode", fixedCompletion(model.ImportModeAllOrNothing, model.ImportModeValidOnly))
+	importCmd.RegisterFlagCompletionFunc("format", fixedCompletion("csv", "ndjson"))
+
+	var batchFile, batchKey string
+	batch := &cobra.Command{
+		Use:     "batch",
+		Short:   "Execute create/update/delete operations from JSON file in one transaction",
+		Example: `  subctl subs batch -f operations.json`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var batchRequest model.BatchRequest
+			if err := readJSONFile(cmd, batchFile, &batchRequest); err != nil {
+				return err
+			}
+			request := &Request{Method: http.MethodPost, Path: "/subscriptions/batch", JSON: &batchRequest}
+			if batchKey != "" {
+				request.Header = http.Header{"Idempotency-Key": {batchKey}}
+			}
+			return opts.call(cmd, request, view{List: "results"})
+		},
+	}
+	batch.Flags().StringVarP(&batchFile, "file", "f", "-", "JSON file with operations, - for stdin")
+	batch.Flags().StringVar(&batchKey, "idempotency-key", "", "Idempotency-Key of request")
+
+	var within, upcomingUID string
+	upcoming := &cobra.Command{
+		Use:   "upcoming",
+		Short: "List subscriptions ending or renewing soon",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			if within != "" {
+				query.Set("within", within)
+			}
+			if upcomingUID != "" {
+				query.Set("user_id", upcomingUID)
+			}
+			return opts.call(cmd, &Request{Method: http.MethodGet, Path: "/subscriptions/upcoming", Query: query}, view{Columns: []string{"event", "date", "subscription"}})
+		},
+	}
+	upcoming.Flags().StringVar(&within, "within", "", "window: days (30d), weeks (2w) or Go duration, 30d by default")
+	upcoming.Flags().StringVar(&upcomingUID, "user", "", "user ID")
+
+	cmd.AddCommand(create, get, list, update, remove, cancel, switchCmd, importCmd, batch, upcoming)
+	return cmd
+}
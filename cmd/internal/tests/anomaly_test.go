@@ -0,0 +1,102 @@
+package tests_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+)
+
+func getAnomalies(t *testing.T, h *handler.SubscriptionHandler, query string) (*httptest.ResponseRecorder, model.AnomalyReport) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.Anomalies(rec, httptest.NewRequest(http.MethodGet, "/subscriptions/report/anomalies?"+query, nil))
+	var report model.AnomalyReport
+	json.Unmarshal(rec.Body.Bytes(), &report)
+	return rec, report
+}
+
+func TestAnomalies(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateHandler(db)
+	ph := handler.CreateProviderHandler(db)
+	ch := handler.CreateCategoryHandler(db)
+
+	createCategory(t, ch, model.RawCategory{Name: "Video"})
+	for _, name := range []string{"Netflix", "Kinopoisk", "Ivi"} {
+		createProvider(t, ph, model.RawProvider{Name: name, Category: "Video"})
+	}
+	month := func(offset int) string {
+		return time.Now().AddDate(0, offset, -time.Now().Day()+1).Format("01-2006")
+	}
+	price := func(p uint) *uint { return &p }
+
+	subs := []model.RawSubscription{
+		{Provider: "Netflix", Price: price(400), UID: "user1", Start: month(-2)},
+		{Provider: "Netflx", Price: price(400), UID: "user1", Start: month(0)}, // опечатка в названии - дубликат
+		{Provider: "Kinopoisk", Price: price(300), UID: "user1", Start: month(0)},
+		{Provider: "Ivi", Price: price(250), UID: "user1", Start: month(-1)},
+		{Provider: "Netflix", Price: price(400), UID: "user2", Start: month(0)},
+		{Provider: "Spotify", Price: price(200), UID: "user2", Start: month(-4), End: month(-2)},
+		{Provider: "Spotifi", Price: price(200), UID: "user2", Start: month(0)}, // не пересекается - не дубликат
+		{Provider: "Netflix", Price: price(2000), UID: "user3", Start: month(0)},
+		{Provider: "Netflix", Price: price(100), UID: "user4", Start: month(0)},
+	}
+	for _, sub := range subs {
+		if rec, _ := createSub(t, h, sub); rec.Code != http.StatusCreated {
+			t.Fatalf("Create: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	rec, report := getAnomalies(t, h, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Anomalies: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// 1. Дубликаты
+	if len(report.Duplicates) != 1 {
+		t.Fatalf("Anomalies: expected 1 duplicate pair, got %+v", report.Duplicates)
+	}
+	pair := report.Duplicates[0]
+	if pair.Subscriptions[0].Provider != "Netflix" || pair.Subscriptions[1].Provider != "Netflx" || pair.Similarity != 0.86 {
+		t.Errorf("Anomalies: unexpected duplicate pair %+v", pair)
+	}
+
+	// 2. Цены относительно медианы 400
+	if len(report.PriceOutliers) != 2 {
+		t.Fatalf("Anomalies: expected 2 price outliers, got %+v", report.PriceOutliers)
+	}
+	for _, outlier := range report.PriceOutliers {
+		if outlier.Median != 400 || (outlier.Subscription.UID != "user3" && outlier.Subscription.UID != "user4") {
+			t.Errorf("Anomalies: unexpected price outlier %+v", outlier)
+		}
+	}
+
+	// 3. Одновременные подписки одной категории
+	if len(report.CategoryOverloads) != 1 {
+		t.Fatalf("Anomalies: expected 1 category overload, got %+v", report.CategoryOverloads)
+	}
+	overload := report.CategoryOverloads[0]
+	if overload.UID != "user1" || overload.Category != "Video" || len(overload.Subscriptions) != 3 || overload.Period != month(0) {
+		t.Errorf("Anomalies: unexpected category overload %+v", overload)
+	}
+	if _, report := getAnomalies(t, h, "period="+month(-2)); len(report.CategoryOverloads) != 0 {
+		t.Errorf("Anomalies: expected no category overload in %s, got %+v", month(-2), report.CategoryOverloads)
+	}
+
+	// 4. Фильтр по пользователю не меняет медиану
+	_, report = getAnomalies(t, h, "uid=user3")
+	if len(report.Duplicates) != 0 || len(report.PriceOutliers) != 1 || len(report.CategoryOverloads) != 0 {
+		t.Errorf("Anomalies: unexpected report for user3 %+v", report)
+	}
+
+	for _, query := range []string{"similarity=2", "price_ratio=1", "max_concurrent=abc", "period=2025-07"} {
+		if rec, _ := getAnomalies(t, h, query); rec.Code != http.StatusBadRequest {
+			t.Errorf("Anomalies %s: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	database "em-test/cmd/internal/db"
+	"em-test/cmd/internal/migrations"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/service"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Benchmarks of report, list and overlap check on a seeded dataset, each run without indexes on subscriptions and with them:
+//
+//	go test ./cmd/internal/tests -run '^$' -bench . -benchtime 100x
+//
+// BENCH_SUBSCRIPTIONS sets approximate number of seeded subscriptions, 1M by default. BENCH_DATABASE_URL runs them against Postgres
+// with all migrations applied instead of in-memory sqlite; it must point to a scratch database: its monthly spend aggregate is dropped,
+// indexes of subscriptions are dropped and recreated and subscriptions are seeded once if there are none. A database which already
+// has subscriptions, including one seeded by a previous run, is refused unless BENCH_ALLOW_DESTRUCTIVE=1 is set
+const benchDefaultSize = 1_000_000
+
+// benchIndexes - indexes on subscriptions toggled by benchmarks; the primary key and provider_id index are kept
+var benchIndexes = []string{
+	"idx_subscriptions_user_id",
+	"idx_subscriptions_service_name",
+	"idx_subscriptions_start_end",
+	"idx_subscriptions_user_service_period",
+	"idx_subscriptions_service_period",
+	"idx_subscriptions_service_sid",
+	"idx_subscriptions_period",
+}
+
+var benchSeedNow = time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC)
+
+var (
+	benchOnce sync.Once
+	benchDB   *gorm.DB
+	benchUID  string
+	benchErr  error
+)
+
+// openBenchDB - database shared by all benchmarks, seeded on first use
+func openBenchDB(b *testing.B) (*gorm.DB, string) {
+	b.Helper()
+	benchOnce.Do(func() {
+		benchDB, benchUID, benchErr = seedBenchDB(context.Background(), b)
+	})
+	if benchErr != nil {
+		b.Fatalf("failed to prepare benchmark DB: %v", benchErr)
+	}
+	return benchDB, benchUID
+}
+
+func seedBenchDB(ctx context.Context, b *testing.B) (*gorm.DB, string, error) {
+	size := benchDefaultSize
+	if value := os.Getenv("BENCH_SUBSCRIPTIONS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return nil, "", fmt.Errorf("BENCH_SUBSCRIPTIONS: expected positive number, got %q", value)
+		}
+		size = parsed
+	}
+
+	var db *gorm.DB
+	if dsn := os.Getenv("BENCH_DATABASE_URL"); dsn != "" {
+		db = database.OpenPostgres(dsn)
+		if err := checkBenchDBDisposable(db); err != nil {
+			return nil, "", err
+		}
+		migrator, err := database.CreateMigrator(db, migrations.FS)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := migrator.Up(ctx); err != nil {
+			return nil, "", err
+		}
+	} else {
+		var err error
+		if db, err = gorm.Open(sqlite.Open(":memory:"), &gorm.Config{}); err != nil {
+			return nil, "", err
+		}
+		// каждое соединение с :memory: открывает свою пустую базу
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, "", err
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+	// логи запросов искажают замеры
+	db.Logger = logger.Default.LogMode(logger.Silent)
+	if err := database.Migrate(db); err != nil {
+		return nil, "", err
+	}
+
+	ms := service.CreateMaintenanceService(db)
+	// отчёты должны считаться по подпискам, а не по агрегату
+	if err := ms.Spend.Clear(ctx); err != nil {
+		return nil, "", err
+	}
+	var count int64
+	if err := db.Model(&model.Subscription{}).Count(&count).Error; err != nil {
+		return nil, "", err
+	}
+	if count == 0 {
+		started := time.Now()
+		seeded, err := ms.Seed(ctx, model.SeedOptions{Users: max(size/3, 1), SubsPerUser: 3, Months: 36, Seed: 1, Now: benchSeedNow})
+		if err != nil {
+			return nil, "", err
+		}
+		b.Logf("seeded %d subscriptions in %v", seeded, time.Since(started))
+	}
+
+	uids, _, err := service.CreateService(db).GetUserIDs(ctx, "", 1)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(uids) == 0 {
+		return nil, "", errors.New("no users seeded")
+	}
+	return db, uids[0], nil
+}
+
+// checkBenchDBDisposable - refuses database with subscriptions, as benchmarks drop its aggregate and indexes, unless BENCH_ALLOW_DESTRUCTIVE=1
+func checkBenchDBDisposable(db *gorm.DB) error {
+	if os.Getenv("BENCH_ALLOW_DESTRUCTIVE") == "1" || !db.Migrator().HasTable(&model.Subscription{}) {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&model.Subscription{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("BENCH_DATABASE_URL has %d subscriptions: benchmarks drop its monthly spend aggregate and indexes, set BENCH_ALLOW_DESTRUCTIVE=1 to run on it anyway", count)
+	}
+	return nil
+}
+
+// setBenchIndexes - drops benchIndexes or creates them as a migrated database has them, then refreshes planner statistics of Postgres
+func setBenchIndexes(b *testing.B, db *gorm.DB, on bool) {
+	b.Helper()
+	switch {
+	case !on:
+		for _, name := range benchIndexes {
+			if err := db.Exec("DROP INDEX IF EXISTS " + name).Error; err != nil {
+				b.Fatalf("DROP INDEX %s: %v", name, err)
+			}
+		}
+	case db.Dialector.Name() == "postgres":
+		for _, file := range []string{"002_add_indices.up.sql", "014_add_report_indexes.up.sql"} {
+			sql, err := fs.ReadFile(migrations.FS, file)
+			if err != nil {
+				b.Fatalf("read %s: %v", file, err)
+			}
+			if err := db.Exec(string(sql)).Error; err != nil {
+				b.Fatalf("apply %s: %v", file, err)
+			}
+		}
+	default:
+		// на sqlite доступны только индексы модели, GiST-индекс есть лишь у Postgres
+		for _, name := range []string{"idx_subscriptions_user_service_period", "idx_subscriptions_service_period", "idx_subscriptions_service_sid"} {
+			if err := db.Migrator().CreateIndex(&model.Subscription{}, name); err != nil {
+				b.Fatalf("CreateIndex %s: %v", name, err)
+			}
+		}
+	}
+	// статистику Postgres собирает autovacuum, у sqlite её нет, пока не запущен ANALYZE: с ней sqlite выбирает skip-scan
+	// по индексу сервиса для отчёта без фильтров, который медленнее полного просмотра
+	if db.Dialector.Name() == "postgres" {
+		if err := db.Exec("ANALYZE subscriptions").Error; err != nil {
+			b.Fatalf("ANALYZE: %v", err)
+		}
+	}
+}
+
+// withAndWithoutIndexes - runs fn as sub-benchmarks indexes=off and indexes=on, leaving indexes in place
+func withAndWithoutIndexes(b *testing.B, db *gorm.DB, fn func(b *testing.B)) {
+	for _, on := range []bool{false, true} {
+		b.Run("indexes="+map[bool]string{false: "off", true: "on"}[on], func(b *testing.B) {
+			setBenchIndexes(b, db, on)
+			fn(b)
+		})
+	}
+}
+
+func BenchmarkReport(b *testing.B) {
+	ctx := context.Background()
+	db, uid := openBenchDB(b)
+	ss := service.CreateService(db)
+	cases := []struct {
+		name   string
+		filter model.RawReportFilter
+	}{
+		{"all", model.RawReportFilter{Period: "06-2025"}},
+		{"user", model.RawReportFilter{Period: "06-2025", UID: uid}},
+		{"service", model.RawReportFilter{Period: "06-2025", Provider: "Netflix"}},
+		{"user_service", model.RawReportFilter{Period: "06-2025", UID: uid, Provider: "Netflix"}},
+	}
+
+	withAndWithoutIndexes(b, db, func(b *testing.B) {
+		for _, c := range cases {
+			b.Run(c.name, func(b *testing.B) {
+				for b.Loop() {
+					filter := c.filter
+					if _, err := ss.Report(ctx, &filter); err != nil {
+						b.Fatalf("Report %+v: unexpected error %v", c.filter, err)
+					}
+				}
+			})
+		}
+	})
+}
+
+func BenchmarkSubscriptionsPage(b *testing.B) {
+	ctx := context.Background()
+	db, uid := openBenchDB(b)
+	ss := service.CreateService(db)
+	cases := []struct {
+		name   string
+		filter model.SubscriptionPageFilter
+	}{
+		{"user", model.SubscriptionPageFilter{UID: uid, Limit: 50}},
+		{"service", model.SubscriptionPageFilter{Provider: "Netflix", Limit: 50}},
+		{"service_active", model.SubscriptionPageFilter{Provider: "Netflix", Status: model.StatusActive, Limit: 50}},
+	}
+
+	withAndWithoutIndexes(b, db, func(b *testing.B) {
+		for _, c := range cases {
+			b.Run(c.name, func(b *testing.B) {
+				for b.Loop() {
+					filter := c.filter
+					if _, _, err := ss.GetPage(ctx, &filter); err != nil {
+						b.Fatalf("GetPage %+v: unexpected error %v", c.filter, err)
+					}
+				}
+			})
+		}
+	})
+}
+
+func BenchmarkCheckIfExists(b *testing.B) {
+	ctx := context.Background()
+	db, uid := openBenchDB(b)
+	repo := repository.CreateRepo(db)
+	end := benchSeedNow.AddDate(0, 6, 0)
+	candidate := &model.Subscription{UID: uid, Provider: "Netflix", Start: benchSeedNow, End: &end}
+
+	withAndWithoutIndexes(b, db, func(b *testing.B) {
+		for b.Loop() {
+			if err := repo.CheckIfExists(ctx, candidate); !errors.Is(err, repository.ErrSubExists) && !errors.Is(err, repository.ErrSubNotFound) {
+				b.Fatalf("CheckIfExists: unexpected error %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+)
+
+func createBudget(t *testing.T, h *handler.BudgetHandler, budget model.RawBudget) *httptest.ResponseRecorder {
+	t.Helper()
+	bodyBytes, _ := json.Marshal(budget)
+	rec := httptest.NewRecorder()
+	h.Create(rec, httptest.NewRequest(http.MethodPost, "/budgets", bytes.NewReader(bodyBytes)))
+	return rec
+}
+
+func createSub(t *testing.T, h *handler.SubscriptionHandler, sub model.RawSubscription) (*httptest.ResponseRecorder, model.RawSubscription) {
+	t.Helper()
+	bodyBytes, _ := json.Marshal(sub)
+	rec := httptest.NewRecorder()
+	h.Create(rec, httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewReader(bodyBytes)))
+	var created model.RawSubscription
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	return rec, created
+}
+
+func TestBudgets(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateHandler(db)
+	bh := handler.CreateBudgetHandler(db)
+	ph := handler.CreateProviderHandler(db)
+	ch := handler.CreateCategoryHandler(db)
+
+	createCategory(t, ch, model.RawCategory{Name: "Entertainment"})
+	createProvider(t, ph, model.RawProvider{Name: "Netflix", Category: "Entertainment"})
+	createProvider(t, ph, model.RawProvider{Name: "Kinopoisk", Category: "Entertainment"})
+	thisMonth := time.Now().Format("01-2006")
+
+	// 1. Бюджеты: общий с предупреждением и по категории с отказом
+	total, entertainment := uint(1000), uint(500)
+	if rec := createBudget(t, bh, model.RawBudget{UID: "user1", Amount: &total}); rec.Code != http.StatusCreated {
+		t.Fatalf("Create budget: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := createBudget(t, bh, model.RawBudget{UID: "user1", Amount: &entertainment, Category: "Entertainment", Mode: model.BudgetModeReject})
+	var categoryBudget model.RawBudget
+	json.Unmarshal(rec.Body.Bytes(), &categoryBudget)
+	if rec.Code != http.StatusCreated || categoryBudget.CategoryID == nil || categoryBudget.Category != "Entertainment" {
+		t.Fatalf("Create budget: unexpected result %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := createBudget(t, bh, model.RawBudget{UID: "user1", Amount: &total, Mode: "block"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("Create budget: expected status 400 for unknown mode, got %d", rec.Code)
+	}
+
+	// 2. Подписка в пределах бюджетов
+	price := uint(400)
+	rec, created := createSub(t, h, model.RawSubscription{Provider: "Netflix", Price: &price, UID: "user1", Start: thisMonth})
+	if rec.Code != http.StatusCreated || len(created.Warnings) != 0 {
+		t.Fatalf("Create: expected 201 without warnings, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// 3. Превышение бюджета категории в режиме reject - отказ
+	kinoPrice := uint(300)
+	rec, _ = createSub(t, h, model.RawSubscription{Provider: "Kinopoisk", Price: &kinoPrice, UID: "user1", Start: thisMonth})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Create: expected status 422 for exceeded budget, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var count int64
+	db.Model(&model.Subscription{}).Where("service_name = ?", "Kinopoisk").Count(&count)
+	if count != 0 {
+		t.Errorf("Create: rejected subscription was stored")
+	}
+
+	// 4. Превышение общего бюджета в режиме warn - предупреждение; подписка другого пользователя не затрагивается
+	spotifyPrice := uint(700)
+	rec, created = createSub(t, h, model.RawSubscription{Provider: "Spotify", Price: &spotifyPrice, UID: "user1", Start: thisMonth})
+	if rec.Code != http.StatusCreated || len(created.Warnings) != 1 || !strings.Contains(created.Warnings[0], "1100") {
+		t.Fatalf("Create: expected 201 with one warning, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec, created = createSub(t, h, model.RawSubscription{Provider: "Kinopoisk", Price: &kinoPrice, UID: "user2", Start: thisMonth})
+	if rec.Code != http.StatusCreated || len(created.Warnings) != 0 {
+		t.Fatalf("Create: expected 201 for user without budgets, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// 5. Отчет о превышениях
+	rec = httptest.NewRecorder()
+	bh.OverBudget(rec, httptest.NewRequest(http.MethodGet, "/budgets/over?period="+thisMonth, nil))
+	var over []model.BudgetStatus
+	json.Unmarshal(rec.Body.Bytes(), &over)
+	if rec.Code != http.StatusOK || len(over) != 1 || over[0].Spent != 1100 || over[0].OverBy != 100 || over[0].Budget.UID != "user1" {
+		t.Fatalf("OverBudget: unexpected result %d %s", rec.Code, rec.Body.String())
+	}
+}
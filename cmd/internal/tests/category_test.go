@@ -0,0 +1,122 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+)
+
+func createCategory(t *testing.T, h *handler.CategoryHandler, category model.RawCategory) (*httptest.ResponseRecorder, model.RawCategory) {
+	t.Helper()
+	bodyBytes, _ := json.Marshal(category)
+	rec := httptest.NewRecorder()
+	h.Create(rec, httptest.NewRequest(http.MethodPost, "/categories", bytes.NewReader(bodyBytes)))
+
+	var created model.RawCategory
+	if rec.Code == http.StatusCreated {
+		if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+			t.Fatalf("Create category: failed to parse response: %v", err)
+		}
+	}
+	return rec, created
+}
+
+func getReport(t *testing.T, h *handler.SubscriptionHandler, query url.Values) (*httptest.ResponseRecorder, model.Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.Report(rec, httptest.NewRequest(http.MethodGet, "/subscriptions/report?"+query.Encode(), nil))
+
+	var report model.Report
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("Report: failed to parse response: %v", err)
+		}
+	}
+	return rec, report
+}
+
+func TestCategoryReports(t *testing.T) {
+	db := SetupTestDB(t)
+	subHandler := handler.CreateHandler(db)
+	providerHandler := handler.CreateProviderHandler(db)
+	categoryHandler := handler.CreateCategoryHandler(db)
+
+	// 1. Дерево категорий: Entertainment > Video, Entertainment > Music, Cloud
+	_, entertainment := createCategory(t, categoryHandler, model.RawCategory{Name: "Entertainment"})
+	rec, video := createCategory(t, categoryHandler, model.RawCategory{Name: "Video", ParentID: entertainment.ID})
+	if rec.Code != http.StatusCreated || video.Path != "Entertainment > Video" {
+		t.Fatalf("Create category: expected path Entertainment > Video, got %d %+v", rec.Code, video)
+	}
+	createCategory(t, categoryHandler, model.RawCategory{Name: "Music", ParentID: entertainment.ID})
+	createCategory(t, categoryHandler, model.RawCategory{Name: "Cloud"})
+
+	rec, _ = createCategory(t, categoryHandler, model.RawCategory{Name: "video", ParentID: entertainment.ID})
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Create category: expected status 409 for duplicate sibling, got %d", rec.Code)
+	}
+
+	// 2. Провайдеры с категориями по пути и по ID
+	for _, provider := range []model.RawProvider{
+		{Name: "Netflix", Category: "Entertainment > Video"},
+		{Name: "Spotify", Category: "music"},
+		{Name: "iCloud", Category: "Cloud"},
+	} {
+		rec, created := createProvider(t, providerHandler, provider)
+		if rec.Code != http.StatusCreated || created.CategoryID == nil {
+			t.Fatalf("Create provider %s: expected category to be attached, got %d: %s", provider.Name, rec.Code, rec.Body.String())
+		}
+	}
+
+	for _, sub := range []model.Subscription{
+		{Provider: "Netflix", Price: 300, UID: "user1", Start: *mustParseDate("01-2025")},
+		{Provider: "Netflix", Price: 300, UID: "user2", Start: *mustParseDate("01-2025")},
+		{Provider: "Spotify", Price: 200, UID: "user1", Start: *mustParseDate("01-2025")},
+		{Provider: "iCloud", Price: 100, UID: "user1", Start: *mustParseDate("01-2025")},
+		{Provider: "Kinopoisk", Price: 50, UID: "user1", Start: *mustParseDate("01-2025")},
+	} {
+		raw := model.RawSubscription{Provider: sub.Provider, Price: &sub.Price, UID: sub.UID, Start: "01-2025"}
+		bodyBytes, _ := json.Marshal(raw)
+		createRec := httptest.NewRecorder()
+		subHandler.Create(createRec, httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewReader(bodyBytes)))
+		if createRec.Code != http.StatusCreated {
+			t.Fatalf("Create subscription: expected status 201, got %d", createRec.Code)
+		}
+	}
+
+	// 3. Фильтр по родительской категории включает подкатегории
+	_, report := getReport(t, subHandler, url.Values{"period": {"03-2025"}, "category": {"Entertainment"}})
+	if report.Total != 800 {
+		t.Errorf("Report by category: expected total 800, got %d", report.Total)
+	}
+
+	// 4. Группировка по категориям
+	rec, report = getReport(t, subHandler, url.Values{"period": {"03-2025"}, "uid": {"user1"}, "group_by": {"category"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Report group_by: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	totals := map[string]uint{}
+	for _, group := range report.Groups {
+		totals[group.Category] = group.Total
+	}
+	expected := map[string]uint{"Entertainment": 500, "Entertainment > Video": 300, "Entertainment > Music": 200, "Cloud": 100, "": 50}
+	if report.Total != 650 || len(totals) != len(expected) {
+		t.Fatalf("Report group_by: unexpected report %+v", report)
+	}
+	for category, total := range expected {
+		if totals[category] != total {
+			t.Errorf("Report group_by: expected %q total %d, got %d", category, total, totals[category])
+		}
+	}
+
+	// 5. Неизвестная категория
+	rec, _ = getReport(t, subHandler, url.Values{"period": {"03-2025"}, "category": {"Games"}})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Report: expected status 400 for unknown category, got %d", rec.Code)
+	}
+}
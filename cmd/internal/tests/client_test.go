@@ -0,0 +1,373 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+	"em-test/pkg/client"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// apiRouter - routes of cmd/main.go with real handlers
+func apiRouter(db *gorm.DB) chi.Router {
+	subHandler := handler.CreateHandler(db)
+	idempotency := handler.CreateIdempotencyHandler(db)
+	providerHandler := handler.CreateProviderHandler(db)
+	categoryHandler := handler.CreateCategoryHandler(db)
+	webhookHandler := handler.CreateWebhookHandler(db)
+	eventHandler := handler.CreateEventHandler(db)
+	eventHandler.PollInterval = 10 * time.Millisecond
+	budgetHandler := handler.CreateBudgetHandler(db)
+	graphqlHandler := handler.CreateGraphQLHandler(db)
+
+	r := chi.NewRouter()
+	r.Post("/subscriptions", idempotency.Idempotent(subHandler.Create))
+	r.Post("/subscriptions/import", subHandler.Import)
+	r.Post("/subscriptions/batch", idempotency.Idempotent(subHandler.Batch))
+	r.Get("/subscriptions", subHandler.GetList)
+	r.Get("/subscriptions/{sid}", subHandler.GetBySID)
+	r.Delete("/subscriptions/{sid}", subHandler.Delete)
+	r.Put("/subscriptions/{sid}", subHandler.UpdateBySID)
+	r.Patch("/subscriptions/{sid}", subHandler.UpdateBySID)
+	r.Post("/subscriptions/{sid}/switch", subHandler.Switch)
+	r.Post("/subscriptions/{sid}/cancel", subHandler.Cancel)
+	r.Get("/subscriptions/report", subHandler.Report)
+	r.Get("/subscriptions/report/trials", subHandler.TrialReport)
+	r.Get("/subscriptions/report/anomalies", subHandler.Anomalies)
+	r.Get("/subscriptions/report/cache", subHandler.ReportCacheStats)
+	r.Get("/subscriptions/upcoming", subHandler.Upcoming)
+	r.Get("/subscriptions/forecast", subHandler.Forecast)
+	r.Post("/providers", providerHandler.Create)
+	r.Get("/providers", providerHandler.GetList)
+	r.Get("/providers/{id}", providerHandler.GetByID)
+	r.Put("/providers/{id}", providerHandler.UpdateByID)
+	r.Delete("/providers/{id}", providerHandler.Delete)
+	r.Post("/categories", categoryHandler.Create)
+	r.Get("/categories", categoryHandler.GetList)
+	r.Get("/categories/{id}", categoryHandler.GetByID)
+	r.Put("/categories/{id}", categoryHandler.UpdateByID)
+	r.Delete("/categories/{id}", categoryHandler.Delete)
+	r.Post("/budgets", budgetHandler.Create)
+	r.Get("/budgets", budgetHandler.GetList)
+	r.Get("/budgets/over", budgetHandler.OverBudget)
+	r.Get("/budgets/{id}", budgetHandler.GetByID)
+	r.Put("/budgets/{id}", budgetHandler.UpdateByID)
+	r.Delete("/budgets/{id}", budgetHandler.Delete)
+	r.Post("/webhooks", webhookHandler.Create)
+	r.Get("/webhooks", webhookHandler.GetList)
+	r.Get("/webhooks/deliveries", webhookHandler.GetDeliveries)
+	r.Post("/webhooks/deliveries/{id}/retry", webhookHandler.RetryDelivery)
+	r.Get("/webhooks/{id}", webhookHandler.GetByID)
+	r.Put("/webhooks/{id}", webhookHandler.UpdateByID)
+	r.Delete("/webhooks/{id}", webhookHandler.Delete)
+	r.Get("/events", eventHandler.GetList)
+	r.Get("/events/stream", eventHandler.Stream)
+	r.Post("/graphql", graphqlHandler.Query)
+	return r
+}
+
+// setupClient - client of httptest server with real handlers; retries are fast
+func setupClient(t *testing.T, wrap func(http.Handler) http.Handler) *client.Client {
+	var h http.Handler = apiRouter(SetupTestDB(t))
+	if wrap != nil {
+		h = wrap(h)
+	}
+	server := httptest.NewServer(h)
+	t.Cleanup(server.Close)
+	c := client.CreateClient(server.URL)
+	c.Retry = client.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+	return c
+}
+
+func expectErr(t *testing.T, step string, err error, targets ...error) {
+	t.Helper()
+	for _, target := range targets {
+		if !errors.Is(err, target) {
+			t.Errorf("%s: expected error matching %v, got %v", step, target, err)
+		}
+	}
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		t.Errorf("%s: expected *client.Error, got %T", step, err)
+	}
+}
+
+func TestClientSubscriptions(t *testing.T) {
+	c := setupClient(t, nil)
+	ctx := context.Background()
+
+	// 1. Создание, получение и список
+	created, err := c.CreateSubscription(ctx, &client.Subscription{UID: "user1", Provider: "Netflix", Price: client.Ptr[uint](500), Start: "07-2025"}, "")
+	if err != nil || created.SID == nil || *created.Version != 1 {
+		t.Fatalf("Create: expected subscription with version 1, got %+v (%v)", created, err)
+	}
+	sid := *created.SID
+	got, err := c.GetSubscription(ctx, sid)
+	if err != nil || got.Provider != "Netflix" || got.Status != client.StatusActive {
+		t.Fatalf("Get: expected active Netflix, got %+v (%v)", got, err)
+	}
+	_, err = c.CreateSubscription(ctx, &client.Subscription{UID: "user1", Provider: "Netflix", Price: client.Ptr[uint](500), Start: "08-2025"}, "")
+	expectErr(t, "Create duplicate", err, client.ErrSubExists, client.ErrConflict)
+	if subs, err := c.ListSubscriptions(ctx, client.StatusActive); err != nil || len(subs) != 1 {
+		t.Fatalf("List: expected 1 active subscription, got %+v (%v)", subs, err)
+	}
+
+	// 2. Повтор с тем же ключом идемпотентности не создает дубликат
+	sub := &client.Subscription{UID: "user2", Provider: "Kinopoisk", Price: client.Ptr[uint](300), Start: "07-2025"}
+	first, err := c.CreateSubscription(ctx, sub, "create-kinopoisk")
+	if err != nil {
+		t.Fatalf("Create with key: unexpected error %v", err)
+	}
+	second, err := c.CreateSubscription(ctx, sub, "create-kinopoisk")
+	if err != nil || *second.SID != *first.SID {
+		t.Fatalf("Repeat with key: expected SID %d, got %+v (%v)", *first.SID, second, err)
+	}
+
+	// 3. Обновление с версией и конфликт версий
+	updated, err := c.UpdateSubscription(ctx, sid, &client.Subscription{Price: client.Ptr[uint](700), Version: client.Ptr[uint64](1)})
+	if err != nil || *updated.Price != 700 || *updated.Version != 2 {
+		t.Fatalf("Update: expected price 700 and version 2, got %+v (%v)", updated, err)
+	}
+	_, err = c.UpdateSubscription(ctx, sid, &client.Subscription{Price: client.Ptr[uint](800), Version: client.Ptr[uint64](1)})
+	expectErr(t, "Update stale", err, client.ErrVersionMismatch, client.ErrPreconditionFailed)
+
+	// 4. Отчет
+	report, err := c.Report(ctx, client.ReportFilter{Period: "07-2025"})
+	if err != nil || report.Total != 1000 {
+		t.Fatalf("Report: expected total 1000, got %+v (%v)", report, err)
+	}
+	_, err = c.Report(ctx, client.ReportFilter{})
+	expectErr(t, "Report without period", err, client.ErrBadRequest)
+
+	// 5. Пакет откатывается и возвращает результат вместе с ошибкой
+	result, err := c.BatchSubscriptions(ctx, &client.BatchRequest{Operations: []client.BatchOperation{
+		{Op: client.BatchOpUpdate, SID: &sid, Subscription: &client.Subscription{End: "12-2025"}},
+		{Op: client.BatchOpDelete, SID: client.Ptr[uint64](1000)},
+	}}, "")
+	expectErr(t, "Batch", err, client.ErrSubNotFound, client.ErrNotFound)
+	if result == nil || result.Committed || len(result.Results) != 2 || result.Results[1].Status != client.BatchOpFailed {
+		t.Fatalf("Batch: expected rolled back result, got %+v", result)
+	}
+
+	// 6. Переход на другой тариф, отмена, удаление
+	switched, err := c.SwitchSubscription(ctx, sid, &client.SwitchRequest{Provider: "Netflix Premium", Price: client.Ptr[uint](900), SwitchMonth: "09-2025"})
+	if err != nil || switched.Next == nil || *switched.Next.PreviousSID != sid {
+		t.Fatalf("Switch: expected successor of %d, got %+v (%v)", sid, switched, err)
+	}
+	if _, err := c.CancelSubscription(ctx, *switched.Next.SID); err != nil {
+		t.Fatalf("Cancel: unexpected error %v", err)
+	}
+	_, err = c.CancelSubscription(ctx, *switched.Next.SID)
+	expectErr(t, "Cancel twice", err, client.ErrSubCancelled, client.ErrConflict)
+	if err := c.DeleteSubscription(ctx, *first.SID, nil); err != nil {
+		t.Fatalf("Delete: unexpected error %v", err)
+	}
+	_, err = c.GetSubscription(ctx, *first.SID)
+	expectErr(t, "Get deleted", err, client.ErrSubNotFound, client.ErrNotFound)
+	err = c.DeleteSubscription(ctx, *first.SID, nil)
+	expectErr(t, "Delete deleted", err, client.ErrSubNotFound)
+
+	// 7. Импорт с невалидной строкой возвращает отчет вместе с ошибкой
+	payload := "service_name,price,user_id,start_date,end_date\nOkko,200,user3,07-2025,\nOkko,oops,user3,07-2025,\n"
+	imported, err := c.ImportSubscriptions(ctx, client.ImportCSV, strings.NewReader(payload), client.ImportOptions{})
+	expectErr(t, "Import", err, client.ErrUnprocessable)
+	if imported == nil || imported.Total != 2 || imported.Failed != 1 || imported.Committed {
+		t.Fatalf("Import: expected report of 2 rows with 1 failed, got %+v", imported)
+	}
+}
+
+func TestClientCatalog(t *testing.T) {
+	c := setupClient(t, nil)
+	ctx := context.Background()
+
+	video, err := c.CreateCategory(ctx, &client.Category{Name: "Video"})
+	if err != nil {
+		t.Fatalf("Create category: unexpected error %v", err)
+	}
+	provider, err := c.CreateProvider(ctx, &client.Provider{Name: "Netflix", Aliases: []string{"netflix.com"}, CategoryID: video.ID})
+	if err != nil || provider.Category != "Video" {
+		t.Fatalf("Create provider: expected category Video, got %+v (%v)", provider, err)
+	}
+	_, err = c.CreateProvider(ctx, &client.Provider{Name: "NETFLIX"})
+	expectErr(t, "Create provider duplicate", err, client.ErrProviderExists, client.ErrConflict)
+	_, err = c.GetProvider(ctx, 100)
+	expectErr(t, "Get missing provider", err, client.ErrProviderNotFound, client.ErrNotFound)
+	err = c.DeleteCategory(ctx, *video.ID)
+	expectErr(t, "Delete used category", err, client.ErrCategoryInUse)
+
+	// бюджет в режиме reject отклоняет подписку сверх лимита
+	if _, err := c.CreateBudget(ctx, &client.Budget{UID: "user1", Amount: client.Ptr[uint](500), Mode: client.BudgetModeReject}); err != nil {
+		t.Fatalf("Create budget: unexpected error %v", err)
+	}
+	_, err = c.CreateSubscription(ctx, &client.Subscription{UID: "user1", Provider: "netflix.com", Price: client.Ptr[uint](600), Start: "07-2025"}, "")
+	expectErr(t, "Create over budget", err, client.ErrBudgetExceeded, client.ErrUnprocessable)
+	budgets, err := c.ListBudgets(ctx, "user1")
+	if err != nil || len(budgets) != 1 {
+		t.Fatalf("List budgets: expected 1, got %+v (%v)", budgets, err)
+	}
+
+	_, err = c.RetryDelivery(ctx, 100)
+	expectErr(t, "Retry missing delivery", err, client.ErrDeliveryNotFound, client.ErrNotFound)
+
+	// GraphQL и журнал событий
+	sub, err := c.CreateSubscription(ctx, &client.Subscription{UID: "user1", Provider: "netflix.com", Price: client.Ptr[uint](400), Start: "07-2025"}, "")
+	if err != nil || sub.Provider != "Netflix" {
+		t.Fatalf("Create by alias: expected Netflix, got %+v (%v)", sub, err)
+	}
+	var data struct {
+		Subscription struct {
+			ServiceName string `json:"serviceName"`
+			Provider    struct {
+				Category string `json:"category"`
+			} `json:"provider"`
+		} `json:"subscription"`
+	}
+	err = c.GraphQL(ctx, `query($id: ID!) { subscription(id: $id) { serviceName provider { category } } }`, map[string]any{"id": strconv.FormatUint(*sub.SID, 10)}, &data)
+	if err != nil || data.Subscription.Provider.Category != "Video" {
+		t.Fatalf("GraphQL: expected provider category Video, got %+v (%v)", data, err)
+	}
+	var gqlErrs client.GraphQLErrors
+	if err := c.GraphQL(ctx, `{ subscription(id: "x") { id } }`, nil, nil); !errors.As(err, &gqlErrs) {
+		t.Errorf("GraphQL bad ID: expected GraphQLErrors, got %v", err)
+	}
+
+	page, err := c.ListEvents(ctx, 0, 10)
+	if err != nil || len(page.Events) != 1 || page.Events[0].Type != client.EventSubscriptionCreated {
+		t.Fatalf("Events: expected subscription.created, got %+v (%v)", page, err)
+	}
+	streamCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	stop := errors.New("stop")
+	var streamed *client.Event
+	err = c.StreamEvents(streamCtx, 0, func(event *client.Event) error {
+		streamed = event
+		return stop
+	})
+	if !errors.Is(err, stop) || streamed == nil || *streamed.Subscription.SID != *sub.SID {
+		t.Fatalf("Stream: expected event of subscription %d, got %+v (%v)", *sub.SID, streamed, err)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	// сервер отвечает 503 на первые две попытки каждого запроса
+	var calls atomic.Int64
+	var mu sync.Mutex
+	failures := map[string]int{}
+	c := setupClient(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			key := r.Method + " " + r.URL.Path
+			mu.Lock()
+			failures[key]++
+			failed := failures[key] <= 2
+			mu.Unlock()
+			if failed {
+				http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	ctx := context.Background()
+
+	// POST с ключом идемпотентности и GET повторяются
+	created, err := c.CreateSubscription(ctx, &client.Subscription{UID: "user1", Provider: "Netflix", Price: client.Ptr[uint](500), Start: "07-2025"}, "")
+	if err != nil || calls.Load() != 3 {
+		t.Fatalf("Create: expected success on 3rd attempt, got %d calls (%v)", calls.Load(), err)
+	}
+	if _, err := c.GetSubscription(ctx, *created.SID); err != nil || calls.Load() != 6 {
+		t.Fatalf("Get: expected success on 3rd attempt, got %d calls (%v)", calls.Load(), err)
+	}
+
+	// POST без ключа не повторяется
+	_, err = c.CancelSubscription(ctx, *created.SID)
+	expectErr(t, "Cancel", err, client.ErrServer)
+	if calls.Load() != 7 {
+		t.Errorf("Cancel: expected single attempt, got %d calls", calls.Load()-6)
+	}
+
+	// попытки кончаются
+	c.Retry.MaxAttempts = 2
+	_, err = c.ListProviders(ctx)
+	expectErr(t, "List providers", err, client.ErrServer)
+	if calls.Load() != 9 {
+		t.Errorf("List providers: expected 2 attempts, got %d calls", calls.Load()-7)
+	}
+
+	// отмена контекста прерывает ожидание повтора
+	c.Retry = client.RetryPolicy{MaxAttempts: 5, MinBackoff: time.Hour, MaxBackoff: time.Hour}
+	cancelled, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.ListBudgets(cancelled, ""); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("List budgets: expected deadline exceeded, got %v", err)
+	}
+}
+
+// TestClientTypes - JSON fields of client types match models of the server
+func TestClientTypes(t *testing.T) {
+	pairs := []struct {
+		client, server any
+	}{
+		{client.Subscription{}, model.RawSubscription{}},
+		{client.SwitchRequest{}, model.SwitchRequest{}},
+		{client.UpcomingSubscription{}, model.UpcomingSubscription{}},
+		{client.ImportReport{}, model.ImportReport{}},
+		{client.ImportRowResult{}, model.ImportRowResult{}},
+		{client.BatchOperation{}, model.BatchOperation{}},
+		{client.BatchOpResult{}, model.BatchOpResult{}},
+		{client.BatchResult{}, model.BatchResult{}},
+		{client.Report{}, model.Report{}},
+		{client.ReportGroup{}, model.ReportGroup{}},
+		{client.TrialReport{}, model.TrialReport{}},
+		{client.ReportCacheStats{}, model.ReportCacheStats{}},
+		{client.Forecast{}, model.Forecast{}},
+		{client.ForecastMonth{}, model.ForecastMonth{}},
+		{client.AnomalyReport{}, model.AnomalyReport{}},
+		{client.DuplicateSubscriptions{}, model.DuplicateSubscriptions{}},
+		{client.PriceOutlier{}, model.PriceOutlier{}},
+		{client.CategoryOverload{}, model.CategoryOverload{}},
+		{client.Provider{}, model.RawProvider{}},
+		{client.Category{}, model.RawCategory{}},
+		{client.Budget{}, model.RawBudget{}},
+		{client.BudgetStatus{}, model.BudgetStatus{}},
+		{client.Webhook{}, model.RawWebhook{}},
+		{client.WebhookDelivery{}, model.WebhookDelivery{}},
+		{client.Event{}, model.EventPayload{}},
+		{client.EventPage{}, model.EventPage{}},
+	}
+	for _, pair := range pairs {
+		clientType, serverType := reflect.TypeOf(pair.client), reflect.TypeOf(pair.server)
+		if got, want := jsonFields(clientType), jsonFields(serverType); !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: expected fields of %v %v, got %v", clientType, serverType, want, got)
+		}
+	}
+}
+
+// jsonFields - JSON tags of struct fields including embedded structs
+func jsonFields(typ reflect.Type) []string {
+	var fields []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+		if tag := field.Tag.Get("json"); tag != "" && tag != "-" {
+			fields = append(fields, tag)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,137 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// doConditional - sends request to subscription handler with sid URL param and provided headers
+func doConditional(fn http.HandlerFunc, method string, sid uint64, body any, headers map[string]string) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		bodyBytes, _ := json.Marshal(body)
+		reader = bytes.NewReader(bodyBytes)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, "/subscriptions/"+strconv.FormatUint(sid, 10), reader)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	chiCtx := chi.NewRouteContext()
+	chiCtx.URLParams.Add("sid", strconv.FormatUint(sid, 10))
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+	rec := httptest.NewRecorder()
+	fn(rec, req)
+	return rec
+}
+
+func TestETagAndConditionalRequests(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateHandler(db)
+	price := uint(400)
+	rec, created := createSub(t, h, model.RawSubscription{Provider: "Netflix", Price: &price, UID: "user1", Start: "07-2025"})
+	if rec.Code != http.StatusCreated || created.Version == nil || *created.Version != 1 || rec.Header().Get("ETag") != `"1-active"` {
+		t.Fatalf("Create: expected version 1 with ETag \"1-active\", got %d %q: %s", rec.Code, rec.Header().Get("ETag"), rec.Body.String())
+	}
+	sid := *created.SID
+
+	// 1. Чтение с ETag и If-None-Match
+	rec = doConditional(h.GetBySID, http.MethodGet, sid, nil, nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"1-active"` {
+		t.Fatalf("GetBySID: expected ETag \"1-active\", got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+	for _, tag := range []string{`"1-active"`, `W/"1-active"`, `"5-active", "1-active"`, "*"} {
+		if rec := doConditional(h.GetBySID, http.MethodGet, sid, nil, map[string]string{"If-None-Match": tag}); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("GetBySID If-None-Match %s: expected 304 without body, got %d", tag, rec.Code)
+		}
+	}
+	if rec := doConditional(h.GetBySID, http.MethodGet, sid, nil, map[string]string{"If-None-Match": `"2-active"`}); rec.Code != http.StatusOK {
+		t.Errorf("GetBySID: expected 200 for stale If-None-Match, got %d", rec.Code)
+	}
+	// статус меняется со временем без новой версии, как при окончании подписки
+	db.Model(&model.Subscription{}).Where("subscription_id = ?", sid).Update("end_date", time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC))
+	rec = doConditional(h.GetBySID, http.MethodGet, sid, nil, map[string]string{"If-None-Match": `"1-active"`})
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"1-expired"` {
+		t.Errorf("GetBySID after status change: expected 200 with ETag \"1-expired\", got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+	db.Model(&model.Subscription{}).Where("subscription_id = ?", sid).Update("end_date", nil)
+
+	// 2. Обновление с актуальной и устаревшей версией
+	newPrice := uint(500)
+	rec = doConditional(h.UpdateBySID, http.MethodPut, sid, model.RawSubscription{Price: &newPrice}, map[string]string{"If-Match": `"1-expired"`})
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"2-active"` {
+		t.Fatalf("UpdateBySID: expected 200 with ETag \"2-active\" regardless of status in If-Match, got %d %q: %s", rec.Code, rec.Header().Get("ETag"), rec.Body.String())
+	}
+	stalePrice := uint(600)
+	for _, tag := range []string{`"1"`, `"1-active"`, `W/"2-active"`, "garbage"} {
+		rec = doConditional(h.UpdateBySID, http.MethodPut, sid, model.RawSubscription{Price: &stalePrice}, map[string]string{"If-Match": tag})
+		if rec.Code != http.StatusPreconditionFailed {
+			t.Errorf("UpdateBySID If-Match %s: expected 412, got %d", tag, rec.Code)
+		}
+	}
+	current, _ := h.Service.GetBySID(context.Background(), sid)
+	if *current.Price != 500 || *current.Version != 2 {
+		t.Errorf("UpdateBySID: stale update was applied: %+v", current)
+	}
+
+	// 3. PATCH с версией в теле
+	staleVersion, version := uint64(1), uint64(2)
+	if rec := doConditional(h.UpdateBySID, http.MethodPatch, sid, model.RawSubscription{Price: &stalePrice, Version: &staleVersion}, nil); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("UpdateBySID PATCH: expected 412 for stale version in body, got %d", rec.Code)
+	}
+	if rec := doConditional(h.UpdateBySID, http.MethodPatch, sid, model.RawSubscription{Price: &stalePrice, Version: &version}, nil); rec.Code != http.StatusOK {
+		t.Errorf("UpdateBySID PATCH: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// 4. Пакетное обновление с устаревшей версией откатывается
+	rec, _ = doBatch(t, h, model.BatchRequest{Operations: []model.BatchOperation{
+		{Op: model.BatchOpUpdate, SID: &sid, Subscription: &model.RawSubscription{Price: &price, Version: &version}},
+	}})
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("Batch: expected 412 for stale version, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// 5. Конкурентная запись поверх прочитанной версии
+	repo := repository.CreateRepo(db)
+	first, _ := repo.GetSubscriptionBySID(context.Background(), sid)
+	second, _ := repo.GetSubscriptionBySID(context.Background(), sid)
+	first.Price = 700
+	if err := repo.UpdateSubscriptionInfo(context.Background(), first); err != nil || first.Version != 4 {
+		t.Fatalf("UpdateSubscriptionInfo: unexpected result %v, version %d", err, first.Version)
+	}
+	second.Price = 800
+	if err := repo.UpdateSubscriptionInfo(context.Background(), second); !errors.Is(err, repository.ErrVersionMismatch) || second.Version != 3 {
+		t.Errorf("UpdateSubscriptionInfo: expected ErrVersionMismatch, got %v, version %d", err, second.Version)
+	}
+
+	// 6. Обязательный If-Match
+	h.RequireIfMatch = true
+	if rec := doConditional(h.UpdateBySID, http.MethodPut, sid, model.RawSubscription{Price: &price}, nil); rec.Code != http.StatusPreconditionRequired {
+		t.Errorf("UpdateBySID: expected 428 without If-Match, got %d", rec.Code)
+	}
+	if rec := doConditional(h.Delete, http.MethodDelete, sid, nil, nil); rec.Code != http.StatusPreconditionRequired {
+		t.Errorf("Delete: expected 428 without If-Match, got %d", rec.Code)
+	}
+
+	// 7. Удаление
+	if rec := doConditional(h.Delete, http.MethodDelete, sid, nil, map[string]string{"If-Match": `"3"`}); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("Delete: expected 412 for stale If-Match, got %d", rec.Code)
+	}
+	if rec := doConditional(h.Delete, http.MethodDelete, sid, nil, map[string]string{"If-Match": `"4"`}); rec.Code != http.StatusNoContent {
+		t.Errorf("Delete: expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
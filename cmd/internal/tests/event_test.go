@@ -0,0 +1,124 @@
+package tests_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+)
+
+func getEvents(t *testing.T, h *handler.EventHandler, query string) model.EventPage {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.GetList(rec, httptest.NewRequest(http.MethodGet, "/events?"+query, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetList events: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var page model.EventPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("GetList events: failed to parse response: %v", err)
+	}
+	return page
+}
+
+func TestEventLog(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateHandler(db)
+	eh := handler.CreateEventHandler(db)
+	eh.PollInterval = 10 * time.Millisecond
+	ctx := context.Background()
+
+	// 1. Мутации записываются в журнал по порядку, отклоненные - нет
+	price := uint(300)
+	sub := model.RawSubscription{Provider: "Netflix", Price: &price, UID: "user1", Start: "01-2025"}
+	if err := h.Service.CreateSubscription(ctx, &sub); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	newPrice := uint(350)
+	if err := h.Service.UpdateBySID(ctx, &model.RawSubscription{Price: &newPrice}, strconv.FormatUint(*sub.SID, 10)); err != nil {
+		t.Fatalf("UpdateBySID: %v", err)
+	}
+	if _, err := h.Service.SwitchPlan(ctx, *sub.SID, &model.SwitchRequest{SwitchMonth: "06-2025"}); err != nil {
+		t.Fatalf("SwitchPlan: %v", err)
+	}
+	if err := h.Service.DeleteSubscription(ctx, uint(*sub.SID+100), nil); err == nil {
+		t.Fatalf("DeleteSubscription: expected not found")
+	}
+
+	first := getEvents(t, eh, "limit=2")
+	if len(first.Events) != 2 || first.Events[0].Type != model.EventSubscriptionCreated || first.Events[1].Type != model.EventSubscriptionUpdated {
+		t.Fatalf("Events: unexpected first page %+v", first.Events)
+	}
+	if *first.Events[1].Subscription.Price != 350 || first.NextCursor != first.Events[1].ID {
+		t.Fatalf("Events: unexpected payload or cursor %+v, %d", first.Events[1].Subscription, first.NextCursor)
+	}
+	rest := getEvents(t, eh, "after="+strconv.FormatUint(first.NextCursor, 10))
+	if len(rest.Events) != 2 || rest.Events[0].Type != model.EventSubscriptionUpdated || rest.Events[1].Type != model.EventSubscriptionCreated ||
+		rest.Events[0].Subscription.End != "06-2025" || *rest.Events[1].Subscription.PreviousSID != *sub.SID {
+		t.Fatalf("Events: unexpected second page %+v", rest.Events)
+	}
+	if empty := getEvents(t, eh, "after="+strconv.FormatUint(rest.NextCursor, 10)); len(empty.Events) != 0 || empty.NextCursor != rest.NextCursor {
+		t.Fatalf("Events: expected empty page keeping cursor, got %+v", empty)
+	}
+
+	// 2. SSE-поток отдает новые события после курсора
+	server := httptest.NewServer(http.HandlerFunc(eh.Stream))
+	defer server.Close()
+	streamCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(streamCtx, http.MethodGet, server.URL+"?after="+strconv.FormatUint(rest.NextCursor, 10), nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("Stream: unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+
+	if err := h.Service.DeleteSubscription(ctx, uint(*sub.SID), nil); err != nil {
+		t.Fatalf("DeleteSubscription: %v", err)
+	}
+	var id, eventType string
+	var payload model.EventPayload
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &payload)
+		}
+		if line == "" && id != "" {
+			break
+		}
+	}
+	if eventType != model.EventSubscriptionDeleted || id != strconv.FormatUint(rest.NextCursor+1, 10) || *payload.Subscription.SID != *sub.SID {
+		t.Fatalf("Stream: unexpected event id=%s type=%s payload=%+v", id, eventType, payload)
+	}
+
+	// 3. Привязка подписок к провайдеру каталога тоже попадает в журнал
+	switched := rest.Events[1].Subscription
+	ph := handler.CreateProviderHandler(db)
+	if rec, _ := createProvider(t, ph, model.RawProvider{Name: "Netflix"}); rec.Code != http.StatusCreated {
+		t.Fatalf("Create provider: expected 201, got %d", rec.Code)
+	}
+	linked := getEvents(t, eh, "after="+id)
+	if len(linked.Events) != 1 || linked.Events[0].Type != model.EventSubscriptionUpdated {
+		t.Fatalf("Events after linking: expected one subscription.updated, got %+v", linked.Events)
+	}
+	if got := linked.Events[0].Subscription; *got.SID != *switched.SID || got.ProviderID == nil || *got.Version != *switched.Version+1 {
+		t.Fatalf("Events after linking: unexpected payload %+v", got)
+	}
+}
@@ -0,0 +1,74 @@
+package tests_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+)
+
+func TestForecast(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateHandler(db)
+
+	month := func(offset int) string {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, offset, 0).Format("01-2006")
+	}
+	price := func(p uint) *uint { return &p }
+
+	subs := []model.RawSubscription{
+		{Provider: "Netflix", Price: price(100), UID: "user1", Start: month(-3)},                  // бессрочная
+		{Provider: "Spotify", Price: price(200), UID: "user1", Start: month(-1), End: month(2)},   // заканчивается
+		{Provider: "Ivi", Price: price(300), UID: "user1", Start: month(0), TrialUntil: month(1)}, // пробный период
+		{Provider: "Okko", Price: price(80), UID: "user1", Start: month(3)},                       // начнется позже
+		{Provider: "Netflix", Price: price(1000), UID: "user2", Start: month(0)},                  // другой пользователь
+	}
+	for _, sub := range subs {
+		if rec, _ := createSub(t, h, sub); rec.Code != http.StatusCreated {
+			t.Fatalf("Create: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.Forecast(rec, httptest.NewRequest(http.MethodGet, "/subscriptions/forecast?months=5&uid=user1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Forecast: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var forecast model.Forecast
+	json.Unmarshal(rec.Body.Bytes(), &forecast)
+
+	expected := []uint{300, 300, 600, 480, 480}
+	if len(forecast.Months) != len(expected) {
+		t.Fatalf("Forecast: expected %d months, got %d", len(expected), len(forecast.Months))
+	}
+	for i, total := range expected {
+		if forecast.Months[i].Period != month(i) || forecast.Months[i].Total != total {
+			t.Errorf("Forecast: month %d expected %s=%d, got %s=%d", i, month(i), total, forecast.Months[i].Period, forecast.Months[i].Total)
+		}
+	}
+	if forecast.Total != 2160 {
+		t.Errorf("Forecast: expected total 2160, got %d", forecast.Total)
+	}
+
+	// Месяц прогноза совпадает с отчетом за тот же месяц
+	rec = httptest.NewRecorder()
+	h.Report(rec, httptest.NewRequest(http.MethodGet, "/subscriptions/report?uid=user1&period="+month(2), nil))
+	var report model.Report
+	json.Unmarshal(rec.Body.Bytes(), &report)
+	if report.Total != forecast.Months[2].Total {
+		t.Errorf("Forecast: month %s differs from report: %d vs %d", month(2), forecast.Months[2].Total, report.Total)
+	}
+
+	for _, query := range []string{"months=0", "months=37", "months=abc", "group_by=provider"} {
+		rec := httptest.NewRecorder()
+		h.Forecast(rec, httptest.NewRequest(http.MethodGet, "/subscriptions/forecast?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Forecast %s: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
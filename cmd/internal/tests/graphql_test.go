@@ -0,0 +1,266 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func doGraphQL(t *testing.T, h *handler.GraphQLHandler, query string, variables map[string]any, data any) graphQLResponse {
+	t.Helper()
+	bodyBytes, _ := json.Marshal(model.GraphQLRequest{Query: query, Variables: variables})
+	rec := httptest.NewRecorder()
+	h.Query(rec, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(bodyBytes)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GraphQL: expected 200, got %d %s", rec.Code, rec.Body.String())
+	}
+	var res graphQLResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("GraphQL: failed to parse response: %v", err)
+	}
+	if data != nil && len(res.Data) > 0 {
+		if err := json.Unmarshal(res.Data, data); err != nil {
+			t.Fatalf("GraphQL: failed to parse data: %v", err)
+		}
+	}
+	return res
+}
+
+// countQueries - counts queries and row scans executed through db
+func countQueries(t *testing.T, db *gorm.DB) *atomic.Int64 {
+	var count atomic.Int64
+	err := db.Callback().Query().After("gorm:query").Register("test:count_queries", func(*gorm.DB) { count.Add(1) })
+	if err != nil {
+		t.Fatalf("Failed to register query counter: %v", err)
+	}
+	err = db.Callback().Row().After("gorm:row").Register("test:count_rows", func(*gorm.DB) { count.Add(1) })
+	if err != nil {
+		t.Fatalf("Failed to register query counter: %v", err)
+	}
+	return &count
+}
+
+const usersQuery = `query($first: Int, $after: String) {
+	users(first: $first, after: $after) {
+		nodes {
+			id
+			total(period: "07-2025")
+			subscriptions {
+				id serviceName price
+				provider { name category }
+				previous { id serviceName }
+			}
+		}
+		pageInfo { endCursor hasNextPage }
+	}
+}`
+
+type usersData struct {
+	Users struct {
+		Nodes []struct {
+			ID            string `json:"id"`
+			Total         int    `json:"total"`
+			Subscriptions []struct {
+				ID          string `json:"id"`
+				ServiceName string `json:"serviceName"`
+				Price       int    `json:"price"`
+				Provider    *struct {
+					Name     string `json:"name"`
+					Category string `json:"category"`
+				} `json:"provider"`
+				Previous *struct {
+					ServiceName string `json:"serviceName"`
+				} `json:"previous"`
+			} `json:"subscriptions"`
+		} `json:"nodes"`
+		PageInfo struct {
+			EndCursor   *string `json:"endCursor"`
+			HasNextPage bool    `json:"hasNextPage"`
+		} `json:"pageInfo"`
+	} `json:"users"`
+}
+
+func TestGraphQL(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateGraphQLHandler(db)
+	subHandler := handler.CreateHandler(db)
+	_, video := createCategory(t, handler.CreateCategoryHandler(db), model.RawCategory{Name: "Video"})
+	providerHandler := handler.CreateProviderHandler(db)
+	createProvider(t, providerHandler, model.RawProvider{Name: "Netflix", CategoryID: video.ID})
+	createProvider(t, providerHandler, model.RawProvider{Name: "Kinopoisk", CategoryID: video.ID})
+
+	price := func(p uint) *uint { return &p }
+	for i := 1; i <= 4; i++ {
+		uid := fmt.Sprintf("user%d", i)
+		for _, name := range []string{"Netflix", "Kinopoisk", "Local Gym"} {
+			if rec, _ := createSub(t, subHandler, model.RawSubscription{UID: uid, Provider: name, Price: price(uint(100 * i)), Start: "01-2025"}); rec.Code != http.StatusCreated {
+				t.Fatalf("Create subscription %s of %s: expected 201, got %d %s", name, uid, rec.Code, rec.Body.String())
+			}
+		}
+	}
+	_, yandex := createSub(t, subHandler, model.RawSubscription{UID: "user1", Provider: "Yandex Plus", Price: price(400), Start: "01-2025"})
+	if rec := doSwitch(t, subHandler, *yandex.SID, model.SwitchRequest{Provider: "Yandex Plus Family", Price: price(600), SwitchMonth: "06-2025"}); rec.Code != http.StatusCreated {
+		t.Fatalf("Switch: expected 201, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// 1. Пользователи с подписками, провайдерами, предыдущими подписками и суммой за месяц
+	var first usersData
+	res := doGraphQL(t, h, usersQuery, map[string]any{"first": 2}, &first)
+	if len(res.Errors) > 0 {
+		t.Fatalf("Users query: unexpected errors %+v", res.Errors)
+	}
+	users := first.Users
+	if len(users.Nodes) != 2 || users.Nodes[0].ID != "user1" || !users.PageInfo.HasNextPage || users.PageInfo.EndCursor == nil {
+		t.Fatalf("Users query: expected first page of user1, user2, got %+v", users)
+	}
+	user1 := users.Nodes[0]
+	// Netflix, Kinopoisk, Local Gym по 100 и Yandex Plus Family 600 (Yandex Plus закончилась в июне)
+	if user1.Total != 900 || len(user1.Subscriptions) != 5 {
+		t.Errorf("User1: expected total 900 and 5 subscriptions, got %d and %d", user1.Total, len(user1.Subscriptions))
+	}
+	for _, sub := range user1.Subscriptions {
+		switch sub.ServiceName {
+		case "Netflix", "Kinopoisk":
+			if sub.Provider == nil || sub.Provider.Name != sub.ServiceName || sub.Provider.Category != "Video" {
+				t.Errorf("Subscription %s: expected catalogued provider in Video, got %+v", sub.ServiceName, sub.Provider)
+			}
+		case "Yandex Plus Family":
+			if sub.Previous == nil || sub.Previous.ServiceName != "Yandex Plus" {
+				t.Errorf("Subscription %s: expected previous Yandex Plus, got %+v", sub.ServiceName, sub.Previous)
+			}
+		default:
+			if sub.Provider != nil {
+				t.Errorf("Subscription %s: expected no provider, got %+v", sub.ServiceName, sub.Provider)
+			}
+		}
+	}
+	if users.Nodes[1].Total != 600 {
+		t.Errorf("User2: expected total 600, got %d", users.Nodes[1].Total)
+	}
+
+	// 2. Вложенные списки загружаются пакетно: число запросов не зависит от числа пользователей
+	queries := countQueries(t, db)
+	var second usersData
+	doGraphQL(t, h, usersQuery, map[string]any{"first": 1}, nil)
+	small := queries.Swap(0)
+	doGraphQL(t, h, usersQuery, map[string]any{"first": 10}, &second)
+	if all := queries.Load(); small == 0 || all != small || len(second.Users.Nodes) != 4 || second.Users.PageInfo.HasNextPage {
+		t.Errorf("Batching: expected the same number of queries for 1 and 4 users, got %d and %d (%d users)", small, all, len(second.Users.Nodes))
+	}
+	// пользователи подписок загружаются пакетно так же
+	const subscriptionUsersQuery = `query($first: Int) {
+		subscriptions(first: $first) { nodes { user { total(period: "07-2025") subscriptions { id } } } }
+	}`
+	queries.Store(0)
+	doGraphQL(t, h, subscriptionUsersQuery, map[string]any{"first": 1}, nil)
+	small = queries.Swap(0)
+	if res := doGraphQL(t, h, subscriptionUsersQuery, map[string]any{"first": 20}, nil); len(res.Errors) > 0 {
+		t.Fatalf("Subscription users query: unexpected errors %+v", res.Errors)
+	}
+	if all := queries.Load(); small == 0 || all != small {
+		t.Errorf("Batching: expected the same number of queries for users of 1 and 14 subscriptions, got %d and %d", small, all)
+	}
+
+	// 3. Подписки с фильтром и постраничной выдачей по курсору
+	type subscriptionsData struct {
+		Subscriptions struct {
+			Nodes []struct {
+				UserID string `json:"userId"`
+				User   struct {
+					Total int `json:"total"`
+				} `json:"user"`
+			} `json:"nodes"`
+			PageInfo struct {
+				EndCursor   *string `json:"endCursor"`
+				HasNextPage bool    `json:"hasNextPage"`
+			} `json:"pageInfo"`
+		} `json:"subscriptions"`
+	}
+	const subscriptionsQuery = `query($after: String) {
+		subscriptions(filter: {serviceName: "netflix"}, first: 3, after: $after) {
+			nodes { userId user { total(period: "07-2025") } }
+			pageInfo { endCursor hasNextPage }
+		}
+	}`
+	var page subscriptionsData
+	doGraphQL(t, h, subscriptionsQuery, nil, &page)
+	if len(page.Subscriptions.Nodes) != 3 || !page.Subscriptions.PageInfo.HasNextPage || page.Subscriptions.Nodes[2].User.Total != 900 {
+		t.Fatalf("Subscriptions: expected 3 Netflix subscriptions with next page, got %+v", page.Subscriptions)
+	}
+	doGraphQL(t, h, subscriptionsQuery, map[string]any{"after": *page.Subscriptions.PageInfo.EndCursor}, &page)
+	if len(page.Subscriptions.Nodes) != 1 || page.Subscriptions.Nodes[0].UserID != "user4" || page.Subscriptions.PageInfo.HasNextPage {
+		t.Errorf("Subscriptions: expected last page with user4, got %+v", page.Subscriptions)
+	}
+
+	// 4. Отчёт, провайдеры и отдельные объекты
+	var misc struct {
+		Report struct {
+			Total  int `json:"total"`
+			Groups []struct {
+				Category string `json:"category"`
+				Total    int    `json:"total"`
+			} `json:"groups"`
+		} `json:"report"`
+		Providers []struct {
+			Name string `json:"name"`
+		} `json:"providers"`
+		Subscription *struct {
+			ServiceName string `json:"serviceName"`
+		} `json:"subscription"`
+		Missing *struct {
+			ID string `json:"id"`
+		} `json:"missing"`
+		User *struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	}
+	query := fmt.Sprintf(`{
+		report(period: "07-2025", groupBy: "category") { total groups { category total } }
+		providers { name }
+		subscription(id: "%d") { serviceName }
+		missing: subscription(id: "999") { id }
+		user(id: "nobody") { id }
+	}`, *yandex.SID)
+	if res := doGraphQL(t, h, query, nil, &misc); len(res.Errors) > 0 {
+		t.Fatalf("Misc query: unexpected errors %+v", res.Errors)
+	}
+	if misc.Report.Total != 3600 || len(misc.Report.Groups) != 2 {
+		t.Errorf("Report: expected total 3600 in 2 groups, got %+v", misc.Report)
+	}
+	if len(misc.Providers) != 2 || misc.Subscription == nil || misc.Subscription.ServiceName != "Yandex Plus" || misc.Missing != nil || misc.User != nil {
+		t.Errorf("Misc query: unexpected result %+v", misc)
+	}
+
+	// 5. Ошибки в аргументах возвращаются с кодом
+	for _, query := range []string{
+		`{ report(period: "2025-07") { total } }`,
+		`{ subscriptions(first: 0) { nodes { id } } }`,
+		`{ subscriptions(filter: {status: "unknown"}) { nodes { id } } }`,
+		`{ subscription(id: "abc") { id } }`,
+	} {
+		res := doGraphQL(t, h, query, nil, nil)
+		if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != "BAD_USER_INPUT" {
+			t.Errorf("Query %s: expected BAD_USER_INPUT error, got %+v", query, res.Errors)
+		}
+	}
+	if res := doGraphQL(t, h, `{ unknownField }`, nil, nil); len(res.Errors) == 0 {
+		t.Errorf("Invalid query: expected validation error")
+	}
+}
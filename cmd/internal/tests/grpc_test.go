@@ -0,0 +1,141 @@
+package tests_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"em-test/cmd/internal/grpcapi"
+	"em-test/cmd/internal/grpcapi/pb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// setupGRPC - starts gRPC server over in-memory listener and returns client connected to it
+func setupGRPC(t *testing.T) pb.SubscriptionServiceClient {
+	db := SetupTestDB(t)
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	grpcapi.CreateServer(db).Register(server)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial gRPC server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewSubscriptionServiceClient(conn)
+}
+
+func expectCode(t *testing.T, step string, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Errorf("%s: expected %v, got %v", step, code, err)
+	}
+}
+
+func TestGRPCSubscriptionAPI(t *testing.T) {
+	client := setupGRPC(t)
+	ctx := context.Background()
+	uid := "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
+	// 1. Создание подписок
+	var sids []uint64
+	for _, name := range []string{"Yandex Plus", "Netflix", "Spotify"} {
+		sub, err := client.CreateSubscription(ctx, &pb.CreateSubscriptionRequest{Subscription: &pb.Subscription{
+			UserId: uid, ServiceName: name, Price: proto.Uint64(400), StartDate: "07-2025",
+		}})
+		if err != nil {
+			t.Fatalf("CreateSubscription %s: %v", name, err)
+		}
+		if sub.GetVersion() != 1 || sub.SubscriptionId == nil {
+			t.Fatalf("CreateSubscription %s: expected SID and version 1, got %v", name, sub)
+		}
+		sids = append(sids, sub.GetSubscriptionId())
+	}
+	_, err := client.CreateSubscription(ctx, &pb.CreateSubscriptionRequest{Subscription: &pb.Subscription{
+		UserId: uid, ServiceName: "Netflix", Price: proto.Uint64(400), StartDate: "07-2025",
+	}})
+	expectCode(t, "Duplicate subscription", err, codes.AlreadyExists)
+	_, err = client.CreateSubscription(ctx, &pb.CreateSubscriptionRequest{Subscription: &pb.Subscription{ServiceName: "Kion"}})
+	expectCode(t, "Incomplete subscription", err, codes.InvalidArgument)
+
+	// 2. Получение по SID
+	sub, err := client.GetSubscription(ctx, &pb.GetSubscriptionRequest{SubscriptionId: sids[0]})
+	if err != nil || sub.GetServiceName() != "Yandex Plus" || sub.GetPrice() != 400 {
+		t.Errorf("GetSubscription: expected Yandex Plus for 400, got %v, %v", sub, err)
+	}
+	_, err = client.GetSubscription(ctx, &pb.GetSubscriptionRequest{SubscriptionId: 999})
+	expectCode(t, "Get unknown subscription", err, codes.NotFound)
+
+	// 3. Постраничный список
+	var listed []uint64
+	token := ""
+	for page := 0; ; page++ {
+		res, err := client.ListSubscriptions(ctx, &pb.ListSubscriptionsRequest{PageSize: 2, PageToken: token})
+		if err != nil {
+			t.Fatalf("ListSubscriptions page %d: %v", page, err)
+		}
+		for _, s := range res.GetSubscriptions() {
+			listed = append(listed, s.GetSubscriptionId())
+		}
+		if token = res.GetNextPageToken(); token == "" {
+			break
+		}
+		if page > 2 {
+			t.Fatalf("ListSubscriptions: too many pages")
+		}
+	}
+	if len(listed) != 3 || listed[0] != sids[0] || listed[2] != sids[2] {
+		t.Errorf("ListSubscriptions: expected %v, got %v", sids, listed)
+	}
+	_, err = client.ListSubscriptions(ctx, &pb.ListSubscriptionsRequest{PageToken: "abc"})
+	expectCode(t, "Invalid page token", err, codes.InvalidArgument)
+	_, err = client.ListSubscriptions(ctx, &pb.ListSubscriptionsRequest{Status: "unknown"})
+	expectCode(t, "Unknown status", err, codes.InvalidArgument)
+
+	// 4. Изменение с проверкой версии
+	updated, err := client.UpdateSubscription(ctx, &pb.UpdateSubscriptionRequest{SubscriptionId: sids[0], Subscription: &pb.Subscription{
+		Price: proto.Uint64(500), Version: proto.Uint64(1),
+	}})
+	if err != nil || updated.GetPrice() != 500 || updated.GetVersion() != 2 || updated.GetServiceName() != "Yandex Plus" {
+		t.Errorf("UpdateSubscription: expected price 500 and version 2, got %v, %v", updated, err)
+	}
+	_, err = client.UpdateSubscription(ctx, &pb.UpdateSubscriptionRequest{SubscriptionId: sids[0], Subscription: &pb.Subscription{
+		Price: proto.Uint64(600), Version: proto.Uint64(1),
+	}})
+	expectCode(t, "Update with stale version", err, codes.FailedPrecondition)
+	_, err = client.UpdateSubscription(ctx, &pb.UpdateSubscriptionRequest{SubscriptionId: sids[0], Subscription: &pb.Subscription{}})
+	expectCode(t, "Update without fields", err, codes.InvalidArgument)
+	_, err = client.UpdateSubscription(ctx, &pb.UpdateSubscriptionRequest{SubscriptionId: 999, Subscription: &pb.Subscription{Price: proto.Uint64(1)}})
+	expectCode(t, "Update unknown subscription", err, codes.NotFound)
+
+	// 5. Отчёт
+	report, err := client.Report(ctx, &pb.ReportRequest{Period: "07-2025", UserId: uid})
+	if err != nil || report.GetTotal() != 1300 {
+		t.Errorf("Report: expected total 1300, got %v, %v", report, err)
+	}
+	_, err = client.Report(ctx, &pb.ReportRequest{})
+	expectCode(t, "Report without period", err, codes.InvalidArgument)
+	_, err = client.Report(ctx, &pb.ReportRequest{Period: "2025-07"})
+	expectCode(t, "Report with invalid period", err, codes.InvalidArgument)
+
+	// 6. Удаление
+	_, err = client.DeleteSubscription(ctx, &pb.DeleteSubscriptionRequest{SubscriptionId: sids[0], Version: proto.Uint64(1)})
+	expectCode(t, "Delete with stale version", err, codes.FailedPrecondition)
+	if _, err = client.DeleteSubscription(ctx, &pb.DeleteSubscriptionRequest{SubscriptionId: sids[0], Version: proto.Uint64(2)}); err != nil {
+		t.Errorf("DeleteSubscription: %v", err)
+	}
+	_, err = client.GetSubscription(ctx, &pb.GetSubscriptionRequest{SubscriptionId: sids[0]})
+	expectCode(t, "Get deleted subscription", err, codes.NotFound)
+	_, err = client.DeleteSubscription(ctx, &pb.DeleteSubscriptionRequest{SubscriptionId: sids[0]})
+	expectCode(t, "Delete deleted subscription", err, codes.NotFound)
+}
@@ -0,0 +1,112 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/service"
+)
+
+func doIdempotent(fn http.HandlerFunc, url, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, url, bytes.NewReader([]byte(body)))
+	if key != "" {
+		req.Header.Set(handler.IdempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	fn(rec, req)
+	return rec
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateHandler(db)
+	ih := handler.CreateIdempotencyHandler(db)
+	create := ih.Idempotent(h.Create)
+	batch := ih.Idempotent(h.Batch)
+	ctx := context.Background()
+
+	countSubs := func() int64 {
+		var count int64
+		db.Model(&model.Subscription{}).Count(&count)
+		return count
+	}
+
+	// 1. Повтор запроса с тем же ключом получает исходный ответ
+	body := `{"service_name": "Netflix", "price": 400, "user_id": "user1", "start_date": "07-2025"}`
+	first := doIdempotent(create, "/subscriptions", "key-1", body)
+	if first.Code != http.StatusCreated || first.Header().Get(handler.IdempotentReplayedHeader) != "" {
+		t.Fatalf("Create: expected status 201, got %d: %s", first.Code, first.Body.String())
+	}
+	retry := doIdempotent(create, "/subscriptions", "key-1", body)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() ||
+		retry.Header().Get("ETag") != first.Header().Get("ETag") || retry.Header().Get(handler.IdempotentReplayedHeader) != "true" {
+		t.Errorf("Create retry: expected replayed 201, got %d %v: %s", retry.Code, retry.Header(), retry.Body.String())
+	}
+	if count := countSubs(); count != 1 {
+		t.Errorf("Create retry: expected 1 subscription, got %d", count)
+	}
+
+	// 2. Тот же ключ с другим телом - 422, без ключа - обычная проверка пересечения
+	if rec := doIdempotent(create, "/subscriptions", "key-1", `{"service_name": "Spotify", "price": 200, "user_id": "user1", "start_date": "07-2025"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Create: expected status 422 for reused key, got %d", rec.Code)
+	}
+	if rec := doIdempotent(create, "/subscriptions", "", body); rec.Code != http.StatusConflict {
+		t.Errorf("Create without key: expected status 409, got %d", rec.Code)
+	}
+
+	// 3. Ответ с ошибкой клиента тоже сохраняется
+	if rec := doIdempotent(create, "/subscriptions", "key-2", `{"price": 400}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("Create: expected status 400, got %d", rec.Code)
+	}
+	if rec := doIdempotent(create, "/subscriptions", "key-2", `{"price": 400}`); rec.Code != http.StatusBadRequest || rec.Header().Get(handler.IdempotentReplayedHeader) != "true" {
+		t.Errorf("Create retry: expected replayed 400, got %d", rec.Code)
+	}
+
+	// 4. Пакетные операции
+	batchBody := `{"operations":[{"op":"create","subscription":{"service_name":"Ivi","price":300,"user_id":"user2","start_date":"07-2025"}}]}`
+	first = doIdempotent(batch, "/subscriptions/batch", "batch-1", batchBody)
+	retry = doIdempotent(batch, "/subscriptions/batch", "batch-1", batchBody)
+	if first.Code != http.StatusOK || retry.Code != http.StatusOK || retry.Body.String() != first.Body.String() {
+		t.Errorf("Batch retry: expected the same 200 response, got %d/%d", first.Code, retry.Code)
+	}
+	if count := countSubs(); count != 2 {
+		t.Errorf("Batch retry: expected 2 subscriptions, got %d", count)
+	}
+	if rec := doIdempotent(batch, "/subscriptions", "batch-1", batchBody); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Batch: expected status 422 for key reused on another endpoint, got %d", rec.Code)
+	}
+
+	// 5. Запрос в процессе, перехват зависшего ключа и освобождение ключа после ошибки сервера
+	if stored, err := ih.Service.Begin(ctx, "key-3", "hash"); stored != nil || err != nil {
+		t.Fatalf("Begin: expected reservation, got %v %v", stored, err)
+	}
+	if _, err := ih.Service.Begin(ctx, "key-3", "hash"); !errors.Is(err, service.ErrIdempotencyInProgress) {
+		t.Errorf("Begin: expected ErrIdempotencyInProgress, got %v", err)
+	}
+	ih.Service.Lease = -time.Second
+	if stored, err := ih.Service.Begin(ctx, "key-3", "hash"); stored != nil || err != nil {
+		t.Errorf("Begin: expected stale key to be reclaimed, got %v %v", stored, err)
+	}
+	ih.Service.Complete(ctx, &model.IdempotencyKey{Key: "key-3", StatusCode: http.StatusInternalServerError})
+	if stored, err := ih.Service.Begin(ctx, "key-3", "other-hash"); stored != nil || err != nil {
+		t.Errorf("Begin: expected key to be free after server error, got %v %v", stored, err)
+	}
+
+	// 6. Планировщик удаляет устаревшие ключи
+	scheduler := service.CreateScheduler(db, time.Minute, 7*24*time.Hour)
+	if _, err := scheduler.RunOnce(ctx, time.Now().Add(25*time.Hour)); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	var keys int64
+	db.Model(&model.IdempotencyKey{}).Count(&keys)
+	if keys != 0 {
+		t.Errorf("RunOnce: expected expired idempotency keys to be removed, %d left", keys)
+	}
+}
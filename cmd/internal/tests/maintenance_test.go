@@ -0,0 +1,240 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	database "em-test/cmd/internal/db"
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/migrations"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/service"
+	"em-test/cmd/internal/utils"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test DB: %v", err)
+	}
+	fsys := fstest.MapFS{
+		"001_create_items.sql":     {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL);")},
+		"002_add_price.up.sql":     {Data: []byte("ALTER TABLE items ADD COLUMN price INTEGER;\nCREATE INDEX idx_items_price ON items(price);")},
+		"002_add_price.down.sql":   {Data: []byte("DROP INDEX idx_items_price;\nALTER TABLE items DROP COLUMN price;")},
+		"003_seed_items.up.sql":    {Data: []byte("INSERT INTO items (name, price) VALUES ('a', 1);")},
+		"003_seed_items.down.sql":  {Data: []byte("DELETE FROM items;")},
+		"migrations_readme.txt":    {Data: []byte("not a migration")},
+		"004_broken_insert.up.sql": {Data: []byte("INSERT INTO missing_table VALUES (1);")},
+	}
+
+	// 1. Встроенные миграции читаются и упорядочены по версии
+	embedded, err := database.CreateMigrator(db, migrations.FS)
+	if err != nil || len(embedded.Migrations) < 12 || embedded.Migrations[0].Version != "001" || embedded.Migrations[0].Down != "" || embedded.Migrations[1].Down == "" {
+		t.Fatalf("Embedded migrations: unexpected result %v", err)
+	}
+
+	// 2. Ошибка миграции откатывает её и останавливает применение
+	migrator, err := database.CreateMigrator(db, fsys)
+	if err != nil {
+		t.Fatalf("CreateMigrator: unexpected error %v", err)
+	}
+	done, err := migrator.Up(ctx)
+	if err == nil || len(done) != 3 {
+		t.Fatalf("Up: expected 3 migrations applied and error on 004, got %d (%v)", len(done), err)
+	}
+	delete(fsys, "004_broken_insert.up.sql")
+	if migrator, _ = database.CreateMigrator(db, fsys); len(migrator.Migrations) != 3 {
+		t.Fatalf("CreateMigrator: expected 3 migrations, got %d", len(migrator.Migrations))
+	}
+	if done, err = migrator.Up(ctx); err != nil || len(done) != 0 {
+		t.Fatalf("Up again: expected nothing to apply, got %d (%v)", len(done), err)
+	}
+	var count int64
+	if db.Table("items").Where("price = 1").Count(&count); count != 1 {
+		t.Fatalf("Up: expected seeded item, got %d", count)
+	}
+
+	// 3. Статус и откат
+	statuses, err := migrator.Status(ctx)
+	if err != nil || len(statuses) != 3 || statuses[2].AppliedAt == nil || statuses[0].HasDown {
+		t.Fatalf("Status: unexpected result %+v (%v)", statuses, err)
+	}
+	if done, err = migrator.Down(ctx, 2); err != nil || len(done) != 2 || done[0].Version != "003" {
+		t.Fatalf("Down: expected 003 and 002 reverted, got %+v (%v)", done, err)
+	}
+	if db.Migrator().HasColumn("items", "price") {
+		t.Errorf("Down: expected price column dropped")
+	}
+	if _, err = migrator.Down(ctx, 1); !errors.Is(err, database.ErrMigrationNoDown) {
+		t.Errorf("Down 001: expected ErrMigrationNoDown, got %v", err)
+	}
+
+	// 4. Baseline отмечает миграции без выполнения
+	if done, err = migrator.Baseline(ctx); err != nil || len(done) != 2 || db.Migrator().HasColumn("items", "price") {
+		t.Errorf("Baseline: expected 2 migrations marked without running, got %d (%v)", len(done), err)
+	}
+
+	if _, err := database.CreateMigrator(db, fstest.MapFS{"init.sql": {Data: []byte("SELECT 1;")}}); !errors.Is(err, database.ErrMigrationInvalid) {
+		t.Errorf("CreateMigrator: expected ErrMigrationInvalid for file without version, got %v", err)
+	}
+}
+
+func TestMaintenanceSeedAndRecompute(t *testing.T) {
+	ctx := context.Background()
+	db := SetupTestDB(t)
+	ms := service.CreateMaintenanceService(db)
+	opts := model.SeedOptions{Users: 50, SubsPerUser: 3, Months: 24, Seed: 7, Now: time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC)}
+
+	// 1. Генерация воспроизводима по seed
+	first, second := utils.GenerateSubscriptions(opts), utils.GenerateSubscriptions(opts)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("GenerateSubscriptions: expected the same subscriptions for the same seed")
+	}
+	opts.Seed = 8
+	if reflect.DeepEqual(first, utils.GenerateSubscriptions(opts)) {
+		t.Errorf("GenerateSubscriptions: expected other subscriptions for other seed")
+	}
+
+	// 2. Подписки связываются с каталогом и проходят проверку целостности
+	providerHandler := handler.CreateProviderHandler(db)
+	if rec, _ := createProvider(t, providerHandler, model.RawProvider{Name: "Yandex Plus"}); rec.Code != 201 {
+		t.Fatalf("Create provider: expected 201, got %d", rec.Code)
+	}
+	count, err := ms.Seed(ctx, opts)
+	if err != nil || count < opts.Users {
+		t.Fatalf("Seed: expected at least %d subscriptions, got %d (%v)", opts.Users, count, err)
+	}
+	var unlinked int64
+	db.Model(&model.Subscription{}).Where("service_name = ? AND provider_id IS NULL", "Yandex Plus").Count(&unlinked)
+	if unlinked != 0 {
+		t.Errorf("Seed: expected Yandex Plus subscriptions linked, got %d unlinked", unlinked)
+	}
+	if issues, err := ms.Check(ctx); err != nil || len(issues) != 0 {
+		t.Fatalf("Check seeded: expected no issues, got %+v (%v)", issues, err)
+	}
+
+	// 3. Провайдер, добавленный в обход API, связывается пересчётом
+	netflix := model.Provider{Name: "Netflix", NormalizedName: utils.NormalizeProviderName("Netflix")}
+	if err := db.Create(&netflix).Error; err != nil {
+		t.Fatalf("Create provider in DB: %v", err)
+	}
+	var netflixSubs int64
+	db.Model(&model.Subscription{}).Where("service_name = ?", "Netflix").Count(&netflixSubs)
+	var eventsBefore int64
+	db.Model(&model.Event{}).Where("event_type = ?", model.EventSubscriptionUpdated).Count(&eventsBefore)
+	report, err := ms.Recompute(ctx)
+	if err != nil || report.LinkedSubscriptions != netflixSubs || netflixSubs == 0 {
+		t.Fatalf("Recompute: expected %d linked subscriptions, got %+v (%v)", netflixSubs, report, err)
+	}
+	var linkedEvents int64
+	db.Model(&model.Event{}).Where("event_type = ?", model.EventSubscriptionUpdated).Count(&linkedEvents)
+	if linkedEvents-eventsBefore != netflixSubs {
+		t.Errorf("Recompute: expected %d subscription.updated events, got %d", netflixSubs, linkedEvents-eventsBefore)
+	}
+	if report, err = ms.Recompute(ctx); err != nil || report.LinkedSubscriptions != 0 {
+		t.Errorf("Recompute again: expected nothing to link, got %+v (%v)", report, err)
+	}
+}
+
+func TestMaintenanceBackup(t *testing.T) {
+	ctx := context.Background()
+	source := SetupTestDB(t)
+	h := handler.CreateHandler(source)
+	providerHandler := handler.CreateProviderHandler(source)
+	categoryHandler := handler.CreateCategoryHandler(source)
+
+	createWebhook(t, handler.CreateWebhookHandler(source), model.RawWebhook{URL: "http://127.0.0.1:1/hooks"})
+	_, category := createCategory(t, categoryHandler, model.RawCategory{Name: "Кино"})
+	createProvider(t, providerHandler, model.RawProvider{Name: "Kinopoisk", CategoryID: category.ID, Aliases: []string{"Кинопоиск"}})
+	price := uint(300)
+	_, old := createSub(t, h, model.RawSubscription{UID: "user1", Provider: "Кинопоиск", Price: &price, Start: "01-2025", TrialUntil: "01-2025"})
+	doSwitch(t, h, *old.SID, model.SwitchRequest{SwitchMonth: "03-2025", Price: &price})
+
+	// 1. Выгрузка и восстановление в пустую базу дают те же строки
+	ms := service.CreateMaintenanceService(source)
+	var backup bytes.Buffer
+	stats, err := ms.Export(ctx, &backup)
+	if err != nil || len(stats) != 8 || stats[3].Table != "subscriptions" || stats[3].Rows != 2 || stats[2].Rows != 1 || stats[7].Rows == 0 {
+		t.Fatalf("Export: unexpected stats %+v (%v)", stats, err)
+	}
+	target := service.CreateMaintenanceService(SetupTestDB(t))
+	if _, err := target.Import(ctx, bytes.NewReader(backup.Bytes()), false); err != nil {
+		t.Fatalf("Import: unexpected error %v", err)
+	}
+	var restored bytes.Buffer
+	if _, err := target.Export(ctx, &restored); err != nil || restored.String() != backup.String() {
+		t.Fatalf("Import: restored data differs from backup\nbackup:\n%s\nrestored:\n%s", backup.String(), restored.String())
+	}
+
+	// 2. Непустая база не перезаписывается без replace
+	if _, err := target.Import(ctx, bytes.NewReader(backup.Bytes()), false); !errors.Is(err, service.ErrDatabaseNotEmpty) {
+		t.Errorf("Import into non-empty: expected ErrDatabaseNotEmpty, got %v", err)
+	}
+	if _, err := target.Import(ctx, bytes.NewReader(backup.Bytes()), true); err != nil {
+		t.Errorf("Import with replace: unexpected error %v", err)
+	}
+
+	// 3. Ошибочная строка откатывает всё восстановление
+	broken := backup.String() + `{"table": "subscriptions", "row": {"unknown": 1}}` + "\n"
+	empty := service.CreateMaintenanceService(SetupTestDB(t))
+	if _, err := empty.Import(ctx, strings.NewReader(broken), false); err == nil {
+		t.Fatalf("Import broken: expected error")
+	}
+	if _, err := empty.Import(ctx, strings.NewReader("not json\n"), false); !errors.Is(err, service.ErrBackupInvalid) {
+		t.Errorf("Import not json: expected ErrBackupInvalid, got %v", err)
+	}
+	var left int64
+	empty.Repo.DB.Model(&model.Subscription{}).Count(&left)
+	if left != 0 {
+		t.Errorf("Import broken: expected rollback, got %d subscriptions", left)
+	}
+}
+
+func TestMaintenanceCheck(t *testing.T) {
+	ctx := context.Background()
+	db := SetupTestDB(t)
+	ms := service.CreateMaintenanceService(db)
+	month := func(value string) *time.Time {
+		res, _ := utils.ParseMonth(value)
+		return res
+	}
+	missing := uint64(100)
+
+	// подписки записываются напрямую, в обход проверок сервиса
+	subs := []*model.Subscription{
+		{UID: "user1", Provider: "Netflix", Price: 500, Start: *month("01-2025")},
+		{UID: "user1", Provider: "Netflix", Price: 600, Start: *month("03-2025"), End: month("06-2025")},
+		{UID: "user2", Provider: "Netflix", Price: 500, Start: *month("03-2025")},
+		{UID: "user1", Provider: "Spotify", Price: 200, Start: *month("05-2025"), End: month("02-2025")},
+		{UID: "user1", Provider: "Okko", Price: 200, Start: *month("05-2025"), TrialUntil: month("04-2025"), PreviousSID: &missing, ProviderID: &missing},
+	}
+	if err := ms.Repo.InsertSubscriptions(ctx, subs); err != nil {
+		t.Fatalf("InsertSubscriptions: %v", err)
+	}
+
+	issues, err := ms.Check(ctx)
+	if err != nil {
+		t.Fatalf("Check: unexpected error %v", err)
+	}
+	var kinds []string
+	for _, issue := range issues {
+		kinds = append(kinds, issue.Kind)
+	}
+	expected := []string{model.IssueOverlap, model.IssueEndBeforeStart, model.IssueTrialBeforeStart, model.IssueMissingPrevious, model.IssueMissingProvider}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Fatalf("Check: expected issues %v, got %+v", expected, issues)
+	}
+	if issues[0].SID != 1 || *issues[0].OtherSID != 2 || issues[1].SID != 4 || !strings.Contains(issues[1].Detail, "02-2025") {
+		t.Errorf("Check: unexpected issue details %+v", issues[:2])
+	}
+}
@@ -0,0 +1,150 @@
+package tests_test
+
+import (
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/middleware"
+	"em-test/cmd/internal/model"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	limiter := middleware.CreateRateLimiter(1, 2)
+	limiter.Now = func() time.Time { return now }
+	limited := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	send := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+		req.RemoteAddr = "10.0.0.1:51000"
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		limited.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 1. Запас запросов расходуется, затем 429 до пополнения
+	for i, remaining := range []string{"1", "0"} {
+		rec := send(nil)
+		if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Remaining") != remaining || rec.Header().Get("RateLimit-Limit") != "2" {
+			t.Fatalf("Request %d: expected 200 with %s remaining, got %d %v", i, remaining, rec.Code, rec.Header())
+		}
+	}
+	rec := send(nil)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" || rec.Header().Get("RateLimit-Policy") != "2;w=2" {
+		t.Fatalf("Request over limit: expected 429 with Retry-After 1, got %d %v", rec.Code, rec.Header())
+	}
+	now = now.Add(time.Second)
+	if rec := send(nil); rec.Code != http.StatusOK {
+		t.Errorf("Request after refill: expected 200, got %d", rec.Code)
+	}
+
+	// 2. Непроверенные ключи и токены не дают нового запаса, клиенты различаются по IP
+	token := "Bearer eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user1"}`)) + ".a"
+	for _, headers := range []map[string]string{{"X-API-Key": "key-1"}, {"X-API-Key": "key-2"}, {"Authorization": token}} {
+		if rec := send(headers); rec.Code != http.StatusTooManyRequests {
+			t.Errorf("Request with %v: expected the bucket of IP, got %d", headers, rec.Code)
+		}
+	}
+	other := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	other.RemoteAddr = "10.0.0.2:51000"
+	rec = httptest.NewRecorder()
+	limited.ServeHTTP(rec, other)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Request from other IP: expected own bucket, got %d", rec.Code)
+	}
+
+	// 3. Число хранимых клиентов ограничено, первыми вытесняются давно не приходившие
+	limiter.MaxClients = 2
+	for _, client := range []string{"a", "b", "a", "c"} {
+		limiter.Allow(client)
+	}
+	if allowed, remaining, _ := limiter.Allow("b"); !allowed || remaining != 1 {
+		t.Errorf("Evicted client: expected a new bucket, got %v with %d remaining", allowed, remaining)
+	}
+	if _, remaining, _ := limiter.Allow("c"); remaining != 0 {
+		t.Errorf("Recent client: expected its bucket kept, got %d remaining", remaining)
+	}
+
+	// 4. Запас меньше одного запроса поднимается до одного
+	if allowed, _, _ := middleware.CreateRateLimiter(1, 0).Allow("a"); !allowed {
+		t.Errorf("Burst 0: expected the first request allowed")
+	}
+
+	// 5. За доверенным прокси клиент - самый правый недоверенный адрес X-Forwarded-For, подделанные левее адреса не учитываются
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	key := middleware.ForwardedClientKey([]*net.IPNet{proxies})
+	cases := []struct {
+		remote, forwarded, want string
+	}{
+		{"10.0.0.1:51000", "203.0.113.7", "ip:203.0.113.7"},
+		{"10.0.0.1:51000", "198.51.100.1, 203.0.113.7, 10.0.0.5", "ip:203.0.113.7"},
+		{"10.0.0.1:51000", "", "ip:10.0.0.1"},
+		{"10.0.0.1:51000", "garbage, 10.0.0.5", "ip:10.0.0.5"},
+		{"192.0.2.10:51000", "203.0.113.7", "ip:192.0.2.10"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+		req.RemoteAddr = c.remote
+		if c.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", c.forwarded)
+		}
+		if got := key(req); got != c.want {
+			t.Errorf("ForwardedClientKey from %s with %q: expected %s, got %s", c.remote, c.forwarded, c.want, got)
+		}
+	}
+}
+
+func TestRequestBodyLimits(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateHandler(db)
+	create := middleware.MaxBodySize(256)(http.HandlerFunc(h.Create))
+	send := func(body string, unknownLength bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(body))
+		if unknownLength {
+			req.ContentLength = -1
+		}
+		rec := httptest.NewRecorder()
+		create.ServeHTTP(rec, req)
+		return rec
+	}
+	valid := `{"service_name": "Netflix", "price": 400, "user_id": "user1", "start_date": "07-2025"}`
+
+	// 1. Размер тела
+	large := `{"service_name": "` + strings.Repeat("a", 300) + `", "price": 400, "user_id": "user1", "start_date": "07-2025"}`
+	for _, unknownLength := range []bool{false, true} {
+		if rec := send(large, unknownLength); rec.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Create (unknown length %v): expected status 413, got %d: %s", unknownLength, rec.Code, rec.Body.String())
+		}
+	}
+
+	// 2. Строгий разбор JSON
+	for _, body := range []string{
+		`{"service_name": "Netflix", "price": 400, "user_id": "user1", "start_date": "07-2025", "discount": 10}`,
+		valid + valid,
+		valid + ` garbage`,
+	} {
+		if rec := send(body, false); rec.Code != http.StatusBadRequest {
+			t.Errorf("Create %s: expected status 400, got %d", body, rec.Code)
+		}
+	}
+	if rec := send(valid+"\n", false); rec.Code != http.StatusCreated {
+		t.Errorf("Create: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// 3. Строка NDJSON с неизвестным полем отклоняется
+	_, report := doImport(t, h, "?mode=valid_only", "application/x-ndjson",
+		`{"service_name": "Spotify", "price": 200, "user_id": "user1", "start_date": "07-2025", "discount": 10}`+"\n")
+	if report.Failed != 1 || report.Rows[0].Status != model.ImportRowFailed {
+		t.Errorf("Import: expected row with unknown field to be invalid, got %+v", report)
+	}
+}
@@ -0,0 +1,106 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func createProvider(t *testing.T, h *handler.ProviderHandler, provider model.RawProvider) (*httptest.ResponseRecorder, model.RawProvider) {
+	t.Helper()
+	bodyBytes, _ := json.Marshal(provider)
+	req := httptest.NewRequest(http.MethodPost, "/providers", bytes.NewReader(bodyBytes))
+	rec := httptest.NewRecorder()
+	h.Create(rec, req)
+
+	var created model.RawProvider
+	if rec.Code == http.StatusCreated {
+		if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+			t.Fatalf("Create provider: failed to parse response: %v", err)
+		}
+	}
+	return rec, created
+}
+
+func TestProviderCatalog(t *testing.T) {
+	db := SetupTestDB(t)
+	subHandler := handler.CreateHandler(db)
+	providerHandler := handler.CreateProviderHandler(db)
+
+	// Подписка, созданная до появления провайдера в каталоге
+	db.Create(&model.Subscription{Provider: "yandex plus", Price: 400, UID: "user1", Start: *mustParseDate("01-2025")})
+
+	// 1. Создать провайдера: существующая подписка привязывается к нему
+	price := uint(400)
+	rec, yandex := createProvider(t, providerHandler, model.RawProvider{Name: "Yandex Plus", Aliases: []string{"YandexPlus", "Яндекс Плюс"}, DefaultPrice: &price})
+	if rec.Code != http.StatusCreated || yandex.ID == nil {
+		t.Fatalf("Create provider: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var linked model.Subscription
+	db.First(&linked)
+	if linked.Provider != "Yandex Plus" || linked.ProviderID == nil || *linked.ProviderID != *yandex.ID {
+		t.Errorf("Create provider: expected subscription to be linked, got %+v", linked)
+	}
+
+	// 2. Повторное название через синоним отклоняется
+	rec, _ = createProvider(t, providerHandler, model.RawProvider{Name: "yandexplus"})
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Create provider: expected status 409 for taken alias, got %d", rec.Code)
+	}
+
+	// 3. Подписка по синониму получает каноническое имя и цену по умолчанию; пересечение определяется по каноническому имени
+	newSub := model.RawSubscription{Provider: "Яндекс  плюс", UID: "user2", Start: "07-2025"}
+	bodyBytes, _ := json.Marshal(newSub)
+	createRec := httptest.NewRecorder()
+	subHandler.Create(createRec, httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewReader(bodyBytes)))
+	if createRec.Code != http.StatusCreated {
+		t.Fatalf("Create subscription: expected status 201, got %d: %s", createRec.Code, createRec.Body.String())
+	}
+	var created model.RawSubscription
+	if err := json.Unmarshal(createRec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Create subscription: failed to parse response: %v", err)
+	}
+	if created.Provider != "Yandex Plus" || created.Price == nil || *created.Price != 400 || *created.ProviderID != *yandex.ID {
+		t.Errorf("Create subscription: expected canonical provider and default price, got %+v", created)
+	}
+
+	dupSub := model.RawSubscription{Provider: "YandexPlus", Price: &price, UID: "user2", Start: "09-2025"}
+	bodyBytes, _ = json.Marshal(dupSub)
+	dupRec := httptest.NewRecorder()
+	subHandler.Create(dupRec, httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewReader(bodyBytes)))
+	if dupRec.Code != http.StatusConflict {
+		t.Errorf("Create subscription: expected status 409 for alias overlap, got %d", dupRec.Code)
+	}
+
+	// 4. Отчет по синониму провайдера
+	reportRec := httptest.NewRecorder()
+	subHandler.Report(reportRec, httptest.NewRequest(http.MethodGet, "/subscriptions/report?period=07-2025&provider=yandex%20PLUS", nil))
+	var report model.Report
+	if err := json.Unmarshal(reportRec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Report: failed to parse response: %v", err)
+	}
+	if report.Total != 800 {
+		t.Errorf("Report: expected total 800, got %d", report.Total)
+	}
+
+	// 5. Удаление провайдера с подписками запрещено
+	idStr := strconv.FormatUint(*yandex.ID, 10)
+	deleteReq := httptest.NewRequest(http.MethodDelete, "/providers/"+idStr, nil)
+	chiCtx := chi.NewRouteContext()
+	chiCtx.URLParams.Add("id", idStr)
+	deleteReq = deleteReq.WithContext(context.WithValue(deleteReq.Context(), chi.RouteCtxKey, chiCtx))
+	deleteRec := httptest.NewRecorder()
+	providerHandler.Delete(deleteRec, deleteReq)
+	if deleteRec.Code != http.StatusConflict {
+		t.Errorf("Delete provider: expected status 409, got %d", deleteRec.Code)
+	}
+}
@@ -0,0 +1,147 @@
+package tests_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/service"
+)
+
+func TestReportCache(t *testing.T) {
+	ctx := context.Background()
+	db := SetupTestDB(t)
+	cache := service.CreateReportCache(service.CreateLRUReportBackend(100, time.Hour))
+	h := handler.CreateHandler(db)
+	h.Service.Cache = cache
+	// второй экземпляр сервиса с тем же кэшем, как у gRPC и GraphQL
+	other := service.CreateService(db)
+	other.Cache = cache
+	providers := service.CreateProviderService(db)
+	providers.Cache = cache
+
+	price := func(value uint) *uint { return &value }
+	report := func(filter model.RawReportFilter) uint {
+		t.Helper()
+		total, err := other.Report(ctx, &filter)
+		if err != nil {
+			t.Fatalf("Report %+v: unexpected error %v", filter, err)
+		}
+		return total
+	}
+	stats := func() model.ReportCacheStats {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ReportCacheStats(rec, httptest.NewRequest(http.MethodGet, "/subscriptions/report/cache", nil))
+		var res model.ReportCacheStats
+		if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &res) != nil {
+			t.Fatalf("ReportCacheStats: expected 200, got %d %s", rec.Code, rec.Body.String())
+		}
+		return res
+	}
+
+	_, netflix := createSub(t, h, model.RawSubscription{UID: "user1", Provider: "Netflix", Price: price(500), Start: "01-2025"})
+	createSub(t, h, model.RawSubscription{UID: "user2", Provider: "Spotify", Price: price(200), Start: "01-2025"})
+
+	// 1. Повторный отчёт берётся из кэша
+	march := []model.RawReportFilter{{Period: "03-2025"}, {Period: "03-2025", UID: "user1"}, {Period: "03-2025", UID: "user2"}, {Period: "03-2025", Provider: "Spotify"}}
+	for _, filter := range march {
+		report(filter)
+	}
+	if total := report(model.RawReportFilter{Period: "03-2025", UID: "user1"}); total != 500 {
+		t.Fatalf("Report cached: expected 500, got %d", total)
+	}
+	if s := stats(); !s.Enabled || s.Hits != 1 || s.Misses != 4 || s.Entries != 4 || s.HitRatio != 0.2 {
+		t.Fatalf("Stats: expected 1 hit and 4 misses, got %+v", s)
+	}
+	report(model.RawReportFilter{Period: "01-2025", UID: "user1"})
+
+	// 2. Запись сбрасывает только отчёты затронутых пользователя, сервиса и месяцев
+	createSub(t, h, model.RawSubscription{UID: "user1", Provider: "Okko", Price: price(300), Start: "03-2025", End: "04-2025"})
+	if s := stats(); s.Invalidations != 2 || s.Entries != 3 {
+		t.Fatalf("Create: expected totals of march and user1 in march invalidated, got %+v", s)
+	}
+	if total := report(model.RawReportFilter{Period: "03-2025"}); total != 1000 {
+		t.Errorf("Report after create: expected 1000, got %d", total)
+	}
+	if total := report(model.RawReportFilter{Period: "03-2025", UID: "user2"}); total != 200 {
+		t.Errorf("Report of untouched user: expected 200, got %d", total)
+	}
+
+	// 3. Изменение и удаление через другой сервис видны сразу
+	update := model.RawSubscription{Price: price(700)}
+	if err := other.UpdateBySID(ctx, &update, strconv.FormatUint(*netflix.SID, 10)); err != nil {
+		t.Fatalf("UpdateBySID: unexpected error %v", err)
+	}
+	if total := report(model.RawReportFilter{Period: "03-2025", UID: "user1"}); total != 1000 {
+		t.Errorf("Report after update: expected 1000, got %d", total)
+	}
+	if err := other.DeleteSubscription(ctx, uint(*netflix.SID), nil); err != nil {
+		t.Fatalf("DeleteSubscription: unexpected error %v", err)
+	}
+	if total := report(model.RawReportFilter{Period: "01-2025", UID: "user1"}); total != 0 {
+		t.Errorf("Report after delete: expected 0, got %d", total)
+	}
+
+	// 4. Связывание с каталогом сбрасывает отчёты по категории провайдера
+	music := model.RawCategory{Name: "Music"}
+	if err := service.CreateCategoryService(db).CreateCategory(ctx, &music); err != nil {
+		t.Fatalf("CreateCategory: unexpected error %v", err)
+	}
+	if total := report(model.RawReportFilter{Period: "03-2025", Category: "Music"}); total != 0 {
+		t.Fatalf("Report of empty category: expected 0, got %d", total)
+	}
+	spotify := model.RawProvider{Name: "Spotify Music", Aliases: []string{"Spotify"}, CategoryID: music.ID}
+	if err := providers.CreateProvider(ctx, &spotify); err != nil {
+		t.Fatalf("CreateProvider: unexpected error %v", err)
+	}
+	if total := report(model.RawReportFilter{Period: "03-2025", Category: "Music"}); total != 200 {
+		t.Errorf("Report after link: expected 200, got %d", total)
+	}
+
+	// 5. Неудачная транзакция ничего не сбрасывает
+	before := stats()
+	if err := other.CreateSubscription(ctx, &model.RawSubscription{UID: "user1", Provider: "Okko", Price: price(300), Start: "04-2025"}); err == nil {
+		t.Fatalf("Create overlapping: expected error")
+	}
+	if s := stats(); s.Invalidations != before.Invalidations {
+		t.Errorf("Failed create: expected no invalidations, got %d after %d", s.Invalidations, before.Invalidations)
+	}
+
+	// 6. Итог, посчитанный до сброса, не попадает в кэш
+	key := model.ReportCacheKey{Month: "2025-05", UID: "user1"}
+	_, generation, _ := cache.Get(ctx, key)
+	cache.Invalidate(ctx, &model.Subscription{UID: "user1", Provider: "Okko", Start: time.Date(2025, 5, 16, 0, 0, 0, 0, time.UTC)})
+	cache.Set(ctx, key, 1, generation)
+	if _, _, ok := cache.Get(ctx, key); ok {
+		t.Errorf("Set after invalidation: expected stale total dropped")
+	}
+
+	// 7. Вытеснение по размеру и сроку жизни, выключенный кэш
+	lru := service.CreateLRUReportBackend(2, time.Hour)
+	for i, month := range []string{"2025-01", "2025-02", "2025-03"} {
+		lru.Set(ctx, model.ReportCacheKey{Month: month}, uint(i))
+	}
+	if _, ok, _ := lru.Get(ctx, model.ReportCacheKey{Month: "2025-01"}); ok {
+		t.Errorf("LRU: expected the oldest entry evicted")
+	}
+	lru.TTL = time.Nanosecond
+	lru.Set(ctx, model.ReportCacheKey{Month: "2025-04"}, 4)
+	time.Sleep(time.Millisecond)
+	if _, ok, _ := lru.Get(ctx, model.ReportCacheKey{Month: "2025-04"}); ok {
+		t.Errorf("LRU: expected expired entry dropped")
+	}
+	if s, _ := lru.Stats(ctx); s.Evictions != 3 || s.Entries != 1 {
+		t.Errorf("LRU: expected 3 evictions and 1 entry, got %+v", s)
+	}
+	h.Service.Cache = nil
+	if s := stats(); s.Enabled || s.Hits != 0 {
+		t.Errorf("Stats without cache: expected disabled, got %+v", s)
+	}
+}
@@ -0,0 +1,129 @@
+package tests_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/repository"
+	"em-test/cmd/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func listByStatus(t *testing.T, h *handler.SubscriptionHandler, status string) []model.RawSubscription {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.GetList(rec, httptest.NewRequest(http.MethodGet, "/subscriptions?status="+status, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetList(status=%s): expected status 200, got %d: %s", status, rec.Code, rec.Body.String())
+	}
+	var subs []model.RawSubscription
+	json.Unmarshal(rec.Body.Bytes(), &subs)
+	return subs
+}
+
+func doCancel(h *handler.SubscriptionHandler, sid uint64) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/subscriptions/"+strconv.FormatUint(sid, 10)+"/cancel", nil)
+	chiCtx := chi.NewRouteContext()
+	chiCtx.URLParams.Add("sid", strconv.FormatUint(sid, 10))
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+	rec := httptest.NewRecorder()
+	h.Cancel(rec, req)
+	return rec
+}
+
+func TestSubscriptionStatusAndScheduler(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateHandler(db)
+	eh := handler.CreateEventHandler(db)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	lastMonth := thisMonth.AddDate(0, -1, 0)
+
+	expired := model.Subscription{Provider: "Netflix", Price: 300, UID: "user1", Start: thisMonth.AddDate(0, -6, 0), End: &lastMonth}
+	endingNow := model.Subscription{Provider: "Spotify", Price: 200, UID: "user1", Start: thisMonth.AddDate(0, -6, 0), End: &thisMonth}
+	scheduled := model.Subscription{Provider: "iCloud", Price: 100, UID: "user1", Start: thisMonth.AddDate(0, 2, 0)}
+	toCancel := model.Subscription{Provider: "Yandex Plus", Price: 400, UID: "user1", Start: thisMonth.AddDate(0, -2, 0)}
+	for _, sub := range []*model.Subscription{&expired, &endingNow, &scheduled, &toCancel} {
+		db.Create(sub)
+	}
+
+	// 1. Отмена: подписка заканчивается текущим месяцем, повторная отмена - конфликт
+	rec := doCancel(h, *toCancel.SID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Cancel: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var cancelled model.RawSubscription
+	json.Unmarshal(rec.Body.Bytes(), &cancelled)
+	if cancelled.Status != model.StatusCancelled || cancelled.End != thisMonth.Format("01-2006") || cancelled.CancelledAt == "" {
+		t.Fatalf("Cancel: unexpected result %+v", cancelled)
+	}
+	if rec := doCancel(h, *toCancel.SID); rec.Code != http.StatusConflict {
+		t.Errorf("Cancel: expected status 409 for cancelled subscription, got %d", rec.Code)
+	}
+	if rec := doCancel(h, 9999); rec.Code != http.StatusNotFound {
+		t.Errorf("Cancel: expected status 404 for unknown subscription, got %d", rec.Code)
+	}
+
+	// 2. Фильтр по вычисленному статусу
+	for status, expected := range map[string]uint64{
+		model.StatusExpired:   *expired.SID,
+		model.StatusActive:    *endingNow.SID,
+		model.StatusScheduled: *scheduled.SID,
+		model.StatusCancelled: *toCancel.SID,
+	} {
+		subs := listByStatus(t, h, status)
+		if len(subs) != 1 || *subs[0].SID != expected || subs[0].Status != status {
+			t.Errorf("GetList(status=%s): expected only SID %d, got %+v", status, expected, subs)
+		}
+	}
+	rec = httptest.NewRecorder()
+	h.GetList(rec, httptest.NewRequest(http.MethodGet, "/subscriptions?status=paused", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GetList: expected status 400 for unknown status, got %d", rec.Code)
+	}
+
+	// 3. Планировщик отмечает истекшую подписку один раз
+	var stale model.Subscription
+	db.First(&stale, *expired.SID)
+	scheduler := service.CreateScheduler(db, time.Minute, 0)
+	for range 2 {
+		if locked, err := scheduler.RunOnce(ctx, now); err != nil || !locked {
+			t.Fatalf("RunOnce: locked=%v, err=%v", locked, err)
+		}
+	}
+	var expiredEvents []*model.EventPayload
+	for _, event := range getEvents(t, eh, "").Events {
+		if event.Type == model.EventSubscriptionExpired {
+			expiredEvents = append(expiredEvents, event)
+		}
+	}
+	if len(expiredEvents) != 1 || *expiredEvents[0].Subscription.SID != *expired.SID || expiredEvents[0].Subscription.Status != model.StatusExpired {
+		t.Fatalf("Scheduler: expected one subscription.expired event for SID %d, got %+v", *expired.SID, expiredEvents)
+	}
+	var marked model.Subscription
+	db.First(&marked, *expired.SID)
+	if marked.ExpiredAt == nil || marked.Version != stale.Version+1 || *expiredEvents[0].Subscription.Version != marked.Version {
+		t.Errorf("Scheduler: expected expired_at to be set and version bumped to %d, got %+v", stale.Version+1, marked)
+	}
+
+	// 4. Изменение, прочитанное до отметки, не затирает её
+	stale.Price = 350
+	if err := repository.CreateRepo(db).UpdateSubscriptionInfo(ctx, &stale); !errors.Is(err, repository.ErrVersionMismatch) {
+		t.Fatalf("UpdateSubscriptionInfo with stale version: expected ErrVersionMismatch, got %v", err)
+	}
+	db.First(&marked, *expired.SID)
+	if marked.ExpiredAt == nil || marked.Price != 300 {
+		t.Errorf("UpdateSubscriptionInfo with stale version: expected subscription untouched, got %+v", marked)
+	}
+}
@@ -0,0 +1,173 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/service"
+	"em-test/cmd/internal/utils"
+)
+
+func TestMonthlySpend(t *testing.T) {
+	ctx := context.Background()
+	db := SetupTestDB(t)
+	ss := service.CreateService(db)
+	ms := service.CreateMaintenanceService(db)
+	price := func(value uint) *uint { return &value }
+	create := func(sub model.RawSubscription) uint64 {
+		t.Helper()
+		if err := ss.CreateSubscription(ctx, &sub); err != nil {
+			t.Fatalf("CreateSubscription %+v: unexpected error %v", sub, err)
+		}
+		return *sub.SID
+	}
+	filters := []model.RawReportFilter{{}, {UID: "user1"}, {Provider: "Netflix"}, {Provider: "Kinopoisk"}}
+	reportsOf := func(ss *service.SubscriptionService) []uint {
+		t.Helper()
+		var totals []uint
+		for month := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC); month.Year() < 2027; month = month.AddDate(0, 1, 0) {
+			for _, filter := range filters {
+				filter.Period = month.Format("01-2006")
+				total, err := ss.Report(ctx, &filter)
+				if err != nil {
+					t.Fatalf("Report %+v: unexpected error %v", filter, err)
+				}
+				totals = append(totals, total)
+			}
+		}
+		return totals
+	}
+	reports := func() []uint {
+		t.Helper()
+		return reportsOf(ss)
+	}
+	expectConsistentOf := func(ms *service.MaintenanceService, step string) {
+		t.Helper()
+		issues, err := ms.Check(ctx)
+		if err != nil || len(issues) != 0 {
+			t.Fatalf("%s: expected no issues, got %+v (%v)", step, issues, err)
+		}
+	}
+	expectConsistent := func(step string) {
+		t.Helper()
+		expectConsistentOf(ms, step)
+	}
+
+	// 1. Без агрегата отчёт считается на лету, пересчёт его строит
+	create(model.RawSubscription{UID: "user1", Provider: "Netflix", Price: price(500), Start: "01-2025", TrialUntil: "02-2025"})
+	create(model.RawSubscription{UID: "user2", Provider: "Кинопоиск", Price: price(300), Start: "03-2025", End: "08-2025"})
+	live := reports()
+	report, err := ms.Recompute(ctx)
+	if err != nil || report.SpendRows == 0 || report.SpendCoveredUntil.Before(time.Now()) {
+		t.Fatalf("Recompute: expected monthly spend built, got %+v (%v)", report, err)
+	}
+	if built := reports(); !slices.Equal(live, built) {
+		t.Fatalf("Report from aggregate: expected %v, got %v", live, built)
+	}
+	expectConsistent("Check after rebuild")
+
+	// 2. Каждая запись через сервис обновляет агрегат
+	toCancel := create(model.RawSubscription{UID: "user1", Provider: "Spotify", Price: price(200), Start: "06-2025"})
+	toDelete := create(model.RawSubscription{UID: "user3", Provider: "Okko", Price: price(400), Start: "02-2025", End: "12-2025"})
+	toSwitch := create(model.RawSubscription{UID: "user3", Provider: "Netflix", Price: price(700), Start: "04-2025"})
+	update := model.RawSubscription{Price: price(250), TrialUntil: "07-2025"}
+	if err := ss.UpdateBySID(ctx, &update, strconv.FormatUint(toCancel, 10)); err != nil {
+		t.Fatalf("UpdateBySID: unexpected error %v", err)
+	}
+	if _, err := ss.CancelSubscription(ctx, toCancel); err != nil {
+		t.Fatalf("CancelSubscription: unexpected error %v", err)
+	}
+	if _, err := ss.SwitchPlan(ctx, toSwitch, &model.SwitchRequest{SwitchMonth: "09-2025", Price: price(900), End: "03-2026"}); err != nil {
+		t.Fatalf("SwitchPlan: unexpected error %v", err)
+	}
+	if err := ss.DeleteSubscription(ctx, uint(toDelete), nil); err != nil {
+		t.Fatalf("DeleteSubscription: unexpected error %v", err)
+	}
+	// подписка на Кинопоиск переходит к провайдеру каталога под каноническим именем
+	kinopoisk := model.RawProvider{Name: "Kinopoisk", Aliases: []string{"Кинопоиск"}}
+	if err := service.CreateProviderService(db).CreateProvider(ctx, &kinopoisk); err != nil {
+		t.Fatalf("CreateProvider: unexpected error %v", err)
+	}
+	expectConsistent("Check after writes")
+
+	maintained := reports()
+	if err := ms.Spend.Clear(ctx); err != nil {
+		t.Fatalf("Clear: unexpected error %v", err)
+	}
+	if live = reports(); !slices.Equal(live, maintained) {
+		t.Fatalf("Report from aggregate: expected %v as computed on the fly, got %v", live, maintained)
+	}
+	if live[4*2+3] != 300 {
+		t.Errorf("Report: expected Kinopoisk subscription linked, got totals %v", live[:12])
+	}
+
+	// 3. Расхождение находится проверкой и исправляется пересчётом
+	if _, err := ms.Recompute(ctx); err != nil {
+		t.Fatalf("Recompute: unexpected error %v", err)
+	}
+	db.Model(&model.MonthlySpend{}).Where("user_id = ?", "user1").Update("amount", 1)
+	if total, err := ss.Report(ctx, &model.RawReportFilter{Period: "03-2025", UID: "user1"}); err != nil || total != 1 {
+		t.Errorf("Report tampered: expected total read from aggregate, got %d (%v)", total, err)
+	}
+	issues, err := ms.Check(ctx)
+	if err != nil || len(issues) == 0 || issues[0].Kind != model.IssueSpendMismatch || issues[0].SID != 0 {
+		t.Fatalf("Check tampered: expected spend mismatch, got %+v (%v)", issues, err)
+	}
+	if _, err := ms.Recompute(ctx); err != nil {
+		t.Fatalf("Recompute: unexpected error %v", err)
+	}
+	expectConsistent("Check after recompute")
+
+	// 4. Планировщик продлевает агрегат с ходом времени
+	later := time.Now().AddDate(0, 3, 0)
+	if _, err := service.CreateScheduler(db, time.Minute, time.Hour).RunOnce(ctx, later); err != nil {
+		t.Fatalf("RunOnce: unexpected error %v", err)
+	}
+	state, err := ms.Spend.GetState(ctx)
+	if err != nil || state == nil || !state.CoveredUntil.Equal(utils.StartOfMonth(later.UTC()).AddDate(0, 24, 0)) {
+		t.Fatalf("RunOnce: expected aggregate extended past %v, got %+v (%v)", later, state, err)
+	}
+	expectConsistent("Check after extension")
+
+	// 5. Тестовые данные и восстановление из копии попадают в агрегат
+	if _, err := ms.Seed(ctx, model.SeedOptions{Users: 30, SubsPerUser: 3, Months: 24, Seed: 3}); err != nil {
+		t.Fatalf("Seed: unexpected error %v", err)
+	}
+	expectConsistent("Check after seed")
+	seeded := reports()
+	var backup bytes.Buffer
+	if _, err := ms.Export(ctx, &backup); err != nil {
+		t.Fatalf("Export: unexpected error %v", err)
+	}
+	if err := ms.Spend.Clear(ctx); err != nil {
+		t.Fatalf("Clear: unexpected error %v", err)
+	}
+	if live = reports(); !slices.Equal(live, seeded) {
+		t.Fatalf("Report after seed: expected %v as computed on the fly, got %v", live, seeded)
+	}
+
+	// в целевой базе свой агрегат, который восстановление с replace должно заменить
+	target := SetupTestDB(t)
+	targetSubs, targetMs := service.CreateService(target), service.CreateMaintenanceService(target)
+	if err := targetSubs.CreateSubscription(ctx, &model.RawSubscription{UID: "other", Provider: "Okko", Price: price(100), Start: "01-2025"}); err != nil {
+		t.Fatalf("CreateSubscription: unexpected error %v", err)
+	}
+	if _, err := targetMs.Recompute(ctx); err != nil {
+		t.Fatalf("Recompute: unexpected error %v", err)
+	}
+	if _, err := targetMs.Import(ctx, bytes.NewReader(backup.Bytes()), true); err != nil {
+		t.Fatalf("Import: unexpected error %v", err)
+	}
+	if state, err := targetMs.Spend.GetState(ctx); err != nil || state == nil {
+		t.Fatalf("Import: expected monthly spend rebuilt, got %+v (%v)", state, err)
+	}
+	if restored := reportsOf(targetSubs); !slices.Equal(restored, seeded) {
+		t.Errorf("Report after import: expected %v, got %v", seeded, restored)
+	}
+	expectConsistentOf(targetMs, "Check after import")
+}
@@ -0,0 +1,147 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/subctl"
+)
+
+// setupSubctl - API server with real handlers
+func setupSubctl(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(apiRouter(SetupTestDB(t)))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// runSubctl - runs subctl with args and stdin, returns its output
+func runSubctl(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	root := subctl.CreateRootCommand(nil)
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetIn(strings.NewReader(stdin))
+	root.SetArgs(args)
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestSubctl(t *testing.T) {
+	server := setupSubctl(t)
+	config := filepath.Join(t.TempDir(), "config.json")
+	base := []string{"--config", config, "--base-url", server.URL}
+	run := func(stdin string, args ...string) string {
+		t.Helper()
+		out, err := runSubctl(t, stdin, append(append([]string{}, base...), args...)...)
+		if err != nil {
+			t.Fatalf("subctl %v: unexpected error %v\n%s", args, err, out)
+		}
+		return out
+	}
+
+	// 1. Создание подписки флагами и из JSON на stdin
+	out := run("", "subs", "create", "-o", "json", "--user", "user1", "--service", "Netflix", "--price", "500", "--start", "07-2025")
+	var created model.RawSubscription
+	if err := json.Unmarshal([]byte(out), &created); err != nil || created.SID == nil {
+		t.Fatalf("Create: expected subscription JSON, got %q (%v)", out, err)
+	}
+	run(`{"user_id": "user1", "service_name": "Kinopoisk", "price": 300, "start_date": "07-2025"}`, "subs", "create", "-f", "-")
+
+	// 2. Таблица списка с заголовком из колонок вида
+	out = run("", "subs", "list")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "SUBSCRIPTION_ID") || !strings.Contains(out, "Kinopoisk") {
+		t.Fatalf("List table: expected header and 2 rows, got\n%s", out)
+	}
+
+	// 3. CSV одиночного объекта - пары поле-значение
+	out = run("", "subs", "get", "1", "-o", "csv")
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil || records[0][0] != "field" || records[1][0] != "subscription_id" || records[3][0] != "service_name" || records[3][1] != "Netflix" {
+		t.Fatalf("Get CSV: expected field-value rows, got %q (%v)", out, err)
+	}
+
+	// 4. Обновление с версией и отчёт
+	run("", "subs", "update", "1", "--price", "700", "--if-match", "1")
+	out = run("", "report", "--period", "07-2025", "--user", "user1", "-o", "json")
+	var report model.Report
+	if err := json.Unmarshal([]byte(out), &report); err != nil || report.Total != 1000 {
+		t.Fatalf("Report: expected total 1000, got %q (%v)", out, err)
+	}
+	if out = run("", "report", "cache", "-o", "json"); !strings.Contains(out, `"hits"`) {
+		t.Errorf("Report cache: expected stats, got %q", out)
+	}
+
+	// 5. Ошибка API возвращается со статусом
+	_, err = runSubctl(t, "", append(append([]string{}, base...), "subs", "get", "100")...)
+	var apiErr *subctl.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Get missing: expected 404 API error, got %v", err)
+	}
+	run("", "subs", "delete", "2")
+	if _, err := runSubctl(t, "", append(append([]string{}, base...), "subs", "get", "2")...); err == nil {
+		t.Fatalf("Get deleted: expected error")
+	}
+
+	// 6. Ресурсы каталога
+	run(`{"name": "Yandex Plus", "default_price": 400}`, "providers", "create")
+	if out = run("", "providers", "list", "-o", "csv"); !strings.Contains(out, "Yandex Plus") {
+		t.Errorf("Providers list: expected Yandex Plus, got\n%s", out)
+	}
+
+	// 7. Неизвестный формат вывода
+	if _, err := runSubctl(t, "", append(append([]string{}, base...), "subs", "list", "-o", "xml")...); err == nil {
+		t.Errorf("Output xml: expected error")
+	}
+}
+
+func TestSubctlProfiles(t *testing.T) {
+	server := setupSubctl(t)
+	config := filepath.Join(t.TempDir(), "config.json")
+
+	// первый профиль становится текущим, второй - только с --use
+	for _, args := range [][]string{
+		{"config", "set", "local", "--base-url", server.URL, "--token", "secret"},
+		{"config", "set", "broken", "--base-url", "http://127.0.0.1:1"},
+	} {
+		if out, err := runSubctl(t, "", append([]string{"--config", config}, args...)...); err != nil {
+			t.Fatalf("subctl %v: unexpected error %v\n%s", args, err, out)
+		}
+	}
+	loaded, err := subctl.LoadConfig(config)
+	if err != nil || loaded.Current != "local" || loaded.Profiles["local"].Token != "secret" || len(loaded.Profiles) != 2 {
+		t.Fatalf("Config: expected 2 profiles with current local, got %+v (%v)", loaded, err)
+	}
+
+	out, err := runSubctl(t, "", "--config", config, "config", "list", "-o", "json")
+	if err != nil || strings.Contains(out, "secret") || !strings.Contains(out, `"current": true`) {
+		t.Fatalf("Config list: expected profiles without tokens, got %q (%v)", out, err)
+	}
+
+	// запрос через текущий профиль, затем через выбранный флагом
+	if out, err := runSubctl(t, "", "--config", config, "subs", "list"); err != nil || !strings.HasPrefix(out, "SUBSCRIPTION_ID") {
+		t.Fatalf("List with current profile: got %q (%v)", out, err)
+	}
+	if _, err := runSubctl(t, "", "--config", config, "-p", "broken", "subs", "list"); err == nil {
+		t.Errorf("List with broken profile: expected connection error")
+	}
+	if _, err := runSubctl(t, "", "--config", config, "-p", "missing", "subs", "list"); !errors.Is(err, subctl.ErrProfileNotFound) {
+		t.Errorf("List with missing profile: expected ErrProfileNotFound, got %v", err)
+	}
+
+	if _, err := runSubctl(t, "", "--config", config, "config", "delete", "local"); err != nil {
+		t.Fatalf("Delete profile: unexpected error %v", err)
+	}
+	if loaded, _ = subctl.LoadConfig(config); loaded.Current != "" || len(loaded.Profiles) != 1 {
+		t.Errorf("Delete profile: expected 1 profile without current, got %+v", loaded)
+	}
+}
@@ -0,0 +1,77 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+)
+
+func doBatch(t *testing.T, h *handler.SubscriptionHandler, request model.BatchRequest) (*httptest.ResponseRecorder, model.BatchResult) {
+	t.Helper()
+	bodyBytes, _ := json.Marshal(request)
+	req := httptest.NewRequest(http.MethodPost, "/subscriptions/batch", bytes.NewReader(bodyBytes))
+	rec := httptest.NewRecorder()
+	h.Batch(rec, req)
+
+	var result model.BatchResult
+	if rec.Header().Get("Content-Type") == "application/json" {
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Batch: failed to parse response: %v", err)
+		}
+	}
+	return rec, result
+}
+
+func TestSubscriptionBatch(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateHandler(db)
+
+	current := model.Subscription{Provider: "Yandex Plus", Price: 400, UID: "user1", Start: *mustParseDate("01-2025")}
+	db.Create(&current)
+
+	price := uint(600)
+	endDate := "07-2025"
+
+	// 1. Переход на другой тариф: закрыть текущую подписку и открыть новую
+	rec, result := doBatch(t, h, model.BatchRequest{Operations: []model.BatchOperation{
+		{Op: model.BatchOpUpdate, SID: current.SID, Subscription: &model.RawSubscription{End: endDate}},
+		{Op: model.BatchOpCreate, Subscription: &model.RawSubscription{Provider: "Yandex Plus Family", Price: &price, UID: "user1", Start: "08-2025"}},
+	}})
+	if rec.Code != http.StatusOK || !result.Committed {
+		t.Fatalf("Batch: expected status 200 and commit, got %d (%+v)", rec.Code, result)
+	}
+	if result.Results[0].Subscription.End != endDate || result.Results[1].Subscription.SID == nil {
+		t.Fatalf("Batch: unexpected results %+v", result.Results)
+	}
+	familySID := result.Results[1].Subscription.SID
+
+	// 2. Ошибка во второй операции откатывает первую
+	rec, result = doBatch(t, h, model.BatchRequest{Operations: []model.BatchOperation{
+		{Op: model.BatchOpDelete, SID: familySID},
+		{Op: model.BatchOpCreate, Subscription: &model.RawSubscription{Provider: "Yandex Plus", Price: &price, UID: "user1", Start: "05-2025"}},
+		{Op: model.BatchOpDelete, SID: current.SID},
+	}})
+	if rec.Code != http.StatusConflict || result.Committed {
+		t.Fatalf("Batch rollback: expected status 409 without commit, got %d (%+v)", rec.Code, result)
+	}
+	statuses := []string{result.Results[0].Status, result.Results[1].Status, result.Results[2].Status}
+	if statuses[0] != model.BatchOpRolledBack || statuses[1] != model.BatchOpFailed || statuses[2] != model.BatchOpSkipped {
+		t.Errorf("Batch rollback: unexpected statuses %v", statuses)
+	}
+	var count int64
+	db.Model(&model.Subscription{}).Count(&count)
+	if count != 2 {
+		t.Errorf("Batch rollback: expected 2 subscriptions to remain, got %d", count)
+	}
+
+	// 3. Некорректная операция отклоняется до выполнения
+	rec, _ = doBatch(t, h, model.BatchRequest{Operations: []model.BatchOperation{{Op: model.BatchOpUpdate}}})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Batch: expected status 400 for incomplete operation, got %d", rec.Code)
+	}
+}
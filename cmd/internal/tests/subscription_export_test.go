@@ -0,0 +1,97 @@
+package tests_test
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+)
+
+func TestSubscriptionExport(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateHandler(db)
+
+	db.Create(&model.Subscription{Provider: "Netflix", Price: 300, UID: "user1", Start: *mustParseDate("06-2025")})
+	db.Create(&model.Subscription{Provider: "Spotify", Price: 200, UID: "user1", Start: *mustParseDate("05-2025"), End: mustParseDate("07-2025")})
+	db.Create(&model.Subscription{Provider: "Spotify", Price: 250, UID: "user2", Start: *mustParseDate("01-2024"), End: mustParseDate("02-2024")})
+
+	// 1. CSV-выгрузка списка
+	listReq := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	listReq.Header.Set("Accept", "text/csv")
+	listRec := httptest.NewRecorder()
+	h.GetList(listRec, listReq)
+
+	if listRec.Code != http.StatusOK || !strings.HasPrefix(listRec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("GetList CSV: expected 200 text/csv, got %d %q", listRec.Code, listRec.Header().Get("Content-Type"))
+	}
+	records, err := csv.NewReader(listRec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("GetList CSV: failed to parse response: %v", err)
+	}
+	if len(records) != 4 || records[0][0] != "subscription_id" || records[2][1] != "Spotify" || records[2][5] != "07-2025" {
+		t.Errorf("GetList CSV: unexpected records %v", records)
+	}
+
+	// 2. NDJSON-выгрузка отчета: только подписки, активные в периоде
+	reportReq := httptest.NewRequest(http.MethodGet, "/subscriptions/report?period=07-2025", nil)
+	reportReq.Header.Set("Accept", "application/x-ndjson")
+	reportRec := httptest.NewRecorder()
+	h.Report(reportRec, reportReq)
+
+	if reportRec.Code != http.StatusOK || reportRec.Header().Get("X-Report-Total") != "500" {
+		t.Fatalf("Report NDJSON: expected 200 with total 500, got %d %q", reportRec.Code, reportRec.Header().Get("X-Report-Total"))
+	}
+	var lines int
+	scanner := bufio.NewScanner(reportRec.Body)
+	for scanner.Scan() {
+		var rawSub model.RawSubscription
+		if err := json.Unmarshal(scanner.Bytes(), &rawSub); err != nil {
+			t.Fatalf("Report NDJSON: failed to parse line: %v", err)
+		}
+		if rawSub.UID != "user1" {
+			t.Errorf("Report NDJSON: unexpected subscription %+v", rawSub)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("Report NDJSON: expected 2 lines, got %d", lines)
+	}
+
+	// 3. Неподдерживаемый Accept
+	xmlReq := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	xmlReq.Header.Set("Accept", "application/xml")
+	xmlRec := httptest.NewRecorder()
+	h.GetList(xmlRec, xmlReq)
+	if xmlRec.Code != http.StatusNotAcceptable {
+		t.Errorf("GetList: expected status 406 for xml, got %d", xmlRec.Code)
+	}
+
+	// 4. Форматы выбираются по убыванию q, q=0 исключает формат
+	for accept, expected := range map[string]string{
+		"text/csv;q=0.1, application/json":           "application/json",
+		"application/json;q=0.5, text/csv":           "text/csv",
+		"text/csv;q=0.0, application/x-ndjson;q=0.2": "application/x-ndjson",
+		"application/x-ndjson;q=0.3, */*;q=0.9":      "application/json",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		h.GetList(rec, req)
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), expected) {
+			t.Errorf("GetList Accept %q: expected 200 %s, got %d %q", accept, expected, rec.Code, rec.Header().Get("Content-Type"))
+		}
+	}
+	onlyExcluded := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	onlyExcluded.Header.Set("Accept", "text/csv;q=0.0")
+	excludedRec := httptest.NewRecorder()
+	h.GetList(excludedRec, onlyExcluded)
+	if excludedRec.Code != http.StatusNotAcceptable {
+		t.Errorf("GetList: expected status 406 for excluded csv, got %d", excludedRec.Code)
+	}
+}
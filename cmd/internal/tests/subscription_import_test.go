@@ -0,0 +1,90 @@
+package tests_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+)
+
+func doImport(t *testing.T, h *handler.SubscriptionHandler, query, contentType, body string) (*httptest.ResponseRecorder, model.ImportReport) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/subscriptions/import"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	h.Import(rec, req)
+
+	var report model.ImportReport
+	if rec.Header().Get("Content-Type") == "application/json" {
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("Import: failed to parse response: %v", err)
+		}
+	}
+	return rec, report
+}
+
+func TestSubscriptionImport(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateHandler(db)
+
+	// Строка 3 пересекается со строкой 2 внутри пакета, строка 4 без цены
+	csvBody := "service_name,price,user_id,start_date,end_date\n" +
+		"Netflix,300,user1,01-2025,06-2025\n" +
+		"Netflix,300,user1,05-2025,\n" +
+		"Spotify,,user1,01-2025,\n" +
+		"Spotify,200,user2,01-2025,12-2025\n"
+
+	// 1. Dry run ничего не сохраняет
+	rec, report := doImport(t, h, "?dry_run=true&mode=valid_only", "text/csv", csvBody)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Import dry run: expected status 200, got %d", rec.Code)
+	}
+	if report.Valid != 2 || report.Failed != 2 || report.Created != 0 || report.Committed {
+		t.Fatalf("Import dry run: unexpected report %+v", report)
+	}
+	if report.Rows[1].Line != 3 || report.Rows[1].Status != model.ImportRowFailed {
+		t.Errorf("Import dry run: expected line 3 to fail on overlap, got %+v", report.Rows[1])
+	}
+
+	// 2. all_or_nothing отклоняет весь пакет
+	rec, report = doImport(t, h, "", "text/csv", csvBody)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Import all_or_nothing: expected status 422, got %d", rec.Code)
+	}
+	var count int64
+	db.Model(&model.Subscription{}).Count(&count)
+	if count != 0 || report.Committed {
+		t.Fatalf("Import all_or_nothing: expected nothing stored, got %d rows", count)
+	}
+
+	// 3. valid_only сохраняет только валидные строки
+	rec, report = doImport(t, h, "?mode=valid_only", "text/csv", csvBody)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Import valid_only: expected status 201, got %d", rec.Code)
+	}
+	db.Model(&model.Subscription{}).Count(&count)
+	if count != 2 || report.Created != 2 || report.Rows[0].SID == nil {
+		t.Fatalf("Import valid_only: expected 2 rows stored, got %d (report %+v)", count, report)
+	}
+
+	// 4. NDJSON: пересечение с уже сохраненной подпиской в базе
+	ndjson := `{"service_name":"Netflix","price":300,"user_id":"user1","start_date":"03-2025"}` + "\n" +
+		`{"service_name":"Netflix","price":300,"user_id":"user3","start_date":"03-2025"}` + "\n"
+	rec, report = doImport(t, h, "?mode=valid_only", "application/x-ndjson", ndjson)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Import NDJSON: expected status 201, got %d", rec.Code)
+	}
+	if report.Rows[0].Status != model.ImportRowFailed || report.Rows[1].Status != model.ImportRowCreated {
+		t.Errorf("Import NDJSON: unexpected rows %+v", report.Rows)
+	}
+
+	// 5. Неподдерживаемый формат
+	rec, _ = doImport(t, h, "", "application/xml", "<xml/>")
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Import: expected status 415 for xml, got %d", rec.Code)
+	}
+}
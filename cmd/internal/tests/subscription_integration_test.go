@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	database "em-test/cmd/internal/db"
 	"em-test/cmd/internal/handler"
 	"em-test/cmd/internal/model"
 
@@ -25,7 +26,7 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatalf("failed to open test DB: %v", err)
 	}
-	if err := db.AutoMigrate(&model.Subscription{}); err != nil {
+	if err := database.Migrate(db); err != nil {
 		t.Fatalf("failed to migrate test DB: %v", err)
 	}
 	return db
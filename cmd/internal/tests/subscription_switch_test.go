@@ -0,0 +1,76 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func doSwitch(t *testing.T, h *handler.SubscriptionHandler, sid uint64, request model.SwitchRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	bodyBytes, _ := json.Marshal(request)
+	req := httptest.NewRequest(http.MethodPost, "/subscriptions/"+strconv.FormatUint(sid, 10)+"/switch", bytes.NewReader(bodyBytes))
+	chiCtx := chi.NewRouteContext()
+	chiCtx.URLParams.Add("sid", strconv.FormatUint(sid, 10))
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, chiCtx))
+	rec := httptest.NewRecorder()
+	h.Switch(rec, req)
+	return rec
+}
+
+func TestSubscriptionSwitch(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateHandler(db)
+
+	current := model.Subscription{Provider: "Yandex Plus", Price: 400, UID: "user1", Start: *mustParseDate("01-2025")}
+	db.Create(&current)
+
+	// 1. Переход на семейный тариф с июля
+	price := uint(600)
+	rec := doSwitch(t, h, *current.SID, model.SwitchRequest{Provider: "Yandex Plus Family", Price: &price, SwitchMonth: "07-2025"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Switch: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result model.SwitchResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Switch: failed to parse response: %v", err)
+	}
+	if result.Previous.End != "07-2025" || result.Next.Start != "08-2025" || *result.Next.PreviousSID != *current.SID {
+		t.Fatalf("Switch: unexpected result %+v / %+v", result.Previous, result.Next)
+	}
+
+	// 2. Отчет: в июле старая подписка, в августе новая
+	for period, expected := range map[string]uint{"07-2025": 400, "08-2025": 600} {
+		reportReq := httptest.NewRequest(http.MethodGet, "/subscriptions/report?period="+period+"&uid=user1", nil)
+		reportRec := httptest.NewRecorder()
+		h.Report(reportRec, reportReq)
+		var report model.Report
+		if err := json.Unmarshal(reportRec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("Report: failed to parse response: %v", err)
+		}
+		if report.Total != expected {
+			t.Errorf("Report %s: expected total %d, got %d", period, expected, report.Total)
+		}
+	}
+
+	// 3. Повторный переход уже закрытой подписки после даты окончания невозможен
+	rec = doSwitch(t, h, *current.SID, model.SwitchRequest{SwitchMonth: "09-2025"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Switch: expected status 400 for ended subscription, got %d", rec.Code)
+	}
+
+	// 4. Несуществующая подписка
+	rec = doSwitch(t, h, 9999, model.SwitchRequest{SwitchMonth: "09-2025"})
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Switch: expected status 404, got %d", rec.Code)
+	}
+}
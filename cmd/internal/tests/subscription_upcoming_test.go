@@ -0,0 +1,83 @@
+package tests_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+)
+
+func TestSubscriptionUpcoming(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateHandler(db)
+
+	now := time.Now()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	nextMonth := thisMonth.AddDate(0, 1, 0)
+	farFuture := thisMonth.AddDate(1, 0, 0)
+
+	// Заканчивается в текущем месяце
+	ending := model.Subscription{Provider: "Netflix", Price: 300, UID: "user1", Start: thisMonth.AddDate(0, -6, 0), End: &thisMonth}
+	// Бессрочная, текущий месяц оплачен, очередное списание - первого числа следующего месяца
+	renewing := model.Subscription{Provider: "Spotify", Price: 200, UID: "user1", Start: thisMonth.AddDate(-1, 0, 15)}
+	// Начинается через три месяца - первое списание за пределами окна
+	scheduled := model.Subscription{Provider: "Kinopoisk", Price: 250, UID: "user1", Start: thisMonth.AddDate(0, 3, 15)}
+	// Заканчивается через год
+	later := model.Subscription{Provider: "iCloud", Price: 100, UID: "user1", Start: thisMonth, End: &farFuture}
+	// Другой пользователь
+	other := model.Subscription{Provider: "Netflix", Price: 300, UID: "user2", Start: thisMonth.AddDate(0, -6, 0), End: &thisMonth}
+	for _, sub := range []*model.Subscription{&ending, &renewing, &scheduled, &later, &other} {
+		db.Create(sub)
+	}
+
+	rec := httptest.NewRecorder()
+	h.Upcoming(rec, httptest.NewRequest(http.MethodGet, "/subscriptions/upcoming?within=45d&user_id=user1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Upcoming: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var upcoming []model.UpcomingSubscription
+	if err := json.Unmarshal(rec.Body.Bytes(), &upcoming); err != nil {
+		t.Fatalf("Upcoming: failed to parse response: %v", err)
+	}
+
+	events := map[string]string{}
+	for _, item := range upcoming {
+		events[item.Subscription.Provider] = item.Event
+	}
+	if len(upcoming) != 2 || events["Netflix"] != model.UpcomingExpiry || events["Spotify"] != model.UpcomingRenewal {
+		t.Fatalf("Upcoming: unexpected result %+v", upcoming)
+	}
+	for _, item := range upcoming {
+		if item.Event == model.UpcomingRenewal && item.Date != nextMonth.Format("2006-01-02") {
+			t.Errorf("Upcoming: expected renewal on %s, got %s", nextMonth.Format("2006-01-02"), item.Date)
+		}
+	}
+
+	// Окно пошире захватывает первое списание запланированной подписки - первое число месяца начала
+	rec = httptest.NewRecorder()
+	h.Upcoming(rec, httptest.NewRequest(http.MethodGet, "/subscriptions/upcoming?within=100d&user_id=user1", nil))
+	upcoming = nil
+	if err := json.Unmarshal(rec.Body.Bytes(), &upcoming); err != nil {
+		t.Fatalf("Upcoming: failed to parse response: %v", err)
+	}
+	var scheduledDate string
+	for _, item := range upcoming {
+		if item.Subscription.Provider == "Kinopoisk" {
+			scheduledDate = item.Date
+		}
+	}
+	if want := thisMonth.AddDate(0, 3, 0).Format("2006-01-02"); scheduledDate != want {
+		t.Errorf("Upcoming: expected first charge of scheduled subscription on %s, got %q", want, scheduledDate)
+	}
+
+	// Некорректное окно
+	rec = httptest.NewRecorder()
+	h.Upcoming(rec, httptest.NewRequest(http.MethodGet, "/subscriptions/upcoming?within=soon", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Upcoming: expected status 400 for invalid window, got %d", rec.Code)
+	}
+}
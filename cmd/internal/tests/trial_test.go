@@ -0,0 +1,71 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+)
+
+func TestTrialPeriods(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateHandler(db)
+	ctx := context.Background()
+
+	price := uint(500)
+	converting := model.RawSubscription{Provider: "Netflix", Price: &price, UID: "user1", Start: "01-2025", TrialUntil: "03-2025"}
+	if err := h.Service.CreateSubscription(ctx, &converting); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	otherPrice := uint(200)
+	dropping := model.RawSubscription{Provider: "Spotify", Price: &otherPrice, UID: "user1", Start: "02-2025", End: "03-2025", TrialUntil: "03-2025"}
+	if err := h.Service.CreateSubscription(ctx, &dropping); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+
+	// 1. Пробный период раньше начала подписки отклоняется
+	bodyBytes, _ := json.Marshal(model.RawSubscription{Provider: "iCloud", Price: &price, UID: "user1", Start: "05-2025", TrialUntil: "04-2025"})
+	rec := httptest.NewRecorder()
+	h.Create(rec, httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewReader(bodyBytes)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Create: expected status 400 for trial before start, got %d", rec.Code)
+	}
+
+	// 2. Месяцы пробного периода бесплатны
+	for period, expected := range map[string]uint{"01-2025": 0, "03-2025": 0, "04-2025": 500} {
+		_, report := getReport(t, h, url.Values{"period": {period}})
+		if report.Total != expected {
+			t.Errorf("Report %s: expected %d, got %d", period, expected, report.Total)
+		}
+	}
+
+	// 3. Конверсия пробных периодов
+	for period, expected := range map[string]model.TrialReport{
+		"02-2025": {Period: "02-2025", InTrial: 2},
+		"03-2025": {Period: "03-2025", InTrial: 2, TrialsEnded: 2, Converted: 1, NotConverted: 1, ConversionRate: 0.5, ConvertedRevenue: 500},
+		"04-2025": {Period: "04-2025"},
+	} {
+		rec := httptest.NewRecorder()
+		h.TrialReport(rec, httptest.NewRequest(http.MethodGet, "/subscriptions/report/trials?period="+period, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("TrialReport %s: expected status 200, got %d: %s", period, rec.Code, rec.Body.String())
+		}
+		var report model.TrialReport
+		json.Unmarshal(rec.Body.Bytes(), &report)
+		if report != expected {
+			t.Errorf("TrialReport %s: expected %+v, got %+v", period, expected, report)
+		}
+	}
+
+	// 4. Пробный период виден в подписке
+	sub, err := h.Service.GetBySID(ctx, *converting.SID)
+	if err != nil || sub.TrialUntil != "03-2025" {
+		t.Errorf("GetBySID: expected trial_until 03-2025, got %+v (%v)", sub, err)
+	}
+}
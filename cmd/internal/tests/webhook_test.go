@@ -0,0 +1,196 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/model"
+	"em-test/cmd/internal/service"
+	"em-test/cmd/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// webhookReceiver - local receiver which checks signatures and records received events
+type webhookReceiver struct {
+	mu     sync.Mutex
+	secret string
+	status int
+	events []model.EventPayload
+	badSig int
+}
+
+func (wr *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	timestamp, _ := strconv.ParseInt(r.Header.Get(utils.WebhookTimestampHeader), 10, 64)
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	if r.Header.Get(utils.WebhookSignatureHeader) != utils.SignWebhookPayload(wr.secret, timestamp, body) {
+		wr.badSig++
+	}
+	var event model.EventPayload
+	json.Unmarshal(body, &event)
+	wr.events = append(wr.events, event)
+	w.WriteHeader(wr.status)
+}
+
+func createWebhook(t *testing.T, h *handler.WebhookHandler, webhook model.RawWebhook) model.RawWebhook {
+	t.Helper()
+	bodyBytes, _ := json.Marshal(webhook)
+	rec := httptest.NewRecorder()
+	h.Create(rec, httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(bodyBytes)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Create webhook: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created model.RawWebhook
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Create webhook: failed to parse response: %v", err)
+	}
+	return created
+}
+
+func getDeliveries(t *testing.T, h *handler.WebhookHandler, query string) []model.WebhookDelivery {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.GetDeliveries(rec, httptest.NewRequest(http.MethodGet, "/webhooks/deliveries?"+query, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetDeliveries: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var deliveries []model.WebhookDelivery
+	if err := json.Unmarshal(rec.Body.Bytes(), &deliveries); err != nil {
+		t.Fatalf("GetDeliveries: failed to parse response: %v", err)
+	}
+	return deliveries
+}
+
+func TestWebhooks(t *testing.T) {
+	db := SetupTestDB(t)
+	h := handler.CreateHandler(db)
+	wh := handler.CreateWebhookHandler(db)
+	ctx := context.Background()
+
+	good := &webhookReceiver{secret: "good-secret", status: http.StatusOK}
+	goodServer := httptest.NewServer(good)
+	defer goodServer.Close()
+	broken := &webhookReceiver{status: http.StatusInternalServerError}
+	brokenServer := httptest.NewServer(broken)
+	defer brokenServer.Close()
+
+	// 1. Регистрация: неизвестный тип события и некорректный URL отклоняются
+	for _, invalid := range []model.RawWebhook{
+		{URL: goodServer.URL, EventTypes: []string{"subscription.renamed"}},
+		{URL: "ftp://example.com"},
+	} {
+		bodyBytes, _ := json.Marshal(invalid)
+		rec := httptest.NewRecorder()
+		wh.Create(rec, httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(bodyBytes)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Create webhook %+v: expected status 400, got %d", invalid, rec.Code)
+		}
+	}
+	createWebhook(t, wh, model.RawWebhook{URL: goodServer.URL, Secret: good.secret})
+	brokenHook := createWebhook(t, wh, model.RawWebhook{URL: brokenServer.URL, EventTypes: []string{model.EventSubscriptionCreated}})
+	if brokenHook.Secret == "" {
+		t.Fatalf("Create webhook: expected generated secret")
+	}
+	broken.secret = brokenHook.Secret
+
+	// 2. Изменения подписки пишут события в outbox
+	price := uint(300)
+	sub := model.RawSubscription{Provider: "Netflix", Price: &price, UID: "user1", Start: "01-2025"}
+	bodyBytes, _ := json.Marshal(sub)
+	rec := httptest.NewRecorder()
+	h.Create(rec, httptest.NewRequest(http.MethodPost, "/subscriptions", bytes.NewReader(bodyBytes)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Create: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	json.Unmarshal(rec.Body.Bytes(), &sub)
+	newPrice := uint(350)
+	if err := h.Service.UpdateBySID(ctx, &model.RawSubscription{Price: &newPrice}, strconv.FormatUint(*sub.SID, 10)); err != nil {
+		t.Fatalf("UpdateBySID: %v", err)
+	}
+	if err := h.Service.DeleteSubscription(ctx, uint(*sub.SID), nil); err != nil {
+		t.Fatalf("DeleteSubscription: %v", err)
+	}
+	// Отклоненное создание не оставляет событий
+	if err := h.Service.CreateSubscription(ctx, &model.RawSubscription{Provider: "Netflix", UID: "user1", Start: "13-2025", Price: &price}); err == nil {
+		t.Fatalf("CreateSubscription: expected validation error")
+	}
+	if pending := getDeliveries(t, wh, "status=pending"); len(pending) != 4 {
+		t.Fatalf("Outbox: expected 4 pending deliveries (3 + 1 created for second webhook), got %d", len(pending))
+	}
+
+	// 3. Доставка: подписи верны, события идут по порядку; неудачные попытки откладываются, затем уходят в dead
+	dispatcher := service.CreateWebhookDispatcher(db)
+	dispatcher.Client = goodServer.Client()
+	dispatcher.MaxAttempts = 2
+	dispatcher.BaseBackoff, dispatcher.MaxBackoff = 0, 0
+	delivered, err := dispatcher.DispatchPending(ctx)
+	if err != nil || delivered != 3 {
+		t.Fatalf("DispatchPending: expected 3 delivered, got %d (%v)", delivered, err)
+	}
+	if good.badSig != 0 || broken.badSig != 0 {
+		t.Errorf("Receiver: invalid signatures %d/%d", good.badSig, broken.badSig)
+	}
+	var types []string
+	for _, event := range good.events {
+		types = append(types, event.Type)
+	}
+	if len(types) != 3 || types[0] != model.EventSubscriptionCreated || types[1] != model.EventSubscriptionUpdated ||
+		types[2] != model.EventSubscriptionDeleted || *good.events[1].Subscription.Price != 350 {
+		t.Fatalf("Receiver: unexpected events %v", types)
+	}
+	if pending := getDeliveries(t, wh, "status=pending"); len(pending) != 1 || pending[0].Attempts != 1 || pending[0].LastError == "" {
+		t.Fatalf("Retry: expected 1 pending delivery after failed attempt, got %+v", pending)
+	}
+	if _, err := dispatcher.DispatchPending(ctx); err != nil {
+		t.Fatalf("DispatchPending: %v", err)
+	}
+	dead := getDeliveries(t, wh, "status=dead")
+	if len(dead) != 1 || dead[0].Attempts != 2 || len(broken.events) != 2 {
+		t.Fatalf("Dead-letter: expected 1 dead delivery after 2 attempts, got %+v", dead)
+	}
+
+	// 4. Ручной повтор недоставленного события
+	retryReq := httptest.NewRequest(http.MethodPost, "/webhooks/deliveries/"+strconv.FormatUint(dead[0].ID, 10)+"/retry", nil)
+	chiCtx := chi.NewRouteContext()
+	chiCtx.URLParams.Add("id", strconv.FormatUint(dead[0].ID, 10))
+	retryReq = retryReq.WithContext(context.WithValue(retryReq.Context(), chi.RouteCtxKey, chiCtx))
+	rec = httptest.NewRecorder()
+	wh.RetryDelivery(rec, retryReq)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("RetryDelivery: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	wh.RetryDelivery(rec, retryReq)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("RetryDelivery: expected status 409 for pending delivery, got %d", rec.Code)
+	}
+
+	// 5. subscription.expiring отправляется один раз на подписку и месяц окончания
+	thisMonth := time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.UTC)
+	db.Create(&model.Subscription{Provider: "Spotify", Price: 200, UID: "user2", Start: thisMonth.AddDate(0, -3, 0), End: &thisMonth})
+	for range 2 {
+		if _, err := h.Service.NotifyExpiring(ctx, 45*24*time.Hour); err != nil {
+			t.Fatalf("NotifyExpiring: %v", err)
+		}
+	}
+	expiring := 0
+	for _, delivery := range getDeliveries(t, wh, "") {
+		if delivery.EventType == model.EventSubscriptionExpiring {
+			expiring++
+		}
+	}
+	if expiring != 1 {
+		t.Errorf("NotifyExpiring: expected 1 expiring delivery, got %d", expiring)
+	}
+}
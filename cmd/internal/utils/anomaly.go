@@ -0,0 +1,46 @@
+package utils
+
+import "sort"
+
+// NameSimilarity - similarity of provider names from 0 to 1 by Levenshtein distance of their normalized forms
+func NameSimilarity(a, b string) float64 {
+	ra, rb := []rune(NormalizeProviderName(a)), []rune(NormalizeProviderName(b))
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// MedianPrice - median of prices; prices are sorted in place
+func MedianPrice(prices []uint) float64 {
+	if len(prices) == 0 {
+		return 0
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i] < prices[j] })
+	mid := len(prices) / 2
+	if len(prices)%2 == 0 {
+		return float64(prices[mid-1]+prices[mid]) / 2
+	}
+	return float64(prices[mid])
+}
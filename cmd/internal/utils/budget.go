@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"em-test/cmd/internal/model"
+	"time"
+)
+
+// ConvertNormalBudgetToRaw - converts budget for output with full path of its category
+func ConvertNormalBudgetToRaw(budget *model.Budget, categoryPath string) *model.RawBudget {
+	amount := budget.Amount
+	rawBudget := &model.RawBudget{
+		ID:         &budget.ID,
+		UID:        budget.UID,
+		Amount:     &amount,
+		CategoryID: budget.CategoryID,
+		Category:   categoryPath,
+		Mode:       budget.Mode,
+	}
+	if budget.Provider != nil {
+		rawBudget.Provider = *budget.Provider
+	}
+	return rawBudget
+}
+
+// BudgetFilter - builds report filter counting spend of budget scope in the month of t
+func BudgetFilter(budget *model.Budget, t time.Time) *model.ReportFilter {
+	uid := budget.UID
+	return &model.ReportFilter{
+		Start:      StartOfMonth(t),
+		End:        EndOfMonth(t),
+		UID:        &uid,
+		Provider:   budget.Provider,
+		CategoryID: budget.CategoryID,
+	}
+}
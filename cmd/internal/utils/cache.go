@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"em-test/cmd/internal/model"
+)
+
+// reportCacheMonth - format of month in model.ReportCacheKey, ordered as strings
+const reportCacheMonth = "2006-01"
+
+// ReportCacheKey - key of report total cached for normalized filter
+func ReportCacheKey(filter *model.ReportFilter) model.ReportCacheKey {
+	key := model.ReportCacheKey{Month: filter.Start.Format(reportCacheMonth)}
+	if filter.UID != nil {
+		key.UID = *filter.UID
+	}
+	if filter.Provider != nil {
+		key.Provider = *filter.Provider
+	}
+	if filter.CategoryID != nil {
+		key.CategoryID = *filter.CategoryID
+	}
+	return key
+}
+
+// ReportCacheKeyTouched - whether subscription (in its state before or after a write) is counted in report cached under key,
+// so the write may change that report. Category filter is assumed to match any subscription linked to a provider
+func ReportCacheKeyTouched(key model.ReportCacheKey, sub *model.Subscription) bool {
+	if key.UID != "" && key.UID != sub.UID {
+		return false
+	}
+	if key.Provider != "" && key.Provider != sub.Provider {
+		return false
+	}
+	if key.CategoryID != 0 && sub.ProviderID == nil {
+		return false
+	}
+	if key.Month < sub.Start.UTC().Format(reportCacheMonth) {
+		return false
+	}
+	return sub.End == nil || key.Month <= sub.End.UTC().Format(reportCacheMonth)
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"em-test/cmd/internal/model"
+	"strings"
+)
+
+// CategoryPathSeparator - separator of category names in category path, e.g. "Entertainment > Video"
+const CategoryPathSeparator = " > "
+
+// SplitCategoryPath - splits category path into trimmed non-empty names
+func SplitCategoryPath(path string) []string {
+	var names []string
+	for _, name := range strings.Split(path, ">") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// BuildCategoryPaths - returns full path of every category by its ID
+func BuildCategoryPaths(categories []*model.Category) map[uint64]string {
+	byID := make(map[uint64]*model.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+
+	paths := make(map[uint64]string, len(categories))
+	var pathOf func(category *model.Category, depth int) string
+	pathOf = func(category *model.Category, depth int) string {
+		if path, ok := paths[category.ID]; ok {
+			return path
+		}
+		path := category.Name
+		parent, ok := byID[derefID(category.ParentID)]
+		if category.ParentID != nil && ok && depth < len(categories) {
+			path = pathOf(parent, depth+1) + CategoryPathSeparator + category.Name
+		}
+		paths[category.ID] = path
+		return path
+	}
+	for _, category := range categories {
+		pathOf(category, 0)
+	}
+	return paths
+}
+
+func ConvertNormalCategoryToRaw(category *model.Category, path string) *model.RawCategory {
+	id := category.ID
+	return &model.RawCategory{
+		ID:       &id,
+		Name:     category.Name,
+		ParentID: category.ParentID,
+		Path:     path,
+	}
+}
+
+func derefID(id *uint64) uint64 {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
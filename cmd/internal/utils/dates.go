@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"em-test/cmd/internal/model"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseWindow - parses time window given in days ("30d"), weeks ("2w") or Go duration format ("36h")
+func ParseWindow(source string) (time.Duration, error) {
+	source = strings.TrimSpace(source)
+	if len(source) > 1 {
+		unit := source[len(source)-1]
+		if unit == 'd' || unit == 'w' {
+			count, err := strconv.Atoi(source[:len(source)-1])
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("%w: invalid window %q", ErrConvertToNorm, source)
+			}
+			days := count
+			if unit == 'w' {
+				days *= 7
+			}
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+	window, err := time.ParseDuration(source)
+	if err != nil || window < 0 {
+		return 0, fmt.Errorf("%w: invalid window %q", ErrConvertToNorm, source)
+	}
+	return window, nil
+}
+
+// StartOfMonth - returns the first moment of month of t
+func StartOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// EndOfMonth - returns the last moment of month of t; subscription with end date in this month is paid up to this moment
+func EndOfMonth(t time.Time) time.Time {
+	return StartOfMonth(t).AddDate(0, 1, 0).Add(-time.Nanosecond)
+}
+
+// NextCharge - returns the first monthly charge of subscription started at start which is not earlier than now.
+// Dates are kept with month precision and a month is paid up to EndOfMonth, so charges happen at the start of every month from the start month:
+// the next one is the start of the first month not paid yet
+func NextCharge(start, now time.Time) time.Time {
+	charge := StartOfMonth(now.In(start.Location()))
+	if charge.Before(now) {
+		charge = charge.AddDate(0, 1, 0)
+	}
+	if first := StartOfMonth(start); first.After(charge) {
+		return first
+	}
+	return charge
+}
+
+// SubscriptionStatus - computes status of subscription at now with month granularity: subscription is active
+// through the whole end month and becomes active from the first day of start month
+func SubscriptionStatus(sub *model.Subscription, now time.Time) string {
+	now = now.UTC()
+	switch {
+	case sub.CancelledAt != nil:
+		return model.StatusCancelled
+	case sub.End != nil && sub.End.Before(StartOfMonth(now)):
+		return model.StatusExpired
+	case !sub.Start.Before(StartOfMonth(now).AddDate(0, 1, 0)):
+		return model.StatusScheduled
+	default:
+		return model.StatusActive
+	}
+}
+
+// IsSubscriptionStatus - checks if status is known; empty status means no filter
+func IsSubscriptionStatus(status string) bool {
+	return status == "" || slices.Contains(model.SubscriptionStatuses, status)
+}
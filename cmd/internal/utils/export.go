@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"em-test/cmd/internal/model"
+	"strconv"
+)
+
+// SubscriptionCSVHeader - header row of CSV export; compatible with CSV import
+var SubscriptionCSVHeader = []string{"subscription_id", "service_name", "price", "user_id", "start_date", "end_date", "previous_subscription_id", "trial_until"}
+
+// SubscriptionToCSVRecord - converts raw subscription to CSV record in order of SubscriptionCSVHeader
+func SubscriptionToCSVRecord(rawSub *model.RawSubscription) []string {
+	var sid, price, previousSID string
+	if rawSub.SID != nil {
+		sid = strconv.FormatUint(*rawSub.SID, 10)
+	}
+	if rawSub.Price != nil {
+		price = strconv.FormatUint(uint64(*rawSub.Price), 10)
+	}
+	if rawSub.PreviousSID != nil {
+		previousSID = strconv.FormatUint(*rawSub.PreviousSID, 10)
+	}
+	return []string{sid, rawSub.Provider, price, rawSub.UID, rawSub.Start, rawSub.End, previousSID, rawSub.TrialUntil}
+}
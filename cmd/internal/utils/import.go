@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"em-test/cmd/internal/model"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrImportFormat - error reflecting problems with structure of the whole import payload (not a single row)
+var ErrImportFormat = errors.New("malformed import payload")
+
+// csvColumns - columns recognized in CSV header, matching json-tags of model.RawSubscription
+var csvColumns = []string{"service_name", "price", "user_id", "start_date", "end_date", "trial_until"}
+
+// ParseCSVSubscriptions - decodes CSV payload with a header row into import rows; row-level problems are stored in model.ImportRow.Err
+func ParseCSVSubscriptions(source io.Reader) ([]model.ImportRow, error) {
+	reader := csv.NewReader(source)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read CSV header: %w", ErrImportFormat, err)
+	}
+	index := make(map[string]int, len(header))
+	for i, column := range header {
+		index[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, column := range []string{"service_name", "user_id", "start_date"} {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("%w: CSV header lacks mandatory column %q (expected %s)", ErrImportFormat, column, strings.Join(csvColumns, ","))
+		}
+	}
+
+	var rows []model.ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, model.ImportRow{Line: parseErr.Line, Err: fmt.Errorf("%w: %w", ErrConvertToNorm, err)})
+				continue
+			}
+			return nil, fmt.Errorf("%w: %w", ErrImportFormat, err)
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, csvRecordToRow(line, record, index))
+	}
+	return rows, nil
+}
+
+func csvRecordToRow(line int, record []string, index map[string]int) model.ImportRow {
+	field := func(name string) string {
+		i, ok := index[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rawSub := &model.RawSubscription{
+		Provider: field("service_name"),
+		UID:      field("user_id"),
+		Start:    field("start_date"),
+		End:      field("end_date"),
+
+		TrialUntil: field("trial_until"),
+	}
+	if priceStr := field("price"); priceStr != "" {
+		price, err := strconv.ParseUint(priceStr, 10, 0)
+		if err != nil {
+			return model.ImportRow{Line: line, Err: fmt.Errorf("%w: invalid price %q: %w", ErrConvertToNorm, priceStr, err)}
+		}
+		p := uint(price)
+		rawSub.Price = &p
+	}
+	return model.ImportRow{Line: line, Sub: rawSub}
+}
+
+// ParseNDJSONSubscriptions - decodes newline-delimited JSON payload (one model.RawSubscription per line) into import rows; blank lines are skipped.
+// Lines with unknown fields or more than one JSON value are invalid.
+func ParseNDJSONSubscriptions(source io.Reader) ([]model.ImportRow, error) {
+	scanner := bufio.NewScanner(source)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []model.ImportRow
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var rawSub model.RawSubscription
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&rawSub)
+		if err == nil && decoder.More() {
+			err = errors.New("more than one JSON value in line")
+		}
+		if err != nil {
+			rows = append(rows, model.ImportRow{Line: line, Err: fmt.Errorf("%w: invalid JSON: %w", ErrConvertToNorm, err)})
+			continue
+		}
+		rows = append(rows, model.ImportRow{Line: line, Sub: &rawSub})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrImportFormat, err)
+	}
+	return rows, nil
+}
+
+// SubsOverlap - reports whether two subscriptions of the same user and provider are active in at least one common month; mirrors repository.CheckIfExists
+func SubsOverlap(a, b *model.Subscription) bool {
+	return a.UID == b.UID && a.Provider == b.Provider && PeriodsOverlap(a, b)
+}
+
+// PeriodsOverlap - reports whether periods of subscriptions share at least one month; open-ended subscription lasts forever
+func PeriodsOverlap(a, b *model.Subscription) bool {
+	return (b.End == nil || !b.End.Before(a.Start)) && (a.End == nil || !a.End.Before(b.Start))
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"em-test/cmd/internal/model"
+	"strings"
+	"unicode"
+)
+
+// NormalizeProviderName - reduces provider name to a comparison key: lower case letters and digits only, so "Yandex Plus", "yandex plus" and "YandexPlus" match
+func NormalizeProviderName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func ConvertRawProviderToNormal(rawProvider *model.RawProvider) *model.Provider {
+	var provider model.Provider
+	if rawProvider.ID != nil {
+		provider.ID = *rawProvider.ID
+	}
+	provider.Name = strings.TrimSpace(rawProvider.Name)
+	provider.NormalizedName = NormalizeProviderName(provider.Name)
+	provider.CategoryID = rawProvider.CategoryID
+	provider.DefaultPrice = rawProvider.DefaultPrice
+	provider.Aliases = ConvertAliasesToNormal(rawProvider.Aliases)
+	return &provider
+}
+
+// ConvertAliasesToNormal - trims aliases and drops empty ones and duplicates by normalized key
+func ConvertAliasesToNormal(aliases []string) []model.ProviderAlias {
+	res := make([]model.ProviderAlias, 0, len(aliases))
+	seen := make(map[string]bool, len(aliases))
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		key := NormalizeProviderName(alias)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		res = append(res, model.ProviderAlias{Alias: alias, NormalizedAlias: key})
+	}
+	return res
+}
+
+func ConvertNormalProviderToRaw(provider *model.Provider) *model.RawProvider {
+	var rawProvider model.RawProvider
+	id := provider.ID
+	rawProvider.ID = &id
+	rawProvider.Name = provider.Name
+	rawProvider.CategoryID = provider.CategoryID
+	rawProvider.DefaultPrice = provider.DefaultPrice
+	rawProvider.Aliases = make([]string, len(provider.Aliases))
+	for i, alias := range provider.Aliases {
+		rawProvider.Aliases[i] = alias.Alias
+	}
+	return &rawProvider
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"em-test/cmd/internal/model"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// seedProvider - service offered to generated users with its monthly tariffs and relative popularity
+type seedProvider struct {
+	name    string
+	prices  []uint
+	weight  int
+	trials  bool // whether the service usually starts with a free month
+	churned int  // percent of subscriptions that end
+}
+
+var seedProviders = []seedProvider{
+	{"Yandex Plus", []uint{299, 399, 449}, 30, true, 20},
+	{"Kinopoisk", []uint{269, 399}, 15, true, 35},
+	{"Okko", []uint{199, 399, 599}, 10, true, 45},
+	{"ivi", []uint{199, 399}, 8, true, 50},
+	{"Start", []uint{299, 499}, 6, true, 50},
+	{"Amediateka", []uint{399, 599}, 5, false, 45},
+	{"Netflix", []uint{599, 799, 999}, 12, false, 30},
+	{"YouTube Premium", []uint{199, 299, 399}, 14, true, 25},
+	{"Spotify", []uint{169, 269}, 10, true, 30},
+	{"Apple Music", []uint{169, 269}, 9, true, 30},
+	{"VK Music", []uint{149, 249}, 12, true, 35},
+	{"iCloud+", []uint{59, 149, 599}, 16, false, 10},
+	{"Google One", []uint{139, 279, 699}, 7, false, 15},
+	{"Microsoft 365", []uint{349, 499}, 5, false, 25},
+	{"ChatGPT Plus", []uint{1990}, 6, false, 40},
+	{"Adobe Creative Cloud", []uint{1999, 3499}, 2, true, 40},
+	{"Dropbox", []uint{799, 1199}, 2, false, 30},
+	{"Notion", []uint{790}, 3, false, 35},
+	{"JetBrains", []uint{1450}, 2, false, 20},
+	{"Boosty", []uint{100, 300, 500}, 4, false, 60},
+}
+
+// GenerateSubscriptions - fake subscriptions of opts.Users users for load testing, the same opts give the same subscriptions.
+// A user has 1 to 2*opts.SubsPerUser-1 subscriptions to distinct services, so generated subscriptions never overlap;
+// subscriptions that ended before the current month are marked as expired, like the scheduler does.
+func GenerateSubscriptions(opts model.SeedOptions) []*model.Subscription {
+	random := rand.New(rand.NewSource(opts.Seed))
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	thisMonth := StartOfMonth(now.UTC())
+	months := max(opts.Months, 1)
+	perUser := max(opts.SubsPerUser, 1)
+
+	totalWeight := 0
+	for _, provider := range seedProviders {
+		totalWeight += provider.weight
+	}
+
+	var subs []*model.Subscription
+	for range opts.Users {
+		uid := seedUUID(random)
+		count := min(1+random.Intn(2*perUser-1), len(seedProviders))
+		taken := map[int]bool{}
+		for len(taken) < count {
+			// сервисы выбираются пропорционально популярности, без повторов у одного пользователя
+			pick := random.Intn(totalWeight)
+			i := 0
+			for ; pick >= seedProviders[i].weight; i++ {
+				pick -= seedProviders[i].weight
+			}
+			if taken[i] {
+				continue
+			}
+			taken[i] = true
+			subs = append(subs, seedSubscription(random, uid, seedProviders[i], thisMonth, months))
+		}
+	}
+	return subs
+}
+
+// seedSubscription - subscription of uid to provider started within months before thisMonth
+func seedSubscription(random *rand.Rand, uid string, provider seedProvider, thisMonth time.Time, months int) *model.Subscription {
+	monthAt := func(offset int) *time.Time {
+		month := thisMonth.AddDate(0, offset, 15)
+		return &month
+	}
+	startOffset := -random.Intn(months)
+	sub := &model.Subscription{
+		UID:      uid,
+		Provider: provider.name,
+		Price:    provider.prices[random.Intn(len(provider.prices))],
+		Start:    *monthAt(startOffset),
+		Version:  1,
+	}
+	if provider.trials && random.Intn(100) < 40 {
+		sub.TrialUntil = monthAt(startOffset)
+	}
+	if random.Intn(100) < provider.churned {
+		endOffset := startOffset + random.Intn(24)
+		sub.End = monthAt(endOffset)
+		if endOffset < 0 {
+			expiredAt := StartOfMonth(*sub.End).AddDate(0, 1, 0)
+			sub.ExpiredAt = &expiredAt
+		}
+	}
+	return sub
+}
+
+// seedUUID - random UUID v4 from random, so that seeded user IDs are reproducible
+func seedUUID(random *rand.Rand) string {
+	buf := make([]byte, 16)
+	random.Read(buf)
+	buf[6] = buf[6]&0x0f | 0x40
+	buf[8] = buf[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", buf[0:4], buf[4:6], buf[6:8], buf[8:10], buf[10:])
+}
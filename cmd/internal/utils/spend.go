@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"em-test/cmd/internal/model"
+	"time"
+)
+
+// SpendRows - contribution of subscription to monthly_spend in months from `from` to `until` (zero `from` - from the start of subscription),
+// multiplied by sign: 1 adds subscription, -1 removes it. Months covered by trial are counted with zero amount, like in report
+func SpendRows(sub *model.Subscription, sign int64, from, until time.Time) []model.MonthlySpend {
+	month := StartOfMonth(sub.Start.UTC())
+	if from.After(month) {
+		month = StartOfMonth(from.UTC())
+	}
+	last := StartOfMonth(until.UTC())
+	if sub.End != nil && sub.End.Before(last) {
+		last = StartOfMonth(sub.End.UTC())
+	}
+	var providerID uint64
+	if sub.ProviderID != nil {
+		providerID = *sub.ProviderID
+	}
+
+	var rows []model.MonthlySpend
+	for ; !month.After(last); month = month.AddDate(0, 1, 0) {
+		amount := int64(sub.Price)
+		if sub.TrialUntil != nil && !sub.TrialUntil.Before(month) {
+			amount = 0
+		}
+		rows = append(rows, model.MonthlySpend{
+			Month:         month,
+			UID:           sub.UID,
+			ProviderID:    providerID,
+			Provider:      sub.Provider,
+			Amount:        sign * amount,
+			Subscriptions: sign,
+		})
+	}
+	return rows
+}
+
+// MergeSpendRows - sums rows with the same month, user and service; rows that cancel each other out are dropped
+func MergeSpendRows(rows []model.MonthlySpend) []model.MonthlySpend {
+	type key struct {
+		month      time.Time
+		uid        string
+		providerID uint64
+		provider   string
+	}
+	index := make(map[key]int, len(rows))
+	merged := make([]model.MonthlySpend, 0, len(rows))
+	for _, row := range rows {
+		k := key{row.Month, row.UID, row.ProviderID, row.Provider}
+		if i, ok := index[k]; ok {
+			merged[i].Amount += row.Amount
+			merged[i].Subscriptions += row.Subscriptions
+			continue
+		}
+		index[k] = len(merged)
+		merged = append(merged, row)
+	}
+	res := merged[:0]
+	for _, row := range merged {
+		if row.Amount != 0 || row.Subscriptions != 0 {
+			res = append(res, row)
+		}
+	}
+	return res
+}
@@ -16,16 +16,24 @@ func ConvertRawSubToNormal(rawSub *model.RawSubscription) (*model.Subscription,
 	price := rawSub.Price
 	start, err1 := formatTextToTime(rawSub.Start)
 	end, err2 := formatTextToTime(rawSub.End)
+	trialUntil, err3 := formatTextToTime(rawSub.TrialUntil)
 	normSub.End = end
-	if err1 != nil || err2 != nil {
-		return nil, fmt.Errorf("%w: %w\n%w", ErrConvertToNorm, err1, err2)
+	normSub.TrialUntil = trialUntil
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConvertToNorm, errors.Join(err1, err2, err3))
 	}
 	normSub.SID = sid
+	normSub.PreviousSID = rawSub.PreviousSID
+	normSub.ProviderID = rawSub.ProviderID
 	normSub.UID = rawSub.UID
 	normSub.Provider = rawSub.Provider
 
-	normSub.Price = *price
-	normSub.Start = *start
+	if price != nil {
+		normSub.Price = *price
+	}
+	if start != nil {
+		normSub.Start = *start
+	}
 
 	return &normSub, nil
 }
@@ -38,6 +46,14 @@ func ConvertNormalSubToRaw(normSub *model.Subscription) *model.RawSubscription {
 	rawSub.Price = &normSub.Price
 	rawSub.Start = formatTimeToText(&normSub.Start)
 	rawSub.End = formatTimeToText(normSub.End)
+	rawSub.PreviousSID = normSub.PreviousSID
+	rawSub.ProviderID = normSub.ProviderID
+	rawSub.TrialUntil = formatTimeToText(normSub.TrialUntil)
+	rawSub.Status = SubscriptionStatus(normSub, time.Now())
+	rawSub.Version = &normSub.Version
+	if normSub.CancelledAt != nil {
+		rawSub.CancelledAt = normSub.CancelledAt.UTC().Format(time.RFC3339)
+	}
 	return &rawSub
 }
 
@@ -69,6 +85,27 @@ func ConvertFilterToNorm(rawfilter *model.RawReportFilter) (*model.ReportFilter,
 	return normFilter, nil
 }
 
+// ParseMonth - converts month in "01-2006" format to time in the same representation as dates of model.Subscription
+func ParseMonth(month string) (*time.Time, error) {
+	if month == "" {
+		return nil, fmt.Errorf("%w: empty month", ErrConvertToNorm)
+	}
+	res, err := formatTextToTime(month)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConvertToNorm, err)
+	}
+	return res, nil
+}
+
+// NextMonth - returns the month following the provided one in "01-2006" format
+func NextMonth(month string) (string, error) {
+	startOfMonth, err := time.Parse("01-2006", month)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrConvertToNorm, err)
+	}
+	return startOfMonth.AddDate(0, 1, 0).Format("01-2006"), nil
+}
+
 func formatTextToTime(source string) (*time.Time, error) {
 	if source == "" {
 		return nil, nil
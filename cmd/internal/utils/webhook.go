@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"em-test/cmd/internal/model"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook request headers
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookEventIDHeader   = "X-Webhook-Event-Id"
+)
+
+// SignWebhookPayload - returns "sha256=<hex>" HMAC-SHA256 signature of "<timestamp>.<body>" with webhook secret;
+// receivers should recompute it and reject stale timestamps to prevent replays
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// RandomHex - returns n random bytes hex-encoded, used for generated webhook secrets
+func RandomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// ConvertRawWebhookToNormal - validates URL and event types of webhook; empty list of event types means all of them
+func ConvertRawWebhookToNormal(rawWebhook *model.RawWebhook) (*model.Webhook, error) {
+	webhook := &model.Webhook{URL: strings.TrimSpace(rawWebhook.URL), Secret: rawWebhook.Secret, Active: true}
+	if rawWebhook.Active != nil {
+		webhook.Active = *rawWebhook.Active
+	}
+	if webhook.URL != "" {
+		u, err := url.Parse(webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%w: url must be absolute http(s) URL", ErrConvertToNorm)
+		}
+	}
+	eventTypes := rawWebhook.EventTypes
+	if len(eventTypes) == 0 {
+		eventTypes = model.EventTypes
+	}
+	for _, eventType := range eventTypes {
+		if !slices.Contains(model.EventTypes, eventType) {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrConvertToNorm, eventType)
+		}
+	}
+	webhook.EventTypes = strings.Join(eventTypes, ",")
+	return webhook, nil
+}
+
+// ConvertNormalWebhookToRaw - converts webhook for output, secret is not exposed
+func ConvertNormalWebhookToRaw(webhook *model.Webhook) *model.RawWebhook {
+	active := webhook.Active
+	return &model.RawWebhook{
+		ID:         &webhook.ID,
+		URL:        webhook.URL,
+		EventTypes: strings.Split(webhook.EventTypes, ","),
+		Active:     &active,
+		CreatedAt:  webhook.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// WebhookAccepts - checks if webhook is subscribed to event type
+func WebhookAccepts(webhook *model.Webhook, eventType string) bool {
+	return slices.Contains(strings.Split(webhook.EventTypes, ","), eventType)
+}
+
+// WebhookBackoff - returns delay before attempt following the given number of failed ones: base * 2^(attempts-1), capped by max
+func WebhookBackoff(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	return min(delay, max)
+}
+
+// ConvertEventToPayload - converts stored event to the form seen by consumers
+func ConvertEventToPayload(event *model.Event) *model.EventPayload {
+	payload := &model.EventPayload{ID: event.ID, Type: event.Type, CreatedAt: event.CreatedAt.UTC()}
+	var sub model.RawSubscription
+	if err := json.Unmarshal([]byte(event.Data), &sub); err == nil {
+		payload.Subscription = &sub
+	}
+	return payload
+}
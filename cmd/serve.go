@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"em-test/cmd/config"
+	"em-test/cmd/internal/db"
+	"em-test/cmd/internal/grpcapi"
+	"em-test/cmd/internal/handler"
+	"em-test/cmd/internal/middleware"
+	"em-test/cmd/internal/service"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/spf13/cobra"
+	httpSwagger "github.com/swaggo/http-swagger"
+	"google.golang.org/grpc"
+)
+
+func serveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Start HTTP and gRPC servers with background jobs (default command)",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			serve(config.Load())
+		},
+	}
+}
+
+// serve - creates tables, starts background jobs, HTTP and gRPC servers and blocks until the process is stopped
+func serve(cfg *config.Config) {
+	database := db.ConnectPostgres(cfg.DSN)
+
+	//Graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-quit
+		cancel()
+		sqlDB, err := database.DB()
+		if err == nil {
+			sqlDB.Close()
+		}
+		log.Printf("[%v] Subscription server stopped: DB-connections closed.\n", time.Now().Format("2006-01-02 15:04:05"))
+		os.Exit(0)
+	}()
+
+	//Creting hadnler with embedded service and repo
+	subHandler := handler.CreateHandler(database)
+	subHandler.RequireIfMatch = cfg.RequireIfMatch
+	idempotency := handler.CreateIdempotencyHandler(database)
+	providerHandler := handler.CreateProviderHandler(database)
+	categoryHandler := handler.CreateCategoryHandler(database)
+	webhookHandler := handler.CreateWebhookHandler(database)
+	eventHandler := handler.CreateEventHandler(database)
+	budgetHandler := handler.CreateBudgetHandler(database)
+	graphqlHandler := handler.CreateGraphQLHandler(database)
+	grpcAPI := grpcapi.CreateServer(database)
+
+	//Report cache shared by every service writing subscriptions, so their writes invalidate it
+	if cfg.ReportCacheSize > 0 {
+		reportCache := service.CreateReportCache(service.CreateLRUReportBackend(cfg.ReportCacheSize, cfg.ReportCacheTTL))
+		subHandler.Service.Cache = reportCache
+		providerHandler.Service.Cache = reportCache
+		categoryHandler.Service.Cache = reportCache
+		graphqlHandler.Schema.Root().Service.Cache = reportCache
+		graphqlHandler.Schema.Root().ProviderService.Cache = reportCache
+		grpcAPI.Service.Cache = reportCache
+	}
+
+	r := chi.NewRouter()
+
+	//Middlewares: per-client rate limiting and request body size limit
+	if cfg.RateLimitRPS > 0 {
+		limiter := middleware.CreateRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst)
+		if len(cfg.TrustedProxies) > 0 {
+			limiter.Key = middleware.ForwardedClientKey(cfg.TrustedProxies)
+		}
+		r.Use(limiter.Middleware)
+	}
+	r.Use(middleware.MaxBodySize(cfg.MaxBodySize))
+
+	//Background jobs: webhook outbox dispatching and subscription scheduler (expiry marks, expiring notifications)
+	dispatcher := service.CreateWebhookDispatcher(database)
+	dispatcher.MaxAttempts = cfg.WebhookMaxAttempts
+	go service.RunEvery(ctx, cfg.WebhookInterval, "webhook dispatch", func(ctx context.Context) error {
+		_, err := dispatcher.DispatchPending(ctx)
+		return err
+	})
+	scheduler := service.CreateScheduler(database, cfg.SchedulerInterval, cfg.ExpiringWindow)
+	scheduler.IdempotencyTTL = cfg.IdempotencyTTL
+	go scheduler.Run(ctx)
+
+	//HTTP-handlers: service and swagger
+	r.Post("/subscriptions", idempotency.Idempotent(subHandler.Create))
+	r.Post("/subscriptions/import", subHandler.Import)
+	r.Post("/subscriptions/batch", idempotency.Idempotent(subHandler.Batch))
+	r.Get("/subscriptions", subHandler.GetList)
+	r.Get("/subscriptions/{sid}", subHandler.GetBySID)
+	r.Delete("/subscriptions/{sid}", subHandler.Delete)
+	r.Put("/subscriptions/{sid}", subHandler.UpdateBySID)
+	r.Patch("/subscriptions/{sid}", subHandler.UpdateBySID)
+	r.Post("/subscriptions/{sid}/switch", subHandler.Switch)
+	r.Post("/subscriptions/{sid}/cancel", subHandler.Cancel)
+
+	r.Get("/subscriptions/report", subHandler.Report)
+	r.Get("/subscriptions/report/trials", subHandler.TrialReport)
+	r.Get("/subscriptions/report/anomalies", subHandler.Anomalies)
+	r.Get("/subscriptions/report/cache", subHandler.ReportCacheStats)
+	r.Get("/subscriptions/upcoming", subHandler.Upcoming)
+	r.Get("/subscriptions/forecast", subHandler.Forecast)
+	//GET  /subscriptions/report?period=05-2024&uid=42&provider=YoutubePremium
+	//GET  /subscriptions/report?period=05-2024&category=Entertainment&group_by=category
+	//GET  /subscriptions/forecast?months=12&uid=42
+
+	r.Post("/providers", providerHandler.Create)
+	r.Get("/providers", providerHandler.GetList)
+	r.Get("/providers/{id}", providerHandler.GetByID)
+	r.Put("/providers/{id}", providerHandler.UpdateByID)
+	r.Delete("/providers/{id}", providerHandler.Delete)
+
+	r.Post("/categories", categoryHandler.Create)
+	r.Get("/categories", categoryHandler.GetList)
+	r.Get("/categories/{id}", categoryHandler.GetByID)
+	r.Put("/categories/{id}", categoryHandler.UpdateByID)
+	r.Delete("/categories/{id}", categoryHandler.Delete)
+
+	r.Post("/budgets", budgetHandler.Create)
+	r.Get("/budgets", budgetHandler.GetList)
+	r.Get("/budgets/over", budgetHandler.OverBudget)
+	r.Get("/budgets/{id}", budgetHandler.GetByID)
+	r.Put("/budgets/{id}", budgetHandler.UpdateByID)
+	r.Delete("/budgets/{id}", budgetHandler.Delete)
+
+	r.Post("/webhooks", webhookHandler.Create)
+	r.Get("/webhooks", webhookHandler.GetList)
+	r.Get("/webhooks/deliveries", webhookHandler.GetDeliveries)
+	r.Post("/webhooks/deliveries/{id}/retry", webhookHandler.RetryDelivery)
+	r.Get("/webhooks/{id}", webhookHandler.GetByID)
+	r.Put("/webhooks/{id}", webhookHandler.UpdateByID)
+	r.Delete("/webhooks/{id}", webhookHandler.Delete)
+
+	r.Get("/events", eventHandler.GetList)
+	r.Get("/events/stream", eventHandler.Stream)
+
+	r.Post("/graphql", graphqlHandler.Query)
+
+	r.Get("/swagger/*", httpSwagger.WrapHandler)
+
+	//Starting gRPC server on its own port
+	grpcServer := grpc.NewServer()
+	grpcAPI.Register(grpcServer)
+	listener, err := net.Listen("tcp", cfg.GRPCPort)
+	if err != nil {
+		log.Fatalf("gRPC server failed to listen: %v", err)
+	}
+	go func() {
+		log.Printf("gRPC server running on %s", cfg.GRPCPort)
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Fatalf("gRPC server failed: %v", err)
+		}
+	}()
+
+	//Starting server
+	log.Printf("Server running on http://localhost%s", cfg.Port)
+	if err := http.ListenAndServe(cfg.Port, r); err != nil {
+		log.Fatalf("Server failed to start: %v", err)
+	}
+}
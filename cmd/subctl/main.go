@@ -0,0 +1,8 @@
+// Command subctl - command-line client of subscription API
+package main
+
+import "em-test/cmd/internal/subctl"
+
+func main() {
+	subctl.Execute()
+}
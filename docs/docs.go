@@ -15,23 +15,32 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/subscriptions": {
+        "/budgets": {
             "get": {
-                "description": "Отдает массив из всех подписок в базе; пустой json если подписок нет",
+                "description": "Отдает массив бюджетов, при указании user_id - только бюджеты этого пользователя",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "budgets"
+                ],
+                "summary": "Получение списка бюджетов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "UID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Получение списка всех подписок из базы",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/model.RawSubscription"
+                                "$ref": "#/definitions/model.RawBudget"
                             }
                         }
                     },
@@ -44,7 +53,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Создаёт новую подписку из данных в теле запроса",
+                "description": "Создает бюджет пользователя на месяц; необязательно ограничивается категорией (category_id или путь category, включая подкатегории) и/или сервисом (service_name). Режим warn (по умолчанию) - превышение при создании/изменении подписки возвращается предупреждением, reject - изменение отклоняется.",
                 "consumes": [
                     "application/json"
                 ],
@@ -52,25 +61,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "budgets"
                 ],
-                "summary": "Cоздание новой подписки",
+                "summary": "Создание месячного бюджета пользователя",
                 "parameters": [
                     {
-                        "description": "Subscription info",
-                        "name": "subscription",
+                        "description": "Budget info",
+                        "name": "budget",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.RawSubscription"
+                            "$ref": "#/definitions/model.RawBudget"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Subscription successfully created",
+                        "description": "Budget successfully created",
                         "schema": {
-                            "$ref": "#/definitions/model.RawSubscription"
+                            "$ref": "#/definitions/model.RawBudget"
                         }
                     },
                     "400": {
@@ -79,8 +88,8 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
-                    "409": {
-                        "description": "Subscription already exists",
+                    "413": {
+                        "description": "Request body too large",
                         "schema": {
                             "type": "string"
                         }
@@ -94,42 +103,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscriptions/report": {
+        "/budgets/over": {
             "get": {
-                "description": "Выдает сумму стоимости подписок по указанному периоду(конкретному месяцу в формате \"07-2024\"), пользователю и провайдеру; пользователь и провайдер не являются обязательными полями.",
+                "description": "Отдает бюджеты, траты по которым в указанном месяце (по умолчанию - текущем) превышают сумму бюджета, с фактической суммой и размером превышения",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "subscriptions"
+                    "budgets"
                 ],
-                "summary": "Подсчет суммы подписок удовлетворяющим условиям",
+                "summary": "Пользователи с превышением бюджета",
                 "parameters": [
                     {
                         "type": "string",
                         "example": "07-2025",
-                        "description": "Период(конкретный месяц в формате 07-2024) для поиска подписок в активном статусе",
+                        "description": "Месяц в формате 07-2025",
                         "name": "period",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "example": "adjhdjfnv-njdfv889",
-                        "description": "UID пользователя",
-                        "name": "uid",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "example": "Yandex",
-                        "description": "Имя провайдера услуги",
-                        "name": "provider",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "UID пользователя",
+                        "name": "user_id",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Status OK",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.Report"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.BudgetStatus"
+                            }
                         }
                     },
                     "400": {
@@ -147,22 +154,22 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscriptions/{sid}": {
+        "/budgets/{id}": {
             "get": {
-                "description": "Возвращает подписку в формате JSON по ее SID из URL",
+                "description": "Возвращает бюджет пользователя",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "budgets"
                 ],
-                "summary": "Получение подписки по SID",
+                "summary": "Получение бюджета по ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "example": 20,
-                        "description": "SID подписки",
-                        "name": "sid",
+                        "example": 1,
+                        "description": "ID бюджета",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -171,11 +178,17 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/model.RawSubscription"
+                            "$ref": "#/definitions/model.RawBudget"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "404": {
-                        "description": "Subscription not found",
+                        "description": "Budget not found",
                         "schema": {
                             "type": "string"
                         }
@@ -189,7 +202,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "Обновляет подписку по ее SID из URL, новые данные берутся из тела запроса",
+                "description": "Обновляет переданные поля бюджета; category_id равный 0 снимает ограничение по категории",
                 "consumes": [
                     "application/json"
                 ],
@@ -197,33 +210,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "budgets"
                 ],
-                "summary": "Обновление подписки по ее SID",
+                "summary": "Обновление бюджета по ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "example": 20,
-                        "description": "SID подписки",
-                        "name": "sid",
+                        "example": 1,
+                        "description": "ID бюджета",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Subscription info",
-                        "name": "subscription",
+                        "description": "Budget info",
+                        "name": "budget",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.RawSubscription"
+                            "$ref": "#/definitions/model.RawBudget"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Subscription updated successfully",
+                        "description": "Budget updated successfully",
                         "schema": {
-                            "$ref": "#/definitions/model.RawSubscription"
+                            "$ref": "#/definitions/model.RawBudget"
                         }
                     },
                     "400": {
@@ -233,7 +246,13 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Subscription not found",
+                        "description": "Budget not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
                         "schema": {
                             "type": "string"
                         }
@@ -247,17 +266,17 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Удаляет подписки по ее SID из URL",
+                "description": "Удаляет бюджет пользователя",
                 "tags": [
-                    "subscriptions"
+                    "budgets"
                 ],
-                "summary": "Удаление подписки по SID",
+                "summary": "Удаление бюджета по ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "example": 20,
-                        "description": "SID подписки",
-                        "name": "sid",
+                        "example": 1,
+                        "description": "ID бюджета",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -276,7 +295,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Subscription not found",
+                        "description": "Budget not found",
                         "schema": {
                             "type": "string"
                         }
@@ -289,42 +308,2782 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "model.RawSubscription": {
-            "type": "object",
-            "properties": {
-                "end_date": {
-                    "type": "string",
-                    "example": "12-2025"
-                },
-                "price": {
-                    "type": "integer",
-                    "example": 400
-                },
-                "service_name": {
-                    "type": "string",
-                    "example": "Yandex Plus"
-                },
-                "start_date": {
-                    "type": "string",
-                    "example": "07-2025"
-                },
-                "subscription_id": {
-                    "type": "integer",
-                    "example": 20
-                },
-                "user_id": {
-                    "type": "string",
-                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+        },
+        "/categories": {
+            "get": {
+                "description": "Отдает все категории с полными путями, отсортированные по пути",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Получение дерева категорий",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.RawCategory"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создает категорию; parent_id задает родительскую категорию (например, Video внутри Entertainment)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Создание категории провайдеров",
+                "parameters": [
+                    {
+                        "description": "Category info",
+                        "name": "category",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RawCategory"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Category successfully created",
+                        "schema": {
+                            "$ref": "#/definitions/model.RawCategory"
+                        }
+                    },
+                    "400": {
+                        "description": "Incomplete/incorrect data input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Parent category not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Category already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
                 }
             }
         },
-        "model.Report": {
-            "type": "object",
-            "properties": {
-                "total": {
+        "/categories/{id}": {
+            "get": {
+                "description": "Возвращает категорию с полным путем",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Получение категории по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 2,
+                        "description": "ID категории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.RawCategory"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Переименовывает категорию и/или переносит ее под другого родителя; parent_id=0 переносит категорию в корень",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Обновление категории по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 2,
+                        "description": "ID категории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Category info",
+                        "name": "category",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RawCategory"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Category updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/model.RawCategory"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Category already exists or move creates a cycle",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет категорию, если у нее нет подкатегорий и привязанных провайдеров",
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Удаление категории по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 2,
+                        "description": "ID категории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Category has subcategories or providers",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/events": {
+            "get": {
+                "description": "Отдает события изменений подписок (subscription.created/updated/deleted/expiring) в порядке записи, следующие за курсором after. Для продолжения чтения передайте next_cursor из ответа как after.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Чтение журнала событий подписок",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 42,
+                        "description": "Курсор: ID последнего полученного события (0 - с начала журнала)",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 100,
+                        "description": "Максимум событий (по умолчанию и не более 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.EventPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/events/stream": {
+            "get": {
+                "description": "Держит соединение открытым и отправляет события журнала по мере появления в формате text/event-stream (id - курсор события, event - тип, data - событие в JSON). Чтение начинается после курсора after или заголовка Last-Event-ID при переподключении.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Поток событий подписок (SSE)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 42,
+                        "description": "Курсор: ID последнего полученного события",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор при переподключении",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Streaming unsupported",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/graphql": {
+            "post": {
+                "description": "Выполняет GraphQL-запрос к схеме подписок, пользователей, провайдеров и отчётов (cmd/internal/gqlapi/schema.graphql). Списки поддерживают фильтры и постраничную выдачу (first, after); вложенные подписки, провайдеры и суммы пользователей загружаются пакетно, без запроса к базе на каждый элемент. Ошибки выполнения возвращаются в errors с кодом в extensions.code (BAD_USER_INPUT, NOT_FOUND, INTERNAL).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "graphql"
+                ],
+                "summary": "GraphQL-запрос",
+                "parameters": [
+                    {
+                        "description": "GraphQL query",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.GraphQLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "data и errors",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or empty query",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/providers": {
+            "get": {
+                "description": "Отдает массив всех провайдеров каталога, отсортированных по названию",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Получение каталога провайдеров",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.RawProvider"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создает провайдера с каноническим названием, синонимами, категорией (category_id или путь category) и ценой по умолчанию. Существующие подписки с совпадающим названием сервиса привязываются к провайдеру.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Добавление провайдера в каталог",
+                "parameters": [
+                    {
+                        "description": "Provider info",
+                        "name": "provider",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RawProvider"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Provider successfully created",
+                        "schema": {
+                            "$ref": "#/definitions/model.RawProvider"
+                        }
+                    },
+                    "400": {
+                        "description": "Incomplete/incorrect data input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Provider name or alias already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/providers/{id}": {
+            "get": {
+                "description": "Возвращает провайдера из каталога вместе с синонимами",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Получение провайдера по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 3,
+                        "description": "ID провайдера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.RawProvider"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Provider not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновляет непустые поля провайдера; список синонимов заменяется целиком, если передан. При переименовании обновляются названия сервиса у привязанных подписок.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Обновление провайдера по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 3,
+                        "description": "ID провайдера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Provider info",
+                        "name": "provider",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RawProvider"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Provider updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/model.RawProvider"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Provider not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Provider name or alias already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет провайдера из каталога, если на него не ссылаются подписки",
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Удаление провайдера по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 3,
+                        "description": "ID провайдера",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Provider not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Provider is referenced by subscriptions",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions": {
+            "get": {
+                "description": "Отдает массив из всех подписок в базе; пустой json если подписок нет.\nПри заголовке Accept: text/csv или application/x-ndjson подписки выгружаются потоково из базы в соответствующем формате.\nПараметр status оставляет только подписки с вычисленным статусом: active, scheduled (начинается в будущем месяце), expired (месяц окончания прошел), cancelled (отменена).",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Получение списка всех подписок из базы",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "active",
+                        "description": "Статус подписки",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.RawSubscription"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown status",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "406": {
+                        "description": "Unsupported Accept header",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создаёт новую подписку из данных в теле запроса. Если месячные траты пользователя превышают его бюджет, подписка отклоняется (бюджет в режиме reject) или создается с предупреждением в warnings (режим warn).\nС заголовком Idempotency-Key повторный запрос с тем же ключом и телом получает исходный ответ (с заголовком Idempotent-Replayed), а запрос с тем же ключом и другим телом - 422.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Cоздание новой подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "5f0c8d2e-create-netflix",
+                        "description": "Ключ идемпотентности запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Subscription info",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RawSubscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Subscription successfully created",
+                        "schema": {
+                            "$ref": "#/definitions/model.RawSubscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия и статус подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Incomplete/incorrect data input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Subscription already exists or request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Budget exceeded or Idempotency-Key reused with another request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/batch": {
+            "post": {
+                "description": "Выполняет операции create/update/delete по порядку в одной транзакции. При ошибке любой операции вся транзакция откатывается, а в ответе указывается результат каждой операции.\nПоле version в subscription операций update и delete задает ожидаемую версию подписки; если подписка уже изменена, пакет откатывается с 412.\nС заголовком Idempotency-Key повторный запрос с тем же ключом и телом получает исходный ответ, а с другим телом - 422.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Пакетное создание/обновление/удаление подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "5f0c8d2e-batch-1",
+                        "description": "Ключ идемпотентности запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Operations",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "All operations committed",
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Incomplete/incorrect data input",
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchResult"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found, batch rolled back",
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchResult"
+                        }
+                    },
+                    "409": {
+                        "description": "Subscription already exists, batch rolled back; or request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchResult"
+                        }
+                    },
+                    "412": {
+                        "description": "Subscription was modified, batch rolled back",
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchResult"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Budget exceeded, batch rolled back; or Idempotency-Key reused with another request",
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchResult"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/forecast": {
+            "get": {
+                "description": "Начиная с текущего месяца, для каждого из months месяцев выдает отчет в формате /subscriptions/report по текущим подпискам: бессрочные продолжаются, подписки с датой окончания прекращаются после нее, месяцы пробного периода бесплатны, а смена тарифа с будущего месяца учитывается с этого месяца.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Прогноз ежемесячных расходов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 12,
+                        "description": "Число месяцев прогноза (1-36), по умолчанию 12",
+                        "name": "months",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "adjhdjfnv-njdfv889",
+                        "description": "UID пользователя",
+                        "name": "uid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Yandex",
+                        "description": "Имя провайдера услуги",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Entertainment \u003e Video",
+                        "description": "ID или путь категории, включая подкатегории",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "category"
+                        ],
+                        "type": "string",
+                        "description": "Группировка по категориям",
+                        "name": "group_by",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Forecast"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/import": {
+            "post": {
+                "description": "Принимает CSV (с заголовком service_name,price,user_id,start_date,end_date) или NDJSON (по одному объекту подписки на строку). Каждая строка проверяется по тем же правилам, что и при создании подписки, включая пересечения с базой и внутри пакета.\nmode=all_or_nothing (по умолчанию) сохраняет строки только если все они валидны, mode=valid_only сохраняет только валидные строки. dry_run=true только проверяет данные.",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Массовый импорт подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "valid_only",
+                        "description": "Режим импорта: all_or_nothing или valid_only",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "example": true,
+                        "description": "Только проверка без сохранения",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Validation report (dry run or nothing to create)",
+                        "schema": {
+                            "$ref": "#/definitions/model.ImportReport"
+                        }
+                    },
+                    "201": {
+                        "description": "Rows imported",
+                        "schema": {
+                            "$ref": "#/definitions/model.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed payload or parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported content type",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Some rows are invalid, nothing imported",
+                        "schema": {
+                            "$ref": "#/definitions/model.ImportReport"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/report": {
+            "get": {
+                "description": "Выдает сумму стоимости подписок по указанному периоду(конкретному месяцу в формате \"07-2024\"), пользователю и провайдеру; пользователь и провайдер не являются обязательными полями.\nМесяцы пробного периода (до trial_until включительно) учитываются с нулевой стоимостью.\nПри заголовке Accept: text/csv или application/x-ndjson вместо суммы потоково выгружаются подписки, учтенные в отчете; сумма передается в заголовке X-Report-Total.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Подсчет суммы подписок удовлетворяющим условиям",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "07-2025",
+                        "description": "Период(конкретный месяц в формате 07-2024) для поиска подписок в активном статусе",
+                        "name": "period",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "adjhdjfnv-njdfv889",
+                        "description": "UID пользователя",
+                        "name": "uid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Yandex",
+                        "description": "Имя провайдера услуги",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Entertainment \u003e Video",
+                        "description": "ID или путь категории, включая подкатегории",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "category",
+                        "description": "Группировка суммы: category",
+                        "name": "group_by",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Report"
+                        },
+                        "headers": {
+                            "X-Report-Total": {
+                                "type": "integer",
+                                "description": "Сумма стоимости подписок (для CSV и NDJSON)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "406": {
+                        "description": "Unsupported Accept header",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/report/anomalies": {
+            "get": {
+                "description": "Находит вероятные дубликаты (пересекающиеся по датам подписки одного пользователя на одного провайдера или на провайдеров с похожими нормализованными названиями), подписки с ценой, в price_ratio раз отличающейся от медианы по провайдеру (при не менее чем 3 подписках на него), и пользователей с max_concurrent и более подписками одной категории, действующими в месяце period.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Отчет о дубликатах и аномалиях подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "adjhdjfnv-njdfv889",
+                        "description": "UID пользователя",
+                        "name": "uid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "07-2025",
+                        "description": "Месяц проверки одновременных подписок в формате 07-2024, по умолчанию текущий",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "example": 0.8,
+                        "description": "Минимальная похожесть названий провайдеров от 0 до 1, по умолчанию 0.8",
+                        "name": "similarity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "example": 2,
+                        "description": "Во сколько раз цена должна отличаться от медианы, по умолчанию 2",
+                        "name": "price_ratio",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 3,
+                        "description": "Число одновременных подписок одной категории, начиная с которого пользователь попадает в отчет, по умолчанию 3",
+                        "name": "max_concurrent",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.AnomalyReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/report/cache": {
+            "get": {
+                "description": "Число попаданий и промахов кэша сумм /subscriptions/report с запуска процесса, доля попаданий, число записей, а также записей, сброшенных из-за изменения подписок их пользователя, сервиса или месяца (invalidations) и вытесненных по размеру или сроку жизни (evictions). При REPORT_CACHE_SIZE=0 кэш выключен (enabled=false).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Метрики кэша отчетов",
+                "responses": {
+                    "200": {
+                        "description": "Status OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ReportCacheStats"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/report/trials": {
+            "get": {
+                "description": "Для указанного месяца выдает число подписок в пробном периоде, число закончившихся в этом месяце пробных периодов и сколько из них переходят в платные (подписка продолжается после trial_until) с их ежемесячной стоимостью.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Отчет по пробным периодам за месяц",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "07-2025",
+                        "description": "Месяц в формате 07-2024",
+                        "name": "period",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "adjhdjfnv-njdfv889",
+                        "description": "UID пользователя",
+                        "name": "uid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Yandex",
+                        "description": "Имя провайдера услуги",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Entertainment \u003e Video",
+                        "description": "ID или путь категории, включая подкатегории",
+                        "name": "category",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Status OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.TrialReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/upcoming": {
+            "get": {
+                "description": "Возвращает подписки, у которых дата окончания (конец месяца end_date) попадает в окно от текущего момента, и бессрочные подписки, очередное ежемесячное списание которых (первое число ближайшего неоплаченного месяца) попадает в окно. Результат отсортирован по дате события.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Ближайшие продления и окончания подписок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "30d",
+                        "description": "Окно: дни (30d), недели (2w) или длительность Go (36h); по умолчанию 30d",
+                        "name": "within",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+                        "description": "UID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.UpcomingSubscription"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{sid}": {
+            "get": {
+                "description": "Возвращает подписку в формате JSON по ее SID из URL с ETag из версии и вычисляемого статуса (\"3-active\"): статус меняется со временем без новой версии. Если ETag совпадает с If-None-Match, возвращается 304 без тела.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Получение подписки по SID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 20,
+                        "description": "SID подписки",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"3-active\"",
+                        "description": "ETag ранее полученного ответа",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.RawSubscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия и статус подписки"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновляет подписку по ее SID из URL, новые данные берутся из тела запроса; пустые поля не меняются (PUT и PATCH работают одинаково). Бюджеты пользователя проверяются так же, как при создании.\nДля защиты от одновременного изменения передайте в If-Match значение ETag, полученное при чтении подписки (или version в теле): если подписка уже изменена, возвращается 412.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Обновление подписки по ее SID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 20,
+                        "description": "SID подписки",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"3-active\"",
+                        "description": "ETag подписки",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Subscription info",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RawSubscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscription updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/model.RawSubscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия и статус подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Subscription was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Budget exceeded",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет подписки по ее SID из URL; при заголовке If-Match подписка удаляется, только если ее ETag совпадает, иначе возвращается 412.",
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Удаление подписки по SID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 20,
+                        "description": "SID подписки",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"3-active\"",
+                        "description": "ETag подписки",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Subscription was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Обновляет подписку по ее SID из URL, новые данные берутся из тела запроса; пустые поля не меняются (PUT и PATCH работают одинаково). Бюджеты пользователя проверяются так же, как при создании.\nДля защиты от одновременного изменения передайте в If-Match значение ETag, полученное при чтении подписки (или version в теле): если подписка уже изменена, возвращается 412.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Обновление подписки по ее SID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 20,
+                        "description": "SID подписки",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "\"3-active\"",
+                        "description": "ETag подписки",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Subscription info",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RawSubscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscription updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/model.RawSubscription"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия и статус подписки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Subscription was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Budget exceeded",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{sid}/cancel": {
+            "post": {
+                "description": "Отменяет подписку по SID: она остается оплаченной до конца текущего месяца (или месяца начала, если еще не началась) и заканчивается в нем, если не заканчивается раньше. Статус подписки становится cancelled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Отмена подписки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 20,
+                        "description": "SID подписки",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscription cancelled",
+                        "schema": {
+                            "$ref": "#/definitions/model.RawSubscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Subscription already cancelled",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{sid}/switch": {
+            "post": {
+                "description": "Закрывает подписку по SID в указанном месяце (switch_month) и создает новую подписку с началом в следующем месяце, связанную с предыдущей через previous_subscription_id. Название сервиса и цена по умолчанию берутся из текущей подписки.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Переход подписки на другой тариф",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 20,
+                        "description": "SID подписки",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Switch info",
+                        "name": "switch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.SwitchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Subscription switched",
+                        "schema": {
+                            "$ref": "#/definitions/model.SwitchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Incomplete/incorrect data input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Successor overlaps existing subscription",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Budget exceeded",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "Отдает массив всех зарегистрированных вебхуков (без secret)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Получение списка вебхуков",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.RawWebhook"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Регистрирует URL-получатель событий подписок (subscription.created/updated/deleted/expiring). Пустой event_types означает все события. Если secret не передан, он генерируется; secret возвращается только в ответе на создание. Каждый запрос подписывается заголовком X-Webhook-Signature: sha256=HMAC-SHA256(secret, \"\u003cX-Webhook-Timestamp\u003e.\u003cbody\u003e\").",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Регистрация вебхука",
+                "parameters": [
+                    {
+                        "description": "Webhook info",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RawWebhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Webhook registered",
+                        "schema": {
+                            "$ref": "#/definitions/model.RawWebhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Incomplete/incorrect data input",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries": {
+            "get": {
+                "description": "Отдает доставки событий (новые первыми) с фильтром по статусу (pending, delivered, dead) и вебхуку. status=dead - просмотр недоставленных событий, попытки по которым исчерпаны.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Просмотр доставок вебхуков",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "dead",
+                        "description": "Статус доставки",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID вебхука",
+                        "name": "webhook_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 100,
+                        "description": "Максимум записей (по умолчанию и не более 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries/{id}/retry": {
+            "post": {
+                "description": "Возвращает доставку со статусом dead в очередь: счетчик попыток сбрасывается, отправка происходит при ближайшем проходе диспетчера",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Повторная отправка недоставленного события",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 15,
+                        "description": "ID доставки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Delivery requeued",
+                        "schema": {
+                            "$ref": "#/definitions/model.WebhookDelivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Delivery not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Delivery is not dead",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "description": "Возвращает зарегистрированный вебхук (без secret)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Получение вебхука по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID вебхука",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.RawWebhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновляет переданные поля вебхука: url, secret, event_types, active. Неактивный вебхук не получает новых событий.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Обновление вебхука по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID вебхука",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook info",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.RawWebhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/model.RawWebhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет вебхук вместе с историей его доставок",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Удаление вебхука по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "ID вебхука",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "model.AnomalyReport": {
+            "type": "object",
+            "properties": {
+                "category_overloads": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CategoryOverload"
+                    }
+                },
+                "duplicates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DuplicateSubscriptions"
+                    }
+                },
+                "price_outliers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PriceOutlier"
+                    }
+                }
+            }
+        },
+        "model.BatchOpResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "op": {
+                    "type": "string",
+                    "example": "update"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "done"
+                },
+                "subscription": {
+                    "$ref": "#/definitions/model.RawSubscription"
+                }
+            }
+        },
+        "model.BatchOperation": {
+            "type": "object",
+            "properties": {
+                "op": {
+                    "type": "string",
+                    "example": "update"
+                },
+                "subscription": {
+                    "$ref": "#/definitions/model.RawSubscription"
+                },
+                "subscription_id": {
+                    "type": "integer",
+                    "example": 20
+                }
+            }
+        },
+        "model.BatchRequest": {
+            "type": "object",
+            "properties": {
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BatchOperation"
+                    }
+                }
+            }
+        },
+        "model.BatchResult": {
+            "type": "object",
+            "properties": {
+                "committed": {
+                    "type": "boolean"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BatchOpResult"
+                    }
+                }
+            }
+        },
+        "model.BudgetStatus": {
+            "type": "object",
+            "properties": {
+                "budget": {
+                    "$ref": "#/definitions/model.RawBudget"
+                },
+                "over_by": {
+                    "type": "integer",
+                    "example": 300
+                },
+                "period": {
+                    "type": "string",
+                    "example": "07-2025"
+                },
+                "spent": {
+                    "type": "integer",
+                    "example": 1800
+                }
+            }
+        },
+        "model.CategoryOverload": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "Entertainment \u003e Video"
+                },
+                "category_id": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "period": {
+                    "type": "string",
+                    "example": "07-2025"
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.RawSubscription"
+                    }
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "model.DuplicateSubscriptions": {
+            "type": "object",
+            "properties": {
+                "similarity": {
+                    "type": "number",
+                    "example": 0.91
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.RawSubscription"
+                    }
+                }
+            }
+        },
+        "model.EventPage": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.EventPayload"
+                    }
+                },
+                "next_cursor": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "model.EventPayload": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "subscription": {
+                    "$ref": "#/definitions/model.RawSubscription"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "subscription.created"
+                }
+            }
+        },
+        "model.Forecast": {
+            "type": "object",
+            "properties": {
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ForecastMonth"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 4800
+                }
+            }
+        },
+        "model.ForecastMonth": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ReportGroup"
+                    }
+                },
+                "period": {
+                    "type": "string",
+                    "example": "07-2025"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.GraphQLRequest": {
+            "type": "object",
+            "properties": {
+                "extensions": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "operationName": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string",
+                    "example": "{ users(first: 10) { nodes { id total(period: \"07-2025\") subscriptions { serviceName price } } } }"
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": {}
+                }
+            }
+        },
+        "model.ImportReport": {
+            "type": "object",
+            "properties": {
+                "committed": {
+                    "type": "boolean"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "all_or_nothing"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ImportRowResult"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ImportRowResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "status": {
+                    "type": "string",
+                    "example": "created"
+                },
+                "subscription_id": {
+                    "type": "integer",
+                    "example": 20
+                }
+            }
+        },
+        "model.PriceOutlier": {
+            "type": "object",
+            "properties": {
+                "median": {
+                    "type": "number",
+                    "example": 400
+                },
+                "ratio": {
+                    "type": "number",
+                    "example": 3.5
+                },
+                "subscription": {
+                    "$ref": "#/definitions/model.RawSubscription"
+                }
+            }
+        },
+        "model.RawBudget": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 1500
+                },
+                "budget_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "category": {
+                    "type": "string",
+                    "example": "Entertainment \u003e Video"
+                },
+                "category_id": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "warn"
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Yandex Plus"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "model.RawCategory": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Video"
+                },
+                "parent_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "path": {
+                    "type": "string",
+                    "example": "Entertainment \u003e Video"
+                }
+            }
+        },
+        "model.RawProvider": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "yandex plus",
+                        "YandexPlus"
+                    ]
+                },
+                "category": {
+                    "type": "string",
+                    "example": "Entertainment \u003e Video"
+                },
+                "category_id": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "default_price": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Yandex Plus"
+                },
+                "provider_id": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "model.RawSubscription": {
+            "type": "object",
+            "properties": {
+                "cancelled_at": {
+                    "type": "string",
+                    "example": "2025-07-10T12:00:00Z"
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "12-2025"
+                },
+                "previous_subscription_id": {
+                    "type": "integer",
+                    "example": 19
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "provider_id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Yandex Plus"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "07-2025"
+                },
+                "status": {
+                    "description": "Status and CancelledAt are computed on output and ignored on input",
+                    "type": "string",
+                    "example": "active"
+                },
+                "subscription_id": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "trial_until": {
+                    "type": "string",
+                    "example": "08-2025"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                },
+                "version": {
+                    "description": "Version is returned on output; on update and in batch operations it is the expected current version, the change fails if it differs",
+                    "type": "integer",
+                    "example": 3
+                },
+                "warnings": {
+                    "description": "Warnings are returned on creation/update, e.g. when a budget in warn mode is exceeded",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.RawWebhook": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-07-01T10:00:00Z"
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "subscription.created",
+                        "subscription.deleted"
+                    ]
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "s3cr3t"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://notify.example.com/hooks/subscriptions"
+                },
+                "webhook_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "model.Report": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ReportGroup"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ReportCacheStats": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer",
+                    "example": 10000
+                },
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "entries": {
+                    "type": "integer",
+                    "example": 812
+                },
+                "evictions": {
+                    "description": "entries dropped as least recently used or expired",
+                    "type": "integer",
+                    "example": 120
+                },
+                "hit_ratio": {
+                    "type": "number",
+                    "example": 0.89
+                },
+                "hits": {
+                    "type": "integer",
+                    "example": 15230
+                },
+                "invalidations": {
+                    "description": "entries dropped because a write touched their user, service, month or category",
+                    "type": "integer",
+                    "example": 640
+                },
+                "misses": {
+                    "type": "integer",
+                    "example": 1904
+                }
+            }
+        },
+        "model.ReportGroup": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "Entertainment \u003e Video"
+                },
+                "category_id": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 800
+                }
+            }
+        },
+        "model.SwitchRequest": {
+            "type": "object",
+            "properties": {
+                "end_date": {
+                    "type": "string",
+                    "example": "12-2026"
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 600
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Yandex Plus Family"
+                },
+                "switch_month": {
+                    "type": "string",
+                    "example": "07-2025"
+                }
+            }
+        },
+        "model.SwitchResult": {
+            "type": "object",
+            "properties": {
+                "next": {
+                    "$ref": "#/definitions/model.RawSubscription"
+                },
+                "previous": {
+                    "$ref": "#/definitions/model.RawSubscription"
+                }
+            }
+        },
+        "model.TrialReport": {
+            "type": "object",
+            "properties": {
+                "conversion_rate": {
+                    "type": "number",
+                    "example": 0.6
+                },
+                "converted": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "converted_revenue": {
+                    "description": "ConvertedRevenue is monthly price of converted subscriptions, charged from the next month",
+                    "type": "integer",
+                    "example": 1200
+                },
+                "in_trial": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "not_converted": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "period": {
+                    "type": "string",
+                    "example": "07-2025"
+                },
+                "trials_ended": {
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
+        "model.UpcomingSubscription": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2025-08-01"
+                },
+                "event": {
+                    "type": "string",
+                    "example": "renewal"
+                },
+                "subscription": {
+                    "$ref": "#/definitions/model.RawSubscription"
+                }
+            }
+        },
+        "model.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "delivery_id": {
+                    "type": "integer"
+                },
+                "event_id": {
+                    "type": "integer"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                },
+                "webhook_id": {
                     "type": "integer"
                 }
             }
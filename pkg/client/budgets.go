@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+const budgetsPath = "/budgets"
+
+// CreateBudget - sets monthly budget of a user
+func (c *Client) CreateBudget(ctx context.Context, budget *Budget) (*Budget, error) {
+	var created Budget
+	if err := c.sendJSON(ctx, http.MethodPost, budgetsPath, budget, nil, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetBudget - budget by ID
+func (c *Client) GetBudget(ctx context.Context, id uint64) (*Budget, error) {
+	var budget Budget
+	if err := c.get(ctx, idPath(budgetsPath, id, ""), nil, ErrBudgetNotFound, &budget); err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// ListBudgets - all budgets, only the ones of the user if uid is not empty
+func (c *Client) ListBudgets(ctx context.Context, uid string) ([]Budget, error) {
+	query := url.Values{}
+	setQuery(query, "user_id", uid)
+	var budgets []Budget
+	if err := c.get(ctx, budgetsPath, query, nil, &budgets); err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+// UpdateBudget - replaces budget
+func (c *Client) UpdateBudget(ctx context.Context, id uint64, budget *Budget) (*Budget, error) {
+	var updated Budget
+	if err := c.sendJSON(ctx, http.MethodPut, idPath(budgetsPath, id, ""), budget, ErrBudgetNotFound, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteBudget - removes budget
+func (c *Client) DeleteBudget(ctx context.Context, id uint64) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: idPath(budgetsPath, id, ""), notFound: ErrBudgetNotFound}, nil)
+}
+
+// OverBudget - budgets exceeded in period (current month if empty), of the user if uid is not empty
+func (c *Client) OverBudget(ctx context.Context, period, uid string) ([]BudgetStatus, error) {
+	query := url.Values{}
+	setQuery(query, "period", period)
+	setQuery(query, "user_id", uid)
+	var statuses []BudgetStatus
+	if err := c.get(ctx, budgetsPath+"/over", query, nil, &statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
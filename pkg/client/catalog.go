@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	providersPath  = "/providers"
+	categoriesPath = "/categories"
+)
+
+// CreateProvider - adds provider to catalog
+func (c *Client) CreateProvider(ctx context.Context, provider *Provider) (*Provider, error) {
+	var created Provider
+	if err := c.sendJSON(ctx, http.MethodPost, providersPath, provider, nil, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetProvider - provider by ID
+func (c *Client) GetProvider(ctx context.Context, id uint64) (*Provider, error) {
+	var provider Provider
+	if err := c.get(ctx, idPath(providersPath, id, ""), nil, ErrProviderNotFound, &provider); err != nil {
+		return nil, err
+	}
+	return &provider, nil
+}
+
+// ListProviders - all catalogued providers
+func (c *Client) ListProviders(ctx context.Context) ([]Provider, error) {
+	var providers []Provider
+	if err := c.get(ctx, providersPath, nil, nil, &providers); err != nil {
+		return nil, err
+	}
+	return providers, nil
+}
+
+// UpdateProvider - replaces provider with its aliases
+func (c *Client) UpdateProvider(ctx context.Context, id uint64, provider *Provider) (*Provider, error) {
+	var updated Provider
+	if err := c.sendJSON(ctx, http.MethodPut, idPath(providersPath, id, ""), provider, ErrProviderNotFound, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteProvider - removes provider not referenced by subscriptions
+func (c *Client) DeleteProvider(ctx context.Context, id uint64) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: idPath(providersPath, id, ""), notFound: ErrProviderNotFound}, nil)
+}
+
+// CreateCategory - adds category, under category with ParentID if it is set
+func (c *Client) CreateCategory(ctx context.Context, category *Category) (*Category, error) {
+	var created Category
+	if err := c.sendJSON(ctx, http.MethodPost, categoriesPath, category, ErrCategoryNotFound, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetCategory - category by ID
+func (c *Client) GetCategory(ctx context.Context, id uint64) (*Category, error) {
+	var category Category
+	if err := c.get(ctx, idPath(categoriesPath, id, ""), nil, ErrCategoryNotFound, &category); err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// ListCategories - all categories with their paths
+func (c *Client) ListCategories(ctx context.Context) ([]Category, error) {
+	var categories []Category
+	if err := c.get(ctx, categoriesPath, nil, nil, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// UpdateCategory - renames or moves category
+func (c *Client) UpdateCategory(ctx context.Context, id uint64, category *Category) (*Category, error) {
+	var updated Category
+	if err := c.sendJSON(ctx, http.MethodPut, idPath(categoriesPath, id, ""), category, ErrCategoryNotFound, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteCategory - removes category without subcategories, providers and budgets
+func (c *Client) DeleteCategory(ctx context.Context, id uint64) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: idPath(categoriesPath, id, ""), notFound: ErrCategoryNotFound}, nil)
+}
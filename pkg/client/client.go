@@ -0,0 +1,258 @@
+// Package client is a typed Go client of subscription REST API.
+//
+// Every route of the server has a method on Client; request and response types mirror JSON of the API.
+// Failed calls return *Error that matches sentinel errors (ErrSubNotFound, ErrSubExists, ...) with errors.Is.
+// Idempotent calls - GET, PUT, DELETE and POST with Idempotency-Key - are retried with exponential backoff
+// on network errors, 429 and 5xx responses.
+package client
+
+import (
+	"bytes"
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy - how idempotent calls are retried: MaxAttempts counts the first attempt too (1 disables retries),
+// delay starts at MinBackoff and doubles up to MaxBackoff; Retry-After of 429/503 responses is honoured
+type RetryPolicy struct {
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+// DefaultRetryPolicy - retry policy of CreateClient
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, MinBackoff: 200 * time.Millisecond, MaxBackoff: 5 * time.Second}
+
+// Client - client of subscription API. Fields may be changed after CreateClient, but not concurrently with calls.
+type Client struct {
+	BaseURL string
+	// Token is sent as bearer token, APIKey - in X-API-Key header; both are optional
+	Token  string
+	APIKey string
+	// HTTP - transport, http.DefaultClient if nil
+	HTTP  *http.Client
+	Retry RetryPolicy
+}
+
+// CreateClient - client of API at baseURL, e.g. http://localhost:8080, with DefaultRetryPolicy
+func CreateClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), Retry: DefaultRetryPolicy}
+}
+
+// Ptr - pointer to v, handy for optional fields like Subscription.Price
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// request - single API call
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	body        []byte
+	contentType string
+	// idempotent calls are retried; GET, PUT, DELETE and requests with Idempotency-Key are idempotent anyway
+	idempotent bool
+	// notFound - error of the addressed resource for 404 responses without recognizable message
+	notFound error
+	// resultOnError - response body of failed call is decoded into out as well, e.g. batch result of rolled back batch
+	resultOnError bool
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, notFound error, out any) error {
+	return c.do(ctx, &request{method: http.MethodGet, path: path, query: query, notFound: notFound}, out)
+}
+
+// jsonRequest - request with body marshalled to JSON
+func jsonRequest(method, path string, body any, notFound error) (*request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	return &request{method: method, path: path, body: data, contentType: "application/json", notFound: notFound}, nil
+}
+
+func (c *Client) sendJSON(ctx context.Context, method, path string, body any, notFound error, out any) error {
+	req, err := jsonRequest(method, path, body, notFound)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, out)
+}
+
+// do - executes request with retries and decodes JSON response into out unless out is nil
+func (c *Client) do(ctx context.Context, req *request, out any) error {
+	resp, data, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	apiErr := responseError(req, resp, data)
+	if apiErr != nil && !(req.resultOnError && isJSON(resp)) {
+		return apiErr
+	}
+	if out != nil && len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode response of %s %s: %w", req.method, req.path, err)
+		}
+	}
+	return apiErr
+}
+
+// send - executes request, retrying idempotent ones; returns the last response with its body read
+func (c *Client) send(ctx context.Context, req *request) (*http.Response, []byte, error) {
+	attempts := 1
+	if req.retryable() && c.Retry.MaxAttempts > 1 {
+		attempts = c.Retry.MaxAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		resp, data, err := c.attempt(ctx, req)
+		if attempt >= attempts || ctx.Err() != nil || !shouldRetry(resp, err) {
+			return resp, data, err
+		}
+		if err := sleep(ctx, c.backoff(attempt, resp)); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, req *request) (*http.Response, []byte, error) {
+	httpReq, err := c.newHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := c.httpClient().Do(httpReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response of %s %s: %w", req.method, req.path, err)
+	}
+	return resp, data, nil
+}
+
+// newHTTPRequest - HTTP request with credentials of client; JSON is accepted unless request sets Accept itself
+func (c *Client) newHTTPRequest(ctx context.Context, req *request) (*http.Request, error) {
+	target := c.BaseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	for name, values := range req.header {
+		httpReq.Header[name] = values
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	if c.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if c.APIKey != "" {
+		httpReq.Header.Set("X-API-Key", c.APIKey)
+	}
+	return httpReq, nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	return http.DefaultClient
+}
+
+func (req *request) retryable() bool {
+	switch {
+	case req.idempotent, req.header.Get(IdempotencyKeyHeader) != "":
+		return true
+	default:
+		return req.method == http.MethodGet || req.method == http.MethodPut || req.method == http.MethodDelete
+	}
+}
+
+// shouldRetry - network errors, throttling and server-side failures are worth another attempt
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff - delay before attempt+1: exponential with jitter, not less than Retry-After of the response
+func (c *Client) backoff(attempt int, resp *http.Response) time.Duration {
+	delay := c.Retry.MinBackoff << (attempt - 1)
+	if delay <= 0 || (c.Retry.MaxBackoff > 0 && delay > c.Retry.MaxBackoff) {
+		delay = c.Retry.MaxBackoff
+	}
+	if delay > 0 {
+		delay = delay/2 + rand.N(delay/2+1)
+	}
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && time.Duration(seconds)*time.Second > delay {
+			delay = time.Duration(seconds) * time.Second
+		}
+	}
+	return delay
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func isJSON(resp *http.Response) bool {
+	return strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json")
+}
+
+// IdempotencyKeyHeader - header making POST requests safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// newIdempotencyKey - random key for requests the caller has not provided one for
+func newIdempotencyKey() string {
+	var key [16]byte
+	cryptorand.Read(key[:])
+	return hex.EncodeToString(key[:])
+}
+
+// ifMatch - If-Match header with ETag of expected subscription version, none if version is nil
+func ifMatch(version *uint64) http.Header {
+	if version == nil {
+		return nil
+	}
+	return http.Header{"If-Match": {`"` + strconv.FormatUint(*version, 10) + `"`}}
+}
+
+func idPath(prefix string, id uint64, suffix string) string {
+	return prefix + "/" + strconv.FormatUint(id, 10) + suffix
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Errors of API resources; texts are the ones server puts into error responses, so they are matched by message
+var (
+	ErrSubNotFound     = errors.New("subscription not found")
+	ErrSubExists       = errors.New("subscription already exists")
+	ErrSubCancelled    = errors.New("subscription already cancelled")
+	ErrVersionMismatch = errors.New("subscription was modified by another request")
+	ErrBudgetExceeded  = errors.New("monthly budget exceeded")
+
+	ErrProviderNotFound = errors.New("provider not found")
+	ErrProviderExists   = errors.New("provider name or alias already exists")
+	ErrProviderInUse    = errors.New("provider is referenced by subscriptions")
+
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrCategoryExists   = errors.New("category already exists")
+	ErrCategoryInUse    = errors.New("category has subcategories, providers or budgets")
+	ErrCategoryCycle    = errors.New("category cannot be moved under itself")
+
+	ErrBudgetNotFound   = errors.New("budget not found")
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrDeliveryNotDead  = errors.New("webhook delivery is not dead")
+
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was used with a different request")
+	ErrIdempotencyInProgress = errors.New("request with this idempotency key is in progress")
+)
+
+// Errors by response status, matched in addition to errors of resources
+var (
+	ErrBadRequest           = errors.New("bad request")
+	ErrNotFound             = errors.New("not found")
+	ErrConflict             = errors.New("conflict")
+	ErrPreconditionFailed   = errors.New("precondition failed")
+	ErrPreconditionRequired = errors.New("precondition required")
+	ErrTooLarge             = errors.New("request body too large")
+	ErrUnprocessable        = errors.New("unprocessable request")
+	ErrRateLimited          = errors.New("too many requests")
+	ErrServer               = errors.New("server error")
+)
+
+// resourceErrors - errors of resources recognized in response messages; the ones containing others go first
+var resourceErrors = []error{
+	ErrSubNotFound, ErrSubExists, ErrSubCancelled, ErrVersionMismatch, ErrBudgetExceeded,
+	ErrProviderNotFound, ErrProviderExists, ErrProviderInUse,
+	ErrCategoryNotFound, ErrCategoryExists, ErrCategoryInUse, ErrCategoryCycle,
+	ErrBudgetNotFound, ErrWebhookNotFound, ErrDeliveryNotFound, ErrDeliveryNotDead,
+	ErrIdempotencyKeyReused, ErrIdempotencyInProgress,
+}
+
+var statusErrors = map[int]error{
+	http.StatusBadRequest:            ErrBadRequest,
+	http.StatusNotFound:              ErrNotFound,
+	http.StatusConflict:              ErrConflict,
+	http.StatusPreconditionFailed:    ErrPreconditionFailed,
+	http.StatusPreconditionRequired:  ErrPreconditionRequired,
+	http.StatusRequestEntityTooLarge: ErrTooLarge,
+	http.StatusUnprocessableEntity:   ErrUnprocessable,
+	http.StatusTooManyRequests:       ErrRateLimited,
+}
+
+// Error - API response with non-2xx status. errors.Is matches it against error of the resource, if recognized,
+// and error of the status, e.g. both ErrSubNotFound and ErrNotFound.
+type Error struct {
+	Method     string
+	Path       string
+	StatusCode int
+	// Message - text of the response; for JSON responses (batch, import) - the whole body
+	Message string
+
+	errs []error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s: %d %s: %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func (e *Error) Unwrap() []error {
+	return e.errs
+}
+
+// responseError - *Error for non-2xx response, nil otherwise
+func responseError(req *request, resp *http.Response, body []byte) error {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	apiErr := &Error{Method: req.method, Path: req.path, StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	message := strings.ToLower(apiErr.Message)
+	for _, err := range resourceErrors {
+		if strings.Contains(message, err.Error()) {
+			apiErr.errs = append(apiErr.errs, err)
+			break
+		}
+	}
+	if len(apiErr.errs) == 0 && resp.StatusCode == http.StatusNotFound && req.notFound != nil {
+		apiErr.errs = append(apiErr.errs, req.notFound)
+	}
+	if err, ok := statusErrors[resp.StatusCode]; ok {
+		apiErr.errs = append(apiErr.errs, err)
+	} else if resp.StatusCode >= 500 {
+		apiErr.errs = append(apiErr.errs, ErrServer)
+	}
+	return apiErr
+}
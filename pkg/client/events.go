@@ -0,0 +1,135 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ListEvents - events after cursor (0 - from the start of the log), at most limit of them (server default if 0)
+func (c *Client) ListEvents(ctx context.Context, after uint64, limit int) (*EventPage, error) {
+	query := url.Values{}
+	if after != 0 {
+		query.Set("after", strconv.FormatUint(after, 10))
+	}
+	if limit != 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var page EventPage
+	if err := c.get(ctx, "/events", query, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// errStreamClosed - server closed event stream, the stream is reopened from the last received event
+var errStreamClosed = errors.New("event stream closed")
+
+// StreamEvents - calls handle for every event after cursor as they appear, until ctx is done or handle returns error.
+// Dropped connections are reopened from the last handled event; Retry.MaxAttempts failures in a row end the stream.
+// Client.HTTP must have no timeout, as the stream is kept open.
+func (c *Client) StreamEvents(ctx context.Context, after uint64, handle func(*Event) error) error {
+	failures := 0
+	for {
+		received, err := c.streamOnce(ctx, &after, handle)
+		var handleErr *handlerError
+		switch {
+		case errors.As(err, &handleErr):
+			return handleErr.err
+		case ctx.Err() != nil:
+			return ctx.Err()
+		}
+		if received {
+			failures = 0
+		}
+		failures++
+		if failures >= max(c.Retry.MaxAttempts, 1) {
+			return err
+		}
+		if err := sleep(ctx, c.backoff(failures, nil)); err != nil {
+			return err
+		}
+	}
+}
+
+// handlerError - error of event handler, ends the stream
+type handlerError struct {
+	err error
+}
+
+func (e *handlerError) Error() string {
+	return e.err.Error()
+}
+
+// streamOnce - reads one connection of event stream, moving cursor to every handled event; reports if any event was received
+func (c *Client) streamOnce(ctx context.Context, cursor *uint64, handle func(*Event) error) (bool, error) {
+	req := &request{method: http.MethodGet, path: "/events/stream", header: http.Header{"Accept": {"text/event-stream"}}}
+	if *cursor != 0 {
+		req.header.Set("Last-Event-ID", strconv.FormatUint(*cursor, 10))
+	}
+	httpReq, err := c.newHTTPRequest(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.httpClient().Do(httpReq)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return false, responseError(req, resp, body)
+	}
+
+	// события разделены пустой строкой; строки-комментарии (": keep-alive") пропускаются
+	received := false
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var eventType string
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				eventType = value
+			case "data":
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.WriteString(value)
+			}
+			continue
+		}
+		if data.Len() == 0 {
+			continue
+		}
+		if eventType == "error" {
+			return received, fmt.Errorf("event stream failed: %s", data.String())
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(data.String()), &event); err != nil {
+			return received, fmt.Errorf("failed to decode event: %w", err)
+		}
+		eventType = ""
+		data.Reset()
+		if err := handle(&event); err != nil {
+			return received, &handlerError{err: err}
+		}
+		received = true
+		*cursor = event.ID
+	}
+	if err := scanner.Err(); err != nil {
+		return received, err
+	}
+	return received, errStreamClosed
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GraphQLError - error of GraphQL query; Extensions["code"] is BAD_USER_INPUT, NOT_FOUND or INTERNAL
+type GraphQLError struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// GraphQLErrors - errors of GraphQL query; data of fields without errors is still decoded
+type GraphQLErrors []GraphQLError
+
+func (errs GraphQLErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Message
+	}
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+// GraphQL - executes query with variables and decodes its data into out; returns GraphQLErrors if query has errors.
+// The schema has no mutations, so queries are retried like any read.
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]any, out any) error {
+	body := struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables,omitempty"`
+	}{query, variables}
+	req, err := jsonRequest(http.MethodPost, "/graphql", body, nil)
+	if err != nil {
+		return err
+	}
+	req.idempotent = true
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors GraphQLErrors   `json:"errors"`
+	}
+	if err := c.do(ctx, req, &resp); err != nil {
+		return err
+	}
+	if out != nil && len(resp.Data) > 0 && string(resp.Data) != "null" {
+		if err := json.Unmarshal(resp.Data, out); err != nil {
+			return fmt.Errorf("failed to decode graphql data: %w", err)
+		}
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const subscriptionsPath = "/subscriptions"
+
+// CreateSubscription - creates subscription. The call is sent with idempotencyKey, or a random key if it is empty,
+// so retries never create a duplicate; pass the same key to repeat the call safely later.
+func (c *Client) CreateSubscription(ctx context.Context, sub *Subscription, idempotencyKey string) (*Subscription, error) {
+	req, err := jsonRequest(http.MethodPost, subscriptionsPath, sub, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.header = idempotencyHeader(idempotencyKey)
+	var created Subscription
+	if err := c.do(ctx, req, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetSubscription - subscription by SID
+func (c *Client) GetSubscription(ctx context.Context, sid uint64) (*Subscription, error) {
+	var sub Subscription
+	if err := c.get(ctx, idPath(subscriptionsPath, sid, ""), nil, ErrSubNotFound, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// ListSubscriptions - all subscriptions, only the ones with status if it is not empty
+func (c *Client) ListSubscriptions(ctx context.Context, status string) ([]Subscription, error) {
+	query := url.Values{}
+	setQuery(query, "status", status)
+	var subs []Subscription
+	if err := c.get(ctx, subscriptionsPath, query, nil, &subs); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// UpdateSubscription - changes non-empty fields of subscription (PUT and PATCH behave the same).
+// If sub.Version is set, it is sent as If-Match and the update fails with ErrVersionMismatch when subscription has changed.
+func (c *Client) UpdateSubscription(ctx context.Context, sid uint64, sub *Subscription) (*Subscription, error) {
+	req, err := jsonRequest(http.MethodPut, idPath(subscriptionsPath, sid, ""), sub, ErrSubNotFound)
+	if err != nil {
+		return nil, err
+	}
+	req.header = ifMatch(sub.Version)
+	var updated Subscription
+	if err := c.do(ctx, req, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteSubscription - deletes subscription; non-nil version is the expected current version
+func (c *Client) DeleteSubscription(ctx context.Context, sid uint64, version *uint64) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: idPath(subscriptionsPath, sid, ""), header: ifMatch(version), notFound: ErrSubNotFound}, nil)
+}
+
+// SwitchSubscription - ends subscription at request.SwitchMonth and creates its successor from the next month
+func (c *Client) SwitchSubscription(ctx context.Context, sid uint64, request *SwitchRequest) (*SwitchResult, error) {
+	var result SwitchResult
+	if err := c.sendJSON(ctx, http.MethodPost, idPath(subscriptionsPath, sid, "/switch"), request, ErrSubNotFound, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CancelSubscription - cancels subscription: it stays paid until the end of the current month
+func (c *Client) CancelSubscription(ctx context.Context, sid uint64) (*Subscription, error) {
+	var sub Subscription
+	err := c.do(ctx, &request{method: http.MethodPost, path: idPath(subscriptionsPath, sid, "/cancel"), notFound: ErrSubNotFound}, &sub)
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// ImportSubscriptions - bulk import of payload in format ImportCSV or ImportNDJSON. When rows are invalid and nothing
+// is imported, the report is returned together with error matching ErrUnprocessable.
+func (c *Client) ImportSubscriptions(ctx context.Context, format string, payload io.Reader, options ImportOptions) (*ImportReport, error) {
+	body, err := io.ReadAll(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import payload: %w", err)
+	}
+	query := url.Values{}
+	setQuery(query, "mode", options.Mode)
+	if options.DryRun {
+		query.Set("dry_run", "true")
+	}
+	req := &request{
+		method:        http.MethodPost,
+		path:          subscriptionsPath + "/import",
+		query:         query,
+		body:          body,
+		contentType:   format,
+		idempotent:    options.DryRun,
+		resultOnError: true,
+	}
+	var report ImportReport
+	if err := c.do(ctx, req, &report); err != nil {
+		if report.Total > 0 {
+			return &report, err
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+// BatchSubscriptions - executes operations in a single transaction. Like CreateSubscription the call is sent with
+// idempotencyKey or a random one. If the batch is rolled back, its result is returned together with the error.
+func (c *Client) BatchSubscriptions(ctx context.Context, batch *BatchRequest, idempotencyKey string) (*BatchResult, error) {
+	req, err := jsonRequest(http.MethodPost, subscriptionsPath+"/batch", batch, ErrSubNotFound)
+	if err != nil {
+		return nil, err
+	}
+	req.header = idempotencyHeader(idempotencyKey)
+	req.resultOnError = true
+	var result BatchResult
+	if err := c.do(ctx, req, &result); err != nil {
+		if result.Results != nil {
+			return &result, err
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Upcoming - subscriptions that expire or renew within window (server default if 0), of the user if uid is not empty
+func (c *Client) Upcoming(ctx context.Context, within time.Duration, uid string) ([]UpcomingSubscription, error) {
+	query := url.Values{}
+	if within > 0 {
+		query.Set("within", within.String())
+	}
+	setQuery(query, "user_id", uid)
+	var upcoming []UpcomingSubscription
+	if err := c.get(ctx, subscriptionsPath+"/upcoming", query, nil, &upcoming); err != nil {
+		return nil, err
+	}
+	return upcoming, nil
+}
+
+// Report - total price of subscriptions active in filter.Period, grouped by category if filter.GroupBy is set
+func (c *Client) Report(ctx context.Context, filter ReportFilter) (*Report, error) {
+	var report Report
+	if err := c.get(ctx, subscriptionsPath+"/report", filter.query(), nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// TrialReport - conversion of trials ending in filter.Period; filter.GroupBy is ignored
+func (c *Client) TrialReport(ctx context.Context, filter ReportFilter) (*TrialReport, error) {
+	filter.GroupBy = ""
+	var report TrialReport
+	if err := c.get(ctx, subscriptionsPath+"/report/trials", filter.query(), nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ReportCacheStats - hit and miss counters of server report cache
+func (c *Client) ReportCacheStats(ctx context.Context) (*ReportCacheStats, error) {
+	var stats ReportCacheStats
+	if err := c.get(ctx, subscriptionsPath+"/report/cache", nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Anomalies - probable duplicates, price outliers and category overloads
+func (c *Client) Anomalies(ctx context.Context, filter AnomalyFilter) (*AnomalyReport, error) {
+	query := url.Values{}
+	setQuery(query, "uid", filter.UID)
+	setQuery(query, "period", filter.Period)
+	if filter.Similarity != 0 {
+		query.Set("similarity", strconv.FormatFloat(filter.Similarity, 'f', -1, 64))
+	}
+	if filter.PriceRatio != 0 {
+		query.Set("price_ratio", strconv.FormatFloat(filter.PriceRatio, 'f', -1, 64))
+	}
+	if filter.MaxConcurrent != 0 {
+		query.Set("max_concurrent", strconv.Itoa(filter.MaxConcurrent))
+	}
+	var report AnomalyReport
+	if err := c.get(ctx, subscriptionsPath+"/report/anomalies", query, nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Forecast - projected monthly spend starting from the current month
+func (c *Client) Forecast(ctx context.Context, filter ForecastFilter) (*Forecast, error) {
+	query := ReportFilter{UID: filter.UID, Provider: filter.Provider, Category: filter.Category, GroupBy: filter.GroupBy}.query()
+	if filter.Months != 0 {
+		query.Set("months", strconv.Itoa(filter.Months))
+	}
+	var forecast Forecast
+	if err := c.get(ctx, subscriptionsPath+"/forecast", query, nil, &forecast); err != nil {
+		return nil, err
+	}
+	return &forecast, nil
+}
+
+func (filter ReportFilter) query() url.Values {
+	query := url.Values{}
+	setQuery(query, "period", filter.Period)
+	setQuery(query, "uid", filter.UID)
+	setQuery(query, "provider", filter.Provider)
+	setQuery(query, "category", filter.Category)
+	setQuery(query, "group_by", filter.GroupBy)
+	return query
+}
+
+// setQuery - sets query parameter unless value is empty
+func setQuery(query url.Values, name, value string) {
+	if value != "" {
+		query.Set(name, value)
+	}
+}
+
+func idempotencyHeader(key string) http.Header {
+	if key == "" {
+		key = newIdempotencyKey()
+	}
+	return http.Header{IdempotencyKeyHeader: {key}}
+}
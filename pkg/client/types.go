@@ -0,0 +1,348 @@
+package client
+
+import "time"
+
+// Subscription statuses, computed by server from dates
+const (
+	StatusActive    = "active"
+	StatusScheduled = "scheduled"
+	StatusExpired   = "expired"
+	StatusCancelled = "cancelled"
+)
+
+// Subscription - subscription of a user to a service. Months are in "07-2025" format.
+// On update only non-empty fields are changed; Version, if set, is the expected current version.
+type Subscription struct {
+	SID      *uint64 `json:"subscription_id"`
+	UID      string  `json:"user_id"`
+	Provider string  `json:"service_name"`
+	Price    *uint   `json:"price"`
+	Start    string  `json:"start_date"`
+	End      string  `json:"end_date,omitempty"`
+
+	PreviousSID *uint64 `json:"previous_subscription_id,omitempty"`
+	ProviderID  *uint64 `json:"provider_id,omitempty"`
+	TrialUntil  string  `json:"trial_until,omitempty"`
+
+	// Status, CancelledAt and Warnings are set by server only
+	Status      string   `json:"status,omitempty"`
+	CancelledAt string   `json:"cancelled_at,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+	Version     *uint64  `json:"version,omitempty"`
+}
+
+// SwitchRequest - switching subscription to another plan: current subscription ends at SwitchMonth, the successor starts next month.
+// Provider and Price default to the ones of current subscription.
+type SwitchRequest struct {
+	Provider    string `json:"service_name,omitempty"`
+	Price       *uint  `json:"price,omitempty"`
+	SwitchMonth string `json:"switch_month"`
+	End         string `json:"end_date,omitempty"`
+}
+
+// SwitchResult - closed subscription and its successor
+type SwitchResult struct {
+	Previous *Subscription `json:"previous"`
+	Next     *Subscription `json:"next"`
+}
+
+// Upcoming events of subscription
+const (
+	UpcomingExpiry  = "expiry"
+	UpcomingRenewal = "renewal"
+)
+
+// UpcomingSubscription - subscription that ends (expiry) or is charged again (renewal) within requested window
+type UpcomingSubscription struct {
+	Event        string        `json:"event"`
+	Date         string        `json:"date"`
+	Subscription *Subscription `json:"subscription"`
+}
+
+// Import payload formats and modes: all_or_nothing commits rows only if every row is valid, valid_only commits valid rows
+const (
+	ImportCSV    = "text/csv"
+	ImportNDJSON = "application/x-ndjson"
+
+	ImportModeAllOrNothing = "all_or_nothing"
+	ImportModeValidOnly    = "valid_only"
+)
+
+// ImportOptions - parameters of bulk import; empty Mode is all_or_nothing
+type ImportOptions struct {
+	Mode   string
+	DryRun bool
+}
+
+// ImportRowResult - per-row result of bulk import
+type ImportRowResult struct {
+	Line   int     `json:"line"`
+	Status string  `json:"status"`
+	SID    *uint64 `json:"subscription_id,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// ImportReport - result of bulk import
+type ImportReport struct {
+	DryRun    bool              `json:"dry_run"`
+	Mode      string            `json:"mode"`
+	Committed bool              `json:"committed"`
+	Total     int               `json:"total"`
+	Valid     int               `json:"valid"`
+	Failed    int               `json:"failed"`
+	Created   int               `json:"created"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+// Batch operation types and statuses
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+
+	BatchOpDone       = "done"
+	BatchOpFailed     = "failed"
+	BatchOpRolledBack = "rolled_back"
+	BatchOpSkipped    = "skipped"
+)
+
+// BatchOperation - a single create/update/delete operation of batch; SID is mandatory for update and delete
+type BatchOperation struct {
+	Op           string        `json:"op"`
+	SID          *uint64       `json:"subscription_id,omitempty"`
+	Subscription *Subscription `json:"subscription,omitempty"`
+}
+
+// BatchRequest - list of operations executed in a single transaction
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOpResult - result of a single batch operation
+type BatchOpResult struct {
+	Index        int           `json:"index"`
+	Op           string        `json:"op"`
+	Status       string        `json:"status"`
+	Subscription *Subscription `json:"subscription,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// BatchResult - per-operation results of batch
+type BatchResult struct {
+	Committed bool            `json:"committed"`
+	Results   []BatchOpResult `json:"results"`
+}
+
+// ReportGroupByCategory - grouping of report and forecast totals by category
+const ReportGroupByCategory = "category"
+
+// ReportFilter - parameters of report: Period is mandatory, the rest are optional.
+// Category is ID or path like "Entertainment > Video" and includes subcategories.
+type ReportFilter struct {
+	Period   string
+	UID      string
+	Provider string
+	Category string
+	GroupBy  string
+}
+
+// Report - total price of subscriptions for a month
+type Report struct {
+	Total  uint          `json:"total"`
+	Groups []ReportGroup `json:"groups,omitempty"`
+}
+
+// ReportGroup - total price of subscriptions in a category including its subcategories; nil CategoryID stands for uncategorized subscriptions
+type ReportGroup struct {
+	CategoryID *uint64 `json:"category_id"`
+	Category   string  `json:"category"`
+	Total      uint    `json:"total"`
+}
+
+// TrialReport - trial statistics for a month
+type TrialReport struct {
+	Period           string  `json:"period"`
+	InTrial          int     `json:"in_trial"`
+	TrialsEnded      int     `json:"trials_ended"`
+	Converted        int     `json:"converted"`
+	NotConverted     int     `json:"not_converted"`
+	ConversionRate   float64 `json:"conversion_rate"`
+	ConvertedRevenue uint    `json:"converted_revenue"`
+}
+
+// ReportCacheStats - counters of server report cache since its start
+type ReportCacheStats struct {
+	Enabled       bool    `json:"enabled"`
+	Entries       int     `json:"entries"`
+	Capacity      int     `json:"capacity"`
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	HitRatio      float64 `json:"hit_ratio"`
+	Invalidations uint64  `json:"invalidations"`
+	Evictions     uint64  `json:"evictions"`
+}
+
+// ForecastFilter - parameters of forecast, all optional; zero Months is server default
+type ForecastFilter struct {
+	Months   int
+	UID      string
+	Provider string
+	Category string
+	GroupBy  string
+}
+
+// ForecastMonth - projected report for one month of forecast
+type ForecastMonth struct {
+	Period string `json:"period"`
+	Report
+}
+
+// Forecast - projected monthly spend and its sum over the whole horizon
+type Forecast struct {
+	Total  uint            `json:"total"`
+	Months []ForecastMonth `json:"months"`
+}
+
+// AnomalyFilter - parameters of anomaly report, all optional; zero values are server defaults
+type AnomalyFilter struct {
+	UID           string
+	Period        string
+	Similarity    float64
+	PriceRatio    float64
+	MaxConcurrent int
+}
+
+// AnomalyReport - likely duplicates, abnormally priced subscriptions and users with too many concurrent subscriptions in one category
+type AnomalyReport struct {
+	Duplicates        []DuplicateSubscriptions `json:"duplicates"`
+	PriceOutliers     []PriceOutlier           `json:"price_outliers"`
+	CategoryOverloads []CategoryOverload       `json:"category_overloads"`
+}
+
+// DuplicateSubscriptions - two overlapping subscriptions of the same user to the same or similarly named provider
+type DuplicateSubscriptions struct {
+	Similarity    float64         `json:"similarity"`
+	Subscriptions []*Subscription `json:"subscriptions"`
+}
+
+// PriceOutlier - subscription with price far from median price of its provider
+type PriceOutlier struct {
+	Median       float64       `json:"median"`
+	Ratio        float64       `json:"ratio"`
+	Subscription *Subscription `json:"subscription"`
+}
+
+// CategoryOverload - subscriptions of a user in one category active in the same month
+type CategoryOverload struct {
+	UID           string          `json:"user_id"`
+	CategoryID    uint64          `json:"category_id"`
+	Category      string          `json:"category"`
+	Period        string          `json:"period"`
+	Subscriptions []*Subscription `json:"subscriptions"`
+}
+
+// Provider - catalogued service provider; category may be given by ID or path
+type Provider struct {
+	ID           *uint64  `json:"provider_id"`
+	Name         string   `json:"name"`
+	Aliases      []string `json:"aliases"`
+	CategoryID   *uint64  `json:"category_id,omitempty"`
+	Category     string   `json:"category,omitempty"`
+	DefaultPrice *uint    `json:"default_price,omitempty"`
+}
+
+// Category - node of category tree; Path is set by server only
+type Category struct {
+	ID       *uint64 `json:"category_id"`
+	Name     string  `json:"name"`
+	ParentID *uint64 `json:"parent_id,omitempty"`
+	Path     string  `json:"path,omitempty"`
+}
+
+// Budget modes: warn - change exceeding budget is accepted with a warning, reject - change is refused
+const (
+	BudgetModeWarn   = "warn"
+	BudgetModeReject = "reject"
+)
+
+// Budget - monthly spend cap of a user, optionally limited to a category or provider
+type Budget struct {
+	ID         *uint64 `json:"budget_id"`
+	UID        string  `json:"user_id"`
+	Amount     *uint   `json:"amount"`
+	CategoryID *uint64 `json:"category_id,omitempty"`
+	Category   string  `json:"category,omitempty"`
+	Provider   string  `json:"service_name,omitempty"`
+	Mode       string  `json:"mode,omitempty"`
+}
+
+// BudgetStatus - spend of a month against budget
+type BudgetStatus struct {
+	Budget *Budget `json:"budget"`
+	Period string  `json:"period"`
+	Spent  uint    `json:"spent"`
+	OverBy uint    `json:"over_by"`
+}
+
+// Subscription lifecycle event types
+const (
+	EventSubscriptionCreated  = "subscription.created"
+	EventSubscriptionUpdated  = "subscription.updated"
+	EventSubscriptionDeleted  = "subscription.deleted"
+	EventSubscriptionExpiring = "subscription.expiring"
+	EventSubscriptionExpired  = "subscription.expired"
+)
+
+// Webhook - receiver of subscription lifecycle events; Secret is returned only on creation
+type Webhook struct {
+	ID         *uint64  `json:"webhook_id"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types"`
+	Active     *bool    `json:"active,omitempty"`
+	CreatedAt  string   `json:"created_at,omitempty"`
+}
+
+// Webhook delivery statuses: pending - waiting for (next) attempt, delivered - receiver responded with 2xx, dead - attempts exhausted
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery - delivery of a single event to a single webhook
+type WebhookDelivery struct {
+	ID            uint64     `json:"delivery_id"`
+	WebhookID     uint64     `json:"webhook_id"`
+	EventID       uint64     `json:"event_id"`
+	EventType     string     `json:"event_type"`
+	SID           *uint64    `json:"subscription_id"`
+	Payload       string     `json:"payload"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+// DeliveryFilter - parameters of deliveries list, all optional
+type DeliveryFilter struct {
+	Status    string
+	WebhookID *uint64
+	Limit     int
+}
+
+// Event - subscription lifecycle event; ID serves as cursor
+type Event struct {
+	ID           uint64        `json:"id"`
+	Type         string        `json:"type"`
+	CreatedAt    time.Time     `json:"created_at"`
+	Subscription *Subscription `json:"subscription"`
+}
+
+// EventPage - portion of event log after cursor; NextCursor is to be passed as after in the next request
+type EventPage struct {
+	Events     []*Event `json:"events"`
+	NextCursor uint64   `json:"next_cursor"`
+}
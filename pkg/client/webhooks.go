@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const webhooksPath = "/webhooks"
+
+// CreateWebhook - registers receiver of events; the returned webhook holds the secret for signature checks
+func (c *Client) CreateWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	var created Webhook
+	if err := c.sendJSON(ctx, http.MethodPost, webhooksPath, webhook, nil, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetWebhook - webhook by ID
+func (c *Client) GetWebhook(ctx context.Context, id uint64) (*Webhook, error) {
+	var webhook Webhook
+	if err := c.get(ctx, idPath(webhooksPath, id, ""), nil, ErrWebhookNotFound, &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks - all registered webhooks
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var webhooks []Webhook
+	if err := c.get(ctx, webhooksPath, nil, nil, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhook - changes URL, event types or activity of webhook
+func (c *Client) UpdateWebhook(ctx context.Context, id uint64, webhook *Webhook) (*Webhook, error) {
+	var updated Webhook
+	if err := c.sendJSON(ctx, http.MethodPut, idPath(webhooksPath, id, ""), webhook, ErrWebhookNotFound, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteWebhook - removes webhook
+func (c *Client) DeleteWebhook(ctx context.Context, id uint64) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: idPath(webhooksPath, id, ""), notFound: ErrWebhookNotFound}, nil)
+}
+
+// ListDeliveries - deliveries of events to webhooks, newest first
+func (c *Client) ListDeliveries(ctx context.Context, filter DeliveryFilter) ([]WebhookDelivery, error) {
+	query := url.Values{}
+	setQuery(query, "status", filter.Status)
+	if filter.WebhookID != nil {
+		query.Set("webhook_id", strconv.FormatUint(*filter.WebhookID, 10))
+	}
+	if filter.Limit != 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	var deliveries []WebhookDelivery
+	if err := c.get(ctx, webhooksPath+"/deliveries", query, nil, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RetryDelivery - puts dead delivery back to the queue
+func (c *Client) RetryDelivery(ctx context.Context, id uint64) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := c.do(ctx, &request{method: http.MethodPost, path: idPath(webhooksPath+"/deliveries", id, "/retry"), notFound: ErrDeliveryNotFound}, &delivery)
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}